@@ -15,7 +15,7 @@ import (
 
 func main() {
 	// Initialize the application using wire
-	router, err := di.InitializeApp()
+	app, err := di.InitializeApp()
 	if err != nil {
 		log.Fatalf("Failed to initialize application: %v", err)
 	}
@@ -29,7 +29,7 @@ func main() {
 	// Start server
 	srv := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
-		Handler:      router,
+		Handler:      app.Router,
 		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
 		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
 	}
@@ -43,12 +43,18 @@ func main() {
 
 	log.Printf("Server started on port %s", cfg.Server.Port)
 
+	// Run background jobs until shutdown
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	app.Scheduler.Start(jobsCtx)
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Println("Server shutting down...")
 
+	stopJobs()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -56,5 +62,8 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Let running jobs finish
+	app.Scheduler.Wait()
+
 	log.Println("Server exited")
 }
@@ -2,21 +2,40 @@ package config
 
 import (
 	"fmt"
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
+	App      AppConfig
 	Server   ServerConfig
 	Database DatabaseConfig
 	Redis    RedisConfig
 	JWT      JWTConfig
+	Auth     AuthConfig
+	Password PasswordConfig
+	OAuth    OAuthConfig
+	APIKeys  APIKeyConfig
+	Users    UsersConfig
+	Exports  ExportsConfig
+	Beans    BeansConfig
+	Mail     MailConfig
+	Storage  StorageConfig
 	S3       S3Config
 }
 
+type AppConfig struct {
+	Name    string
+	BaseURL string
+}
+
 type ServerConfig struct {
 	Port         string
 	ReadTimeout  int
 	WriteTimeout int
+	// Addresses or CIDR ranges of the proxies in front of the API whose
+	// X-Forwarded-For headers are believed; empty trusts none
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -36,9 +55,123 @@ type RedisConfig struct {
 }
 
 type JWTConfig struct {
-	Secret           string
-	AccessTokenExp   int
-	RefreshTokenExp  int
+	SigningKeyID    string
+	KeyDir          string
+	Keys            []JWTKeyConfig
+	AccessTokenExp  int
+	RefreshTokenExp int
+}
+
+// JWTKeyConfig is a PEM encoded key given inline instead of as a file.
+type JWTKeyConfig struct {
+	ID  string
+	PEM string
+}
+
+type AuthConfig struct {
+	PasswordResetTokenExp     int
+	EmailVerificationTokenExp int
+	MagicLinkTokenExp         int
+	EmailSendLimit            int
+	MFAChallengeExp           int
+	LoginMaxFailures          int
+	LoginIPMaxFailures        int
+	LoginLockoutBase          int
+	LoginLockoutMax           int
+	LoginFailureWindow        int
+	GuestCreateLimit          int
+	GuestRetentionDays        int
+}
+
+// PasswordConfig holds the password policy and the Argon2id cost of new
+// password hashes.
+type PasswordConfig struct {
+	MinLength        int
+	MaxLength        int
+	BreachedListFile string
+	Argon2           Argon2Config
+}
+
+type Argon2Config struct {
+	Memory      int
+	Iterations  int
+	Parallelism int
+	SaltLength  int
+	KeyLength   int
+}
+
+type OAuthConfig struct {
+	StateExp  int
+	Providers []OAuthProviderConfig
+}
+
+// OAuthProviderConfig registers an OpenID Connect provider for social login.
+// Providers without a client ID are disabled.
+type OAuthProviderConfig struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	ResponseMode string
+}
+
+type APIKeyConfig struct {
+	DailyQuota int
+	MaxPerUser int
+}
+
+type UsersConfig struct {
+	AvatarMaxSize int // bytes
+	// Words that may not appear in handles, one per line; empty to disable
+	BlockedHandleWordsFile string
+	HandleRedirectDays     int // days an old handle redirects and stays reserved
+	DeletionGraceDays      int // days before a deleted account is removed for good
+}
+
+// ExportsConfig limits how often users can export their data and how long
+// the download link of an export works.
+type ExportsConfig struct {
+	RequestLimit    int // exports per user per day
+	LinkExpiryHours int
+}
+
+type BeansConfig struct {
+	ImageMaxSize int // bytes
+	MaxImages    int // images per bean
+	// Freshness windows; the first one matching a bean's roast level and the
+	// brew method applies
+	Freshness []FreshnessWindowConfig
+	// Days before a bean goes stale that its owner is warned
+	StaleWarningDays int
+}
+
+// FreshnessWindowConfig sets how many days off roast a bean rests, peaks and
+// fades. Beans are stale from StaleFrom on.
+type FreshnessWindowConfig struct {
+	RoastLevel  string   // empty matches every roast level
+	BrewMethods []string // empty matches every brew method
+	PeakFrom    int
+	FadingFrom  int
+	StaleFrom   int
+}
+
+type MailConfig struct {
+	Driver    string
+	From      string
+	Host      string
+	Port      string
+	Username  string
+	Password  string
+	OutputDir string
+}
+
+// StorageConfig selects where uploaded files are kept. The local driver
+// writes them to LocalDir and serves them under PublicURL.
+type StorageConfig struct {
+	Driver    string
+	LocalDir  string
+	PublicURL string
 }
 
 type S3Config struct {
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type AccountDeletionController struct {
+	deletionService service.AccountDeletionService
+}
+
+func NewAccountDeletionController(deletionService service.AccountDeletionService) *AccountDeletionController {
+	return &AccountDeletionController{
+		deletionService: deletionService,
+	}
+}
+
+// Guests have neither a password nor two-factor authentication and leave
+// both out
+type deleteAccountRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+func (c *AccountDeletionController) Delete(ctx *gin.Context) {
+	var req deleteAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	user, err := c.deletionService.RequestDeletion(userID, req.Password, req.Code)
+	if err != nil {
+		respondAccountDeletionError(ctx, err)
+		return
+	}
+
+	status := "pending_deletion"
+	if user.IsGuest {
+		status = "deleted"
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"account": gin.H{
+				"status":              status,
+				"deletionRequestedAt": user.DeletionRequestedAt,
+				"deletionScheduledAt": user.DeletionScheduledAt,
+			},
+		},
+	})
+}
+
+func respondAccountDeletionError(ctx *gin.Context, err error) {
+	var rateLimitErr *service.RateLimitError
+	switch {
+	case errors.As(err, &rateLimitErr):
+		respondRateLimited(ctx, rateLimitErr)
+	case errors.Is(err, service.ErrIncorrectPassword):
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INCORRECT_PASSWORD",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrInvalidMFACode):
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_MFA_CODE",
+				"message": err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to delete account",
+			},
+		})
+	}
+}
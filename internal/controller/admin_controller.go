@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type AdminController struct {
+	adminService service.AdminService
+}
+
+func NewAdminController(adminService service.AdminService) *AdminController {
+	return &AdminController{
+		adminService: adminService,
+	}
+}
+
+type suspendUserRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type setRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func (c *AdminController) SearchUsers(ctx *gin.Context) {
+	page, limit := pageParams(ctx)
+	search := repository.UserSearch{
+		Query:  ctx.Query("search"),
+		Role:   domain.Role(ctx.Query("role")),
+		Offset: (page - 1) * limit,
+		Limit:  limit,
+	}
+	if value := ctx.Query("suspended"); value != "" {
+		suspended, err := strconv.ParseBool(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "INVALID_REQUEST",
+					"message": "suspended must be true or false",
+				},
+			})
+			return
+		}
+		search.Suspended = &suspended
+	}
+
+	users, total, err := c.adminService.SearchUsers(search)
+	if err != nil {
+		respondAdminError(ctx, err, "Failed to search users")
+		return
+	}
+
+	items := make([]gin.H, 0, len(users))
+	for i := range users {
+		items = append(items, adminUserJSON(&users[i]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"users":      items,
+			"pagination": paginationJSON(total, page, limit),
+		},
+	})
+}
+
+func (c *AdminController) Suspend(ctx *gin.Context) {
+	userID, ok := parseUserIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var req suspendUserRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "INVALID_REQUEST",
+					"message": "Invalid request format",
+				},
+			})
+			return
+		}
+	}
+
+	actorID := ctx.MustGet("userID").(uuid.UUID)
+	user, err := c.adminService.SuspendUser(actorID, userID, req.Reason)
+	if err != nil {
+		respondAdminError(ctx, err, "Failed to suspend user")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user": adminUserJSON(user),
+		},
+	})
+}
+
+func (c *AdminController) Unsuspend(ctx *gin.Context) {
+	userID, ok := parseUserIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	actorID := ctx.MustGet("userID").(uuid.UUID)
+	user, err := c.adminService.UnsuspendUser(actorID, userID)
+	if err != nil {
+		respondAdminError(ctx, err, "Failed to lift suspension")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user": adminUserJSON(user),
+		},
+	})
+}
+
+func (c *AdminController) SetRole(ctx *gin.Context) {
+	userID, ok := parseUserIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req setRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	actorID := ctx.MustGet("userID").(uuid.UUID)
+	user, err := c.adminService.SetRole(actorID, userID, domain.Role(req.Role))
+	if err != nil {
+		respondAdminError(ctx, err, "Failed to change role")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user": adminUserJSON(user),
+		},
+	})
+}
+
+func parseUserIDParam(ctx *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid user ID",
+			},
+		})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func adminUserJSON(user *domain.User) gin.H {
+	return gin.H{
+		"id":              user.ID,
+		"email":           user.Email,
+		"handle":          user.Handle,
+		"displayName":     user.DisplayName,
+		"role":            user.Role,
+		"emailVerified":   user.IsEmailVerified(),
+		"isGuest":         user.IsGuest,
+		"suspendedAt":     user.SuspendedAt,
+		"suspendedReason": user.SuspendedReason,
+		"createdAt":       user.CreatedAt,
+		"lastLoginAt":     user.LastLoginAt,
+		// Set while the account waits out its deletion grace period
+		"deletionScheduledAt": user.DeletionScheduledAt,
+	}
+}
+
+func respondAdminError(ctx *gin.Context, err error, failure string) {
+	status := http.StatusInternalServerError
+	code := "SERVER_ERROR"
+	message := failure
+
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		status, code, message = http.StatusNotFound, "RESOURCE_NOT_FOUND", err.Error()
+	case errors.Is(err, service.ErrInvalidRole):
+		status, code, message = http.StatusBadRequest, "VALIDATION_ERROR", err.Error()
+	case errors.Is(err, service.ErrCannotManageSelf),
+		errors.Is(err, service.ErrInsufficientRank):
+		status, code, message = http.StatusForbidden, "PERMISSION_DENIED", err.Error()
+	case errors.Is(err, service.ErrGuestCannotBeStaff):
+		status, code, message = http.StatusConflict, "GUEST_ACCOUNT", err.Error()
+	}
+
+	ctx.JSON(status, gin.H{
+		"status": "error",
+		"error": gin.H{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
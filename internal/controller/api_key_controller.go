@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type APIKeyController struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService service.APIKeyService) *APIKeyController {
+	return &APIKeyController{
+		apiKeyService: apiKeyService,
+	}
+}
+
+type createAPIKeyRequest struct {
+	Name      string         `json:"name" binding:"required,max=100"`
+	Scopes    []domain.Scope `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time     `json:"expiresAt"`
+}
+
+func (c *APIKeyController) Create(ctx *gin.Context) {
+	var req createAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	key, rawKey, err := c.apiKeyService.CreateKey(userID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidScope),
+			errors.Is(err, service.ErrNoScopes),
+			errors.Is(err, service.ErrInvalidExpiry):
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "VALIDATION_ERROR",
+					"message": err.Error(),
+				},
+			})
+		case errors.Is(err, service.ErrAPIKeyLimitReached):
+			ctx.JSON(http.StatusConflict, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "API_KEY_LIMIT",
+					"message": err.Error(),
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "SERVER_ERROR",
+					"message": "Failed to create API key",
+				},
+			})
+		}
+		return
+	}
+
+	data := apiKeyJSON(key)
+	// The only time the key is shown
+	data["key"] = rawKey
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"apiKey": data,
+		},
+	})
+}
+
+func (c *APIKeyController) List(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	keys, err := c.apiKeyService.ListKeys(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to list API keys",
+			},
+		})
+		return
+	}
+
+	items := make([]gin.H, 0, len(keys))
+	for i := range keys {
+		items = append(items, apiKeyJSON(&keys[i]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"apiKeys": items,
+		},
+	})
+}
+
+func (c *APIKeyController) Revoke(ctx *gin.Context) {
+	keyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid API key ID",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	if err := c.apiKeyService.RevokeKey(userID, keyID); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "RESOURCE_NOT_FOUND",
+					"message": err.Error(),
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to revoke API key",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
+
+func apiKeyJSON(key *domain.APIKey) gin.H {
+	return gin.H{
+		"id":        key.ID,
+		"name":      key.Name,
+		"prefix":    key.Prefix,
+		"scopes":    key.Scopes,
+		"expiresAt": key.ExpiresAt,
+		"createdAt": key.CreatedAt,
+		"lastUsed":  key.LastUsedAt,
+	}
+}
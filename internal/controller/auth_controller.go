@@ -1,9 +1,11 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/yashkadam007/brewkar/internal/service"
 )
 
@@ -21,17 +23,25 @@ type registerRequest struct {
 	Email       string `json:"email" binding:"required,email"`
 	Password    string `json:"password" binding:"required,min=8"`
 	DisplayName string `json:"displayName" binding:"required"`
+	DeviceName  string `json:"deviceName"`
 }
 
 type loginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required,min=8"`
+	DeviceName string `json:"deviceName"`
 }
 
 type refreshRequest struct {
 	RefreshToken string `json:"refreshToken" binding:"required"`
 }
 
+type verifyMFARequest struct {
+	MFAToken   string `json:"mfaToken" binding:"required"`
+	Code       string `json:"code" binding:"required"`
+	DeviceName string `json:"deviceName"`
+}
+
 func (c *AuthController) Register(ctx *gin.Context) {
 	var req registerRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -45,8 +55,12 @@ func (c *AuthController) Register(ctx *gin.Context) {
 		return
 	}
 
-	user, token, err := c.authService.Register(req.Email, req.Password, req.DisplayName)
+	user, tokens, err := c.authService.Register(req.Email, req.Password, req.DisplayName, clientInfo(ctx, req.DeviceName))
 	if err != nil {
+		if errors.Is(err, service.ErrWeakPassword) {
+			respondWeakPassword(ctx, err)
+			return
+		}
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"status": "error",
 			"error": gin.H{
@@ -61,12 +75,14 @@ func (c *AuthController) Register(ctx *gin.Context) {
 		"status": "success",
 		"data": gin.H{
 			"user": gin.H{
-				"id":          user.ID,
-				"email":       user.Email,
-				"displayName": user.DisplayName,
-				"createdAt":   user.CreatedAt,
+				"id":            user.ID,
+				"email":         user.Email,
+				"displayName":   user.DisplayName,
+				"emailVerified": user.IsEmailVerified(),
+				"createdAt":     user.CreatedAt,
 			},
-			"token": token,
+			"token":        tokens.AccessToken,
+			"refreshToken": tokens.RefreshToken,
 		},
 	})
 }
@@ -84,28 +100,120 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	user, token, err := c.authService.Login(req.Email, req.Password)
+	user, tokens, err := c.authService.Login(req.Email, req.Password, clientInfo(ctx, req.DeviceName))
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{
+		var mfaErr *service.MFARequiredError
+		var lockedErr *service.LoginLockedError
+		switch {
+		case errors.As(err, &mfaErr):
+			ctx.JSON(http.StatusOK, gin.H{
+				"status": "success",
+				"data": gin.H{
+					"mfaRequired": true,
+					"mfaToken":    mfaErr.ChallengeToken,
+				},
+			})
+		case errors.As(err, &lockedErr):
+			respondLoginLocked(ctx, lockedErr)
+		case errors.Is(err, service.ErrAccountSuspended):
+			respondAccountSuspended(ctx)
+		case errors.Is(err, service.ErrInvalidCredentials):
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "INVALID_CREDENTIALS",
+					"message": err.Error(),
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "SERVER_ERROR",
+					"message": "Failed to log in",
+				},
+			})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user": gin.H{
+				"id":            user.ID,
+				"email":         user.Email,
+				"displayName":   user.DisplayName,
+				"emailVerified": user.IsEmailVerified(),
+				"lastLoginAt":   user.LastLoginAt,
+			},
+			"token":        tokens.AccessToken,
+			"refreshToken": tokens.RefreshToken,
+		},
+	})
+}
+
+func (c *AuthController) VerifyMFA(ctx *gin.Context) {
+	var req verifyMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
 			"status": "error",
 			"error": gin.H{
-				"code":    "INVALID_CREDENTIALS",
-				"message": err.Error(),
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
 			},
 		})
 		return
 	}
 
+	user, tokens, err := c.authService.VerifyMFAChallenge(req.MFAToken, req.Code, clientInfo(ctx, req.DeviceName))
+	if err != nil {
+		var rateLimitErr *service.RateLimitError
+		switch {
+		case errors.As(err, &rateLimitErr):
+			respondRateLimited(ctx, rateLimitErr)
+		case errors.Is(err, service.ErrAccountSuspended):
+			respondAccountSuspended(ctx)
+		case errors.Is(err, service.ErrInvalidMFAChallenge), errors.Is(err, service.ErrMFANotEnrolled):
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "INVALID_TOKEN",
+					"message": service.ErrInvalidMFAChallenge.Error(),
+				},
+			})
+		case errors.Is(err, service.ErrInvalidMFACode):
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "INVALID_MFA_CODE",
+					"message": err.Error(),
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "SERVER_ERROR",
+					"message": "Failed to verify code",
+				},
+			})
+		}
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data": gin.H{
 			"user": gin.H{
-				"id":          user.ID,
-				"email":       user.Email,
-				"displayName": user.DisplayName,
-				"lastLoginAt": user.LastLoginAt,
+				"id":            user.ID,
+				"email":         user.Email,
+				"displayName":   user.DisplayName,
+				"emailVerified": user.IsEmailVerified(),
+				"lastLoginAt":   user.LastLoginAt,
 			},
-			"token": token,
+			"token":        tokens.AccessToken,
+			"refreshToken": tokens.RefreshToken,
 		},
 	})
 }
@@ -123,12 +231,20 @@ func (c *AuthController) Refresh(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, err := c.authService.RefreshToken(req.RefreshToken)
+	tokens, err := c.authService.RefreshToken(req.RefreshToken, clientInfo(ctx, ""))
 	if err != nil {
+		if errors.Is(err, service.ErrAccountSuspended) {
+			respondAccountSuspended(ctx)
+			return
+		}
+		code := "INVALID_TOKEN"
+		if errors.Is(err, service.ErrRefreshTokenReused) {
+			code = "TOKEN_REUSED"
+		}
 		ctx.JSON(http.StatusUnauthorized, gin.H{
 			"status": "error",
 			"error": gin.H{
-				"code":    "INVALID_TOKEN",
+				"code":    code,
 				"message": err.Error(),
 			},
 		})
@@ -138,8 +254,57 @@ func (c *AuthController) Refresh(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data": gin.H{
-			"token":        accessToken,
-			"refreshToken": refreshToken,
+			"token":        tokens.AccessToken,
+			"refreshToken": tokens.RefreshToken,
 		},
 	})
 }
+
+func (c *AuthController) Logout(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+	sessionID := ctx.MustGet("sessionID").(uuid.UUID)
+
+	if err := c.authService.Logout(userID, sessionID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to log out",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
+
+func (c *AuthController) LogoutAll(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	if err := c.authService.LogoutAll(userID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to log out",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
+
+func clientInfo(ctx *gin.Context, deviceName string) service.ClientInfo {
+	return service.ClientInfo{
+		IP:         ctx.ClientIP(),
+		UserAgent:  ctx.Request.UserAgent(),
+		DeviceName: deviceName,
+	}
+}
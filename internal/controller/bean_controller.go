@@ -0,0 +1,741 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+// dateLayout is how calendar dates such as roast dates are written
+const dateLayout = "2006-01-02"
+
+// Sort fields of GET /beans and the columns they sort by
+var beanSortFields = map[string]string{
+	"createdAt":     repository.BeanSortCreatedAt,
+	"updatedAt":     repository.BeanSortUpdatedAt,
+	"name":          repository.BeanSortName,
+	"roastDate":     repository.BeanSortRoastDate,
+	"purchaseDate":  repository.BeanSortPurchaseDate,
+	"quantityGrams": repository.BeanSortQuantityGrams,
+	"price":         repository.BeanSortPrice,
+}
+
+type BeanController struct {
+	beanService service.BeanService
+}
+
+func NewBeanController(beanService service.BeanService) *BeanController {
+	return &BeanController{
+		beanService: beanService,
+	}
+}
+
+// Fields left out of the request are not changed. Dates are YYYY-MM-DD.
+type beanRequest struct {
+	Name             *string             `json:"name"`
+	Origin           *string             `json:"origin"`
+	Roaster          *string             `json:"roaster"`
+	RoastDate        *string             `json:"roastDate"`
+	RoastLevel       *domain.RoastLevel  `json:"roastLevel"`
+	FlavorNotes      []string            `json:"flavorNotes"`
+	BeanSpecies      *domain.BeanSpecies `json:"beanSpecies"`
+	ProcessingMethod *string             `json:"processingMethod"`
+	Altitude         *string             `json:"altitude"`
+	PurchaseDate     *string             `json:"purchaseDate"`
+	Price            *float64            `json:"price"`
+	QuantityGrams    *int                `json:"quantityGrams"`
+	IsFavorite       *bool               `json:"isFavorite"`
+	IsActive         *bool               `json:"isActive"`
+	// Catalog links; an empty string unlinks
+	RoasterID *string `json:"roasterId"`
+	CoffeeID  *string `json:"coffeeId"`
+}
+
+type stockAdjustmentRequest struct {
+	ChangeGrams   *int   `json:"changeGrams"`
+	QuantityGrams *int   `json:"quantityGrams"`
+	Note          string `json:"note"`
+}
+
+// Dates are YYYY-MM-DD; the date defaults to today.
+type beanEventRequest struct {
+	Kind domain.BeanEventKind `json:"kind"`
+	Date *string              `json:"date"`
+	Note string               `json:"note"`
+}
+
+type beanPortionsRequest struct {
+	Portions []struct {
+		QuantityGrams int `json:"quantityGrams"`
+	} `json:"portions"`
+	Freeze bool `json:"freeze"`
+}
+
+func (c *BeanController) GetAll(ctx *gin.Context) {
+	page, limit := pageParams(ctx)
+	search := repository.BeanSearch{
+		Query:      ctx.Query("search"),
+		RoastLevel: domain.RoastLevel(ctx.Query("roastLevel")),
+		Asc:        ctx.Query("order") == "asc",
+		Offset:     (page - 1) * limit,
+		Limit:      limit,
+	}
+	if sort := ctx.Query("sort"); sort != "" {
+		column, ok := beanSortFields[sort]
+		if !ok {
+			respondInvalidRequest(ctx, "Unknown sort field")
+			return
+		}
+		search.Sort = column
+	}
+	if order := ctx.Query("order"); order != "" && order != "asc" && order != "desc" {
+		respondInvalidRequest(ctx, "order must be asc or desc")
+		return
+	}
+	if value := ctx.Query("isActive"); value != "" {
+		isActive, err := strconv.ParseBool(value)
+		if err != nil {
+			respondInvalidRequest(ctx, "isActive must be true or false")
+			return
+		}
+		search.IsActive = &isActive
+	}
+	if value := ctx.Query("parentId"); value != "" {
+		parentID, err := uuid.Parse(value)
+		if err != nil {
+			respondInvalidRequest(ctx, "Invalid parent bean ID")
+			return
+		}
+		search.ParentID = &parentID
+	}
+	freshness := domain.FreshnessState(ctx.Query("freshness"))
+	if freshness != "" && !freshness.Valid() {
+		respondInvalidRequest(ctx, "freshness must be resting, peak, fading or stale")
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	rules, ok := c.freshnessRules(ctx, userID)
+	if !ok {
+		return
+	}
+	if freshness != "" {
+		search.RoastDates, search.Today = rules.RoastDates(freshness)
+	}
+
+	beans, total, err := c.beanService.ListBeans(userID, search)
+	if err != nil {
+		respondBeanError(ctx, err, "Failed to list coffee beans")
+		return
+	}
+
+	items := make([]gin.H, 0, len(beans))
+	for i := range beans {
+		items = append(items, beanJSON(&beans[i], rules))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"beans":      items,
+			"pagination": paginationJSON(total, page, limit),
+		},
+	})
+}
+
+func (c *BeanController) Create(ctx *gin.Context) {
+	fields, ok := bindBeanFields(ctx)
+	if !ok {
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	rules, ok := c.freshnessRules(ctx, userID)
+	if !ok {
+		return
+	}
+
+	bean, err := c.beanService.CreateBean(userID, fields)
+	if err != nil {
+		respondBeanError(ctx, err, "Failed to create coffee bean")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"bean": beanJSON(bean, rules),
+		},
+	})
+}
+
+func (c *BeanController) GetByID(ctx *gin.Context) {
+	beanID, ok := parseBeanIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	rules, ok := c.freshnessRules(ctx, userID)
+	if !ok {
+		return
+	}
+
+	bean, err := c.beanService.GetBean(userID, beanID)
+	if err != nil {
+		respondBeanError(ctx, err, "Failed to load coffee bean")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"bean": beanJSON(bean, rules),
+		},
+	})
+}
+
+func (c *BeanController) Update(ctx *gin.Context) {
+	beanID, ok := parseBeanIDParam(ctx)
+	if !ok {
+		return
+	}
+	fields, ok := bindBeanFields(ctx)
+	if !ok {
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	rules, ok := c.freshnessRules(ctx, userID)
+	if !ok {
+		return
+	}
+
+	bean, err := c.beanService.UpdateBean(userID, beanID, fields)
+	if err != nil {
+		respondBeanError(ctx, err, "Failed to update coffee bean")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"bean": beanJSON(bean, rules),
+		},
+	})
+}
+
+func (c *BeanController) Delete(ctx *gin.Context) {
+	beanID, ok := parseBeanIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	if err := c.beanService.DeleteBean(userID, beanID); err != nil {
+		respondBeanError(ctx, err, "Failed to delete coffee bean")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
+
+func (c *BeanController) UploadImages(ctx *gin.Context) {
+	beanID, ok := parseBeanIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil || len(form.File["images"]) == 0 {
+		respondInvalidRequest(ctx, "Expected images in the images form field")
+		return
+	}
+
+	images := make([]io.Reader, 0, len(form.File["images"]))
+	for _, file := range form.File["images"] {
+		f, err := file.Open()
+		if err != nil {
+			respondBeanError(ctx, err, "Failed to read images")
+			return
+		}
+		defer f.Close()
+		images = append(images, f)
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	bean, err := c.beanService.UploadImages(userID, beanID, images)
+	if err != nil {
+		respondBeanError(ctx, err, "Failed to upload images")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"imageUrls": bean.ImageURLs,
+		},
+	})
+}
+
+func (c *BeanController) GetStock(ctx *gin.Context) {
+	beanID, ok := parseBeanIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	stock, err := c.beanService.GetStock(userID, beanID)
+	if err != nil {
+		respondBeanError(ctx, err, "Failed to load stock")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"stock": gin.H{
+				"quantityGrams":          stock.QuantityGrams,
+				"lowStockThresholdGrams": stock.LowStockThresholdGrams,
+				"isLow":                  stock.IsLow,
+				"recentBrews":            stock.RecentBrews,
+				"recentGrams":            stock.RecentGrams,
+				"cupsRemaining":          stock.CupsRemaining,
+				"daysUntilEmpty":         stock.DaysUntilEmpty,
+			},
+		},
+	})
+}
+
+func (c *BeanController) AdjustStock(ctx *gin.Context) {
+	beanID, ok := parseBeanIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req stockAdjustmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	rules, ok := c.freshnessRules(ctx, userID)
+	if !ok {
+		return
+	}
+
+	bean, adjustment, err := c.beanService.AdjustStock(userID, beanID, service.StockChange{
+		ChangeGrams:   req.ChangeGrams,
+		QuantityGrams: req.QuantityGrams,
+		Note:          req.Note,
+	})
+	if err != nil {
+		respondBeanError(ctx, err, "Failed to adjust stock")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"bean":       beanJSON(bean, rules),
+			"adjustment": adjustment,
+		},
+	})
+}
+
+func (c *BeanController) ListStockAdjustments(ctx *gin.Context) {
+	beanID, ok := parseBeanIDParam(ctx)
+	if !ok {
+		return
+	}
+	page, limit := pageParams(ctx)
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	adjustments, total, err := c.beanService.ListStockAdjustments(userID, beanID, (page-1)*limit, limit)
+	if err != nil {
+		respondBeanError(ctx, err, "Failed to list stock adjustments")
+		return
+	}
+	if adjustments == nil {
+		adjustments = []domain.BeanStockAdjustment{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"adjustments": adjustments,
+			"pagination":  paginationJSON(total, page, limit),
+		},
+	})
+}
+
+// bindBeanFields reads a bean request body. It writes the error response
+// and returns false when the body is invalid.
+func bindBeanFields(ctx *gin.Context) (service.BeanFields, bool) {
+	var req beanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(ctx, "Invalid request format")
+		return service.BeanFields{}, false
+	}
+
+	roastDate, err := parseDate(req.RoastDate)
+	if err != nil {
+		respondInvalidRequest(ctx, "roastDate must be a date such as 2023-07-25")
+		return service.BeanFields{}, false
+	}
+	purchaseDate, err := parseDate(req.PurchaseDate)
+	if err != nil {
+		respondInvalidRequest(ctx, "purchaseDate must be a date such as 2023-07-25")
+		return service.BeanFields{}, false
+	}
+	roasterID, err := parseCatalogLink(req.RoasterID)
+	if err != nil {
+		respondInvalidRequest(ctx, "Invalid roaster ID")
+		return service.BeanFields{}, false
+	}
+	coffeeID, err := parseCatalogLink(req.CoffeeID)
+	if err != nil {
+		respondInvalidRequest(ctx, "Invalid coffee ID")
+		return service.BeanFields{}, false
+	}
+
+	return service.BeanFields{
+		Name:             req.Name,
+		Origin:           req.Origin,
+		Roaster:          req.Roaster,
+		RoastDate:        roastDate,
+		RoastLevel:       req.RoastLevel,
+		FlavorNotes:      req.FlavorNotes,
+		BeanSpecies:      req.BeanSpecies,
+		ProcessingMethod: req.ProcessingMethod,
+		Altitude:         req.Altitude,
+		PurchaseDate:     purchaseDate,
+		Price:            req.Price,
+		QuantityGrams:    req.QuantityGrams,
+		IsFavorite:       req.IsFavorite,
+		IsActive:         req.IsActive,
+		RoasterID:        roasterID,
+		CoffeeID:         coffeeID,
+	}, true
+}
+
+// parseCatalogLink parses the ID of a catalog entry to link a bean to. An
+// empty string unlinks the bean, which uuid.Nil stands for.
+func parseCatalogLink(value *string) (*uuid.UUID, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if *value == "" {
+		return &uuid.Nil, nil
+	}
+	id, err := uuid.Parse(*value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func parseDate(value *string) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	date, err := time.Parse(dateLayout, *value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+func formatDate(date *time.Time) *string {
+	if date == nil {
+		return nil
+	}
+	formatted := date.Format(dateLayout)
+	return &formatted
+}
+
+func parseBeanIDParam(ctx *gin.Context) (uuid.UUID, bool) {
+	beanID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		respondInvalidRequest(ctx, "Invalid coffee bean ID")
+		return uuid.Nil, false
+	}
+	return beanID, true
+}
+
+func (c *BeanController) ListEvents(ctx *gin.Context) {
+	beanID, ok := parseBeanIDParam(ctx)
+	if !ok {
+		return
+	}
+	page, limit := pageParams(ctx)
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	events, total, err := c.beanService.ListEvents(userID, beanID, (page-1)*limit, limit)
+	if err != nil {
+		respondBeanError(ctx, err, "Failed to list bean events")
+		return
+	}
+
+	items := make([]gin.H, 0, len(events))
+	for i := range events {
+		items = append(items, beanEventJSON(&events[i]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"events":     items,
+			"pagination": paginationJSON(total, page, limit),
+		},
+	})
+}
+
+func (c *BeanController) AddEvent(ctx *gin.Context) {
+	beanID, ok := parseBeanIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req beanEventRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(ctx, "Invalid request format")
+		return
+	}
+	date, err := parseDate(req.Date)
+	if err != nil {
+		respondInvalidRequest(ctx, "Dates must be formatted as YYYY-MM-DD")
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	rules, ok := c.freshnessRules(ctx, userID)
+	if !ok {
+		return
+	}
+
+	bean, event, err := c.beanService.AddEvent(userID, beanID, service.NewBeanEvent{
+		Kind: req.Kind,
+		Date: date,
+		Note: req.Note,
+	})
+	if err != nil {
+		respondBeanError(ctx, err, "Failed to add bean event")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"bean":  beanJSON(bean, rules),
+			"event": beanEventJSON(event),
+		},
+	})
+}
+
+func (c *BeanController) Split(ctx *gin.Context) {
+	beanID, ok := parseBeanIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req beanPortionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(ctx, "Invalid request format")
+		return
+	}
+	quantities := make([]int, 0, len(req.Portions))
+	for _, portion := range req.Portions {
+		quantities = append(quantities, portion.QuantityGrams)
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	rules, ok := c.freshnessRules(ctx, userID)
+	if !ok {
+		return
+	}
+
+	bean, portions, err := c.beanService.SplitBean(userID, beanID, service.BeanPortions{
+		QuantitiesGrams: quantities,
+		Freeze:          req.Freeze,
+	})
+	if err != nil {
+		respondBeanError(ctx, err, "Failed to split coffee bean")
+		return
+	}
+
+	items := make([]gin.H, 0, len(portions))
+	for i := range portions {
+		items = append(items, beanJSON(&portions[i], rules))
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"bean":     beanJSON(bean, rules),
+			"portions": items,
+		},
+	})
+}
+
+// freshnessRules loads the rules for the freshness in bean responses, for
+// the brew method in the brewMethod query parameter or the user's default.
+func (c *BeanController) freshnessRules(ctx *gin.Context, userID uuid.UUID) (*service.FreshnessRules, bool) {
+	rules, err := c.beanService.FreshnessRules(userID, ctx.Query("brewMethod"))
+	if err != nil {
+		respondBeanError(ctx, err, "Failed to load bean freshness")
+		return nil, false
+	}
+	return rules, true
+}
+
+func beanJSON(bean *domain.CoffeeBean, rules *service.FreshnessRules) gin.H {
+	return gin.H{
+		"id":               bean.ID,
+		"name":             bean.Name,
+		"origin":           bean.Origin,
+		"roaster":          bean.Roaster,
+		"roastDate":        formatDate(bean.RoastDate),
+		"roastLevel":       bean.RoastLevel,
+		"flavorNotes":      bean.FlavorNotes,
+		"beanSpecies":      bean.BeanSpecies,
+		"processingMethod": bean.ProcessingMethod,
+		"altitude":         bean.Altitude,
+		"purchaseDate":     formatDate(bean.PurchaseDate),
+		"price":            bean.Price,
+		"quantityGrams":    bean.QuantityGrams,
+		"imageUrls":        bean.ImageURLs,
+		"isActive":         bean.IsActive,
+		"isFavorite":       bean.IsFavorite,
+		"roasterId":        bean.RoasterID,
+		"coffeeId":         bean.CoffeeID,
+		"parentId":         bean.ParentID,
+		"openedOn":         formatDate(bean.OpenedOn),
+		"frozenOn":         formatDate(bean.FrozenOn),
+		"finishedOn":       formatDate(bean.FinishedOn),
+		"freshness":        freshnessJSON(rules.Of(bean), rules.BrewMethod),
+		"createdAt":        bean.CreatedAt,
+		"updatedAt":        bean.UpdatedAt,
+	}
+}
+
+func freshnessJSON(freshness *service.BeanFreshness, brewMethod string) gin.H {
+	if freshness == nil {
+		return nil
+	}
+	return gin.H{
+		"daysOffRoast": freshness.DaysOffRoast,
+		"frozenDays":   freshness.FrozenDays,
+		"isFrozen":     freshness.IsFrozen,
+		"state":        freshness.State,
+		"brewMethod":   brewMethod,
+		"peakDate":     freshness.PeakDate.Format(dateLayout),
+		"fadingDate":   freshness.FadingDate.Format(dateLayout),
+		"staleDate":    freshness.StaleDate.Format(dateLayout),
+	}
+}
+
+func beanEventJSON(event *domain.BeanEvent) gin.H {
+	return gin.H{
+		"id":        event.ID,
+		"beanId":    event.BeanID,
+		"kind":      event.Kind,
+		"date":      event.Date.Format(dateLayout),
+		"note":      event.Note,
+		"createdAt": event.CreatedAt,
+	}
+}
+
+func respondBeanError(ctx *gin.Context, err error, failure string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidBeanName),
+		errors.Is(err, service.ErrInvalidBeanText),
+		errors.Is(err, service.ErrInvalidRoastLevel),
+		errors.Is(err, service.ErrInvalidBeanSpecies),
+		errors.Is(err, service.ErrInvalidFlavorNotes),
+		errors.Is(err, service.ErrInvalidQuantity),
+		errors.Is(err, service.ErrInvalidPrice),
+		errors.Is(err, service.ErrInvalidRoastDate),
+		errors.Is(err, service.ErrInvalidBeanImage),
+		errors.Is(err, service.ErrTooManyBeanImages),
+		errors.Is(err, service.ErrInvalidStockChange),
+		errors.Is(err, service.ErrStockNoteTooLong),
+		errors.Is(err, service.ErrStockNotTracked),
+		errors.Is(err, service.ErrStockBelowZero),
+		errors.Is(err, service.ErrInvalidDose),
+		errors.Is(err, service.ErrInvalidBeanEvent),
+		errors.Is(err, service.ErrBeanEventNoteTooLong),
+		errors.Is(err, service.ErrInvalidEventDate),
+		errors.Is(err, service.ErrInvalidPortions),
+		errors.Is(err, service.ErrPortionsTooLarge),
+		errors.Is(err, service.ErrRoasterNotFound),
+		errors.Is(err, service.ErrCoffeeNotFound),
+		errors.Is(err, service.ErrCoffeeRoasterMismatch):
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrBeanFinished),
+		errors.Is(err, service.ErrBeanAlreadyOpened),
+		errors.Is(err, service.ErrBeanAlreadyFrozen),
+		errors.Is(err, service.ErrBeanNotFrozen):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_BEAN_STATE",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrBeanImageTooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "FILE_TOO_LARGE",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrBeanNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "RESOURCE_NOT_FOUND",
+				"message": err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": failure,
+			},
+		})
+	}
+}
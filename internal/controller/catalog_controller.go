@@ -0,0 +1,354 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type CatalogController struct {
+	catalogService service.CatalogService
+}
+
+func NewCatalogController(catalogService service.CatalogService) *CatalogController {
+	return &CatalogController{
+		catalogService: catalogService,
+	}
+}
+
+type roasterRequest struct {
+	Name    string `json:"name"`
+	Country string `json:"country"`
+	Website string `json:"website"`
+}
+
+type coffeeRequest struct {
+	RoasterID     *uuid.UUID `json:"roasterId"`
+	Name          string     `json:"name"`
+	OriginCountry string     `json:"originCountry"`
+	Region        string     `json:"region"`
+	Farm          string     `json:"farm"`
+	Variety       string     `json:"variety"`
+	Process       string     `json:"process"`
+}
+
+type catalogMergeRequest struct {
+	IntoID uuid.UUID `json:"intoId" binding:"required"`
+}
+
+func (c *CatalogController) SearchRoasters(ctx *gin.Context) {
+	page, limit := pageParams(ctx)
+	search := repository.CatalogSearch{
+		Query:  ctx.Query("search"),
+		Offset: (page - 1) * limit,
+		Limit:  limit,
+	}
+
+	roasters, total, err := c.catalogService.SearchRoasters(search)
+	if err != nil {
+		respondCatalogError(ctx, err, "Failed to search roasters")
+		return
+	}
+
+	items := make([]gin.H, 0, len(roasters))
+	for i := range roasters {
+		items = append(items, roasterJSON(&roasters[i]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"roasters":   items,
+			"pagination": paginationJSON(total, page, limit),
+		},
+	})
+}
+
+func (c *CatalogController) GetRoaster(ctx *gin.Context) {
+	roasterID, ok := parseCatalogIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	roaster, err := c.catalogService.GetRoaster(roasterID)
+	if err != nil {
+		respondCatalogError(ctx, err, "Failed to load roaster")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"roaster": roasterJSON(roaster),
+		},
+	})
+}
+
+func (c *CatalogController) CreateRoaster(ctx *gin.Context) {
+	var req roasterRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	roaster, created, err := c.catalogService.CreateRoaster(userID, service.RoasterFields{
+		Name:    req.Name,
+		Country: req.Country,
+		Website: req.Website,
+	})
+	if err != nil {
+		respondCatalogError(ctx, err, "Failed to create roaster")
+		return
+	}
+
+	// The roaster was already in the catalog
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	ctx.JSON(status, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"roaster": roasterJSON(roaster),
+			"created": created,
+		},
+	})
+}
+
+func (c *CatalogController) SearchCoffees(ctx *gin.Context) {
+	page, limit := pageParams(ctx)
+	search := repository.CatalogSearch{
+		Query:  ctx.Query("search"),
+		Offset: (page - 1) * limit,
+		Limit:  limit,
+	}
+	if value := ctx.Query("roasterId"); value != "" {
+		roasterID, err := uuid.Parse(value)
+		if err != nil {
+			respondInvalidRequest(ctx, "Invalid roaster ID")
+			return
+		}
+		search.RoasterID = &roasterID
+	}
+
+	coffees, total, err := c.catalogService.SearchCoffees(search)
+	if err != nil {
+		respondCatalogError(ctx, err, "Failed to search coffees")
+		return
+	}
+
+	items := make([]gin.H, 0, len(coffees))
+	for i := range coffees {
+		items = append(items, coffeeJSON(&coffees[i]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"coffees":    items,
+			"pagination": paginationJSON(total, page, limit),
+		},
+	})
+}
+
+func (c *CatalogController) GetCoffee(ctx *gin.Context) {
+	coffeeID, ok := parseCatalogIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	coffee, err := c.catalogService.GetCoffee(coffeeID)
+	if err != nil {
+		respondCatalogError(ctx, err, "Failed to load coffee")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"coffee": coffeeJSON(coffee),
+		},
+	})
+}
+
+func (c *CatalogController) CreateCoffee(ctx *gin.Context) {
+	var req coffeeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(ctx, "Invalid request format")
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	coffee, created, err := c.catalogService.CreateCoffee(userID, service.CoffeeFields{
+		RoasterID:     req.RoasterID,
+		Name:          req.Name,
+		OriginCountry: req.OriginCountry,
+		Region:        req.Region,
+		Farm:          req.Farm,
+		Variety:       req.Variety,
+		Process:       req.Process,
+	})
+	if err != nil {
+		respondCatalogError(ctx, err, "Failed to create coffee")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	ctx.JSON(status, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"coffee":  coffeeJSON(coffee),
+			"created": created,
+		},
+	})
+}
+
+func (c *CatalogController) Suggest(ctx *gin.Context) {
+	suggestions, err := c.catalogService.Suggest(ctx.Query("roaster"), ctx.Query("name"))
+	if err != nil {
+		respondCatalogError(ctx, err, "Failed to suggest catalog entries")
+		return
+	}
+
+	roasters := make([]gin.H, 0, len(suggestions.Roasters))
+	for i := range suggestions.Roasters {
+		roasters = append(roasters, roasterJSON(&suggestions.Roasters[i]))
+	}
+	coffees := make([]gin.H, 0, len(suggestions.Coffees))
+	for i := range suggestions.Coffees {
+		coffees = append(coffees, coffeeJSON(&suggestions.Coffees[i]))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"roasters": roasters,
+			"coffees":  coffees,
+		},
+	})
+}
+
+func (c *CatalogController) MergeRoasters(ctx *gin.Context) {
+	duplicateID, ok := parseCatalogIDParam(ctx)
+	if !ok {
+		return
+	}
+	var req catalogMergeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(ctx, "intoId is required")
+		return
+	}
+
+	roaster, err := c.catalogService.MergeRoasters(duplicateID, req.IntoID)
+	if err != nil {
+		respondCatalogError(ctx, err, "Failed to merge roasters")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"roaster": roasterJSON(roaster),
+		},
+	})
+}
+
+func (c *CatalogController) MergeCoffees(ctx *gin.Context) {
+	duplicateID, ok := parseCatalogIDParam(ctx)
+	if !ok {
+		return
+	}
+	var req catalogMergeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(ctx, "intoId is required")
+		return
+	}
+
+	coffee, err := c.catalogService.MergeCoffees(duplicateID, req.IntoID)
+	if err != nil {
+		respondCatalogError(ctx, err, "Failed to merge coffees")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"coffee": coffeeJSON(coffee),
+		},
+	})
+}
+
+func parseCatalogIDParam(ctx *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		respondInvalidRequest(ctx, "Invalid catalog entry ID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func roasterJSON(roaster *domain.Roaster) gin.H {
+	return gin.H{
+		"id":        roaster.ID,
+		"name":      roaster.Name,
+		"country":   roaster.Country,
+		"website":   roaster.Website,
+		"createdAt": roaster.CreatedAt,
+		"updatedAt": roaster.UpdatedAt,
+	}
+}
+
+func coffeeJSON(coffee *domain.Coffee) gin.H {
+	return gin.H{
+		"id":            coffee.ID,
+		"roasterId":     coffee.RoasterID,
+		"name":          coffee.Name,
+		"originCountry": coffee.OriginCountry,
+		"region":        coffee.Region,
+		"farm":          coffee.Farm,
+		"variety":       coffee.Variety,
+		"process":       coffee.Process,
+		"createdAt":     coffee.CreatedAt,
+		"updatedAt":     coffee.UpdatedAt,
+	}
+}
+
+func respondCatalogError(ctx *gin.Context, err error, failure string) {
+	status := http.StatusInternalServerError
+	code := "SERVER_ERROR"
+	message := failure
+
+	switch {
+	case errors.Is(err, service.ErrRoasterNotFound),
+		errors.Is(err, service.ErrCoffeeNotFound):
+		status, code, message = http.StatusNotFound, "RESOURCE_NOT_FOUND", err.Error()
+	case errors.Is(err, service.ErrInvalidCatalogName),
+		errors.Is(err, service.ErrInvalidCatalogText),
+		errors.Is(err, service.ErrInvalidWebsite),
+		errors.Is(err, service.ErrMergeIntoSelf):
+		status, code, message = http.StatusBadRequest, "VALIDATION_ERROR", err.Error()
+	case errors.Is(err, service.ErrAlreadyMerged):
+		status, code, message = http.StatusConflict, "ALREADY_MERGED", err.Error()
+	case errors.Is(err, service.ErrCoffeeNameTaken):
+		status, code, message = http.StatusConflict, "COFFEE_NAME_TAKEN", err.Error()
+	}
+
+	ctx.JSON(status, gin.H{
+		"status": "error",
+		"error": gin.H{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
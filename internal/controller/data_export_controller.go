@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type DataExportController struct {
+	exportService service.DataExportService
+}
+
+func NewDataExportController(exportService service.DataExportService) *DataExportController {
+	return &DataExportController{
+		exportService: exportService,
+	}
+}
+
+// Request queues an export; the download link is emailed once it is built.
+func (c *DataExportController) Request(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	export, err := c.exportService.RequestExport(userID)
+	if err != nil {
+		respondDataExportError(ctx, err, "Failed to request export")
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"export": dataExportJSON(export),
+		},
+	})
+}
+
+func (c *DataExportController) GetLatest(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	export, err := c.exportService.GetLatestExport(userID)
+	if err != nil {
+		respondDataExportError(ctx, err, "Failed to load export")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"export": dataExportJSON(export),
+		},
+	})
+}
+
+type downloadExportRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// Download serves the archive of the emailed download link. The token from
+// the link authorizes the download, so no authentication is needed. It comes
+// in the body, keeping it out of access logs.
+func (c *DataExportController) Download(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		respondDataExportError(ctx, service.ErrInvalidExportLink, "")
+		return
+	}
+	var req downloadExportRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalidRequest(ctx, "Token is required")
+		return
+	}
+
+	export, archive, err := c.exportService.OpenExport(id, req.Token)
+	if err != nil {
+		respondDataExportError(ctx, err, "Failed to download export")
+		return
+	}
+	defer archive.Close()
+
+	filename := fmt.Sprintf("brewkar-export-%s.zip", export.CreatedAt.UTC().Format("2006-01-02"))
+	ctx.Header("Cache-Control", "no-store")
+	ctx.DataFromReader(http.StatusOK, export.Size, "application/zip", archive, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, filename),
+	})
+}
+
+func dataExportJSON(export *domain.DataExport) gin.H {
+	return gin.H{
+		"id":          export.ID,
+		"status":      export.Status,
+		"size":        export.Size,
+		"createdAt":   export.CreatedAt,
+		"completedAt": export.CompletedAt,
+		"expiresAt":   export.ExpiresAt,
+	}
+}
+
+func respondDataExportError(ctx *gin.Context, err error, failure string) {
+	var rateLimitErr *service.RateLimitError
+	switch {
+	case errors.As(err, &rateLimitErr):
+		respondRateLimited(ctx, rateLimitErr)
+	case errors.Is(err, service.ErrGuestAccount):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "GUEST_ACCOUNT",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrExportNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "RESOURCE_NOT_FOUND",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrInvalidExportLink):
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_TOKEN",
+				"message": err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": failure,
+			},
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type EmailVerificationController struct {
+	verificationService service.EmailVerificationService
+}
+
+func NewEmailVerificationController(verificationService service.EmailVerificationService) *EmailVerificationController {
+	return &EmailVerificationController{
+		verificationService: verificationService,
+	}
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func (c *EmailVerificationController) VerifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	if err := c.verificationService.VerifyEmail(req.Token); err != nil {
+		if errors.Is(err, service.ErrInvalidVerificationToken) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "INVALID_TOKEN",
+					"message": err.Error(),
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to verify email",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
+
+func (c *EmailVerificationController) ResendVerification(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	if err := c.verificationService.ResendVerification(userID); err != nil {
+		var rateLimitErr *service.RateLimitError
+		switch {
+		case errors.As(err, &rateLimitErr):
+			respondRateLimited(ctx, rateLimitErr)
+		case errors.Is(err, service.ErrEmailAlreadyVerified):
+			ctx.JSON(http.StatusConflict, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "EMAIL_ALREADY_VERIFIED",
+					"message": err.Error(),
+				},
+			})
+		case errors.Is(err, service.ErrGuestAccount):
+			ctx.JSON(http.StatusConflict, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "GUEST_ACCOUNT",
+					"message": err.Error(),
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "SERVER_ERROR",
+					"message": "Failed to send verification email",
+				},
+			})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type EquipmentController struct {
+	equipmentService service.EquipmentService
+}
+
+func NewEquipmentController(equipmentService service.EquipmentService) *EquipmentController {
+	return &EquipmentController{
+		equipmentService: equipmentService,
+	}
+}
+
+func (c *EquipmentController) List(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	items, err := c.equipmentService.ListEquipment(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to load equipment",
+			},
+		})
+		return
+	}
+
+	equipment := make([]gin.H, 0, len(items))
+	for _, item := range items {
+		equipment = append(equipment, gin.H{
+			"id":        item.ID,
+			"kind":      item.Kind,
+			"name":      item.Name,
+			"createdAt": item.CreatedAt,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"equipment": equipment,
+		},
+	})
+}
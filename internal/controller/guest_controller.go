@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type GuestController struct {
+	guestService service.GuestService
+}
+
+func NewGuestController(guestService service.GuestService) *GuestController {
+	return &GuestController{
+		guestService: guestService,
+	}
+}
+
+type createGuestRequest struct {
+	DeviceName string `json:"deviceName"`
+}
+
+type upgradeGuestRequest struct {
+	Email       string `json:"email" binding:"required,email"`
+	Password    string `json:"password" binding:"required,min=8"`
+	DisplayName string `json:"displayName"`
+}
+
+func (c *GuestController) Create(ctx *gin.Context) {
+	// The body is optional
+	var req createGuestRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "INVALID_REQUEST",
+					"message": "Invalid request format",
+				},
+			})
+			return
+		}
+	}
+
+	user, tokens, err := c.guestService.CreateGuest(clientInfo(ctx, req.DeviceName))
+	if err != nil {
+		var rateLimitErr *service.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			respondRateLimited(ctx, rateLimitErr)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to create guest account",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user": gin.H{
+				"id":          user.ID,
+				"displayName": user.DisplayName,
+				"isGuest":     user.IsGuest,
+				"createdAt":   user.CreatedAt,
+			},
+			"token":        tokens.AccessToken,
+			"refreshToken": tokens.RefreshToken,
+		},
+	})
+}
+
+func (c *GuestController) Upgrade(ctx *gin.Context) {
+	var req upgradeGuestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	user, err := c.guestService.Upgrade(userID, req.Email, req.Password, req.DisplayName)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotGuest):
+			ctx.JSON(http.StatusConflict, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "NOT_GUEST",
+					"message": err.Error(),
+				},
+			})
+		case errors.Is(err, service.ErrWeakPassword):
+			respondWeakPassword(ctx, err)
+		case errors.Is(err, service.ErrEmailAlreadyInUse):
+			ctx.JSON(http.StatusConflict, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "EMAIL_IN_USE",
+					"message": err.Error(),
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "SERVER_ERROR",
+					"message": "Failed to upgrade guest account",
+				},
+			})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user": gin.H{
+				"id":            user.ID,
+				"email":         user.Email,
+				"displayName":   user.DisplayName,
+				"emailVerified": user.IsEmailVerified(),
+				"isGuest":       user.IsGuest,
+				"createdAt":     user.CreatedAt,
+			},
+		},
+	})
+}
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type HandleController struct {
+	handleService service.HandleService
+}
+
+func NewHandleController(handleService service.HandleService) *HandleController {
+	return &HandleController{
+		handleService: handleService,
+	}
+}
+
+type setHandleRequest struct {
+	Handle string `json:"handle" binding:"required"`
+}
+
+func (c *HandleController) SetHandle(ctx *gin.Context) {
+	var req setHandleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	user, err := c.handleService.SetHandle(userID, req.Handle)
+	if err != nil {
+		respondHandleError(ctx, err, "Failed to change handle")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user": userProfileJSON(user),
+		},
+	})
+}
+
+// GetPublicProfile needs no authentication. Old handles redirect to the
+// owner's current one, temporarily, as the handle is free again once the
+// redirect expires.
+func (c *HandleController) GetPublicProfile(ctx *gin.Context) {
+	profile, err := c.handleService.GetPublicProfile(ctx.Param("handle"))
+	if err != nil {
+		var movedErr *service.HandleMovedError
+		if errors.As(err, &movedErr) {
+			ctx.Redirect(http.StatusFound, path.Join(path.Dir(ctx.Request.URL.Path), url.PathEscape(movedErr.Handle)))
+			return
+		}
+		respondHandleError(ctx, err, "Failed to load profile")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"profile": gin.H{
+				"handle":      profile.Handle,
+				"displayName": profile.DisplayName,
+				"bio":         profile.Bio,
+				"avatarUrl":   profile.AvatarURL,
+				"counts": gin.H{
+					"recipes":   profile.RecipeCount,
+					"brewLogs":  profile.BrewLogCount,
+					"followers": profile.FollowerCount,
+				},
+			},
+		},
+	})
+}
+
+func respondHandleError(ctx *gin.Context, err error, failure string) {
+	var rateLimitErr *service.RateLimitError
+	switch {
+	case errors.As(err, &rateLimitErr):
+		respondRateLimited(ctx, rateLimitErr)
+	case errors.Is(err, service.ErrInvalidHandle):
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrHandleTaken):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "HANDLE_TAKEN",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrGuestAccount):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "GUEST_ACCOUNT",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrProfileNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "RESOURCE_NOT_FOUND",
+				"message": err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": failure,
+			},
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yashkadam007/brewkar/pkg/keyring"
+)
+
+// JWKSController publishes the public keys other services use to verify
+// Brewkar access tokens.
+type JWKSController struct {
+	keyring *keyring.Keyring
+}
+
+func NewJWKSController(kr *keyring.Keyring) *JWKSController {
+	return &JWKSController{
+		keyring: kr,
+	}
+}
+
+// JWKS serves the key set as a plain JWK Set document rather than the API
+// envelope, since that is what JWT libraries expect.
+func (c *JWKSController) JWKS(ctx *gin.Context) {
+	// Short enough that verifiers pick up a new key well before the first
+	// token signed with it could be presented to them after a rotation
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, c.keyring.JWKS())
+}
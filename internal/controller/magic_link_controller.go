@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type MagicLinkController struct {
+	magicLinkService service.MagicLinkService
+}
+
+func NewMagicLinkController(magicLinkService service.MagicLinkService) *MagicLinkController {
+	return &MagicLinkController{
+		magicLinkService: magicLinkService,
+	}
+}
+
+type magicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type magicLinkLoginRequest struct {
+	Token      string `json:"token" binding:"required"`
+	Nonce      string `json:"nonce" binding:"required"`
+	DeviceName string `json:"deviceName"`
+}
+
+func (c *MagicLinkController) Request(ctx *gin.Context) {
+	var req magicLinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	nonce, err := c.magicLinkService.RequestLink(req.Email)
+	if err != nil {
+		var rateLimitErr *service.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			respondRateLimited(ctx, rateLimitErr)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to send login link",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"message": "If an account exists for this email, a login link has been sent",
+			"nonce":   nonce,
+		},
+	})
+}
+
+func (c *MagicLinkController) Login(ctx *gin.Context) {
+	var req magicLinkLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	user, tokens, err := c.magicLinkService.Login(req.Token, req.Nonce, clientInfo(ctx, req.DeviceName))
+	if err != nil {
+		var mfaErr *service.MFARequiredError
+		switch {
+		case errors.As(err, &mfaErr):
+			ctx.JSON(http.StatusOK, gin.H{
+				"status": "success",
+				"data": gin.H{
+					"mfaRequired": true,
+					"mfaToken":    mfaErr.ChallengeToken,
+				},
+			})
+		case errors.Is(err, service.ErrAccountSuspended):
+			respondAccountSuspended(ctx)
+		case errors.Is(err, service.ErrInvalidMagicLink):
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "INVALID_TOKEN",
+					"message": err.Error(),
+				},
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "SERVER_ERROR",
+					"message": "Failed to log in",
+				},
+			})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user": gin.H{
+				"id":            user.ID,
+				"email":         user.Email,
+				"displayName":   user.DisplayName,
+				"emailVerified": user.IsEmailVerified(),
+				"lastLoginAt":   user.LastLoginAt,
+			},
+			"token":        tokens.AccessToken,
+			"refreshToken": tokens.RefreshToken,
+		},
+	})
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type MFAController struct {
+	mfaService service.MFAService
+}
+
+func NewMFAController(mfaService service.MFAService) *MFAController {
+	return &MFAController{
+		mfaService: mfaService,
+	}
+}
+
+type mfaCodeRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+func (c *MFAController) Enroll(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	enrollment, err := c.mfaService.Enroll(userID)
+	if err != nil {
+		if errors.Is(err, service.ErrMFAAlreadyEnabled) {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "MFA_ALREADY_ENABLED",
+					"message": err.Error(),
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to set up two-factor authentication",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"secret":     enrollment.Secret,
+			"otpauthUri": enrollment.URI,
+		},
+	})
+}
+
+func (c *MFAController) Confirm(ctx *gin.Context) {
+	var req mfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	recoveryCodes, err := c.mfaService.Confirm(userID, req.Password, req.Code)
+	if err != nil {
+		respondMFAError(ctx, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"recoveryCodes": recoveryCodes,
+		},
+	})
+}
+
+func (c *MFAController) Disable(ctx *gin.Context) {
+	var req mfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	if err := c.mfaService.Disable(userID, req.Password, req.Code); err != nil {
+		respondMFAError(ctx, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
+
+func respondMFAError(ctx *gin.Context, err error, fallback string) {
+	var rateLimitErr *service.RateLimitError
+	switch {
+	case errors.As(err, &rateLimitErr):
+		respondRateLimited(ctx, rateLimitErr)
+	case errors.Is(err, service.ErrIncorrectPassword):
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INCORRECT_PASSWORD",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrMFANotEnrolled):
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "MFA_NOT_ENROLLED",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "MFA_ALREADY_ENABLED",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrInvalidMFACode):
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_MFA_CODE",
+				"message": err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": fallback,
+			},
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type OAuthController struct {
+	socialAuthService service.SocialAuthService
+}
+
+func NewOAuthController(socialAuthService service.SocialAuthService) *OAuthController {
+	return &OAuthController{
+		socialAuthService: socialAuthService,
+	}
+}
+
+type oauthCallbackRequest struct {
+	Code       string `json:"code" binding:"required"`
+	State      string `json:"state" binding:"required"`
+	Binding    string `json:"binding" binding:"required"`
+	DeviceName string `json:"deviceName"`
+}
+
+type oauthLinkCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+func (c *OAuthController) Start(ctx *gin.Context) {
+	authURL, binding, err := c.socialAuthService.StartLogin(ctx.Param("provider"))
+	if err != nil {
+		respondOAuthError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"authorizationUrl": authURL,
+			"binding":          binding,
+		},
+	})
+}
+
+func (c *OAuthController) Callback(ctx *gin.Context) {
+	var req oauthCallbackRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	user, tokens, err := c.socialAuthService.CompleteLogin(ctx.Param("provider"), req.State, req.Binding, req.Code, clientInfo(ctx, req.DeviceName))
+	if err != nil {
+		var mfaErr *service.MFARequiredError
+		if errors.As(err, &mfaErr) {
+			ctx.JSON(http.StatusOK, gin.H{
+				"status": "success",
+				"data": gin.H{
+					"mfaRequired": true,
+					"mfaToken":    mfaErr.ChallengeToken,
+				},
+			})
+			return
+		}
+		respondOAuthError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user": gin.H{
+				"id":            user.ID,
+				"email":         user.Email,
+				"displayName":   user.DisplayName,
+				"emailVerified": user.IsEmailVerified(),
+				"lastLoginAt":   user.LastLoginAt,
+			},
+			"token":        tokens.AccessToken,
+			"refreshToken": tokens.RefreshToken,
+		},
+	})
+}
+
+func (c *OAuthController) StartLink(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	authURL, err := c.socialAuthService.StartLink(ctx.Param("provider"), userID)
+	if err != nil {
+		respondOAuthError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"authorizationUrl": authURL,
+		},
+	})
+}
+
+func (c *OAuthController) LinkCallback(ctx *gin.Context) {
+	var req oauthLinkCallbackRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	identity, err := c.socialAuthService.CompleteLink(ctx.Param("provider"), userID, req.State, req.Code)
+	if err != nil {
+		respondOAuthError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"identity": identity,
+		},
+	})
+}
+
+func (c *OAuthController) ListIdentities(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	identities, err := c.socialAuthService.ListIdentities(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to list identities",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"identities": identities,
+		},
+	})
+}
+
+func respondOAuthError(ctx *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	code := "SERVER_ERROR"
+	message := "Failed to sign in with the identity provider"
+
+	switch {
+	case errors.Is(err, service.ErrUnknownProvider):
+		status, code, message = http.StatusNotFound, "RESOURCE_NOT_FOUND", err.Error()
+	case errors.Is(err, service.ErrInvalidOAuthState):
+		status, code, message = http.StatusBadRequest, "INVALID_STATE", err.Error()
+	case errors.Is(err, service.ErrOAuthFailed):
+		status, code, message = http.StatusUnauthorized, "OAUTH_FAILED", service.ErrOAuthFailed.Error()
+	case errors.Is(err, service.ErrProviderEmailMissing):
+		status, code, message = http.StatusBadRequest, "EMAIL_REQUIRED", err.Error()
+	case errors.Is(err, service.ErrAccountExists):
+		status, code, message = http.StatusConflict, "ACCOUNT_EXISTS", err.Error()
+	case errors.Is(err, service.ErrIdentityAlreadyLinked):
+		status, code, message = http.StatusConflict, "IDENTITY_LINKED", err.Error()
+	case errors.Is(err, service.ErrAccountSuspended):
+		status, code, message = http.StatusForbidden, "ACCOUNT_SUSPENDED", err.Error()
+	}
+
+	ctx.JSON(status, gin.H{
+		"status": "error",
+		"error": gin.H{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
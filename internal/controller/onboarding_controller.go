@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type OnboardingController struct {
+	onboardingService service.OnboardingService
+}
+
+func NewOnboardingController(onboardingService service.OnboardingService) *OnboardingController {
+	return &OnboardingController{
+		onboardingService: onboardingService,
+	}
+}
+
+func (c *OnboardingController) Get(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	onboarding, err := c.onboardingService.GetOnboarding(userID)
+	if err != nil {
+		respondOnboardingError(ctx, err, "Failed to load onboarding")
+		return
+	}
+	respondOnboarding(ctx, onboarding)
+}
+
+// AnswerStep takes the step's answers as the request body.
+func (c *OnboardingController) AnswerStep(ctx *gin.Context) {
+	answers, err := io.ReadAll(ctx.Request.Body)
+	if err != nil || len(answers) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	onboarding, err := c.onboardingService.AnswerStep(userID, domain.OnboardingStep(ctx.Param("step")), answers)
+	if err != nil {
+		respondOnboardingError(ctx, err, "Failed to save answers")
+		return
+	}
+	respondOnboarding(ctx, onboarding)
+}
+
+func (c *OnboardingController) SkipStep(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	onboarding, err := c.onboardingService.SkipStep(userID, domain.OnboardingStep(ctx.Param("step")))
+	if err != nil {
+		respondOnboardingError(ctx, err, "Failed to skip step")
+		return
+	}
+	respondOnboarding(ctx, onboarding)
+}
+
+func (c *OnboardingController) SkipRemaining(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	onboarding, err := c.onboardingService.SkipRemaining(userID)
+	if err != nil {
+		respondOnboardingError(ctx, err, "Failed to skip onboarding")
+		return
+	}
+	respondOnboarding(ctx, onboarding)
+}
+
+func respondOnboarding(ctx *gin.Context, onboarding *domain.Onboarding) {
+	status := "in_progress"
+	var currentStep interface{} = onboarding.CurrentStep
+	if onboarding.IsComplete() {
+		status = "completed"
+		currentStep = nil
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"onboarding": gin.H{
+				"status":         status,
+				"steps":          domain.OnboardingSteps,
+				"currentStep":    currentStep,
+				"completedSteps": onboarding.CompletedSteps,
+				"skippedSteps":   onboarding.SkippedSteps,
+				"startedAt":      onboarding.StartedAt,
+				"completedAt":    onboarding.CompletedAt,
+			},
+		},
+	})
+}
+
+func respondOnboardingError(ctx *gin.Context, err error, failure string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidOnboardingAnswers), errors.Is(err, service.ErrInvalidPreferences):
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrUnknownOnboardingStep):
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "RESOURCE_NOT_FOUND",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrOnboardingStepOutOfOrder):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "STEP_OUT_OF_ORDER",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrOnboardingComplete):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "ONBOARDING_COMPLETE",
+				"message": err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": failure,
+			},
+		})
+	}
+}
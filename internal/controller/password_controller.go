@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type PasswordController struct {
+	passwordService service.PasswordService
+}
+
+func NewPasswordController(passwordService service.PasswordService) *PasswordController {
+	return &PasswordController{
+		passwordService: passwordService,
+	}
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+func (c *PasswordController) ForgotPassword(ctx *gin.Context) {
+	var req forgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	if err := c.passwordService.ForgotPassword(req.Email); err != nil {
+		var rateLimitErr *service.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			respondRateLimited(ctx, rateLimitErr)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to send password reset email",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"message": "If an account exists for this email, a password reset link has been sent",
+		},
+	})
+}
+
+func (c *PasswordController) ResetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	if err := c.passwordService.ResetPassword(req.Token, req.Password); err != nil {
+		if errors.Is(err, service.ErrWeakPassword) {
+			respondWeakPassword(ctx, err)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidResetToken) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "INVALID_TOKEN",
+					"message": err.Error(),
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to reset password",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
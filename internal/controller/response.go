@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+// respondRateLimited writes a 429 response with a Retry-After header.
+func respondRateLimited(ctx *gin.Context, err *service.RateLimitError) {
+	setRetryAfter(ctx, err.RetryAfter)
+	ctx.JSON(http.StatusTooManyRequests, gin.H{
+		"status": "error",
+		"error": gin.H{
+			"code":    "RATE_LIMIT_EXCEEDED",
+			"message": err.Error(),
+		},
+	})
+}
+
+// respondLoginLocked writes a 429 response for a locked account or IP address.
+func respondLoginLocked(ctx *gin.Context, err *service.LoginLockedError) {
+	setRetryAfter(ctx, err.RetryAfter)
+	ctx.JSON(http.StatusTooManyRequests, gin.H{
+		"status": "error",
+		"error": gin.H{
+			"code":    "LOGIN_LOCKED",
+			"message": err.Error(),
+		},
+	})
+}
+
+// respondAccountSuspended writes a 403 response for a suspended account.
+func respondAccountSuspended(ctx *gin.Context) {
+	ctx.JSON(http.StatusForbidden, gin.H{
+		"status": "error",
+		"error": gin.H{
+			"code":    "ACCOUNT_SUSPENDED",
+			"message": service.ErrAccountSuspended.Error(),
+		},
+	})
+}
+
+// respondWeakPassword writes a 400 response for a password the password policy
+// rejected.
+func respondWeakPassword(ctx *gin.Context, err error) {
+	ctx.JSON(http.StatusBadRequest, gin.H{
+		"status": "error",
+		"error": gin.H{
+			"code":    "WEAK_PASSWORD",
+			"message": err.Error(),
+		},
+	})
+}
+
+func setRetryAfter(ctx *gin.Context, retryAfter time.Duration) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+}
+
+// respondInvalidRequest writes a 400 response for a malformed request.
+func respondInvalidRequest(ctx *gin.Context, message string) {
+	ctx.JSON(http.StatusBadRequest, gin.H{
+		"status": "error",
+		"error": gin.H{
+			"code":    "INVALID_REQUEST",
+			"message": message,
+		},
+	})
+}
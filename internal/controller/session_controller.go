@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type SessionController struct {
+	sessionService service.SessionService
+}
+
+func NewSessionController(sessionService service.SessionService) *SessionController {
+	return &SessionController{
+		sessionService: sessionService,
+	}
+}
+
+func (c *SessionController) List(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+	currentSessionID := ctx.MustGet("sessionID").(uuid.UUID)
+
+	sessions, err := c.sessionService.ListSessions(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to list sessions",
+			},
+		})
+		return
+	}
+
+	items := make([]gin.H, 0, len(sessions))
+	for _, session := range sessions {
+		items = append(items, gin.H{
+			"id":         session.ID,
+			"deviceName": session.DeviceName,
+			"userAgent":  session.UserAgent,
+			"ipAddress":  session.IPAddress,
+			"createdAt":  session.CreatedAt,
+			"lastSeenAt": session.LastSeenAt,
+			"current":    session.ID == currentSessionID,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"sessions": items,
+		},
+	})
+}
+
+func (c *SessionController) Revoke(ctx *gin.Context) {
+	sessionID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid session ID",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	if err := c.sessionService.RevokeSession(userID, sessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "RESOURCE_NOT_FOUND",
+					"message": err.Error(),
+				},
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": "Failed to revoke session",
+			},
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
@@ -0,0 +1,337 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+type UserController struct {
+	userService service.UserService
+}
+
+func NewUserController(userService service.UserService) *UserController {
+	return &UserController{
+		userService: userService,
+	}
+}
+
+// Fields left out of the request are not changed
+type updateProfileRequest struct {
+	DisplayName *string         `json:"displayName"`
+	Bio         *string         `json:"bio"`
+	Preferences json.RawMessage `json:"preferences"`
+}
+
+type changeEmailRequest struct {
+	Email           string `json:"email" binding:"required,email"`
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8"`
+}
+
+func (c *UserController) GetProfile(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	user, err := c.userService.GetProfile(userID)
+	if err != nil {
+		respondUserError(ctx, err, "Failed to load profile")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user": userProfileJSON(user),
+		},
+	})
+}
+
+func (c *UserController) UpdateProfile(ctx *gin.Context) {
+	var req updateProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	user, err := c.userService.UpdateProfile(userID, service.ProfileUpdate{
+		DisplayName: req.DisplayName,
+		Bio:         req.Bio,
+		Preferences: req.Preferences,
+	})
+	if err != nil {
+		respondUserError(ctx, err, "Failed to update profile")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user": userProfileJSON(user),
+		},
+	})
+}
+
+func (c *UserController) UploadAvatar(ctx *gin.Context) {
+	file, err := ctx.FormFile("avatar")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Expected an image in the avatar form field",
+			},
+		})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		respondUserError(ctx, err, "Failed to read avatar")
+		return
+	}
+	defer f.Close()
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	user, err := c.userService.UploadAvatar(userID, f)
+	if err != nil {
+		respondUserError(ctx, err, "Failed to upload avatar")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user": userProfileJSON(user),
+		},
+	})
+}
+
+func (c *UserController) RemoveAvatar(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	user, err := c.userService.RemoveAvatar(userID)
+	if err != nil {
+		respondUserError(ctx, err, "Failed to remove avatar")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user": userProfileJSON(user),
+		},
+	})
+}
+
+func (c *UserController) GetPreferences(ctx *gin.Context) {
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	preferences, err := c.userService.GetPreferences(userID)
+	if err != nil {
+		respondUserError(ctx, err, "Failed to load preferences")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"preferences": preferences,
+		},
+	})
+}
+
+// UpdatePreferences takes a JSON Merge Patch (RFC 7386). Plain JSON is
+// accepted as well, since most clients send that content type.
+func (c *UserController) UpdatePreferences(ctx *gin.Context) {
+	switch ctx.ContentType() {
+	case "application/merge-patch+json", "application/json":
+	default:
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "UNSUPPORTED_MEDIA_TYPE",
+				"message": "Expected an application/merge-patch+json body",
+			},
+		})
+		return
+	}
+
+	patch, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	preferences, err := c.userService.UpdatePreferences(userID, patch)
+	if err != nil {
+		respondUserError(ctx, err, "Failed to update preferences")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"preferences": preferences,
+		},
+	})
+}
+
+func (c *UserController) ChangeEmail(ctx *gin.Context) {
+	var req changeEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+
+	user, err := c.userService.ChangeEmail(userID, req.Email, req.CurrentPassword)
+	if err != nil {
+		respondUserError(ctx, err, "Failed to change email address")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"user": userProfileJSON(user),
+		},
+	})
+}
+
+func (c *UserController) ChangePassword(ctx *gin.Context) {
+	var req changePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INVALID_REQUEST",
+				"message": "Invalid request format",
+			},
+		})
+		return
+	}
+
+	userID := ctx.MustGet("userID").(uuid.UUID)
+	sessionID := ctx.MustGet("sessionID").(uuid.UUID)
+
+	if err := c.userService.ChangePassword(userID, sessionID, req.CurrentPassword, req.NewPassword); err != nil {
+		respondUserError(ctx, err, "Failed to change password")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
+
+func userProfileJSON(user *domain.User) gin.H {
+	return gin.H{
+		"id":            user.ID,
+		"email":         user.Email,
+		"emailVerified": user.IsEmailVerified(),
+		"isGuest":       user.IsGuest,
+		"handle":        user.Handle,
+		"displayName":   user.DisplayName,
+		"bio":           user.Bio,
+		"avatarUrl":     user.AvatarURL,
+		"preferences":   user.Preferences.OrDefault(),
+		"createdAt":     user.CreatedAt,
+		"updatedAt":     user.UpdatedAt,
+		"lastLoginAt":   user.LastLoginAt,
+	}
+}
+
+func respondUserError(ctx *gin.Context, err error, failure string) {
+	var rateLimitErr *service.RateLimitError
+	switch {
+	case errors.As(err, &rateLimitErr):
+		respondRateLimited(ctx, rateLimitErr)
+	case errors.Is(err, service.ErrInvalidDisplayName),
+		errors.Is(err, service.ErrBioTooLong),
+		errors.Is(err, service.ErrInvalidPreferences),
+		errors.Is(err, service.ErrInvalidAvatar):
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrAvatarTooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "FILE_TOO_LARGE",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrWeakPassword):
+		respondWeakPassword(ctx, err)
+	case errors.Is(err, service.ErrIncorrectPassword):
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "INCORRECT_PASSWORD",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrEmailAlreadyInUse):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "EMAIL_IN_USE",
+				"message": err.Error(),
+			},
+		})
+	case errors.Is(err, service.ErrGuestAccount):
+		ctx.JSON(http.StatusConflict, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "GUEST_ACCOUNT",
+				"message": err.Error(),
+			},
+		})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error": gin.H{
+				"code":    "SERVER_ERROR",
+				"message": failure,
+			},
+		})
+	}
+}
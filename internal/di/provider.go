@@ -2,15 +2,34 @@ package di
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/internal/service"
+	"github.com/yashkadam007/brewkar/pkg/handles"
+	"github.com/yashkadam007/brewkar/pkg/keyring"
 	"github.com/yashkadam007/brewkar/pkg/logger"
+	"github.com/yashkadam007/brewkar/pkg/mailer"
+	"github.com/yashkadam007/brewkar/pkg/oidc"
+	"github.com/yashkadam007/brewkar/pkg/passwords"
+	"github.com/yashkadam007/brewkar/pkg/scheduler"
+	"github.com/yashkadam007/brewkar/pkg/storage"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// App is the HTTP router together with the background jobs.
+type App struct {
+	Router    *gin.Engine
+	Scheduler *scheduler.Scheduler
+}
+
 // ProvideConfig loads the application configuration
 func ProvideConfig() (*config.Config, error) {
 	cfg, err := config.LoadConfig(".")
@@ -31,7 +50,8 @@ func ProvideDatabase(cfg *config.Config, l *logger.Logger) (*gorm.DB, error) {
 		cfg.Database.Host, cfg.Database.Port, cfg.Database.User,
 		cfg.Database.Password, cfg.Database.DBName, cfg.Database.SSLMode)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// Unique violations surface as gorm.ErrDuplicatedKey
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		l.Fatal("Failed to connect to database", err)
 		return nil, err
@@ -56,3 +76,208 @@ func ProvideRedisClient(cfg *config.Config, l *logger.Logger) (*redis.Client, er
 
 	return rdb, nil
 }
+
+// ProvideMailer returns the mailer selected by the mail driver setting
+func ProvideMailer(cfg *config.Config) (mailer.Mailer, error) {
+	switch cfg.Mail.Driver {
+	case "smtp":
+		return mailer.NewSMTPMailer(cfg.Mail.Host, cfg.Mail.Port, cfg.Mail.Username, cfg.Mail.Password, cfg.Mail.From), nil
+	case "file", "":
+		return mailer.NewFileMailer(cfg.Mail.OutputDir, cfg.Mail.From)
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Mail.Driver)
+	}
+}
+
+// backgroundMailer sends email without making the caller wait and logs
+// failures. Services whose replies must not tell whether an account exists
+// send through it.
+func backgroundMailer(m mailer.Mailer, l *logger.Logger) mailer.Mailer {
+	return mailer.Async(m, func(msg mailer.Message, err error) {
+		l.Warn(fmt.Sprintf("Failed to send email %q", msg.Subject), err)
+	})
+}
+
+// ProvideStorage returns the file storage selected by the storage driver
+// setting
+func ProvideStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.Storage.Driver {
+	case "local", "":
+		return storage.NewLocalStorage(cfg.Storage.LocalDir, cfg.Storage.PublicURL)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+// ProvideUploadsHandler returns the handler serving uploaded files, or nil
+// when the storage is served from elsewhere
+func ProvideUploadsHandler(store storage.Storage) http.Handler {
+	if handler, ok := store.(http.Handler); ok {
+		return handler
+	}
+	return nil
+}
+
+// ProvideKeyring loads the JWT signing keys from the key directory and the
+// config. Without any keys an ephemeral key is generated, so tokens do not
+// survive a restart; that is only acceptable in development.
+func ProvideKeyring(cfg *config.Config, l *logger.Logger) (*keyring.Keyring, error) {
+	var keys []*keyring.Key
+	if cfg.JWT.KeyDir != "" {
+		dirKeys, err := keyring.LoadDir(cfg.JWT.KeyDir)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, dirKeys...)
+	}
+	for _, keyCfg := range cfg.JWT.Keys {
+		key, err := keyring.ParseKey(keyCfg.ID, []byte(keyCfg.PEM))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		l.Warn("No JWT signing keys configured, using an ephemeral key", nil)
+		key, err := keyring.GenerateEd25519("ephemeral")
+		if err != nil {
+			return nil, err
+		}
+		return keyring.New([]*keyring.Key{key}, "")
+	}
+
+	return keyring.New(keys, cfg.JWT.SigningKeyID)
+}
+
+// ProvidePasswordHasher returns the hasher for user passwords, using the
+// configured Argon2id cost for new hashes
+func ProvidePasswordHasher(cfg *config.Config) *passwords.Hasher {
+	argon := cfg.Password.Argon2
+	return passwords.NewHasher(passwords.Argon2idParams{
+		Memory:      uint32(argon.Memory),
+		Iterations:  uint32(argon.Iterations),
+		Parallelism: uint8(argon.Parallelism),
+		SaltLength:  uint32(argon.SaltLength),
+		KeyLength:   uint32(argon.KeyLength),
+	})
+}
+
+// ProvidePasswordPolicy builds the password policy, loading the breached
+// password list if one is configured
+func ProvidePasswordPolicy(cfg *config.Config) (*passwords.Policy, error) {
+	var breached []string
+	if cfg.Password.BreachedListFile != "" {
+		list, err := passwords.LoadList(cfg.Password.BreachedListFile)
+		if err != nil {
+			return nil, err
+		}
+		breached = list
+	}
+	return passwords.NewPolicy(cfg.Password.MinLength, cfg.Password.MaxLength, breached), nil
+}
+
+// ProvideHandlePolicy builds the handle policy, loading the blocked word
+// list if one is configured
+func ProvideHandlePolicy(cfg *config.Config) (*handles.Policy, error) {
+	var blocked []string
+	if cfg.Users.BlockedHandleWordsFile != "" {
+		list, err := handles.LoadList(cfg.Users.BlockedHandleWordsFile)
+		if err != nil {
+			return nil, err
+		}
+		blocked = list
+	}
+	return handles.NewPolicy(blocked), nil
+}
+
+// ProvideIdentityProviders creates the configured social login providers.
+// Providers without a client ID are skipped.
+func ProvideIdentityProviders(cfg *config.Config) []service.IdentityProvider {
+	var providers []service.IdentityProvider
+	for _, p := range cfg.OAuth.Providers {
+		if p.ClientID == "" {
+			continue
+		}
+		providers = append(providers, oidc.New(oidc.Config{
+			Name:         p.Name,
+			IssuerURL:    p.IssuerURL,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			Scopes:       p.Scopes,
+			ResponseMode: p.ResponseMode,
+		}, nil))
+	}
+	return providers
+}
+
+// ProvideScheduler registers the background jobs.
+func ProvideScheduler(
+	locker repository.JobLockRepository,
+	l *logger.Logger,
+	exportService service.DataExportService,
+	deletionService service.AccountDeletionService,
+	beanService service.BeanService,
+) *scheduler.Scheduler {
+	s := scheduler.New(locker, func(job string, err error) {
+		l.Warn(fmt.Sprintf("Scheduled job %s failed", job), err)
+	})
+
+	s.Add(scheduler.Job{
+		Name:     "guest-cleanup",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			deleted, err := deletionService.DeleteAbandonedGuests()
+			if deleted > 0 {
+				l.Info("Deleted %d abandoned guest accounts", deleted)
+			}
+			return err
+		},
+	})
+
+	s.Add(scheduler.Job{
+		Name:     "data-exports",
+		Interval: time.Minute,
+		Run: func(ctx context.Context) error {
+			// Expired archives are deleted even when building others failed
+			built, buildErr := exportService.ProcessPendingExports()
+			if built > 0 {
+				l.Info("Built %d data exports", built)
+			}
+			deleted, deleteErr := exportService.DeleteExpiredExports()
+			if deleted > 0 {
+				l.Info("Deleted %d expired data exports", deleted)
+			}
+			return errors.Join(buildErr, deleteErr)
+		},
+	})
+
+	s.Add(scheduler.Job{
+		Name:     "account-deletion",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			deleted, err := deletionService.DeleteScheduledAccounts()
+			if deleted > 0 {
+				l.Info("Deleted %d accounts after their grace period", deleted)
+			}
+			return err
+		},
+	})
+
+	s.Add(scheduler.Job{
+		Name:     "bean-freshness",
+		Interval: 24 * time.Hour,
+		// On the clock, so restarts during the day do not keep putting it off
+		Aligned: true,
+		At:      8 * time.Hour,
+		Run: func(ctx context.Context) error {
+			sent, err := beanService.NotifyFreshness()
+			if sent > 0 {
+				l.Info("Sent %d bean freshness emails", sent)
+			}
+			return err
+		},
+	})
+
+	return s
+}
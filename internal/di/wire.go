@@ -8,42 +8,341 @@ import (
 	"github.com/google/wire"
 	"github.com/yashkadam007/brewkar/internal/config"
 	"github.com/yashkadam007/brewkar/internal/controller"
+	"github.com/yashkadam007/brewkar/internal/middleware"
 	"github.com/yashkadam007/brewkar/internal/repository"
 	"github.com/yashkadam007/brewkar/internal/router"
 	"github.com/yashkadam007/brewkar/internal/service"
+	"github.com/yashkadam007/brewkar/pkg/handles"
+	"github.com/yashkadam007/brewkar/pkg/keyring"
+	"github.com/yashkadam007/brewkar/pkg/logger"
+	"github.com/yashkadam007/brewkar/pkg/mailer"
+	"github.com/yashkadam007/brewkar/pkg/passwords"
+	"github.com/yashkadam007/brewkar/pkg/storage"
 )
 
 var infraSet = wire.NewSet(
 	ProvideConfig,
 	ProvideLogger,
 	ProvideDatabase,
+	ProvideRedisClient,
+	ProvideMailer,
+	ProvideStorage,
+	ProvideUploadsHandler,
+	ProvideKeyring,
+	ProvidePasswordHasher,
+	ProvidePasswordPolicy,
+	ProvideHandlePolicy,
+	ProvideIdentityProviders,
+	ProvideScheduler,
 )
 
 var repoSet = wire.NewSet(
 	repository.NewUserRepository,
+	repository.NewRefreshTokenRepository,
+	repository.NewTokenRevocationRepository,
+	repository.NewActionTokenRepository,
+	repository.NewRateLimitRepository,
+	repository.NewMFARepository,
+	repository.NewLoginAttemptRepository,
+	repository.NewSessionRepository,
+	repository.NewUserIdentityRepository,
+	repository.NewOAuthStateRepository,
+	repository.NewJobLockRepository,
+	repository.NewSuspensionRepository,
+	repository.NewAPIKeyRepository,
+	repository.NewHandleChangeRepository,
+	repository.NewEquipmentRepository,
+	repository.NewOnboardingRepository,
+	repository.NewDataExportRepository,
+	repository.NewBeanRepository,
+	repository.NewBeanStockRepository,
+	repository.NewBeanEventRepository,
+	repository.NewCatalogRepository,
 )
 
 var serviceSet = wire.NewSet(
+	wire.Bind(new(service.PasswordHasher), new(*passwords.Hasher)),
 	wire.Bind(new(service.AuthService), new(*service.AuthServiceImpl)),
 	provideAuthService,
+	wire.Bind(new(service.PasswordService), new(*service.PasswordServiceImpl)),
+	providePasswordService,
+	wire.Bind(new(service.MagicLinkService), new(*service.MagicLinkServiceImpl)),
+	provideMagicLinkService,
+	wire.Bind(new(service.EmailVerificationService), new(*service.EmailVerificationServiceImpl)),
+	provideEmailVerificationService,
+	wire.Bind(new(service.MFAService), new(*service.MFAServiceImpl)),
+	provideMFAService,
+	wire.Bind(new(service.SessionService), new(*service.SessionServiceImpl)),
+	provideSessionService,
+	wire.Bind(new(service.SocialAuthService), new(*service.SocialAuthServiceImpl)),
+	provideSocialAuthService,
+	wire.Bind(new(service.GuestService), new(*service.GuestServiceImpl)),
+	provideGuestService,
+	wire.Bind(new(service.AdminService), new(*service.AdminServiceImpl)),
+	provideAdminService,
+	wire.Bind(new(service.APIKeyService), new(*service.APIKeyServiceImpl)),
+	provideAPIKeyService,
+	wire.Bind(new(service.UserService), new(*service.UserServiceImpl)),
+	provideUserService,
+	wire.Bind(new(service.HandleService), new(*service.HandleServiceImpl)),
+	provideHandleService,
+	wire.Bind(new(service.EquipmentService), new(*service.EquipmentServiceImpl)),
+	provideEquipmentService,
+	wire.Bind(new(service.OnboardingService), new(*service.OnboardingServiceImpl)),
+	provideOnboardingService,
+	wire.Bind(new(service.DataExportService), new(*service.DataExportServiceImpl)),
+	provideDataExportService,
+	wire.Bind(new(service.AccountDeletionService), new(*service.AccountDeletionServiceImpl)),
+	provideAccountDeletionService,
+	wire.Bind(new(service.BeanService), new(*service.BeanServiceImpl)),
+	provideBeanService,
+	wire.Bind(new(service.CatalogService), new(*service.CatalogServiceImpl)),
+	provideCatalogService,
 )
 
 var controllerSet = wire.NewSet(
 	controller.NewAuthController,
+	controller.NewPasswordController,
+	controller.NewMagicLinkController,
+	controller.NewEmailVerificationController,
+	controller.NewMFAController,
+	controller.NewSessionController,
+	controller.NewJWKSController,
+	controller.NewOAuthController,
+	controller.NewGuestController,
+	controller.NewAdminController,
+	controller.NewAPIKeyController,
+	controller.NewUserController,
+	controller.NewHandleController,
+	controller.NewOnboardingController,
+	controller.NewEquipmentController,
+	controller.NewDataExportController,
+	controller.NewAccountDeletionController,
+	controller.NewBeanController,
+	controller.NewCatalogController,
+)
+
+var middlewareSet = wire.NewSet(
+	provideAuthMiddleware,
+	middleware.NewAPIKeyAuth,
 )
 
 // InitializeApp initializes the complete application
-func InitializeApp() (*gin.Engine, error) {
+func InitializeApp() (*App, error) {
 	panic(wire.Build(
 		infraSet,
 		repoSet,
 		serviceSet,
 		controllerSet,
+		middlewareSet,
 		router.SetupRouter,
+		wire.Struct(new(App), "*"),
 	))
 }
 
 // Provider functions
-func provideAuthService(userRepo repository.UserRepository, cfg *config.Config) *service.AuthServiceImpl {
-	return service.NewAuthService(userRepo, cfg.JWT).(*service.AuthServiceImpl)
+func provideAuthService(
+	userRepo repository.UserRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	revocationRepo repository.TokenRevocationRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	verificationSvc service.EmailVerificationService,
+	mfaService service.MFAService,
+	sessionService service.SessionService,
+	hasher service.PasswordHasher,
+	policy *passwords.Policy,
+	kr *keyring.Keyring,
+	cfg *config.Config,
+) *service.AuthServiceImpl {
+	return service.NewAuthService(userRepo, refreshTokenRepo, revocationRepo, rateLimitRepo, loginAttemptRepo, verificationSvc, mfaService, sessionService, hasher, policy, kr, cfg.JWT, cfg.Auth).(*service.AuthServiceImpl)
+}
+
+func providePasswordService(
+	userRepo repository.UserRepository,
+	actionTokenRepo repository.ActionTokenRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	authService service.AuthService,
+	hasher service.PasswordHasher,
+	policy *passwords.Policy,
+	m mailer.Mailer,
+	l *logger.Logger,
+	cfg *config.Config,
+) *service.PasswordServiceImpl {
+	return service.NewPasswordService(userRepo, actionTokenRepo, rateLimitRepo, loginAttemptRepo, authService, hasher, policy, backgroundMailer(m, l), cfg.App, cfg.Auth).(*service.PasswordServiceImpl)
+}
+
+func provideMagicLinkService(
+	userRepo repository.UserRepository,
+	actionTokenRepo repository.ActionTokenRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	authService service.AuthService,
+	m mailer.Mailer,
+	l *logger.Logger,
+	cfg *config.Config,
+) *service.MagicLinkServiceImpl {
+	return service.NewMagicLinkService(userRepo, actionTokenRepo, rateLimitRepo, authService, backgroundMailer(m, l), cfg.App, cfg.Auth).(*service.MagicLinkServiceImpl)
+}
+
+func provideEmailVerificationService(
+	userRepo repository.UserRepository,
+	actionTokenRepo repository.ActionTokenRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	m mailer.Mailer,
+	cfg *config.Config,
+) *service.EmailVerificationServiceImpl {
+	return service.NewEmailVerificationService(userRepo, actionTokenRepo, rateLimitRepo, m, cfg.App, cfg.Auth).(*service.EmailVerificationServiceImpl)
+}
+
+func provideMFAService(
+	userRepo repository.UserRepository,
+	mfaRepo repository.MFARepository,
+	rateLimitRepo repository.RateLimitRepository,
+	hasher service.PasswordHasher,
+	cfg *config.Config,
+) *service.MFAServiceImpl {
+	return service.NewMFAService(userRepo, mfaRepo, rateLimitRepo, hasher, cfg.App).(*service.MFAServiceImpl)
+}
+
+func provideSessionService(
+	sessionRepo repository.SessionRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	revocationRepo repository.TokenRevocationRepository,
+	cfg *config.Config,
+) *service.SessionServiceImpl {
+	return service.NewSessionService(sessionRepo, refreshTokenRepo, revocationRepo, cfg.JWT).(*service.SessionServiceImpl)
+}
+
+func provideSocialAuthService(
+	userRepo repository.UserRepository,
+	identityRepo repository.UserIdentityRepository,
+	stateRepo repository.OAuthStateRepository,
+	authService service.AuthService,
+	verificationSvc service.EmailVerificationService,
+	providers []service.IdentityProvider,
+	cfg *config.Config,
+) *service.SocialAuthServiceImpl {
+	return service.NewSocialAuthService(userRepo, identityRepo, stateRepo, authService, verificationSvc, providers, cfg.App, cfg.OAuth).(*service.SocialAuthServiceImpl)
+}
+
+func provideGuestService(
+	userRepo repository.UserRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	authService service.AuthService,
+	verificationSvc service.EmailVerificationService,
+	hasher service.PasswordHasher,
+	policy *passwords.Policy,
+	cfg *config.Config,
+) *service.GuestServiceImpl {
+	return service.NewGuestService(userRepo, rateLimitRepo, authService, verificationSvc, hasher, policy, cfg.Auth).(*service.GuestServiceImpl)
+}
+
+func provideAdminService(
+	userRepo repository.UserRepository,
+	suspensionRepo repository.SuspensionRepository,
+	revocationRepo repository.TokenRevocationRepository,
+	cfg *config.Config,
+) *service.AdminServiceImpl {
+	return service.NewAdminService(userRepo, suspensionRepo, revocationRepo, cfg.JWT).(*service.AdminServiceImpl)
+}
+
+func provideAPIKeyService(
+	apiKeyRepo repository.APIKeyRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	cfg *config.Config,
+) *service.APIKeyServiceImpl {
+	return service.NewAPIKeyService(apiKeyRepo, rateLimitRepo, cfg.APIKeys).(*service.APIKeyServiceImpl)
+}
+
+func provideUserService(
+	userRepo repository.UserRepository,
+	actionTokenRepo repository.ActionTokenRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	verificationSvc service.EmailVerificationService,
+	sessionService service.SessionService,
+	hasher service.PasswordHasher,
+	policy *passwords.Policy,
+	store storage.Storage,
+	m mailer.Mailer,
+	cfg *config.Config,
+) *service.UserServiceImpl {
+	return service.NewUserService(userRepo, actionTokenRepo, rateLimitRepo, verificationSvc, sessionService, hasher, policy, store, m, cfg.App, cfg.Users).(*service.UserServiceImpl)
+}
+
+func provideHandleService(
+	userRepo repository.UserRepository,
+	handleChangeRepo repository.HandleChangeRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	policy *handles.Policy,
+	cfg *config.Config,
+) *service.HandleServiceImpl {
+	return service.NewHandleService(userRepo, handleChangeRepo, rateLimitRepo, policy, cfg.Users).(*service.HandleServiceImpl)
+}
+
+func provideEquipmentService(equipmentRepo repository.EquipmentRepository) *service.EquipmentServiceImpl {
+	return service.NewEquipmentService(equipmentRepo).(*service.EquipmentServiceImpl)
+}
+
+func provideOnboardingService(
+	onboardingRepo repository.OnboardingRepository,
+	userService service.UserService,
+) *service.OnboardingServiceImpl {
+	return service.NewOnboardingService(onboardingRepo, userService).(*service.OnboardingServiceImpl)
+}
+
+func provideDataExportService(
+	exportRepo repository.DataExportRepository,
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	identityRepo repository.UserIdentityRepository,
+	apiKeyRepo repository.APIKeyRepository,
+	handleChangeRepo repository.HandleChangeRepository,
+	equipmentRepo repository.EquipmentRepository,
+	onboardingRepo repository.OnboardingRepository,
+	beanRepo repository.BeanRepository,
+	stockRepo repository.BeanStockRepository,
+	eventRepo repository.BeanEventRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	store storage.Storage,
+	m mailer.Mailer,
+	cfg *config.Config,
+) *service.DataExportServiceImpl {
+	return service.NewDataExportService(exportRepo, userRepo, sessionRepo, identityRepo, apiKeyRepo, handleChangeRepo, equipmentRepo, onboardingRepo, beanRepo, stockRepo, eventRepo, rateLimitRepo, store, m, cfg.App, cfg.Exports).(*service.DataExportServiceImpl)
+}
+
+func provideAccountDeletionService(
+	userRepo repository.UserRepository,
+	exportRepo repository.DataExportRepository,
+	beanRepo repository.BeanRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	mfaService service.MFAService,
+	sessionService service.SessionService,
+	hasher service.PasswordHasher,
+	store storage.Storage,
+	m mailer.Mailer,
+	cfg *config.Config,
+) *service.AccountDeletionServiceImpl {
+	return service.NewAccountDeletionService(userRepo, exportRepo, beanRepo, rateLimitRepo, mfaService, sessionService, hasher, store, m, cfg.App, cfg.Auth, cfg.Users).(*service.AccountDeletionServiceImpl)
+}
+
+func provideBeanService(
+	beanRepo repository.BeanRepository,
+	stockRepo repository.BeanStockRepository,
+	eventRepo repository.BeanEventRepository,
+	catalogRepo repository.CatalogRepository,
+	userRepo repository.UserRepository,
+	store storage.Storage,
+	m mailer.Mailer,
+	cfg *config.Config,
+) *service.BeanServiceImpl {
+	return service.NewBeanService(beanRepo, stockRepo, eventRepo, catalogRepo, userRepo, store, m, cfg.App, cfg.Beans).(*service.BeanServiceImpl)
+}
+
+func provideCatalogService(catalogRepo repository.CatalogRepository) *service.CatalogServiceImpl {
+	return service.NewCatalogService(catalogRepo).(*service.CatalogServiceImpl)
+}
+
+func provideAuthMiddleware(kr *keyring.Keyring, revocationRepo repository.TokenRevocationRepository, suspensionRepo repository.SuspensionRepository) gin.HandlerFunc {
+	return middleware.AuthMiddleware(kr, revocationRepo, suspensionRepo)
 }
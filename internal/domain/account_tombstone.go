@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountTombstone records that an account was deleted. It outlives the user
+// on purpose and holds no personal data, only what is needed to show when
+// and why the deletion happened.
+type AccountTombstone struct {
+	ID                  uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID              uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"userId"`
+	WasGuest            bool       `gorm:"not null;default:false" json:"wasGuest"`
+	AccountCreatedAt    time.Time  `gorm:"not null" json:"accountCreatedAt"`
+	DeletionRequestedAt *time.Time `json:"deletionRequestedAt"`
+	DeletedAt           time.Time  `gorm:"not null;default:now()" json:"deletedAt"`
+	FilesDeleted        int        `gorm:"not null;default:0" json:"filesDeleted"`
+}
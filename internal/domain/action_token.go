@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Purposes of action tokens
+const (
+	ActionTokenPasswordReset     = "password_reset"
+	ActionTokenEmailVerification = "email_verification"
+	ActionTokenMagicLink         = "magic_link"
+)
+
+// ActionToken is a single-use, time-limited token that is emailed to a user to
+// confirm an action such as a password reset or email verification. Only the SHA-256 hash of the
+// token is stored.
+type ActionToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	User      *User      `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Purpose   string     `gorm:"not null" json:"purpose"`
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `gorm:"not null;default:now()" json:"createdAt"`
+	// NonceHash binds the token to the device that requested it, which alone
+	// knows the nonce. It is empty for tokens not bound to a device.
+	NonceHash string `gorm:"not null;default:''" json:"-"`
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scope is an action an API key may be used for.
+type Scope string
+
+const (
+	ScopeProfileRead   Scope = "profile:read"
+	ScopeBeansRead     Scope = "beans:read"
+	ScopeBeansWrite    Scope = "beans:write"
+	ScopeRecipesRead   Scope = "recipes:read"
+	ScopeRecipesWrite  Scope = "recipes:write"
+	ScopeBrewLogsRead  Scope = "brew-logs:read"
+	ScopeBrewLogsWrite Scope = "brew-logs:write"
+)
+
+// Scopes lists every scope an API key can be granted.
+var Scopes = []Scope{
+	ScopeProfileRead,
+	ScopeBeansRead,
+	ScopeBeansWrite,
+	ScopeRecipesRead,
+	ScopeRecipesWrite,
+	ScopeBrewLogsRead,
+	ScopeBrewLogsWrite,
+}
+
+// Valid reports whether s is a known scope.
+func (s Scope) Valid() bool {
+	for _, scope := range Scopes {
+		if scope == s {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey is a personal key for external integrations. Only a hash of the key
+// is stored; the prefix stays visible so users can tell their keys apart.
+type APIKey struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	User       *User      `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Name       string     `gorm:"not null" json:"name"`
+	Prefix     string     `gorm:"not null" json:"prefix"`
+	KeyHash    string     `gorm:"uniqueIndex;not null" json:"-"`
+	Scopes     []Scope    `gorm:"type:jsonb;serializer:json;not null" json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `gorm:"not null;default:now()" json:"createdAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// HasScope reports whether the key was granted scope.
+func (k *APIKey) HasScope(scope Scope) bool {
+	for _, granted := range k.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUsable reports whether the key is neither revoked nor expired at now.
+func (k *APIKey) IsUsable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
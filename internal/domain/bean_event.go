@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BeanEventKind string
+
+const (
+	BeanPurchased BeanEventKind = "purchased"
+	BeanOpened    BeanEventKind = "opened"
+	BeanFrozen    BeanEventKind = "frozen"
+	BeanThawed    BeanEventKind = "thawed"
+	BeanFinished  BeanEventKind = "finished"
+)
+
+// Valid reports whether k is one of the known bean event kinds.
+func (k BeanEventKind) Valid() bool {
+	switch k {
+	case BeanPurchased, BeanOpened, BeanFrozen, BeanThawed, BeanFinished:
+		return true
+	}
+	return false
+}
+
+// BeanEvent is something that happened to a bag of beans. Together they are
+// the bean's timeline; the bean keeps the state they add up to, such as
+// the days it spent frozen.
+type BeanEvent struct {
+	ID     uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BeanID uuid.UUID     `gorm:"type:uuid;not null;index" json:"beanId"`
+	Bean   *CoffeeBean   `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Kind   BeanEventKind `gorm:"type:varchar(20);not null" json:"kind"`
+	// Date is the day the event happened on
+	Date      time.Time `gorm:"type:date;not null" json:"date"`
+	Note      string    `gorm:"type:varchar(200)" json:"note"`
+	CreatedAt time.Time `gorm:"not null;default:now()" json:"createdAt"`
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StockChangeKind string
+
+const (
+	// StockChangeManual is an adjustment the user made, e.g. after weighing
+	// the bag
+	StockChangeManual StockChangeKind = "manual"
+	// StockChangeEdit is a new quantity set by editing the bean
+	StockChangeEdit StockChangeKind = "edit"
+	// StockChangeBrew is coffee used by a brew log, or given back when the
+	// log changed or was deleted
+	StockChangeBrew StockChangeKind = "brew"
+	// StockChangeSplit is coffee moved from a bag into its portions
+	StockChangeSplit StockChangeKind = "split"
+)
+
+// BeanStockAdjustment is one change of a bean's stock. Together they are the
+// audit trail of the bean's quantity.
+type BeanStockAdjustment struct {
+	ID     uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	BeanID uuid.UUID       `gorm:"type:uuid;not null;index" json:"beanId"`
+	Bean   *CoffeeBean     `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Kind   StockChangeKind `gorm:"type:varchar(20);not null" json:"kind"`
+	// ChangeGrams is negative when coffee was used
+	ChangeGrams   int        `gorm:"not null" json:"changeGrams"`
+	QuantityAfter int        `gorm:"not null" json:"quantityAfter"`
+	BrewLogID     *uuid.UUID `gorm:"type:uuid;index" json:"brewLogId"`
+	Note          string     `gorm:"type:varchar(200)" json:"note"`
+	CreatedAt     time.Time  `gorm:"not null;default:now();index" json:"createdAt"`
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roaster is a coffee roaster in the catalog shared by all users. Duplicates
+// are not deleted when merged, but point at the roaster they were merged
+// into, so old links keep working.
+type Roaster struct {
+	ID   uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name string    `gorm:"type:varchar(100);not null" json:"name"`
+	// NormalizedName is the name compared when looking for duplicates
+	NormalizedName string `gorm:"type:varchar(100);not null;index" json:"-"`
+	Country        string `gorm:"type:varchar(100)" json:"country"`
+	Website        string `gorm:"type:varchar(255)" json:"website"`
+	// MergedIntoID is the roaster this duplicate was merged into
+	MergedIntoID *uuid.UUID `gorm:"type:uuid;index" json:"mergedIntoId"`
+	MergedInto   *Roaster   `gorm:"constraint:OnDelete:SET NULL" json:"-"`
+	MergedAt     *time.Time `json:"mergedAt"`
+	CreatedByID  *uuid.UUID `gorm:"type:uuid" json:"-"`
+	CreatedBy    *User      `gorm:"constraint:OnDelete:SET NULL" json:"-"`
+	CreatedAt    time.Time  `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt    time.Time  `gorm:"not null;default:now()" json:"updatedAt"`
+}
+
+// Coffee is a canonical coffee in the shared catalog, such as a roaster's
+// single origin lot. Like roasters, merged duplicates point at the coffee
+// they were merged into.
+type Coffee struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RoasterID      *uuid.UUID `gorm:"type:uuid;index" json:"roasterId"`
+	Roaster        *Roaster   `gorm:"constraint:OnDelete:SET NULL" json:"-"`
+	Name           string     `gorm:"type:varchar(100);not null" json:"name"`
+	NormalizedName string     `gorm:"type:varchar(100);not null;index" json:"-"`
+	OriginCountry  string     `gorm:"type:varchar(100)" json:"originCountry"`
+	Region         string     `gorm:"type:varchar(100)" json:"region"`
+	Farm           string     `gorm:"type:varchar(100)" json:"farm"`
+	Variety        string     `gorm:"type:varchar(100)" json:"variety"`
+	Process        string     `gorm:"type:varchar(100)" json:"process"`
+	MergedIntoID   *uuid.UUID `gorm:"type:uuid;index" json:"mergedIntoId"`
+	MergedInto     *Coffee    `gorm:"constraint:OnDelete:SET NULL" json:"-"`
+	MergedAt       *time.Time `json:"mergedAt"`
+	CreatedByID    *uuid.UUID `gorm:"type:uuid" json:"-"`
+	CreatedBy      *User      `gorm:"constraint:OnDelete:SET NULL" json:"-"`
+	CreatedAt      time.Time  `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt      time.Time  `gorm:"not null;default:now()" json:"updatedAt"`
+}
+
+// Origin describes where the coffee was grown, from the farm out to the
+// country, leaving out what is not known.
+func (c *Coffee) Origin() string {
+	origin := ""
+	for _, part := range []string{c.Farm, c.Region, c.OriginCountry} {
+		if part == "" {
+			continue
+		}
+		if origin != "" {
+			origin += ", "
+		}
+		origin += part
+	}
+	return origin
+}
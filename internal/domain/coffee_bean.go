@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RoastLevel string
+
+const (
+	RoastLight      RoastLevel = "light"
+	RoastMedium     RoastLevel = "medium"
+	RoastMediumDark RoastLevel = "medium-dark"
+	RoastDark       RoastLevel = "dark"
+	RoastUnknown    RoastLevel = "unknown"
+)
+
+// Valid reports whether r is one of the known roast levels.
+func (r RoastLevel) Valid() bool {
+	switch r {
+	case RoastLight, RoastMedium, RoastMediumDark, RoastDark, RoastUnknown:
+		return true
+	}
+	return false
+}
+
+type BeanSpecies string
+
+const (
+	SpeciesArabica BeanSpecies = "arabica"
+	SpeciesRobusta BeanSpecies = "robusta"
+	SpeciesBlend   BeanSpecies = "blend"
+	SpeciesOther   BeanSpecies = "other"
+	SpeciesUnknown BeanSpecies = "unknown"
+)
+
+// Valid reports whether s is one of the known bean species.
+func (s BeanSpecies) Valid() bool {
+	switch s {
+	case SpeciesArabica, SpeciesRobusta, SpeciesBlend, SpeciesOther, SpeciesUnknown:
+		return true
+	}
+	return false
+}
+
+// FreshnessState is where a bean is between its roast date and going stale.
+type FreshnessState string
+
+const (
+	// FreshnessResting beans still give off too much gas after roasting
+	FreshnessResting FreshnessState = "resting"
+	FreshnessPeak    FreshnessState = "peak"
+	FreshnessFading  FreshnessState = "fading"
+	FreshnessStale   FreshnessState = "stale"
+)
+
+// Valid reports whether f is one of the known freshness states.
+func (f FreshnessState) Valid() bool {
+	switch f {
+	case FreshnessResting, FreshnessPeak, FreshnessFading, FreshnessStale:
+		return true
+	}
+	return false
+}
+
+// CoffeeBean is a bag of coffee in a user's inventory. Beans are never
+// deleted by their owner, only deactivated, so brew logs keep pointing at
+// them.
+type CoffeeBean struct {
+	ID               uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID           uuid.UUID   `gorm:"type:uuid;not null;index" json:"userId"`
+	User             *User       `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Name             string      `gorm:"not null" json:"name"`
+	Origin           string      `json:"origin"`
+	Roaster          string      `json:"roaster"`
+	RoastDate        *time.Time  `gorm:"type:date" json:"roastDate"`
+	RoastLevel       RoastLevel  `gorm:"type:varchar(20);not null;default:'unknown';index" json:"roastLevel"`
+	FlavorNotes      []string    `gorm:"type:jsonb;serializer:json;not null" json:"flavorNotes"`
+	BeanSpecies      BeanSpecies `gorm:"type:varchar(20);not null;default:'unknown'" json:"beanSpecies"`
+	ProcessingMethod string      `json:"processingMethod"`
+	Altitude         string      `json:"altitude"`
+	PurchaseDate     *time.Time  `gorm:"type:date" json:"purchaseDate"`
+	Price            *float64    `gorm:"type:decimal(10,2)" json:"price"`
+	QuantityGrams    *int        `json:"quantityGrams"`
+	ImageURLs        []string    `gorm:"type:jsonb;serializer:json;not null" json:"imageUrls"`
+	// ImageKeys are the storage keys behind ImageURLs, in the same order
+	ImageKeys  []string `gorm:"type:jsonb;serializer:json;not null" json:"-"`
+	IsActive   bool     `gorm:"not null;default:true;index" json:"isActive"`
+	IsFavorite bool     `gorm:"not null;default:false" json:"isFavorite"`
+	// RoasterID and CoffeeID link the bean to the shared catalog. The text
+	// fields stay as the user wrote them.
+	RoasterID      *uuid.UUID `gorm:"type:uuid;index" json:"roasterId"`
+	CatalogRoaster *Roaster   `gorm:"foreignKey:RoasterID;constraint:OnDelete:SET NULL" json:"-"`
+	CoffeeID       *uuid.UUID `gorm:"type:uuid;index" json:"coffeeId"`
+	Coffee         *Coffee    `gorm:"constraint:OnDelete:SET NULL" json:"-"`
+	// ParentID is the bag this bean is a portion of
+	ParentID *uuid.UUID  `gorm:"type:uuid;index" json:"parentId"`
+	Parent   *CoffeeBean `gorm:"constraint:OnDelete:SET NULL" json:"-"`
+	// The dates of the bean's latest events. FrozenOn is only set while the
+	// bean is in the freezer; FrozenDays counts the days of earlier stays.
+	OpenedOn   *time.Time `gorm:"type:date" json:"openedOn"`
+	FrozenOn   *time.Time `gorm:"type:date" json:"frozenOn"`
+	FrozenDays int        `gorm:"not null;default:0" json:"frozenDays"`
+	FinishedOn *time.Time `gorm:"type:date" json:"finishedOn"`
+	// LowStockNotifiedAt is when the owner was told the bean is running low.
+	// It is cleared once the stock is back above the threshold.
+	LowStockNotifiedAt *time.Time `json:"-"`
+	// PeakNotifiedAt and StaleWarnedAt are when the owner was told the bean
+	// reached its peak and is about to go stale. Both are cleared when the
+	// roast date or level changes.
+	PeakNotifiedAt *time.Time `json:"-"`
+	StaleWarnedAt  *time.Time `json:"-"`
+	CreatedAt      time.Time  `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt      time.Time  `gorm:"not null;default:now()" json:"updatedAt"`
+}
+
+// FrozenDaysUntil returns the days the bean spent frozen up to today, a
+// date at midnight UTC.
+func (b *CoffeeBean) FrozenDaysUntil(today time.Time) int {
+	days := b.FrozenDays
+	if b.FrozenOn != nil {
+		days += DaysBetween(*b.FrozenOn, today)
+	}
+	return days
+}
+
+// DaysBetween returns the number of calendar days from one date to another,
+// ignoring the time of day.
+func DaysBetween(from, to time.Time) int {
+	fromYear, fromMonth, fromDay := from.Date()
+	toYear, toMonth, toDay := to.Date()
+	start := time.Date(fromYear, fromMonth, fromDay, 0, 0, 0, 0, time.UTC)
+	end := time.Date(toYear, toMonth, toDay, 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// IsLowOnStock reports whether the bean's known stock is below
+// thresholdGrams. A threshold of 0 never reports low stock.
+func (b *CoffeeBean) IsLowOnStock(thresholdGrams int) bool {
+	return thresholdGrams > 0 && b.QuantityGrams != nil && *b.QuantityGrams < thresholdGrams
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportPending    DataExportStatus = "pending"
+	DataExportProcessing DataExportStatus = "processing"
+	DataExportReady      DataExportStatus = "ready"
+	DataExportFailed     DataExportStatus = "failed"
+	DataExportExpired    DataExportStatus = "expired"
+)
+
+// DataExport is a user's request for a copy of their data. A background job
+// builds the archive and stores it under StorageKey; it is downloaded with a
+// time-limited link of which only the SHA-256 hash of the token is stored.
+type DataExport struct {
+	ID         uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID        `gorm:"type:uuid;not null;index" json:"userId"`
+	User       *User            `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Status     DataExportStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	StorageKey string           `json:"-"`
+	Size       int64            `gorm:"not null;default:0" json:"size"`
+	TokenHash  string           `gorm:"index" json:"-"`
+	CreatedAt  time.Time        `gorm:"not null;default:now()" json:"createdAt"`
+	// ClaimedAt is when an instance of the export job started building it
+	ClaimedAt   *time.Time `json:"-"`
+	CompletedAt *time.Time `json:"completedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+}
+
+// IsInProgress reports whether the archive is waiting to be built or being
+// built.
+func (e *DataExport) IsInProgress() bool {
+	return e.Status == DataExportPending || e.Status == DataExportProcessing
+}
+
+// IsDownloadable reports whether the archive is ready and its link has not
+// expired at now.
+func (e *DataExport) IsDownloadable(now time.Time) bool {
+	return e.Status == DataExportReady && e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EquipmentKind string
+
+const (
+	EquipmentBrewer  EquipmentKind = "brewer"
+	EquipmentGrinder EquipmentKind = "grinder"
+	EquipmentKettle  EquipmentKind = "kettle"
+	EquipmentScale   EquipmentKind = "scale"
+	EquipmentOther   EquipmentKind = "other"
+)
+
+// Equipment is a piece of brewing gear a user owns.
+type Equipment struct {
+	ID        uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID     `gorm:"type:uuid;not null;index" json:"userId"`
+	User      *User         `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Kind      EquipmentKind `gorm:"type:varchar(20);not null" json:"kind"`
+	Name      string        `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt time.Time     `gorm:"not null;default:now()" json:"createdAt"`
+}
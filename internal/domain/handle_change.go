@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HandleChange records a handle a user gave up. For a while after the change
+// the old handle redirects to the user's profile and nobody else can take it.
+type HandleChange struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"userId"`
+	User      *User     `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	OldHandle string    `gorm:"not null;index:idx_handle_changes_old_handle,expression:lower(old_handle)" json:"oldHandle"`
+	ChangedAt time.Time `gorm:"not null;default:now()" json:"changedAt"`
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MFAFactor is a user's TOTP authenticator. It is created on enrollment and
+// only enforced at login once ConfirmedAt is set.
+type MFAFactor struct {
+	UserID       uuid.UUID  `gorm:"type:uuid;primary_key" json:"userId"`
+	User         *User      `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Secret       string     `gorm:"not null" json:"-"`
+	ConfirmedAt  *time.Time `json:"confirmedAt"`
+	LastUsedStep int64      `gorm:"not null;default:0" json:"-"`
+	CreatedAt    time.Time  `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt    time.Time  `gorm:"not null;default:now()" json:"updatedAt"`
+}
+
+func (f *MFAFactor) IsConfirmed() bool {
+	return f.ConfirmedAt != nil
+}
+
+// RecoveryCode is a one-time code that can stand in for a TOTP code when the
+// authenticator is lost. Only the SHA-256 hash of the code is stored.
+type RecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	User      *User      `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	CodeHash  string     `gorm:"not null" json:"-"`
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `gorm:"not null;default:now()" json:"createdAt"`
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OnboardingStep is one question of the onboarding questionnaire.
+type OnboardingStep string
+
+const (
+	OnboardingBrewMethods OnboardingStep = "brew_methods"
+	OnboardingBeanTypes   OnboardingStep = "bean_types"
+	OnboardingUnits       OnboardingStep = "units"
+	OnboardingEquipment   OnboardingStep = "equipment"
+)
+
+// OnboardingSteps lists the steps in the order they are asked.
+var OnboardingSteps = []OnboardingStep{
+	OnboardingBrewMethods,
+	OnboardingBeanTypes,
+	OnboardingUnits,
+	OnboardingEquipment,
+}
+
+// Onboarding tracks a user's progress through the questionnaire. Steps are
+// answered or skipped in order; CurrentStep is empty once all are done.
+type Onboarding struct {
+	UserID         uuid.UUID                  `gorm:"type:uuid;primary_key" json:"userId"`
+	User           *User                      `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	CurrentStep    OnboardingStep             `gorm:"type:varchar(30);not null" json:"currentStep"`
+	CompletedSteps []OnboardingStep           `gorm:"type:jsonb;serializer:json;not null" json:"completedSteps"`
+	SkippedSteps   []OnboardingStep           `gorm:"type:jsonb;serializer:json;not null" json:"skippedSteps"`
+	Answers        map[string]json.RawMessage `gorm:"type:jsonb;serializer:json;not null" json:"answers"`
+	StartedAt      time.Time                  `gorm:"not null;default:now()" json:"startedAt"`
+	UpdatedAt      time.Time                  `gorm:"not null;default:now()" json:"updatedAt"`
+	CompletedAt    *time.Time                 `json:"completedAt"`
+}
+
+// IsComplete reports whether every step was answered or skipped.
+func (o *Onboarding) IsComplete() bool {
+	return o.CompletedAt != nil
+}
@@ -0,0 +1,259 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PreferencesVersion is the version of the preferences document written by
+// this code. Older documents are upgraded when they are read.
+const PreferencesVersion = 1
+
+type WeightUnit string
+
+const (
+	WeightGrams  WeightUnit = "g"
+	WeightOunces WeightUnit = "oz"
+)
+
+type TemperatureUnit string
+
+const (
+	TemperatureCelsius    TemperatureUnit = "c"
+	TemperatureFahrenheit TemperatureUnit = "f"
+)
+
+type VolumeUnit string
+
+const (
+	VolumeMilliliters VolumeUnit = "ml"
+	VolumeFluidOunces VolumeUnit = "fl_oz"
+)
+
+type Theme string
+
+const (
+	ThemeSystem Theme = "system"
+	ThemeLight  Theme = "light"
+	ThemeDark   Theme = "dark"
+)
+
+type Visibility string
+
+const (
+	VisibilityPublic  Visibility = "public"
+	VisibilityPrivate Visibility = "private"
+)
+
+// Preferences are a user's settings. They are stored as one jsonb document
+// on the user; the service validates every change against a JSON schema.
+type Preferences struct {
+	Version                int                     `json:"version"`
+	Units                  UnitPreferences         `json:"units"`
+	FavoriteBrewMethods    []string                `json:"favoriteBrewMethods"`
+	FavoriteRoastLevels    []string                `json:"favoriteRoastLevels"`
+	DefaultBrewMethod      string                  `json:"defaultBrewMethod"`
+	DefaultGrinder         string                  `json:"defaultGrinder"`
+	LowStockThresholdGrams int                     `json:"lowStockThresholdGrams"` // 0 turns low-stock warnings off
+	Timezone               string                  `json:"timezone"`
+	Theme                  Theme                   `json:"theme"`
+	Notifications          NotificationPreferences `json:"notifications"`
+	Privacy                PrivacyPreferences      `json:"privacy"`
+}
+
+type UnitPreferences struct {
+	Weight      WeightUnit      `json:"weight"`
+	Temperature TemperatureUnit `json:"temperature"`
+	Volume      VolumeUnit      `json:"volume"`
+}
+
+// NotificationPreferences choose which emails the user gets. Email turns all
+// of them off at once.
+type NotificationPreferences struct {
+	Email          bool `json:"email"`
+	LowStock       bool `json:"lowStock"`
+	BeanFreshness  bool `json:"beanFreshness"`
+	ProductUpdates bool `json:"productUpdates"`
+}
+
+// PrivacyPreferences hold who can see the profile and the visibility new
+// recipes and brew logs start with.
+type PrivacyPreferences struct {
+	ProfileVisibility Visibility `json:"profileVisibility"`
+	RecipeVisibility  Visibility `json:"recipeVisibility"`
+	BrewLogVisibility Visibility `json:"brewLogVisibility"`
+}
+
+// DefaultPreferences returns the preferences of a user who has not changed
+// any.
+func DefaultPreferences() Preferences {
+	return Preferences{
+		Version: PreferencesVersion,
+		Units: UnitPreferences{
+			Weight:      WeightGrams,
+			Temperature: TemperatureCelsius,
+			Volume:      VolumeMilliliters,
+		},
+		FavoriteBrewMethods:    []string{},
+		FavoriteRoastLevels:    []string{},
+		LowStockThresholdGrams: 50,
+		Timezone:               "UTC",
+		Theme:                  ThemeSystem,
+		Notifications: NotificationPreferences{
+			Email:         true,
+			LowStock:      true,
+			BeanFreshness: true,
+		},
+		Privacy: PrivacyPreferences{
+			ProfileVisibility: VisibilityPublic,
+			RecipeVisibility:  VisibilityPrivate,
+			BrewLogVisibility: VisibilityPrivate,
+		},
+	}
+}
+
+// OrDefault returns p, or the defaults if the user never saved preferences.
+func (p Preferences) OrDefault() Preferences {
+	if p.Version == 0 {
+		return DefaultPreferences()
+	}
+	return p
+}
+
+// preferencesMigrations[v] upgrades a version v document to version v+1.
+// Append a migration whenever PreferencesVersion is raised.
+var preferencesMigrations = []func(doc map[string]interface{}) map[string]interface{}{
+	migratePreferencesV0,
+}
+
+// ParsePreferences reads a stored preferences document, upgrading documents
+// written by older versions. Settings the document leaves out get their
+// defaults.
+func ParsePreferences(raw []byte) (Preferences, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return DefaultPreferences(), nil
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return Preferences{}, fmt.Errorf("parse preferences: %w", err)
+	}
+
+	version := 0
+	if v, ok := doc["version"].(float64); ok {
+		version = int(v)
+	}
+	for ; version < len(preferencesMigrations); version++ {
+		doc = preferencesMigrations[version](doc)
+	}
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return Preferences{}, err
+	}
+	prefs := DefaultPreferences()
+	if err := json.Unmarshal(upgraded, &prefs); err != nil {
+		return Preferences{}, fmt.Errorf("parse preferences: %w", err)
+	}
+	prefs.Version = PreferencesVersion
+	return prefs, nil
+}
+
+// migratePreferencesV0 converts the free-form blobs clients stored before
+// preferences had a schema. Only the settings that have a counterpart are
+// kept; values that cannot be mapped fall back to the defaults.
+func migratePreferencesV0(doc map[string]interface{}) map[string]interface{} {
+	units := map[string]interface{}{}
+	switch strings.ToLower(stringValue(doc["weightUnit"])) {
+	case "g", "gram", "grams":
+		units["weight"] = WeightGrams
+	case "oz", "ounce", "ounces":
+		units["weight"] = WeightOunces
+	}
+	switch strings.ToLower(stringValue(doc["temperatureUnit"])) {
+	case "c", "celsius":
+		units["temperature"] = TemperatureCelsius
+	case "f", "fahrenheit":
+		units["temperature"] = TemperatureFahrenheit
+	}
+
+	upgraded := map[string]interface{}{
+		"version": 1,
+		"units":   units,
+	}
+	if methods, ok := doc["favoriteBrewMethods"].([]interface{}); ok {
+		favorites := []string{}
+		seen := map[string]bool{}
+		for _, m := range methods {
+			method := slugify(stringValue(m))
+			if method == "" || len(method) > 50 || seen[method] || len(favorites) == 10 {
+				continue
+			}
+			seen[method] = true
+			favorites = append(favorites, method)
+		}
+		if len(favorites) > 0 {
+			upgraded["favoriteBrewMethods"] = favorites
+			upgraded["defaultBrewMethod"] = favorites[0]
+		}
+	}
+	return upgraded
+}
+
+// slugify turns a brew method name such as "French Press" into the slug the
+// schema expects, "french-press".
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// Scan implements sql.Scanner, upgrading documents written by older versions.
+func (p *Preferences) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("scan preferences: unsupported type %T", value)
+	}
+
+	prefs, err := ParsePreferences(raw)
+	if err != nil {
+		return err
+	}
+	*p = prefs
+	return nil
+}
+
+// Value implements driver.Valuer. Preferences that were never set are stored
+// as NULL so they keep following the defaults.
+func (p Preferences) Value() (driver.Value, error) {
+	if p.Version == 0 {
+		return nil, nil
+	}
+	return json.Marshal(p)
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a single-use opaque token that can be exchanged for a new
+// access token. Only the SHA-256 hash of the token is stored. Every token
+// issued from the same login belongs to its Session so that the whole chain
+// can be revoked when reuse of an already rotated token is detected.
+type RefreshToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	User      *User      `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	SessionID uuid.UUID  `gorm:"type:uuid;not null;index" json:"sessionId"`
+	Session   *Session   `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+	CreatedAt time.Time  `gorm:"not null;default:now()" json:"createdAt"`
+}
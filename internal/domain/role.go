@@ -0,0 +1,42 @@
+package domain
+
+// Role decides what a user may do beyond managing their own data.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Permission is an action restricted to some roles.
+type Permission string
+
+const (
+	PermissionUsersRead    Permission = "users:read"
+	PermissionUsersSuspend Permission = "users:suspend"
+	PermissionUsersRoles   Permission = "users:roles"
+	PermissionCatalogMerge Permission = "catalog:merge"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleUser:      {},
+	RoleModerator: {PermissionUsersRead, PermissionUsersSuspend, PermissionCatalogMerge},
+	RoleAdmin:     {PermissionUsersRead, PermissionUsersSuspend, PermissionUsersRoles, PermissionCatalogMerge},
+}
+
+// Valid reports whether r is one of the known roles.
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can reports whether the role grants permission p.
+func (r Role) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
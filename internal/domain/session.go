@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is one signed-in device. It is created on login, carried in access
+// tokens as the "sid" claim and owns the refresh tokens issued for the login.
+type Session struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	User       *User      `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	DeviceName string     `json:"deviceName"`
+	UserAgent  string     `json:"userAgent"`
+	IPAddress  string     `json:"ipAddress"`
+	CreatedAt  time.Time  `gorm:"not null;default:now()" json:"createdAt"`
+	LastSeenAt time.Time  `gorm:"not null;default:now()" json:"lastSeenAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+func (s *Session) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
@@ -6,15 +6,60 @@ import (
 	"github.com/google/uuid"
 )
 
+// User is a registered account. Guest accounts have no email address and no
+// password until they are upgraded.
 type User struct {
-	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Email        string     `gorm:"unique;not null" json:"email"`
-	PasswordHash string     `gorm:"not null" json:"-"`
-	DisplayName  string     `gorm:"not null" json:"displayName"`
-	Bio          string     `json:"bio"`
-	AvatarURL    string     `json:"avatarUrl"`
-	Preferences  []byte     `gorm:"type:jsonb" json:"preferences"`
-	CreatedAt    time.Time  `gorm:"not null;default:now()" json:"createdAt"`
-	UpdatedAt    time.Time  `gorm:"not null;default:now()" json:"updatedAt"`
-	LastLoginAt  *time.Time `json:"lastLoginAt"`
+	ID              uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Email           *string     `gorm:"unique" json:"email"`
+	EmailVerifiedAt *time.Time  `json:"emailVerifiedAt"`
+	PasswordHash    string      `gorm:"not null" json:"-"`
+	IsGuest         bool        `gorm:"not null;default:false" json:"isGuest"`
+	Role            Role        `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
+	SuspendedAt     *time.Time  `json:"suspendedAt"`
+	SuspendedReason string      `json:"suspendedReason,omitempty"`
+	Handle          *string     `gorm:"index:idx_users_handle,unique,expression:lower(handle)" json:"handle"`
+	DisplayName     string      `gorm:"not null" json:"displayName"`
+	Bio             string      `json:"bio"`
+	AvatarURL       string      `json:"avatarUrl"`
+	AvatarKey       string      `json:"-"`
+	Preferences     Preferences `gorm:"type:jsonb" json:"preferences"`
+	CreatedAt       time.Time   `gorm:"not null;default:now()" json:"createdAt"`
+	UpdatedAt       time.Time   `gorm:"not null;default:now()" json:"updatedAt"`
+	LastLoginAt     *time.Time  `json:"lastLoginAt"`
+	// When the user asked for the account to be deleted and when it will be
+	// deleted for good. Logging in before then cancels the deletion.
+	DeletionRequestedAt *time.Time `json:"deletionRequestedAt"`
+	DeletionScheduledAt *time.Time `gorm:"index" json:"deletionScheduledAt"`
+}
+
+// EmailAddress returns the user's email address, or "" for guests.
+func (u *User) EmailAddress() string {
+	if u.Email == nil {
+		return ""
+	}
+	return *u.Email
+}
+
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// HandleName returns the user's handle, or "" if they have not chosen one.
+func (u *User) HandleName() string {
+	if u.Handle == nil {
+		return ""
+	}
+	return *u.Handle
+}
+
+// IsPendingDeletion reports whether the user asked for the account to be
+// deleted and has not logged in since.
+func (u *User) IsPendingDeletion() bool {
+	return u.DeletionScheduledAt != nil
+}
+
+// IsSuspended reports whether a moderator has blocked the user from signing
+// in.
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
 }
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links an account at an external identity provider, e.g.
+// Google, to a user. Subject is the provider's stable ID for the account;
+// Email is what the provider reported when the identity was linked.
+type UserIdentity struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"userId"`
+	User      *User     `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Provider  string    `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject" json:"provider"`
+	Subject   string    `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject" json:"-"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `gorm:"not null;default:now()" json:"createdAt"`
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+// APIKeyAuth authenticates endpoints that are open to external integrations.
+// Besides the bearer tokens AuthMiddleware accepts, they take personal API
+// keys sent as "Authorization: ApiKey <key>". Endpoints not registered
+// through Scoped never accept API keys.
+type APIKeyAuth struct {
+	tokenAuth     gin.HandlerFunc
+	apiKeyService service.APIKeyService
+}
+
+// NewAPIKeyAuth creates the authenticator. tokenAuth handles requests with
+// bearer tokens and is normally AuthMiddleware.
+func NewAPIKeyAuth(tokenAuth gin.HandlerFunc, apiKeyService service.APIKeyService) *APIKeyAuth {
+	return &APIKeyAuth{tokenAuth: tokenAuth, apiKeyService: apiKeyService}
+}
+
+// Scoped returns a handler admitting bearer tokens and API keys granted
+// scope. API key requests with the scope count against the key's daily
+// quota.
+func (a *APIKeyAuth) Scoped(scope domain.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey, ok := strings.CutPrefix(c.GetHeader("Authorization"), "ApiKey ")
+		if !ok {
+			a.tokenAuth(c)
+			return
+		}
+
+		key, err := a.apiKeyService.Authenticate(strings.TrimSpace(rawKey), scope)
+		if err != nil {
+			var rateLimitErr *service.RateLimitError
+			switch {
+			case errors.As(err, &rateLimitErr):
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))))
+				c.JSON(http.StatusTooManyRequests, gin.H{
+					"status": "error",
+					"error": gin.H{
+						"code":    "RATE_LIMIT_EXCEEDED",
+						"message": "Daily request quota for this API key exceeded",
+					},
+				})
+			case errors.Is(err, service.ErrAccountSuspended):
+				c.JSON(http.StatusForbidden, gin.H{
+					"status": "error",
+					"error": gin.H{
+						"code":    "ACCOUNT_SUSPENDED",
+						"message": "This account has been suspended",
+					},
+				})
+			case errors.Is(err, service.ErrAccountPendingDeletion):
+				c.JSON(http.StatusForbidden, gin.H{
+					"status": "error",
+					"error": gin.H{
+						"code":    "ACCOUNT_PENDING_DELETION",
+						"message": err.Error(),
+					},
+				})
+			case errors.Is(err, service.ErrInsufficientScope):
+				c.JSON(http.StatusForbidden, gin.H{
+					"status": "error",
+					"error": gin.H{
+						"code":    "INSUFFICIENT_SCOPE",
+						"message": "This API key lacks the " + string(scope) + " scope",
+					},
+				})
+			case errors.Is(err, service.ErrInvalidAPIKey):
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"status": "error",
+					"error": gin.H{
+						"code":    "INVALID_API_KEY",
+						"message": err.Error(),
+					},
+				})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"status": "error",
+					"error": gin.H{
+						"code":    "SERVER_ERROR",
+						"message": "Failed to validate API key",
+					},
+				})
+			}
+			c.Abort()
+			return
+		}
+
+		// API keys act for the user but never with staff permissions
+		c.Set("userID", key.UserID)
+		c.Set("emailVerified", key.User.IsEmailVerified())
+		c.Set("role", domain.RoleUser)
+		c.Set("apiKeyID", key.ID)
+		c.Next()
+	}
+}
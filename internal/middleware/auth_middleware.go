@@ -1,17 +1,20 @@
 package middleware
 
 import (
-	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
-	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/internal/service"
+	"github.com/yashkadam007/brewkar/pkg/keyring"
 )
 
-func AuthMiddleware(jwtConfig config.JWTConfig) gin.HandlerFunc {
+func AuthMiddleware(kr *keyring.Keyring, revocationRepo repository.TokenRevocationRepository, suspensionRepo repository.SuspensionRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -43,12 +46,7 @@ func AuthMiddleware(jwtConfig config.JWTConfig) gin.HandlerFunc {
 		tokenString := tokenParts[1]
 
 		// Parse token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return []byte(jwtConfig.Secret), nil
-		})
+		token, err := jwt.Parse(tokenString, kr.Keyfunc)
 
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -76,6 +74,20 @@ func AuthMiddleware(jwtConfig config.JWTConfig) gin.HandlerFunc {
 			return
 		}
 
+		// Only access tokens grant API access; challenge tokens from a
+		// half-finished two-factor login do not
+		if typ, _ := claims["typ"].(string); typ != "access" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "INVALID_TOKEN",
+					"message": "Invalid token type",
+				},
+			})
+			c.Abort()
+			return
+		}
+
 		// Extract user ID
 		userIDStr, ok := claims["sub"].(string)
 		if !ok {
@@ -103,8 +115,88 @@ func AuthMiddleware(jwtConfig config.JWTConfig) gin.HandlerFunc {
 			return
 		}
 
-		// Set user ID in context
+		// Extract token and session IDs and timestamps
+		tokenID, _ := claims["jti"].(string)
+		sessionIDStr, _ := claims["sid"].(string)
+		sessionID, errSid := uuid.Parse(sessionIDStr)
+		issuedAt, okIat := claims["iat"].(float64)
+		expiresAt, okExp := claims["exp"].(float64)
+		if tokenID == "" || errSid != nil || !okIat || !okExp {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "INVALID_TOKEN",
+					"message": "Invalid token claims",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		// Reject tokens revoked by logout or of revoked sessions
+		revoked, err := revocationRepo.IsRevoked(tokenID, sessionID, userID, service.IssuedAt(issuedAt))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "SERVER_ERROR",
+					"message": "Failed to validate token",
+				},
+			})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "TOKEN_REVOKED",
+					"message": "Token has been revoked",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		// Suspension takes effect immediately, not only once the token expires
+		suspended, err := suspensionRepo.IsSuspended(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "SERVER_ERROR",
+					"message": "Failed to validate token",
+				},
+			})
+			c.Abort()
+			return
+		}
+		if suspended {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "ACCOUNT_SUSPENDED",
+					"message": "This account has been suspended",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		emailVerified, _ := claims["email_verified"].(bool)
+		// Tokens issued before roles existed carry none
+		role := domain.RoleUser
+		if claimed, _ := claims["role"].(string); claimed != "" {
+			role = domain.Role(claimed)
+		}
+
+		// Set user and token details in context
 		c.Set("userID", userID)
+		c.Set("emailVerified", emailVerified)
+		c.Set("role", role)
+		c.Set("tokenID", tokenID)
+		c.Set("sessionID", sessionID)
+		c.Set("tokenExpiresAt", time.Unix(int64(expiresAt), 0))
 		c.Next()
 	}
 }
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yashkadam007/brewkar/internal/domain"
+)
+
+// RequirePermission restricts a route to users whose role grants all of the
+// given permissions. It must run after AuthMiddleware.
+func RequirePermission(permissions ...domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get("role")
+		userRole, _ := role.(domain.Role)
+		for _, permission := range permissions {
+			if !userRole.Can(permission) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"status": "error",
+					"error": gin.H{
+						"code":    "PERMISSION_DENIED",
+						"message": "You do not have permission to perform this action",
+					},
+				})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
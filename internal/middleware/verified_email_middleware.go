@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail restricts a route to users whose email address is
+// verified. It must run after AuthMiddleware. Use it on public-facing actions
+// such as publishing or commenting. Guests have no email address, so it turns
+// them away too.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("emailVerified") {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status": "error",
+				"error": gin.H{
+					"code":    "EMAIL_NOT_VERIFIED",
+					"message": "Please verify your email address to use this feature",
+				},
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
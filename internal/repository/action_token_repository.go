@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"gorm.io/gorm"
+)
+
+type ActionTokenRepository interface {
+	Create(token *domain.ActionToken) error
+	GetByHash(purpose, tokenHash string) (*domain.ActionToken, error)
+	// MarkUsed atomically flags the token as used. It reports false when the
+	// token had already been used.
+	MarkUsed(id uuid.UUID, usedAt time.Time) (bool, error)
+	// InvalidateForUser marks every outstanding token of the purpose as used.
+	InvalidateForUser(userID uuid.UUID, purpose string) error
+}
+
+type actionTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewActionTokenRepository(db *gorm.DB) ActionTokenRepository {
+	return &actionTokenRepository{db: db}
+}
+
+func (r *actionTokenRepository) Create(token *domain.ActionToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *actionTokenRepository) GetByHash(purpose, tokenHash string) (*domain.ActionToken, error) {
+	var token domain.ActionToken
+	if err := r.db.Where("purpose = ? AND token_hash = ?", purpose, tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *actionTokenRepository) MarkUsed(id uuid.UUID, usedAt time.Time) (bool, error) {
+	result := r.db.Model(&domain.ActionToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *actionTokenRepository) InvalidateForUser(userID uuid.UUID, purpose string) error {
+	return r.db.Model(&domain.ActionToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(key *domain.APIKey) error
+	// GetByHash returns the key with the given hash together with its user.
+	GetByHash(keyHash string) (*domain.APIKey, error)
+	// ListActiveForUser returns the user's keys that are not revoked, newest
+	// first. Expired keys are included so users can see why they stopped
+	// working.
+	ListActiveForUser(userID uuid.UUID) ([]domain.APIKey, error)
+	CountActiveForUser(userID uuid.UUID) (int64, error)
+	// Revoke revokes one of the user's keys. It reports false when the user
+	// has no such active key.
+	Revoke(id, userID uuid.UUID, at time.Time) (bool, error)
+	Touch(id uuid.UUID, usedAt time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(key *domain.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByHash(keyHash string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	if err := r.db.Preload("User").Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) ListActiveForUser(userID uuid.UUID) ([]domain.APIKey, error) {
+	var keys []domain.APIKey
+	if err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) CountActiveForUser(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *apiKeyRepository) Revoke(id, userID uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.Model(&domain.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *apiKeyRepository) Touch(id uuid.UUID, usedAt time.Time) error {
+	return r.db.Model(&domain.APIKey{}).
+		Where("id = ?", id).
+		Update("last_used_at", usedAt).Error
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BeanEventRepository interface {
+	// AddEvent loads the bean locked against concurrent changes, along with
+	// its latest event if it has one, lets add change the bean and make the
+	// new event, and saves both in one transaction. An error from add rolls
+	// everything back.
+	AddEvent(beanID uuid.UUID, add func(bean *domain.CoffeeBean, latest *domain.BeanEvent) (*domain.BeanEvent, error)) (*domain.CoffeeBean, *domain.BeanEvent, error)
+	// ListForBean returns one page of the bean's timeline, oldest first, and
+	// the total number of events.
+	ListForBean(beanID uuid.UUID, offset, limit int) ([]domain.BeanEvent, int64, error)
+	// ListForUser returns the events of all the user's beans, oldest first.
+	ListForUser(userID uuid.UUID) ([]domain.BeanEvent, error)
+}
+
+type beanEventRepository struct {
+	db *gorm.DB
+}
+
+func NewBeanEventRepository(db *gorm.DB) BeanEventRepository {
+	return &beanEventRepository{db: db}
+}
+
+func (r *beanEventRepository) AddEvent(beanID uuid.UUID, add func(bean *domain.CoffeeBean, latest *domain.BeanEvent) (*domain.BeanEvent, error)) (*domain.CoffeeBean, *domain.BeanEvent, error) {
+	var bean domain.CoffeeBean
+	var event *domain.BeanEvent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", beanID).
+			First(&bean).Error; err != nil {
+			return err
+		}
+
+		var latest []domain.BeanEvent
+		if err := tx.Where("bean_id = ?", beanID).
+			Order("date DESC").
+			Order("created_at DESC").
+			Limit(1).
+			Find(&latest).Error; err != nil {
+			return err
+		}
+		var previous *domain.BeanEvent
+		if len(latest) > 0 {
+			previous = &latest[0]
+		}
+
+		var err error
+		event, err = add(&bean, previous)
+		if err != nil {
+			return err
+		}
+		if err := tx.Save(&bean).Error; err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &bean, event, nil
+}
+
+func (r *beanEventRepository) ListForBean(beanID uuid.UUID, offset, limit int) ([]domain.BeanEvent, int64, error) {
+	query := r.db.Model(&domain.BeanEvent{}).Where("bean_id = ?", beanID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []domain.BeanEvent
+	if err := query.Order("date ASC").
+		Order("created_at ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+func (r *beanEventRepository) ListForUser(userID uuid.UUID) ([]domain.BeanEvent, error) {
+	var events []domain.BeanEvent
+	if err := r.db.Where("bean_id IN (?)", r.db.Model(&domain.CoffeeBean{}).Select("id").Where("user_id = ?", userID)).
+		Order("date ASC").
+		Order("created_at ASC").
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
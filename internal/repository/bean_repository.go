@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"gorm.io/gorm"
+)
+
+// Columns a bean search can be sorted by
+const (
+	BeanSortCreatedAt     = "created_at"
+	BeanSortUpdatedAt     = "updated_at"
+	BeanSortName          = "name"
+	BeanSortRoastDate     = "roast_date"
+	BeanSortPurchaseDate  = "purchase_date"
+	BeanSortQuantityGrams = "quantity_grams"
+	BeanSortPrice         = "price"
+)
+
+var beanSortColumns = map[string]bool{
+	BeanSortCreatedAt:     true,
+	BeanSortUpdatedAt:     true,
+	BeanSortName:          true,
+	BeanSortRoastDate:     true,
+	BeanSortPurchaseDate:  true,
+	BeanSortQuantityGrams: true,
+	BeanSortPrice:         true,
+}
+
+// BeanSearch filters and pages a user's beans. Zero fields do not filter.
+type BeanSearch struct {
+	UserID uuid.UUID
+	// Query matches part of the name, origin or roaster, ignoring case.
+	Query      string
+	RoastLevel domain.RoastLevel
+	IsActive   *bool
+	// ParentID keeps the portions split from that bag
+	ParentID *uuid.UUID
+	// RoastDates, unless nil, keeps the beans matching one of the ranges.
+	// Beans match by their roast date moved forward by the days they spent
+	// frozen up to Today, a date at midnight UTC.
+	RoastDates []RoastDateRange
+	Today      time.Time
+	// Sort is one of the BeanSort columns; newest first when empty.
+	Sort   string
+	Asc    bool
+	Offset int
+	Limit  int
+}
+
+// RoastDateRange matches beans of a roast level roasted between From and
+// To, both dates at midnight UTC and inclusive. A nil bound is open.
+type RoastDateRange struct {
+	RoastLevel domain.RoastLevel
+	From       *time.Time
+	To         *time.Time
+}
+
+type BeanRepository interface {
+	Create(bean *domain.CoffeeBean) error
+	GetByID(id uuid.UUID) (*domain.CoffeeBean, error)
+	// Deactivate marks the bean as no longer in use, leaving its other
+	// columns to the writers that lock it.
+	Deactivate(id uuid.UUID, at time.Time) error
+	// Search returns one page of the user's matching beans and the total
+	// number of matches.
+	Search(search BeanSearch) ([]domain.CoffeeBean, int64, error)
+	// ListForUser returns all of the user's beans, active or not, oldest
+	// first.
+	ListForUser(userID uuid.UUID) ([]domain.CoffeeBean, error)
+	// ListFreshnessCandidates returns the active beans of all users roasted
+	// on or after roastedSince, counting the days they spent frozen, whose
+	// owners were not yet warned they are going stale, grouped by user.
+	// Empty, finished and frozen bags are left out.
+	ListFreshnessCandidates(roastedSince time.Time) ([]domain.CoffeeBean, error)
+	MarkPeakNotified(beanIDs []uuid.UUID, at time.Time) error
+	MarkStaleWarned(beanIDs []uuid.UUID, at time.Time) error
+}
+
+type beanRepository struct {
+	db *gorm.DB
+}
+
+func NewBeanRepository(db *gorm.DB) BeanRepository {
+	return &beanRepository{db: db}
+}
+
+func (r *beanRepository) Create(bean *domain.CoffeeBean) error {
+	return r.db.Create(bean).Error
+}
+
+func (r *beanRepository) GetByID(id uuid.UUID) (*domain.CoffeeBean, error) {
+	var bean domain.CoffeeBean
+	if err := r.db.Where("id = ?", id).First(&bean).Error; err != nil {
+		return nil, err
+	}
+	return &bean, nil
+}
+
+func (r *beanRepository) Deactivate(id uuid.UUID, at time.Time) error {
+	return r.db.Model(&domain.CoffeeBean{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"is_active": false, "updated_at": at}).Error
+}
+
+func (r *beanRepository) Search(search BeanSearch) ([]domain.CoffeeBean, int64, error) {
+	query := r.db.Model(&domain.CoffeeBean{}).Where("user_id = ?", search.UserID)
+	if search.Query != "" {
+		pattern := "%" + escapeLike(search.Query) + "%"
+		query = query.Where("name ILIKE ? OR origin ILIKE ? OR roaster ILIKE ?", pattern, pattern, pattern)
+	}
+	if search.RoastLevel != "" {
+		query = query.Where("roast_level = ?", search.RoastLevel)
+	}
+	if search.IsActive != nil {
+		query = query.Where("is_active = ?", *search.IsActive)
+	}
+	if search.ParentID != nil {
+		query = query.Where("parent_id = ?", *search.ParentID)
+	}
+	if search.RoastDates != nil {
+		// Subtracting dates gives days, adding days to a date gives a date
+		aged := "roast_date + frozen_days + COALESCE(CAST(? AS date) - frozen_on, 0)"
+		today := search.Today.Format(time.DateOnly)
+		ranges := r.db.Where("FALSE")
+		for _, dates := range search.RoastDates {
+			match := r.db.Where("roast_level = ? AND roast_date IS NOT NULL", dates.RoastLevel)
+			if dates.From != nil {
+				match = match.Where(aged+" >= ?", today, *dates.From)
+			}
+			if dates.To != nil {
+				match = match.Where(aged+" <= ?", today, *dates.To)
+			}
+			ranges = ranges.Or(match)
+		}
+		query = query.Where(ranges)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Only known columns reach the ORDER BY clause
+	sort := search.Sort
+	if !beanSortColumns[sort] {
+		sort = BeanSortCreatedAt
+	}
+	order := sort + " DESC NULLS LAST"
+	if search.Asc {
+		order = sort + " ASC NULLS LAST"
+	}
+
+	var beans []domain.CoffeeBean
+	if err := query.Order(order).
+		Order("id").
+		Offset(search.Offset).
+		Limit(search.Limit).
+		Find(&beans).Error; err != nil {
+		return nil, 0, err
+	}
+	return beans, total, nil
+}
+
+func (r *beanRepository) ListForUser(userID uuid.UUID) ([]domain.CoffeeBean, error) {
+	var beans []domain.CoffeeBean
+	if err := r.db.Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&beans).Error; err != nil {
+		return nil, err
+	}
+	return beans, nil
+}
+
+func (r *beanRepository) ListFreshnessCandidates(roastedSince time.Time) ([]domain.CoffeeBean, error) {
+	var beans []domain.CoffeeBean
+	if err := r.db.Where("is_active AND roast_date + frozen_days >= ? AND stale_warned_at IS NULL", roastedSince).
+		Where("frozen_on IS NULL AND finished_on IS NULL").
+		Where("quantity_grams IS NULL OR quantity_grams > 0").
+		Order("user_id").
+		Order("roast_date").
+		Find(&beans).Error; err != nil {
+		return nil, err
+	}
+	return beans, nil
+}
+
+func (r *beanRepository) MarkPeakNotified(beanIDs []uuid.UUID, at time.Time) error {
+	return r.db.Model(&domain.CoffeeBean{}).
+		Where("id IN ?", beanIDs).
+		Update("peak_notified_at", at).Error
+}
+
+func (r *beanRepository) MarkStaleWarned(beanIDs []uuid.UUID, at time.Time) error {
+	return r.db.Model(&domain.CoffeeBean{}).
+		Where("id IN ?", beanIDs).
+		Update("stale_warned_at", at).Error
+}
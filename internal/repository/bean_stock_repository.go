@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BrewUsage sums up how much of a bean brew logs used.
+type BrewUsage struct {
+	Brews int
+	Grams int
+}
+
+// BeanSplit is what splitting a bag makes: its portions, the adjustments
+// moving coffee from the bag into them, and new events of the portions.
+type BeanSplit struct {
+	Portions    []domain.CoffeeBean
+	Adjustments []domain.BeanStockAdjustment
+	Events      []domain.BeanEvent
+}
+
+type BeanStockRepository interface {
+	// UpdateStock loads the bean locked against concurrent changes, lets
+	// update change it and saves it together with the adjustment update
+	// returns, if any, in one transaction. An error from update rolls
+	// everything back.
+	UpdateStock(beanID uuid.UUID, update func(bean *domain.CoffeeBean) (*domain.BeanStockAdjustment, error)) (*domain.CoffeeBean, error)
+	// UpdateStockTx is UpdateStock inside the caller's transaction, so a
+	// brew log and the stock it uses are saved or rolled back together.
+	UpdateStockTx(tx *gorm.DB, beanID uuid.UUID, update func(bean *domain.CoffeeBean) (*domain.BeanStockAdjustment, error)) (*domain.CoffeeBean, error)
+	// SplitBean loads the bean locked against concurrent changes, lets split
+	// change it and make its portions, and saves the bean and everything in
+	// the split in one transaction. Each portion starts with a copy of the
+	// bean's timeline, followed by the split's events.
+	SplitBean(beanID uuid.UUID, split func(bean *domain.CoffeeBean) (*BeanSplit, error)) (*domain.CoffeeBean, error)
+	// ListForBean returns one page of the bean's adjustments, newest first,
+	// and the total number of adjustments.
+	ListForBean(beanID uuid.UUID, offset, limit int) ([]domain.BeanStockAdjustment, int64, error)
+	// ListForUser returns the adjustments of all the user's beans, oldest
+	// first.
+	ListForUser(userID uuid.UUID) ([]domain.BeanStockAdjustment, error)
+	// BrewLogGrams returns the net change a brew log made to the bean's
+	// stock, negative while the log uses the bean.
+	BrewLogGrams(beanID, brewLogID uuid.UUID) (int, error)
+	// RecentBrewUsage sums up the brew logs that used the bean since the
+	// given time.
+	RecentBrewUsage(beanID uuid.UUID, since time.Time) (BrewUsage, error)
+}
+
+type beanStockRepository struct {
+	db *gorm.DB
+}
+
+func NewBeanStockRepository(db *gorm.DB) BeanStockRepository {
+	return &beanStockRepository{db: db}
+}
+
+func (r *beanStockRepository) UpdateStock(beanID uuid.UUID, update func(bean *domain.CoffeeBean) (*domain.BeanStockAdjustment, error)) (*domain.CoffeeBean, error) {
+	var bean *domain.CoffeeBean
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		bean, err = r.UpdateStockTx(tx, beanID, update)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bean, nil
+}
+
+func (r *beanStockRepository) UpdateStockTx(tx *gorm.DB, beanID uuid.UUID, update func(bean *domain.CoffeeBean) (*domain.BeanStockAdjustment, error)) (*domain.CoffeeBean, error) {
+	var bean domain.CoffeeBean
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", beanID).
+		First(&bean).Error; err != nil {
+		return nil, err
+	}
+	adjustment, err := update(&bean)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Save(&bean).Error; err != nil {
+		return nil, err
+	}
+	if adjustment != nil {
+		if err := tx.Create(adjustment).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &bean, nil
+}
+
+func (r *beanStockRepository) SplitBean(beanID uuid.UUID, split func(bean *domain.CoffeeBean) (*BeanSplit, error)) (*domain.CoffeeBean, error) {
+	var bean domain.CoffeeBean
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", beanID).
+			First(&bean).Error; err != nil {
+			return err
+		}
+		result, err := split(&bean)
+		if err != nil {
+			return err
+		}
+		if err := tx.Save(&bean).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&result.Portions).Error; err != nil {
+			return err
+		}
+
+		var timeline []domain.BeanEvent
+		if err := tx.Where("bean_id = ?", beanID).
+			Order("date ASC").
+			Order("created_at ASC").
+			Find(&timeline).Error; err != nil {
+			return err
+		}
+		var events []domain.BeanEvent
+		for _, portion := range result.Portions {
+			for _, event := range timeline {
+				event.ID = uuid.New()
+				event.BeanID = portion.ID
+				events = append(events, event)
+			}
+		}
+		events = append(events, result.Events...)
+		if len(events) > 0 {
+			if err := tx.Create(&events).Error; err != nil {
+				return err
+			}
+		}
+		if len(result.Adjustments) == 0 {
+			return nil
+		}
+		return tx.Create(&result.Adjustments).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &bean, nil
+}
+
+func (r *beanStockRepository) ListForBean(beanID uuid.UUID, offset, limit int) ([]domain.BeanStockAdjustment, int64, error) {
+	query := r.db.Model(&domain.BeanStockAdjustment{}).Where("bean_id = ?", beanID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var adjustments []domain.BeanStockAdjustment
+	if err := query.Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&adjustments).Error; err != nil {
+		return nil, 0, err
+	}
+	return adjustments, total, nil
+}
+
+func (r *beanStockRepository) ListForUser(userID uuid.UUID) ([]domain.BeanStockAdjustment, error) {
+	var adjustments []domain.BeanStockAdjustment
+	if err := r.db.Where("bean_id IN (?)", r.db.Model(&domain.CoffeeBean{}).Select("id").Where("user_id = ?", userID)).
+		Order("created_at ASC").
+		Find(&adjustments).Error; err != nil {
+		return nil, err
+	}
+	return adjustments, nil
+}
+
+func (r *beanStockRepository) BrewLogGrams(beanID, brewLogID uuid.UUID) (int, error) {
+	var grams int
+	err := r.db.Model(&domain.BeanStockAdjustment{}).
+		Select("COALESCE(SUM(change_grams), 0)").
+		Where("bean_id = ? AND brew_log_id = ?", beanID, brewLogID).
+		Scan(&grams).Error
+	return grams, err
+}
+
+func (r *beanStockRepository) RecentBrewUsage(beanID uuid.UUID, since time.Time) (BrewUsage, error) {
+	// A log edited or deleted since counts with what it still uses
+	var usage BrewUsage
+	err := r.db.Raw(`
+		SELECT COUNT(*) AS brews, COALESCE(-SUM(grams), 0) AS grams FROM (
+			SELECT SUM(change_grams) AS grams
+			FROM bean_stock_adjustments
+			WHERE bean_id = ? AND kind = ? AND created_at >= ?
+			GROUP BY brew_log_id
+			HAVING SUM(change_grams) < 0
+		) AS brews`, beanID, domain.StockChangeBrew, since).
+		Scan(&usage).Error
+	return usage, err
+}
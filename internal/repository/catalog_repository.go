@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MinCatalogSimilarity is how similar, from 0 to 1, a catalog entry's name
+// must be to a search to match it without containing it.
+const MinCatalogSimilarity = 0.3
+
+// CatalogSearch pages through the catalog entries that were not merged into
+// another one. Zero fields do not filter.
+type CatalogSearch struct {
+	// Query matches names containing it or similar to it, as normalized
+	// names. The most similar come first; without a query, entries are
+	// sorted by name.
+	Query string
+	// RoasterID keeps the coffees of that roaster
+	RoasterID *uuid.UUID
+	Offset    int
+	Limit     int
+}
+
+type CatalogRepository interface {
+	CreateRoaster(roaster *domain.Roaster) error
+	GetRoaster(id uuid.UUID) (*domain.Roaster, error)
+	// FindRoaster returns the roaster with the normalized name that was not
+	// merged into another one.
+	FindRoaster(normalizedName string) (*domain.Roaster, error)
+	// SearchRoasters returns one page of matching roasters and the total
+	// number of matches.
+	SearchRoasters(search CatalogSearch) ([]domain.Roaster, int64, error)
+	// MergeRoaster marks source as merged into target and moves everything
+	// pointing at source, including earlier duplicates, over to target in
+	// one transaction. Target is saved with it. Source's coffees that target
+	// already has by name are merged into target's.
+	MergeRoaster(source, target *domain.Roaster, at time.Time) error
+
+	CreateCoffee(coffee *domain.Coffee) error
+	GetCoffee(id uuid.UUID) (*domain.Coffee, error)
+	// FindCoffee returns the coffee of the roaster, or without a roaster
+	// when roasterID is nil, with the normalized name that was not merged
+	// into another one.
+	FindCoffee(roasterID *uuid.UUID, normalizedName string) (*domain.Coffee, error)
+	SearchCoffees(search CatalogSearch) ([]domain.Coffee, int64, error)
+	// MergeCoffee is MergeRoaster for coffees. Beans linked to source take
+	// target's roaster, if it has one. Target is saved after source was
+	// merged, so it may take source's roaster and name; it fails with
+	// gorm.ErrDuplicatedKey when another coffee already has them.
+	MergeCoffee(source, target *domain.Coffee, at time.Time) error
+}
+
+type catalogRepository struct {
+	db *gorm.DB
+}
+
+func NewCatalogRepository(db *gorm.DB) CatalogRepository {
+	return &catalogRepository{db: db}
+}
+
+func (r *catalogRepository) CreateRoaster(roaster *domain.Roaster) error {
+	return r.db.Create(roaster).Error
+}
+
+func (r *catalogRepository) GetRoaster(id uuid.UUID) (*domain.Roaster, error) {
+	var roaster domain.Roaster
+	if err := r.db.Where("id = ?", id).First(&roaster).Error; err != nil {
+		return nil, err
+	}
+	return &roaster, nil
+}
+
+func (r *catalogRepository) FindRoaster(normalizedName string) (*domain.Roaster, error) {
+	var roaster domain.Roaster
+	if err := r.db.Where("normalized_name = ? AND merged_into_id IS NULL", normalizedName).
+		Order("created_at").
+		First(&roaster).Error; err != nil {
+		return nil, err
+	}
+	return &roaster, nil
+}
+
+func (r *catalogRepository) SearchRoasters(search CatalogSearch) ([]domain.Roaster, int64, error) {
+	var roasters []domain.Roaster
+	var total int64
+	err := r.inSearch(func(tx *gorm.DB) error {
+		query := searchQuery(tx, &domain.Roaster{}, search)
+		if err := query.Count(&total).Error; err != nil {
+			return err
+		}
+		return searchOrder(query, search).Find(&roasters).Error
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return roasters, total, nil
+}
+
+func (r *catalogRepository) MergeRoaster(source, target *domain.Roaster, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(target).Error; err != nil {
+			return err
+		}
+		// Earlier duplicates of source point straight at target, so one
+		// hop always reaches a roaster that was not merged
+		if err := tx.Model(&domain.Roaster{}).
+			Where("id = ? OR merged_into_id = ?", source.ID, source.ID).
+			Updates(map[string]any{"merged_into_id": target.ID, "merged_at": at}).Error; err != nil {
+			return err
+		}
+		// A roaster has one coffee of a name, so those target has already
+		// take in source's, with their beans and earlier duplicates
+		if err := tx.Exec(`
+			UPDATE coffee_beans AS b SET coffee_id = t.id
+			FROM coffees AS c JOIN coffees AS t ON t.normalized_name = c.normalized_name
+			WHERE b.coffee_id = c.id
+				AND c.roaster_id = ? AND c.merged_into_id IS NULL
+				AND t.roaster_id = ? AND t.merged_into_id IS NULL`,
+			source.ID, target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			UPDATE coffees AS d SET merged_into_id = t.id, merged_at = ?
+			FROM coffees AS c JOIN coffees AS t ON t.normalized_name = c.normalized_name
+			WHERE (d.id = c.id OR d.merged_into_id = c.id)
+				AND c.roaster_id = ? AND c.merged_into_id IS NULL
+				AND t.roaster_id = ? AND t.merged_into_id IS NULL`,
+			at, source.ID, target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.Coffee{}).
+			Where("roaster_id = ?", source.ID).
+			Update("roaster_id", target.ID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.CoffeeBean{}).
+			Where("roaster_id = ?", source.ID).
+			Update("roaster_id", target.ID).Error
+	})
+}
+
+func (r *catalogRepository) CreateCoffee(coffee *domain.Coffee) error {
+	return r.db.Create(coffee).Error
+}
+
+func (r *catalogRepository) GetCoffee(id uuid.UUID) (*domain.Coffee, error) {
+	var coffee domain.Coffee
+	if err := r.db.Where("id = ?", id).First(&coffee).Error; err != nil {
+		return nil, err
+	}
+	return &coffee, nil
+}
+
+func (r *catalogRepository) FindCoffee(roasterID *uuid.UUID, normalizedName string) (*domain.Coffee, error) {
+	query := r.db.Where("normalized_name = ? AND merged_into_id IS NULL", normalizedName)
+	if roasterID != nil {
+		query = query.Where("roaster_id = ?", *roasterID)
+	} else {
+		query = query.Where("roaster_id IS NULL")
+	}
+
+	var coffee domain.Coffee
+	if err := query.Order("created_at").First(&coffee).Error; err != nil {
+		return nil, err
+	}
+	return &coffee, nil
+}
+
+func (r *catalogRepository) SearchCoffees(search CatalogSearch) ([]domain.Coffee, int64, error) {
+	var coffees []domain.Coffee
+	var total int64
+	err := r.inSearch(func(tx *gorm.DB) error {
+		query := searchQuery(tx, &domain.Coffee{}, search)
+		if search.RoasterID != nil {
+			query = query.Where("roaster_id = ?", *search.RoasterID)
+		}
+		if err := query.Count(&total).Error; err != nil {
+			return err
+		}
+		return searchOrder(query, search).Find(&coffees).Error
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return coffees, total, nil
+}
+
+func (r *catalogRepository) MergeCoffee(source, target *domain.Coffee, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Coffee{}).
+			Where("id = ? OR merged_into_id = ?", source.ID, source.ID).
+			Updates(map[string]any{"merged_into_id": target.ID, "merged_at": at}).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(target).Error; err != nil {
+			return err
+		}
+		beans := map[string]any{"coffee_id": target.ID}
+		if target.RoasterID != nil {
+			beans["roaster_id"] = *target.RoasterID
+		}
+		return tx.Model(&domain.CoffeeBean{}).
+			Where("coffee_id = ?", source.ID).
+			Updates(beans).Error
+	})
+}
+
+// inSearch runs a search in a transaction whose pg_trgm similarity
+// threshold, which the % operator compares against, is MinCatalogSimilarity.
+// Setting it locally keeps it from leaking to other users of the connection.
+func (r *catalogRepository) inSearch(search func(tx *gorm.DB) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config('pg_trgm.similarity_threshold', ?, true)",
+			strconv.FormatFloat(MinCatalogSimilarity, 'f', -1, 64)).Error; err != nil {
+			return err
+		}
+		return search(tx)
+	})
+}
+
+// searchQuery filters the model's entries that were not merged by the
+// search's query. Both LIKE and the similarity operator % from the pg_trgm
+// extension can use the trigram index on normalized_name.
+func searchQuery(tx *gorm.DB, model any, search CatalogSearch) *gorm.DB {
+	query := tx.Model(model).Where("merged_into_id IS NULL")
+	if search.Query != "" {
+		pattern := "%" + escapeLike(search.Query) + "%"
+		query = query.Where("normalized_name LIKE ? OR normalized_name % ?", pattern, search.Query)
+	}
+	return query
+}
+
+func searchOrder(query *gorm.DB, search CatalogSearch) *gorm.DB {
+	if search.Query != "" {
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "similarity(normalized_name, ?) DESC, normalized_name, id",
+			Vars:               []any{search.Query},
+			WithoutParentheses: true,
+		}})
+	} else {
+		query = query.Order("normalized_name").Order("id")
+	}
+	return query.Offset(search.Offset).
+		Limit(search.Limit)
+}
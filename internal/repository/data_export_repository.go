@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DataExportRepository interface {
+	Create(export *domain.DataExport) error
+	Update(export *domain.DataExport) error
+	GetByID(id uuid.UUID) (*domain.DataExport, error)
+	// GetLatestForUser returns the user's most recent export.
+	GetLatestForUser(userID uuid.UUID) (*domain.DataExport, error)
+	ListForUser(userID uuid.UUID) ([]domain.DataExport, error)
+	// ClaimPending marks up to limit exports waiting to be built, oldest
+	// first, as processing at now and returns them. Exports another instance
+	// is claiming at the same time are skipped, so each is built once.
+	// Exports claimed before staleBefore are claimed again, as the instance
+	// building them must have stopped.
+	ClaimPending(now, staleBefore time.Time, limit int) ([]domain.DataExport, error)
+	// ListExpired returns ready exports whose link expired before now.
+	ListExpired(now time.Time) ([]domain.DataExport, error)
+}
+
+type dataExportRepository struct {
+	db *gorm.DB
+}
+
+func NewDataExportRepository(db *gorm.DB) DataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+func (r *dataExportRepository) Create(export *domain.DataExport) error {
+	return r.db.Create(export).Error
+}
+
+func (r *dataExportRepository) Update(export *domain.DataExport) error {
+	return r.db.Save(export).Error
+}
+
+func (r *dataExportRepository) GetByID(id uuid.UUID) (*domain.DataExport, error) {
+	var export domain.DataExport
+	if err := r.db.Where("id = ?", id).First(&export).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *dataExportRepository) GetLatestForUser(userID uuid.UUID) (*domain.DataExport, error) {
+	var export domain.DataExport
+	if err := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		First(&export).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *dataExportRepository) ListForUser(userID uuid.UUID) ([]domain.DataExport, error) {
+	var exports []domain.DataExport
+	if err := r.db.Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *dataExportRepository) ClaimPending(now, staleBefore time.Time, limit int) ([]domain.DataExport, error) {
+	var exports []domain.DataExport
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND claimed_at < ?)",
+				domain.DataExportPending, domain.DataExportProcessing, staleBefore).
+			Order("created_at ASC").
+			Limit(limit).
+			Find(&exports).Error; err != nil {
+			return err
+		}
+		if len(exports) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(exports))
+		for i := range exports {
+			ids[i] = exports[i].ID
+			exports[i].Status = domain.DataExportProcessing
+			exports[i].ClaimedAt = &now
+		}
+		return tx.Model(&domain.DataExport{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{"status": domain.DataExportProcessing, "claimed_at": now}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *dataExportRepository) ListExpired(now time.Time) ([]domain.DataExport, error) {
+	var exports []domain.DataExport
+	if err := r.db.Where("status = ? AND expires_at < ?", domain.DataExportReady, now).
+		Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"gorm.io/gorm"
+)
+
+type EquipmentRepository interface {
+	Create(item *domain.Equipment) error
+	// ListForUser returns the user's equipment, oldest first.
+	ListForUser(userID uuid.UUID) ([]domain.Equipment, error)
+}
+
+type equipmentRepository struct {
+	db *gorm.DB
+}
+
+func NewEquipmentRepository(db *gorm.DB) EquipmentRepository {
+	return &equipmentRepository{db: db}
+}
+
+func (r *equipmentRepository) Create(item *domain.Equipment) error {
+	return r.db.Create(item).Error
+}
+
+func (r *equipmentRepository) ListForUser(userID uuid.UUID) ([]domain.Equipment, error) {
+	var items []domain.Equipment
+	if err := r.db.Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
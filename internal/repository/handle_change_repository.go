@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"gorm.io/gorm"
+)
+
+type HandleChangeRepository interface {
+	// ChangeHandle sets the user's handle and, if change is not nil, records
+	// the handle they gave up, in one transaction.
+	ChangeHandle(user *domain.User, handle string, change *domain.HandleChange) error
+	// GetLatestByOldHandle returns the most recent change away from handle,
+	// ignoring case.
+	GetLatestByOldHandle(handle string) (*domain.HandleChange, error)
+	// ListForUser returns the handles the user gave up, oldest first.
+	ListForUser(userID uuid.UUID) ([]domain.HandleChange, error)
+}
+
+type handleChangeRepository struct {
+	db *gorm.DB
+}
+
+func NewHandleChangeRepository(db *gorm.DB) HandleChangeRepository {
+	return &handleChangeRepository{db: db}
+}
+
+func (r *handleChangeRepository) ChangeHandle(user *domain.User, handle string, change *domain.HandleChange) error {
+	now := time.Now()
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.User{}).
+			Where("id = ?", user.ID).
+			Updates(map[string]interface{}{"handle": handle, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		if change != nil {
+			return tx.Create(change).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	user.Handle = &handle
+	user.UpdatedAt = now
+	return nil
+}
+
+func (r *handleChangeRepository) GetLatestByOldHandle(handle string) (*domain.HandleChange, error) {
+	var change domain.HandleChange
+	if err := r.db.Where("lower(old_handle) = lower(?)", handle).
+		Order("changed_at DESC").
+		First(&change).Error; err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (r *handleChangeRepository) ListForUser(userID uuid.UUID) ([]domain.HandleChange, error) {
+	var changes []domain.HandleChange
+	if err := r.db.Where("user_id = ?", userID).
+		Order("changed_at ASC").
+		Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
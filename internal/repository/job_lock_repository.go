@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const jobLockKeyPrefix = "brewkar:jobs:lock:"
+
+// JobLockRepository provides the locks that keep scheduled jobs from running
+// on several instances at once.
+type JobLockRepository interface {
+	TryLock(key string, ttl time.Duration) (bool, error)
+}
+
+type jobLockRepository struct {
+	rdb *redis.Client
+}
+
+func NewJobLockRepository(rdb *redis.Client) JobLockRepository {
+	return &jobLockRepository{rdb: rdb}
+}
+
+func (r *jobLockRepository) TryLock(key string, ttl time.Duration) (bool, error) {
+	return r.rdb.SetNX(context.Background(), jobLockKeyPrefix+key, time.Now().Unix(), ttl).Result()
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	loginFailureKeyPrefix = "brewkar:login:failures:"
+	loginLockKeyPrefix    = "brewkar:login:lock:"
+)
+
+// LoginAttemptRepository tracks failed logins and temporary lockouts in Redis.
+// Keys identify what is being throttled, e.g. an account or an IP address.
+type LoginAttemptRepository interface {
+	// LockedFor returns how long key remains locked, or zero if it is not.
+	LockedFor(key string) (time.Duration, error)
+	// RecordFailure counts a failed login for key and returns the number of
+	// failures seen since the counter was last reset. The counter expires
+	// window after the most recent failure.
+	RecordFailure(key string, window time.Duration) (int64, error)
+	Lock(key string, duration time.Duration) error
+	// Reset clears both the failure counter and any lock for key.
+	Reset(key string) error
+}
+
+type loginAttemptRepository struct {
+	rdb *redis.Client
+}
+
+func NewLoginAttemptRepository(rdb *redis.Client) LoginAttemptRepository {
+	return &loginAttemptRepository{rdb: rdb}
+}
+
+func (r *loginAttemptRepository) LockedFor(key string) (time.Duration, error) {
+	ttl, err := r.rdb.PTTL(context.Background(), loginLockKeyPrefix+key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// Negative values mean the key does not exist or has no expiry; locks
+	// are always written with one
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (r *loginAttemptRepository) RecordFailure(key string, window time.Duration) (int64, error) {
+	ctx := context.Background()
+
+	pipe := r.rdb.TxPipeline()
+	count := pipe.Incr(ctx, loginFailureKeyPrefix+key)
+	pipe.Expire(ctx, loginFailureKeyPrefix+key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return count.Val(), nil
+}
+
+func (r *loginAttemptRepository) Lock(key string, duration time.Duration) error {
+	return r.rdb.Set(context.Background(), loginLockKeyPrefix+key, 1, duration).Err()
+}
+
+func (r *loginAttemptRepository) Reset(key string) error {
+	return r.rdb.Del(context.Background(), loginFailureKeyPrefix+key, loginLockKeyPrefix+key).Err()
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"gorm.io/gorm"
+)
+
+type MFARepository interface {
+	GetFactor(userID uuid.UUID) (*domain.MFAFactor, error)
+	SaveFactor(factor *domain.MFAFactor) error
+	// DeleteFactor removes the factor together with its recovery codes.
+	DeleteFactor(userID uuid.UUID) error
+	// AdvanceStep atomically records step as the last used TOTP step. It
+	// reports false when a code of this or a later step was already used.
+	AdvanceStep(userID uuid.UUID, step int64) (bool, error)
+	ReplaceRecoveryCodes(userID uuid.UUID, codes []domain.RecoveryCode) error
+	// UseRecoveryCode atomically consumes an unused recovery code.
+	UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error)
+}
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepository{db: db}
+}
+
+func (r *mfaRepository) GetFactor(userID uuid.UUID) (*domain.MFAFactor, error) {
+	var factor domain.MFAFactor
+	if err := r.db.Where("user_id = ?", userID).First(&factor).Error; err != nil {
+		return nil, err
+	}
+	return &factor, nil
+}
+
+func (r *mfaRepository) SaveFactor(factor *domain.MFAFactor) error {
+	return r.db.Save(factor).Error
+}
+
+func (r *mfaRepository) DeleteFactor(userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&domain.MFAFactor{}).Error
+	})
+}
+
+func (r *mfaRepository) AdvanceStep(userID uuid.UUID, step int64) (bool, error) {
+	result := r.db.Model(&domain.MFAFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Updates(map[string]interface{}{"last_used_step": step, "updated_at": time.Now()})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(userID uuid.UUID, codes []domain.RecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *mfaRepository) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	result := r.db.Model(&domain.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
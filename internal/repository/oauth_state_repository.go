@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const oauthStateKeyPrefix = "brewkar:oauth:state:"
+
+// OAuthState is what is remembered about an authorization request between
+// sending the user to the provider and the callback.
+type OAuthState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"codeVerifier"`
+	Nonce        string `json:"nonce"`
+	// BindingHash is the SHA-256 hash of the value given to the client that
+	// started a login. Links are bound to LinkUserID instead.
+	BindingHash string `json:"bindingHash,omitempty"`
+	// LinkUserID is set when an existing user is linking the identity
+	// instead of logging in.
+	LinkUserID uuid.UUID `json:"linkUserId"`
+}
+
+// OAuthStateRepository keeps pending authorization requests in Redis, keyed by
+// their state parameter.
+type OAuthStateRepository interface {
+	Save(state string, data *OAuthState, ttl time.Duration) error
+	// Consume returns and deletes the data for state, so each state can be
+	// used once. It returns nil when the state is unknown or expired.
+	Consume(state string) (*OAuthState, error)
+}
+
+type oauthStateRepository struct {
+	rdb *redis.Client
+}
+
+func NewOAuthStateRepository(rdb *redis.Client) OAuthStateRepository {
+	return &oauthStateRepository{rdb: rdb}
+}
+
+func (r *oauthStateRepository) Save(state string, data *OAuthState, ttl time.Duration) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return r.rdb.Set(context.Background(), oauthStateKeyPrefix+state, payload, ttl).Err()
+}
+
+func (r *oauthStateRepository) Consume(state string) (*OAuthState, error) {
+	payload, err := r.rdb.GetDel(context.Background(), oauthStateKeyPrefix+state).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data OAuthState
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"gorm.io/gorm"
+)
+
+type OnboardingRepository interface {
+	GetByUserID(userID uuid.UUID) (*domain.Onboarding, error)
+	// Save creates or updates the user's onboarding progress.
+	Save(onboarding *domain.Onboarding) error
+	// Advance saves the progress of a user moving on from step, together
+	// with the equipment their answer adds, in one transaction. It reports
+	// false and saves nothing when the stored progress is no longer at step,
+	// e.g. because the same answer was sent twice.
+	Advance(onboarding *domain.Onboarding, step domain.OnboardingStep, equipment []domain.Equipment) (bool, error)
+}
+
+type onboardingRepository struct {
+	db *gorm.DB
+}
+
+func NewOnboardingRepository(db *gorm.DB) OnboardingRepository {
+	return &onboardingRepository{db: db}
+}
+
+func (r *onboardingRepository) GetByUserID(userID uuid.UUID) (*domain.Onboarding, error) {
+	var onboarding domain.Onboarding
+	if err := r.db.Where("user_id = ?", userID).First(&onboarding).Error; err != nil {
+		return nil, err
+	}
+	return &onboarding, nil
+}
+
+func (r *onboardingRepository) Save(onboarding *domain.Onboarding) error {
+	return r.db.Save(onboarding).Error
+}
+
+func (r *onboardingRepository) Advance(onboarding *domain.Onboarding, step domain.OnboardingStep, equipment []domain.Equipment) (bool, error) {
+	advanced := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(onboarding).
+			Where("current_step = ?", step).
+			Select("current_step", "completed_steps", "skipped_steps", "answers", "updated_at", "completed_at").
+			Updates(onboarding)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if len(equipment) > 0 {
+			if err := tx.Create(&equipment).Error; err != nil {
+				return err
+			}
+		}
+		advanced = true
+		return nil
+	})
+	return advanced, err
+}
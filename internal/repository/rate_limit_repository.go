@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const rateLimitKeyPrefix = "brewkar:ratelimit:"
+
+// RateLimitRepository counts events per key in fixed Redis windows.
+type RateLimitRepository interface {
+	// Allow records one event for key and reports whether it is within limit
+	// for the current window. When it is not, retryAfter is the time left
+	// until the window resets.
+	Allow(key string, limit int, window time.Duration) (allowed bool, retryAfter time.Duration, err error)
+}
+
+type rateLimitRepository struct {
+	rdb *redis.Client
+}
+
+func NewRateLimitRepository(rdb *redis.Client) RateLimitRepository {
+	return &rateLimitRepository{rdb: rdb}
+}
+
+func (r *rateLimitRepository) Allow(key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	ctx := context.Background()
+	key = rateLimitKeyPrefix + key
+
+	count, err := r.rdb.Incr(ctx, key).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	if count == 1 {
+		if err := r.rdb.Expire(ctx, key, window).Err(); err != nil {
+			return false, 0, err
+		}
+	}
+
+	if count <= int64(limit) {
+		return true, 0, nil
+	}
+
+	ttl, err := r.rdb.TTL(ctx, key).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	if ttl < 0 {
+		// The key lost its expiry; start a fresh window
+		if err := r.rdb.Expire(ctx, key, window).Err(); err != nil {
+			return false, 0, err
+		}
+		ttl = window
+	}
+	return false, ttl, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	Create(session *domain.Session) error
+	GetByID(id uuid.UUID) (*domain.Session, error)
+	// ListActiveForUser returns the user's sessions that are not revoked,
+	// most recently seen first.
+	ListActiveForUser(userID uuid.UUID) ([]domain.Session, error)
+	Touch(id uuid.UUID, ipAddress string, seenAt time.Time) error
+	// Revoke atomically revokes the session. It reports false when the
+	// session was already revoked.
+	Revoke(id uuid.UUID, at time.Time) (bool, error)
+	RevokeAllForUser(userID uuid.UUID, at time.Time) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(session *domain.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepository) GetByID(id uuid.UUID) (*domain.Session, error) {
+	var session domain.Session
+	if err := r.db.Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) ListActiveForUser(userID uuid.UUID) ([]domain.Session, error) {
+	var sessions []domain.Session
+	if err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepository) Touch(id uuid.UUID, ipAddress string, seenAt time.Time) error {
+	return r.db.Model(&domain.Session{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"ip_address": ipAddress, "last_seen_at": seenAt}).Error
+}
+
+func (r *sessionRepository) Revoke(id uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.Model(&domain.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *sessionRepository) RevokeAllForUser(userID uuid.UUID, at time.Time) error {
+	return r.db.Model(&domain.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
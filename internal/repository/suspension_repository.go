@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const suspendedUserKeyPrefix = "brewkar:suspended:user:"
+
+// SuspensionRepository mirrors the suspended users in Redis so every request
+// can be checked without a database query. The users table stays the source
+// of truth; login and token refresh check it directly.
+type SuspensionRepository interface {
+	Suspend(userID uuid.UUID) error
+	Unsuspend(userID uuid.UUID) error
+	IsSuspended(userID uuid.UUID) (bool, error)
+}
+
+type suspensionRepository struct {
+	rdb *redis.Client
+}
+
+func NewSuspensionRepository(rdb *redis.Client) SuspensionRepository {
+	return &suspensionRepository{rdb: rdb}
+}
+
+func (r *suspensionRepository) Suspend(userID uuid.UUID) error {
+	return r.rdb.Set(context.Background(), suspendedUserKeyPrefix+userID.String(), 1, 0).Err()
+}
+
+func (r *suspensionRepository) Unsuspend(userID uuid.UUID) error {
+	return r.rdb.Del(context.Background(), suspendedUserKeyPrefix+userID.String()).Err()
+}
+
+func (r *suspensionRepository) IsSuspended(userID uuid.UUID) (bool, error) {
+	n, err := r.rdb.Exists(context.Background(), suspendedUserKeyPrefix+userID.String()).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	revokedTokenKeyPrefix   = "brewkar:revoked:token:"
+	revokedSessionKeyPrefix = "brewkar:revoked:session:"
+	revokedUserKeyPrefix    = "brewkar:revoked:user:"
+)
+
+// TokenRevocationRepository tracks revoked access tokens in Redis. Single
+// tokens are revoked by their ID (jti) and the tokens of one device by their
+// session ID (sid); all tokens of a user are revoked by recording a cut-off
+// time, in milliseconds, that every token issued before it fails. Entries only
+// need to live as long as the tokens they revoke.
+type TokenRevocationRepository interface {
+	RevokeToken(tokenID string, ttl time.Duration) error
+	RevokeSession(sessionID uuid.UUID, ttl time.Duration) error
+	RevokeUserTokens(userID uuid.UUID, before time.Time, ttl time.Duration) error
+	// IsRevoked checks a token against all three kinds of revocation. Tokens
+	// that do not belong to a session pass uuid.Nil as sessionID.
+	IsRevoked(tokenID string, sessionID uuid.UUID, userID uuid.UUID, issuedAt time.Time) (bool, error)
+}
+
+type tokenRevocationRepository struct {
+	rdb *redis.Client
+}
+
+func NewTokenRevocationRepository(rdb *redis.Client) TokenRevocationRepository {
+	return &tokenRevocationRepository{rdb: rdb}
+}
+
+func (r *tokenRevocationRepository) RevokeToken(tokenID string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return r.rdb.Set(context.Background(), revokedTokenKeyPrefix+tokenID, 1, ttl).Err()
+}
+
+func (r *tokenRevocationRepository) RevokeSession(sessionID uuid.UUID, ttl time.Duration) error {
+	return r.rdb.Set(context.Background(), revokedSessionKeyPrefix+sessionID.String(), 1, ttl).Err()
+}
+
+func (r *tokenRevocationRepository) RevokeUserTokens(userID uuid.UUID, before time.Time, ttl time.Duration) error {
+	return r.rdb.Set(context.Background(), revokedUserKeyPrefix+userID.String(), before.UnixMilli(), ttl).Err()
+}
+
+func (r *tokenRevocationRepository) IsRevoked(tokenID string, sessionID uuid.UUID, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	ctx := context.Background()
+
+	keys := []string{revokedTokenKeyPrefix + tokenID}
+	if sessionID != uuid.Nil {
+		keys = append(keys, revokedSessionKeyPrefix+sessionID.String())
+	}
+
+	pipe := r.rdb.Pipeline()
+	tokenRevoked := pipe.Exists(ctx, keys...)
+	userCutoff := pipe.Get(ctx, revokedUserKeyPrefix+userID.String())
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	if tokenRevoked.Val() > 0 {
+		return true, nil
+	}
+
+	if cutoff, err := userCutoff.Result(); err == nil {
+		revokedBefore, err := strconv.ParseInt(cutoff, 10, 64)
+		if err != nil {
+			return false, err
+		}
+		if issuedAt.UnixMilli() < revokedBefore {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -1,16 +1,44 @@
 package repository
 
 import (
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/yashkadam007/brewkar/internal/domain"
 	"gorm.io/gorm"
 )
 
+// UserSearch filters and pages a user search. Zero fields do not filter.
+type UserSearch struct {
+	// Query matches part of the email address, handle or display name,
+	// ignoring case.
+	Query     string
+	Role      domain.Role
+	Suspended *bool
+	Offset    int
+	Limit     int
+}
+
 type UserRepository interface {
 	Create(user *domain.User) error
 	GetByID(id uuid.UUID) (*domain.User, error)
 	GetByEmail(email string) (*domain.User, error)
+	// GetByHandle finds a user by their current handle, ignoring case.
+	GetByHandle(handle string) (*domain.User, error)
 	Update(user *domain.User) error
+	// Search returns one page of matching users, newest first, and the total
+	// number of matches.
+	Search(search UserSearch) ([]domain.User, int64, error)
+	// ListAbandonedGuests returns guest accounts created before cutoff that
+	// have not been used since, oldest first.
+	ListAbandonedGuests(cutoff time.Time, limit int) ([]domain.User, error)
+	// ListDueForDeletion returns users whose scheduled deletion is due at
+	// now, oldest first.
+	ListDueForDeletion(now time.Time, limit int) ([]domain.User, error)
+	// Delete deletes the user together with everything they own and records
+	// the tombstone, in one transaction.
+	Delete(user *domain.User, tombstone *domain.AccountTombstone) error
 }
 
 type userRepository struct {
@@ -41,6 +69,85 @@ func (r *userRepository) GetByEmail(email string) (*domain.User, error) {
 	return &user, nil
 }
 
+func (r *userRepository) GetByHandle(handle string) (*domain.User, error) {
+	var user domain.User
+	if err := r.db.Where("lower(handle) = lower(?)", handle).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *userRepository) Update(user *domain.User) error {
 	return r.db.Save(user).Error
 }
+
+func (r *userRepository) Search(search UserSearch) ([]domain.User, int64, error) {
+	query := r.db.Model(&domain.User{})
+	if search.Query != "" {
+		pattern := "%" + escapeLike(search.Query) + "%"
+		query = query.Where("email ILIKE ? OR handle ILIKE ? OR display_name ILIKE ?", pattern, pattern, pattern)
+	}
+	if search.Role != "" {
+		query = query.Where("role = ?", search.Role)
+	}
+	if search.Suspended != nil {
+		if *search.Suspended {
+			query = query.Where("suspended_at IS NOT NULL")
+		} else {
+			query = query.Where("suspended_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []domain.User
+	if err := query.Order("created_at DESC").
+		Offset(search.Offset).
+		Limit(search.Limit).
+		Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *userRepository) ListAbandonedGuests(cutoff time.Time, limit int) ([]domain.User, error) {
+	var users []domain.User
+	if err := r.db.
+		Where("is_guest AND created_at < ?", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM sessions WHERE sessions.user_id = users.id AND sessions.last_seen_at >= ?)", cutoff).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepository) ListDueForDeletion(now time.Time, limit int) ([]domain.User, error) {
+	var users []domain.User
+	if err := r.db.Where("deletion_scheduled_at <= ?", now).
+		Order("deletion_scheduled_at ASC").
+		Limit(limit).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepository) Delete(user *domain.User, tombstone *domain.AccountTombstone) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(tombstone).Error; err != nil {
+			return err
+		}
+		// Owned rows go with the user through ON DELETE CASCADE
+		return tx.Delete(&domain.User{}, "id = ?", user.ID).Error
+	})
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
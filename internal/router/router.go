@@ -4,21 +4,50 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yashkadam007/brewkar/internal/config"
 	"github.com/yashkadam007/brewkar/internal/controller"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/middleware"
 )
 
 // SetupRouter configures all routes for the application
 func SetupRouter(
+	cfg *config.Config,
+	uploads http.Handler,
+	authMiddleware gin.HandlerFunc,
+	apiKeyAuth *middleware.APIKeyAuth,
 	authController *controller.AuthController,
+	passwordController *controller.PasswordController,
+	magicLinkController *controller.MagicLinkController,
+	emailVerificationController *controller.EmailVerificationController,
+	mfaController *controller.MFAController,
+	sessionController *controller.SessionController,
+	jwksController *controller.JWKSController,
+	oauthController *controller.OAuthController,
+	guestController *controller.GuestController,
+	adminController *controller.AdminController,
+	apiKeyController *controller.APIKeyController,
+	userController *controller.UserController,
+	handleController *controller.HandleController,
+	onboardingController *controller.OnboardingController,
+	equipmentController *controller.EquipmentController,
+	dataExportController *controller.DataExportController,
+	accountDeletionController *controller.AccountDeletionController,
+	beanController *controller.BeanController,
+	catalogController *controller.CatalogController,
 	// Add more controllers as needed:
-	// userController *controller.UserController,
-	// beanController *controller.BeanController,
 	// recipeController *controller.RecipeController,
 	// brewLogController *controller.BrewLogController,
-) *gin.Engine {
+) (*gin.Engine, error) {
 	// Set up Gin router
 	router := gin.Default()
 
+	// Client IPs, which login throttling and sessions rely on, come from
+	// X-Forwarded-For only behind the configured proxies
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, err
+	}
+
 	// Register ping endpoint for health checks
 	router.GET("/ping", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -27,6 +56,14 @@ func SetupRouter(
 		})
 	})
 
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", jwksController.JWKS)
+
+	// Uploaded files, when the storage driver does not serve them elsewhere
+	if uploads != nil {
+		router.GET("/uploads/*filepath", gin.WrapH(http.StripPrefix("/uploads", uploads)))
+	}
+
 	// Register API routes
 	v1 := router.Group("/v1")
 	{
@@ -35,30 +72,119 @@ func SetupRouter(
 		{
 			auth.POST("/register", authController.Register)
 			auth.POST("/login", authController.Login)
+			auth.POST("/guest", guestController.Create)
+			auth.POST("/guest/upgrade", authMiddleware, guestController.Upgrade)
 			auth.POST("/refresh", authController.Refresh)
+			auth.POST("/logout", authMiddleware, authController.Logout)
+			auth.POST("/logout-all", authMiddleware, authController.LogoutAll)
+			auth.POST("/password/forgot", passwordController.ForgotPassword)
+			auth.POST("/password/reset", passwordController.ResetPassword)
+			auth.POST("/magic-link", magicLinkController.Request)
+			auth.POST("/magic-link/verify", magicLinkController.Login)
+			auth.POST("/email/verify", emailVerificationController.VerifyEmail)
+			auth.POST("/email/resend", authMiddleware, emailVerificationController.ResendVerification)
+			auth.POST("/mfa/verify", authController.VerifyMFA)
+			auth.POST("/mfa/enroll", authMiddleware, mfaController.Enroll)
+			auth.POST("/mfa/confirm", authMiddleware, mfaController.Confirm)
+			auth.POST("/mfa/disable", authMiddleware, mfaController.Disable)
+			auth.GET("/sessions", authMiddleware, sessionController.List)
+			auth.DELETE("/sessions/:id", authMiddleware, sessionController.Revoke)
+			auth.POST("/oauth/:provider/start", oauthController.Start)
+			auth.POST("/oauth/:provider/callback", oauthController.Callback)
+			auth.POST("/oauth/:provider/link", authMiddleware, oauthController.StartLink)
+			auth.POST("/oauth/:provider/link/callback", authMiddleware, oauthController.LinkCallback)
+			auth.GET("/identities", authMiddleware, oauthController.ListIdentities)
+		}
+
+		// API key management is only open to signed-in users, never to API
+		// keys themselves
+		apiKeys := v1.Group("/api-keys", authMiddleware)
+		{
+			apiKeys.POST("", apiKeyController.Create)
+			apiKeys.GET("", apiKeyController.List)
+			apiKeys.DELETE("/:id", apiKeyController.Revoke)
+		}
+
+		// Staff routes
+		admin := v1.Group("/admin", authMiddleware)
+		{
+			admin.GET("/users", middleware.RequirePermission(domain.PermissionUsersRead), adminController.SearchUsers)
+			admin.POST("/users/:id/suspend", middleware.RequirePermission(domain.PermissionUsersSuspend), adminController.Suspend)
+			admin.POST("/users/:id/unsuspend", middleware.RequirePermission(domain.PermissionUsersSuspend), adminController.Unsuspend)
+			admin.PUT("/users/:id/role", middleware.RequirePermission(domain.PermissionUsersRoles), adminController.SetRole)
+			admin.POST("/catalog/roasters/:id/merge", middleware.RequirePermission(domain.PermissionCatalogMerge), catalogController.MergeRoasters)
+			admin.POST("/catalog/coffees/:id/merge", middleware.RequirePermission(domain.PermissionCatalogMerge), catalogController.MergeCoffees)
 		}
 
-		// Protected routes
-		// api := v1.Group("")
-		// TODO: Add auth middleware
+		// Protected routes. Routes external integrations may call use
+		// apiKeyAuth.Scoped(<scope>) instead of authMiddleware so they also
+		// accept API keys.
+		// api := v1.Group("", authMiddleware)
 
 		// User routes
-		// users := api.Group("/users")
-		// {
-		// 	users.GET("/me", userController.GetProfile)
-		// 	users.PUT("/me", userController.UpdateProfile)
-		// }
+		users := v1.Group("/users")
+		{
+			users.GET("/me", apiKeyAuth.Scoped(domain.ScopeProfileRead), userController.GetProfile)
+			users.PUT("/me", authMiddleware, userController.UpdateProfile)
+			users.DELETE("/me", authMiddleware, accountDeletionController.Delete)
+			users.POST("/me/avatar", authMiddleware, userController.UploadAvatar)
+			users.DELETE("/me/avatar", authMiddleware, userController.RemoveAvatar)
+			users.GET("/me/preferences", apiKeyAuth.Scoped(domain.ScopeProfileRead), userController.GetPreferences)
+			users.PATCH("/me/preferences", authMiddleware, userController.UpdatePreferences)
+			users.PUT("/me/email", authMiddleware, userController.ChangeEmail)
+			users.PUT("/me/password", authMiddleware, userController.ChangePassword)
+			users.PUT("/me/handle", authMiddleware, handleController.SetHandle)
+			users.POST("/me/export", authMiddleware, dataExportController.Request)
+			users.GET("/me/export", authMiddleware, dataExportController.GetLatest)
+			users.GET("/:handle", handleController.GetPublicProfile)
+		}
 
-		// // Bean routes
-		// beans := api.Group("/beans")
-		// {
-		// 	beans.GET("", beanController.GetAll)
-		// 	beans.POST("", beanController.Create)
-		// 	beans.GET("/:id", beanController.GetByID)
-		// 	beans.PUT("/:id", beanController.Update)
-		// 	beans.DELETE("/:id", beanController.Delete)
-		// 	beans.POST("/:id/images", beanController.UploadImages)
-		// }
+		// First-run questionnaire
+		onboarding := v1.Group("/onboarding", authMiddleware)
+		{
+			onboarding.GET("", onboardingController.Get)
+			onboarding.POST("/skip", onboardingController.SkipRemaining)
+			onboarding.POST("/steps/:step", onboardingController.AnswerStep)
+			onboarding.POST("/steps/:step/skip", onboardingController.SkipStep)
+		}
+
+		v1.GET("/equipment", authMiddleware, equipmentController.List)
+
+		// Emailed download links carry their own token
+		v1.POST("/exports/:id/download", dataExportController.Download)
+
+		// Bean routes
+		beansRead := apiKeyAuth.Scoped(domain.ScopeBeansRead)
+		beansWrite := apiKeyAuth.Scoped(domain.ScopeBeansWrite)
+		beans := v1.Group("/beans")
+		{
+			beans.GET("", beansRead, beanController.GetAll)
+			beans.POST("", beansWrite, beanController.Create)
+			beans.GET("/:id", beansRead, beanController.GetByID)
+			beans.PUT("/:id", beansWrite, beanController.Update)
+			beans.DELETE("/:id", beansWrite, beanController.Delete)
+			beans.POST("/:id/images", beansWrite, beanController.UploadImages)
+			beans.GET("/:id/stock", beansRead, beanController.GetStock)
+			beans.GET("/:id/stock/adjustments", beansRead, beanController.ListStockAdjustments)
+			beans.POST("/:id/stock/adjustments", beansWrite, beanController.AdjustStock)
+			beans.GET("/:id/events", beansRead, beanController.ListEvents)
+			beans.POST("/:id/events", beansWrite, beanController.AddEvent)
+			beans.POST("/:id/portions", beansWrite, beanController.Split)
+		}
+
+		// The roaster and coffee catalog shared by all users, part of their
+		// beans as far as API keys go. Adding to it is public, so it takes
+		// a verified email address, which guests do not have.
+		catalog := v1.Group("/catalog")
+		{
+			catalog.GET("/suggestions", beansRead, catalogController.Suggest)
+			catalog.GET("/roasters", beansRead, catalogController.SearchRoasters)
+			catalog.POST("/roasters", beansWrite, middleware.RequireVerifiedEmail(), catalogController.CreateRoaster)
+			catalog.GET("/roasters/:id", beansRead, catalogController.GetRoaster)
+			catalog.GET("/coffees", beansRead, catalogController.SearchCoffees)
+			catalog.POST("/coffees", beansWrite, middleware.RequireVerifiedEmail(), catalogController.CreateCoffee)
+			catalog.GET("/coffees/:id", beansRead, catalogController.GetCoffee)
+		}
 
 		// // Recipe routes
 		// recipes := api.Group("/recipes")
@@ -86,5 +212,5 @@ func SetupRouter(
 		// TODO: Add other routes (analytics, social, etc.)
 	}
 
-	return router
+	return router, nil
 }
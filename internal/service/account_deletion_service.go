@@ -0,0 +1,226 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/pkg/mailer"
+	"github.com/yashkadam007/brewkar/pkg/storage"
+)
+
+// deletionBatchSize is how many accounts one run of the deletion job
+// deletes.
+const deletionBatchSize = 50
+
+type AccountDeletionService interface {
+	// RequestDeletion schedules the account to be deleted once the grace
+	// period is over and signs it out everywhere. The password, and with
+	// two-factor authentication a code, confirm the request. Guests have
+	// neither and nothing to log back in with, so their accounts are
+	// deleted right away.
+	RequestDeletion(userID uuid.UUID, password, code string) (*domain.User, error)
+	// DeleteScheduledAccounts deletes the accounts whose grace period is
+	// over. It returns how many were deleted.
+	DeleteScheduledAccounts() (int, error)
+	// DeleteAbandonedGuests deletes guests that have not been used for the
+	// configured retention period, like any other account. It returns how
+	// many were deleted.
+	DeleteAbandonedGuests() (int, error)
+}
+
+type AccountDeletionServiceImpl struct {
+	userRepo       repository.UserRepository
+	exportRepo     repository.DataExportRepository
+	beanRepo       repository.BeanRepository
+	rateLimitRepo  repository.RateLimitRepository
+	mfaService     MFAService
+	sessionService SessionService
+	hasher         PasswordHasher
+	storage        storage.Storage
+	mailer         mailer.Mailer
+	appCfg         config.AppConfig
+	authCfg        config.AuthConfig
+	usersCfg       config.UsersConfig
+}
+
+func NewAccountDeletionService(
+	userRepo repository.UserRepository,
+	exportRepo repository.DataExportRepository,
+	beanRepo repository.BeanRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	mfaService MFAService,
+	sessionService SessionService,
+	hasher PasswordHasher,
+	store storage.Storage,
+	m mailer.Mailer,
+	appCfg config.AppConfig,
+	authCfg config.AuthConfig,
+	usersCfg config.UsersConfig,
+) AccountDeletionService {
+	return &AccountDeletionServiceImpl{
+		userRepo:       userRepo,
+		exportRepo:     exportRepo,
+		beanRepo:       beanRepo,
+		rateLimitRepo:  rateLimitRepo,
+		mfaService:     mfaService,
+		sessionService: sessionService,
+		hasher:         hasher,
+		storage:        store,
+		mailer:         m,
+		appCfg:         appCfg,
+		authCfg:        authCfg,
+		usersCfg:       usersCfg,
+	}
+}
+
+func (s *AccountDeletionServiceImpl) RequestDeletion(userID uuid.UUID, password, code string) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsGuest {
+		if err := s.sessionService.RevokeAllSessions(user.ID); err != nil {
+			return nil, err
+		}
+		if err := s.delete(user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	if err := confirmPassword(s.rateLimitRepo, s.hasher, user, password); err != nil {
+		return nil, err
+	}
+	mfaEnabled, err := s.mfaService.IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfaEnabled {
+		if err := s.mfaService.VerifyCode(user.ID, code); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.IsPendingDeletion() {
+		return user, nil
+	}
+
+	now := time.Now()
+	scheduledAt := now.AddDate(0, 0, s.usersCfg.DeletionGraceDays)
+	user.DeletionRequestedAt = &now
+	user.DeletionScheduledAt = &scheduledAt
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	// Signing out everywhere means any later login is a deliberate one,
+	// which cancels the deletion
+	if err := s.sessionService.RevokeAllSessions(user.ID); err != nil {
+		return nil, err
+	}
+
+	_ = s.mailer.Send(mailer.Message{
+		To:      user.EmailAddress(),
+		Subject: fmt.Sprintf("Your %s account will be deleted", s.appCfg.Name),
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nAs you asked, your %s account and everything in it will be deleted on %s. Until then you can change your mind: logging in cancels the deletion.\n\nIf you did not ask for this, log in and change your password right away.\n",
+			user.DisplayName, s.appCfg.Name, scheduledAt.UTC().Format("January 2, 2006"),
+		),
+	})
+
+	return user, nil
+}
+
+func (s *AccountDeletionServiceImpl) DeleteScheduledAccounts() (int, error) {
+	users, err := s.userRepo.ListDueForDeletion(time.Now(), deletionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return s.deleteAll(users)
+}
+
+func (s *AccountDeletionServiceImpl) DeleteAbandonedGuests() (int, error) {
+	cutoff := time.Now().AddDate(0, 0, -s.authCfg.GuestRetentionDays)
+	users, err := s.userRepo.ListAbandonedGuests(cutoff, deletionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return s.deleteAll(users)
+}
+
+// deleteAll deletes the users one by one, carrying on past failures.
+func (s *AccountDeletionServiceImpl) deleteAll(users []domain.User) (int, error) {
+	deleted := 0
+	var errs []error
+	for i := range users {
+		if err := s.delete(&users[i]); err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", users[i].ID, err))
+			continue
+		}
+		deleted++
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// delete removes the user's stored files, then the user with everything they
+// own, leaving a tombstone behind. Files go first: should the database delete
+// fail, the next run tries again, while files left behind by a deleted user
+// would never be found.
+func (s *AccountDeletionServiceImpl) delete(user *domain.User) error {
+	keys, err := s.fileKeys(user)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := s.storage.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	// Content the user left on other users' content, such as comments, is
+	// anonymized here once those modules exist; everything the user owns is
+	// removed by the cascade.
+	return s.userRepo.Delete(user, &domain.AccountTombstone{
+		UserID:              user.ID,
+		WasGuest:            user.IsGuest,
+		AccountCreatedAt:    user.CreatedAt,
+		DeletionRequestedAt: user.DeletionRequestedAt,
+		DeletedAt:           time.Now(),
+		FilesDeleted:        len(keys),
+	})
+}
+
+// fileKeys lists the storage keys of the files the user uploaded or had
+// built for them.
+func (s *AccountDeletionServiceImpl) fileKeys(user *domain.User) ([]string, error) {
+	var keys []string
+	if user.AvatarKey != "" {
+		keys = append(keys, user.AvatarKey)
+	}
+
+	beans, err := s.beanRepo.ListForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, bean := range beans {
+		keys = append(keys, bean.ImageKeys...)
+	}
+
+	exports, err := s.exportRepo.ListForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, export := range exports {
+		if export.StorageKey != "" {
+			keys = append(keys, export.StorageKey)
+		}
+	}
+	return keys, nil
+}
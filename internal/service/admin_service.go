@@ -0,0 +1,174 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidRole        = errors.New("unknown role")
+	ErrCannotManageSelf   = errors.New("you cannot change your own role or suspension")
+	ErrInsufficientRank   = errors.New("you can only manage users with a lower role than yours")
+	ErrGuestCannotBeStaff = errors.New("guest accounts cannot be given a role")
+)
+
+// Rank of each role; suspending someone or changing their role requires a
+// higher rank
+var roleRank = map[domain.Role]int{
+	domain.RoleUser:      0,
+	domain.RoleModerator: 1,
+	domain.RoleAdmin:     2,
+}
+
+type AdminService interface {
+	SearchUsers(search repository.UserSearch) ([]domain.User, int64, error)
+	// SuspendUser blocks the user from signing in and from using the tokens
+	// they already have. actorID is the moderator doing it.
+	SuspendUser(actorID, userID uuid.UUID, reason string) (*domain.User, error)
+	UnsuspendUser(actorID, userID uuid.UUID) (*domain.User, error)
+	// SetRole changes the user's role. Like suspensions, it takes a higher
+	// rank than the user has, and the role given cannot be above the
+	// actor's. Tokens issued before the change stop working, so the new role
+	// applies from the user's next token refresh.
+	SetRole(actorID, userID uuid.UUID, role domain.Role) (*domain.User, error)
+}
+
+type AdminServiceImpl struct {
+	userRepo       repository.UserRepository
+	suspensionRepo repository.SuspensionRepository
+	revocationRepo repository.TokenRevocationRepository
+	jwtCfg         config.JWTConfig
+}
+
+func NewAdminService(
+	userRepo repository.UserRepository,
+	suspensionRepo repository.SuspensionRepository,
+	revocationRepo repository.TokenRevocationRepository,
+	jwtCfg config.JWTConfig,
+) AdminService {
+	return &AdminServiceImpl{
+		userRepo:       userRepo,
+		suspensionRepo: suspensionRepo,
+		revocationRepo: revocationRepo,
+		jwtCfg:         jwtCfg,
+	}
+}
+
+func (s *AdminServiceImpl) SearchUsers(search repository.UserSearch) ([]domain.User, int64, error) {
+	search.Query = strings.TrimSpace(search.Query)
+	if search.Role != "" && !search.Role.Valid() {
+		return nil, 0, ErrInvalidRole
+	}
+	return s.userRepo.Search(search)
+}
+
+func (s *AdminServiceImpl) SuspendUser(actorID, userID uuid.UUID, reason string) (*domain.User, error) {
+	actor, user, err := s.loadActorAndTarget(actorID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if roleRank[actor.Role] <= roleRank[user.Role] {
+		return nil, ErrInsufficientRank
+	}
+	if user.IsSuspended() {
+		return user, nil
+	}
+
+	now := time.Now()
+	user.SuspendedAt = &now
+	user.SuspendedReason = strings.TrimSpace(reason)
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	if err := s.suspensionRepo.Suspend(user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *AdminServiceImpl) UnsuspendUser(actorID, userID uuid.UUID) (*domain.User, error) {
+	actor, user, err := s.loadActorAndTarget(actorID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if roleRank[actor.Role] <= roleRank[user.Role] {
+		return nil, ErrInsufficientRank
+	}
+	if !user.IsSuspended() {
+		return user, nil
+	}
+
+	user.SuspendedAt = nil
+	user.SuspendedReason = ""
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	if err := s.suspensionRepo.Unsuspend(user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *AdminServiceImpl) SetRole(actorID, userID uuid.UUID, role domain.Role) (*domain.User, error) {
+	if !role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	actor, user, err := s.loadActorAndTarget(actorID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if roleRank[actor.Role] <= roleRank[user.Role] || roleRank[actor.Role] < roleRank[role] {
+		return nil, ErrInsufficientRank
+	}
+	if user.IsGuest && role != domain.RoleUser {
+		return nil, ErrGuestCannotBeStaff
+	}
+	if user.Role == role {
+		return user, nil
+	}
+
+	now := time.Now()
+	user.Role = role
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	// Access tokens carry the role, so the old ones must not outlive the
+	// change, above all after a demotion
+	if err := s.revocationRepo.RevokeUserTokens(user.ID, now, time.Duration(s.jwtCfg.AccessTokenExp)*time.Minute); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// loadActorAndTarget loads the staff member making a change and the user it
+// applies to. Staff cannot change their own account, which also keeps the
+// last admin from locking everyone out.
+func (s *AdminServiceImpl) loadActorAndTarget(actorID, userID uuid.UUID) (*domain.User, *domain.User, error) {
+	if actorID == userID {
+		return nil, nil, ErrCannotManageSelf
+	}
+	actor, err := s.userRepo.GetByID(actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return actor, user, nil
+}
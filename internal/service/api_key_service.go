@@ -0,0 +1,187 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+)
+
+// API keys look like bk_<12 hex characters>_<secret>. The part before the
+// second underscore is stored as the key's visible prefix.
+const apiKeyPrefix = "bk_"
+
+// How stale last-used timestamps may get, so busy keys do not cause a write
+// on every request
+const apiKeyTouchInterval = time.Minute
+
+var (
+	ErrInvalidAPIKey      = errors.New("invalid, expired or revoked API key")
+	ErrAPIKeyNotFound     = errors.New("API key not found")
+	ErrInvalidScope       = errors.New("unknown API key scope")
+	ErrNoScopes           = errors.New("an API key needs at least one scope")
+	ErrInvalidExpiry      = errors.New("expiry must be in the future")
+	ErrAPIKeyLimitReached = errors.New("too many API keys; revoke one first")
+	ErrInsufficientScope  = errors.New("the API key lacks the scope")
+	// API keys stop working while their account is scheduled for deletion
+	ErrAccountPendingDeletion = errors.New("the account is scheduled for deletion; log in to cancel it")
+)
+
+type APIKeyService interface {
+	// CreateKey creates a key and returns it together with the raw key, which
+	// is not stored and cannot be shown again.
+	CreateKey(userID uuid.UUID, name string, scopes []domain.Scope, expiresAt *time.Time) (*domain.APIKey, string, error)
+	ListKeys(userID uuid.UUID) ([]domain.APIKey, error)
+	RevokeKey(userID, keyID uuid.UUID) error
+	// Authenticate checks a raw key from a request and that it was granted
+	// scope, then counts the request against the key's daily quota. The
+	// returned key has its user loaded.
+	Authenticate(rawKey string, scope domain.Scope) (*domain.APIKey, error)
+}
+
+type APIKeyServiceImpl struct {
+	apiKeyRepo    repository.APIKeyRepository
+	rateLimitRepo repository.RateLimitRepository
+	apiKeyCfg     config.APIKeyConfig
+}
+
+func NewAPIKeyService(
+	apiKeyRepo repository.APIKeyRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	apiKeyCfg config.APIKeyConfig,
+) APIKeyService {
+	return &APIKeyServiceImpl{
+		apiKeyRepo:    apiKeyRepo,
+		rateLimitRepo: rateLimitRepo,
+		apiKeyCfg:     apiKeyCfg,
+	}
+}
+
+func (s *APIKeyServiceImpl) CreateKey(userID uuid.UUID, name string, scopes []domain.Scope, expiresAt *time.Time) (*domain.APIKey, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", ErrNoScopes
+	}
+	unique := make([]domain.Scope, 0, len(scopes))
+	seen := make(map[domain.Scope]bool, len(scopes))
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return nil, "", ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+
+	now := time.Now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, "", ErrInvalidExpiry
+	}
+
+	count, err := s.apiKeyRepo.CountActiveForUser(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if count >= int64(s.apiKeyCfg.MaxPerUser) {
+		return nil, "", ErrAPIKeyLimitReached
+	}
+
+	rawKey, prefix, err := generateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &domain.APIKey{
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    prefix,
+		KeyHash:   hashToken(rawKey),
+		Scopes:    unique,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if err := s.apiKeyRepo.Create(key); err != nil {
+		return nil, "", err
+	}
+	return key, rawKey, nil
+}
+
+func (s *APIKeyServiceImpl) ListKeys(userID uuid.UUID) ([]domain.APIKey, error) {
+	return s.apiKeyRepo.ListActiveForUser(userID)
+}
+
+func (s *APIKeyServiceImpl) RevokeKey(userID, keyID uuid.UUID) error {
+	revoked, err := s.apiKeyRepo.Revoke(keyID, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (s *APIKeyServiceImpl) Authenticate(rawKey string, scope domain.Scope) (*domain.APIKey, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := s.apiKeyRepo.GetByHash(hashToken(rawKey))
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if !key.IsUsable(now) || key.User == nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if key.User.IsSuspended() {
+		return nil, ErrAccountSuspended
+	}
+	if key.User.IsPendingDeletion() {
+		return nil, ErrAccountPendingDeletion
+	}
+	// Requests the key may not make do not use up its quota
+	if !key.HasScope(scope) {
+		return nil, ErrInsufficientScope
+	}
+
+	// The quota resets at midnight UTC
+	day := now.UTC().Format("2006-01-02")
+	untilMidnight := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+	allowed, retryAfter, err := s.rateLimitRepo.Allow("api-key:"+key.ID.String()+":"+day, s.apiKeyCfg.DailyQuota, untilMidnight)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, &RateLimitError{RetryAfter: retryAfter}
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.apiKeyRepo.Touch(key.ID, now); err != nil {
+			return nil, err
+		}
+		key.LastUsedAt = &now
+	}
+
+	return key, nil
+}
+
+// generateAPIKey returns a new raw key and its visible prefix.
+func generateAPIKey() (string, string, error) {
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	secret, _, err := generateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	prefix := apiKeyPrefix + hex.EncodeToString(id)
+	return prefix + "_" + secret, prefix, nil
+}
@@ -1,7 +1,12 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"math"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -9,107 +14,500 @@ import (
 	"github.com/yashkadam007/brewkar/internal/config"
 	"github.com/yashkadam007/brewkar/internal/domain"
 	"github.com/yashkadam007/brewkar/internal/repository"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/yashkadam007/brewkar/pkg/keyring"
+	"github.com/yashkadam007/brewkar/pkg/passwords"
 )
 
+// Token types carried in the "typ" claim
+const (
+	TokenTypeAccess     = "access"
+	TokenTypeMFAPending = "mfa_pending"
+)
+
+// Wrong codes allowed per MFA challenge before it has to be restarted
+const mfaChallengeMaxAttempts = 5
+
+// IssuedAtClaim is the "iat" claim of a token issued at t. It keeps
+// milliseconds, so a token issued right after its user's tokens were
+// revoked is not taken for one issued before.
+func IssuedAtClaim(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
+
+// IssuedAt reads an "iat" claim made by IssuedAtClaim.
+func IssuedAt(iat float64) time.Time {
+	return time.UnixMilli(int64(math.Round(iat * 1000)))
+}
+
+// ClientInfo describes the client making an authentication request.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+	// DeviceName is an optional, user-facing name sent by the client, e.g.
+	// "Pixel 8"
+	DeviceName string
+}
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used; all sessions for this login were revoked")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired two-factor challenge")
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+	ErrAccountSuspended    = errors.New("this account has been suspended")
+)
+
+// TokenPair is handed out on every successful authentication. The access token
+// is a short-lived JWT; the refresh token is opaque and single-use.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
 type AuthService interface {
-	Register(email, password, displayName string) (*domain.User, string, error)
-	Login(email, password string) (*domain.User, string, error)
-	RefreshToken(refreshToken string) (string, string, error)
+	Register(email, password, displayName string, client ClientInfo) (*domain.User, *TokenPair, error)
+	Login(email, password string, client ClientInfo) (*domain.User, *TokenPair, error)
+	RefreshToken(refreshToken string, client ClientInfo) (*TokenPair, error)
+	Logout(userID, sessionID uuid.UUID) error
+	LogoutAll(userID uuid.UUID) error
+	VerifyMFAChallenge(challengeToken, code string, client ClientInfo) (*domain.User, *TokenPair, error)
+	// LoginUser logs in a user who was authenticated some other way, e.g. by
+	// an identity provider. Two-factor authentication still applies.
+	LoginUser(user *domain.User, client ClientInfo) (*domain.User, *TokenPair, error)
 }
 
 // Change from authService to AuthServiceImpl
 type AuthServiceImpl struct {
-	userRepo repository.UserRepository
-	jwtCfg   config.JWTConfig
+	userRepo         repository.UserRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	revocationRepo   repository.TokenRevocationRepository
+	rateLimitRepo    repository.RateLimitRepository
+	verificationSvc  EmailVerificationService
+	mfaService       MFAService
+	sessionService   SessionService
+	hasher           PasswordHasher
+	policy           *passwords.Policy
+	keyring          *keyring.Keyring
+	loginThrottle    *loginThrottle
+	jwtCfg           config.JWTConfig
+	authCfg          config.AuthConfig
 }
 
-func NewAuthService(userRepo repository.UserRepository, jwtCfg config.JWTConfig) AuthService {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	revocationRepo repository.TokenRevocationRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	verificationSvc EmailVerificationService,
+	mfaService MFAService,
+	sessionService SessionService,
+	hasher PasswordHasher,
+	policy *passwords.Policy,
+	kr *keyring.Keyring,
+	jwtCfg config.JWTConfig,
+	authCfg config.AuthConfig,
+) AuthService {
 	return &AuthServiceImpl{
-		userRepo: userRepo,
-		jwtCfg:   jwtCfg,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		revocationRepo:   revocationRepo,
+		rateLimitRepo:    rateLimitRepo,
+		verificationSvc:  verificationSvc,
+		mfaService:       mfaService,
+		sessionService:   sessionService,
+		hasher:           hasher,
+		policy:           policy,
+		keyring:          kr,
+		loginThrottle:    &loginThrottle{repo: loginAttemptRepo, cfg: authCfg},
+		jwtCfg:           jwtCfg,
+		authCfg:          authCfg,
 	}
 }
 
-func (s *AuthServiceImpl) Register(email, password, displayName string) (*domain.User, string, error) {
+func (s *AuthServiceImpl) Register(email, password, displayName string, client ClientInfo) (*domain.User, *TokenPair, error) {
 	// Check if user already exists
 	existing, err := s.userRepo.GetByEmail(email)
 	if err == nil && existing != nil {
-		return nil, "", errors.New("user with this email already exists")
+		return nil, nil, errors.New("user with this email already exists")
+	}
+
+	if err := validatePassword(s.policy, password); err != nil {
+		return nil, nil, err
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
 	// Create user
 	user := &domain.User{
-		Email:        email,
-		PasswordHash: string(hashedPassword),
+		Email:        &email,
+		PasswordHash: hashedPassword,
 		DisplayName:  displayName,
+		Role:         domain.RoleUser,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
 
 	if err := s.userRepo.Create(user); err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Generate token
-	token, err := s.generateToken(user.ID)
+	// Send verification email. A failed send must not fail the registration;
+	// the user can ask for another link.
+	_ = s.verificationSvc.SendVerification(user)
+
+	// Generate tokens
+	tokens, err := s.startSession(user, client)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	return user, token, nil
+	return user, tokens, nil
 }
 
-func (s *AuthServiceImpl) Login(email, password string) (*domain.User, string, error) {
+func (s *AuthServiceImpl) Login(email, password string, client ClientInfo) (*domain.User, *TokenPair, error) {
+	// Refuse locked accounts and addresses before doing any password work
+	if err := s.loginThrottle.check(email, client.IP); err != nil {
+		return nil, nil, err
+	}
+
 	// Find user by email
 	user, err := s.userRepo.GetByEmail(email)
 	if err != nil {
-		return nil, "", errors.New("invalid email or password")
+		// Unknown emails count as failures too so they behave like
+		// existing accounts
+		if err := s.loginThrottle.recordFailure(email, client.IP); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidCredentials
 	}
 
 	// Compare password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return nil, "", errors.New("invalid email or password")
+	match, needsRehash, err := s.hasher.Verify(password, user.PasswordHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !match {
+		if err := s.loginThrottle.recordFailure(email, client.IP); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	if err := s.loginThrottle.reset(email); err != nil {
+		return nil, nil, err
+	}
+
+	// The plain password is only available now, so this is when hashes made
+	// with bcrypt or outdated parameters are replaced
+	if needsRehash {
+		if err := s.rehashPassword(user, password); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return s.LoginUser(user, client)
+}
+
+func (s *AuthServiceImpl) rehashPassword(user *domain.User, password string) error {
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hashedPassword
+	user.UpdatedAt = time.Now()
+	return s.userRepo.Update(user)
+}
+
+func (s *AuthServiceImpl) LoginUser(user *domain.User, client ClientInfo) (*domain.User, *TokenPair, error) {
+	if user.IsSuspended() {
+		return nil, nil, ErrAccountSuspended
+	}
+
+	// With two-factor authentication the login only completes after
+	// VerifyMFAChallenge
+	mfaEnabled, err := s.mfaService.IsEnabled(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mfaEnabled {
+		challengeToken, err := s.generateMFAChallengeToken(user.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, &MFARequiredError{ChallengeToken: challengeToken}
 	}
 
-	// Update last login
+	return s.completeLogin(user, client)
+}
+
+// VerifyMFAChallenge exchanges the challenge token from Login and a TOTP or
+// recovery code for real tokens.
+func (s *AuthServiceImpl) VerifyMFAChallenge(challengeToken, code string, client ClientInfo) (*domain.User, *TokenPair, error) {
+	claims, err := s.parseToken(challengeToken, TokenTypeMFAPending)
+	if err != nil {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+
+	tokenID, _ := claims["jti"].(string)
+	userIDStr, _ := claims["sub"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil || tokenID == "" {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+
+	revoked, err := s.revocationRepo.IsRevoked(tokenID, uuid.Nil, userID, IssuedAt(claims["iat"].(float64)))
+	if err != nil {
+		return nil, nil, err
+	}
+	if revoked {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+
+	allowed, retryAfter, err := s.rateLimitRepo.Allow("mfa-challenge:"+tokenID, mfaChallengeMaxAttempts, s.mfaChallengeTTL())
+	if err != nil {
+		return nil, nil, err
+	}
+	if !allowed {
+		return nil, nil, &RateLimitError{RetryAfter: retryAfter}
+	}
+
+	if err := s.mfaService.VerifyCode(userID, code); err != nil {
+		return nil, nil, err
+	}
+
+	// Challenges are single-use
+	if err := s.revocationRepo.RevokeToken(tokenID, s.mfaChallengeTTL()); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+
+	return s.completeLogin(user, client)
+}
+
+// completeLogin records the login and starts a new session.
+func (s *AuthServiceImpl) completeLogin(user *domain.User, client ClientInfo) (*domain.User, *TokenPair, error) {
+	// The user may have been suspended while entering their two-factor code
+	if user.IsSuspended() {
+		return nil, nil, ErrAccountSuspended
+	}
+
+	// Update last login. Logging in during the grace period of an account
+	// deletion cancels it.
 	now := time.Now()
 	user.LastLoginAt = &now
+	user.DeletionRequestedAt = nil
+	user.DeletionScheduledAt = nil
 	user.UpdatedAt = now
 	if err := s.userRepo.Update(user); err != nil {
-		return nil, "", err
+		return nil, nil, err
+	}
+
+	// Generate tokens
+	tokens, err := s.startSession(user, client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokens, nil
+}
+
+func (s *AuthServiceImpl) RefreshToken(refreshToken string, client ClientInfo) (*TokenPair, error) {
+	stored, err := s.refreshTokenRepo.GetByHash(hashToken(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
 	}
 
-	// Generate token
-	token, err := s.generateToken(user.ID)
+	if stored.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// A token that was already rotated is being presented again: either the
+	// client raced itself or the token leaked. Kill the whole session.
+	if stored.UsedAt != nil {
+		if err := s.revokeReusedSession(stored); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	now := time.Now()
+	if now.After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetByID(stored.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	// Checked before rotating so the token still works once the suspension
+	// is lifted
+	if user.IsSuspended() {
+		return nil, ErrAccountSuspended
+	}
+
+	// Rotate: mark the presented token as used. Losing this race means another
+	// request used the token first, which is also reuse.
+	marked, err := s.refreshTokenRepo.MarkUsed(stored.ID, now)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+	if !marked {
+		if err := s.revokeReusedSession(stored); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
 	}
 
-	return user, token, nil
+	if err := s.sessionService.TouchSession(stored.SessionID, client); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, stored.SessionID)
 }
 
-func (s *AuthServiceImpl) RefreshToken(refreshToken string) (string, string, error) {
-	// TODO: Implement refresh token logic
-	return "", "", nil
+// Logout ends the session the request was made with.
+func (s *AuthServiceImpl) Logout(userID, sessionID uuid.UUID) error {
+	err := s.sessionService.RevokeSession(userID, sessionID)
+	if errors.Is(err, ErrSessionNotFound) {
+		// Already logged out
+		return nil
+	}
+	return err
 }
 
-func (s *AuthServiceImpl) generateToken(userID uuid.UUID) (string, error) {
-	// Create claims
+// LogoutAll ends every session of the user.
+func (s *AuthServiceImpl) LogoutAll(userID uuid.UUID) error {
+	return s.sessionService.RevokeAllSessions(userID)
+}
+
+func (s *AuthServiceImpl) revokeReusedSession(stored *domain.RefreshToken) error {
+	err := s.sessionService.RevokeSession(stored.UserID, stored.SessionID)
+	if err != nil && !errors.Is(err, ErrSessionNotFound) {
+		return err
+	}
+	return nil
+}
+
+// startSession creates a session for the client and issues its first tokens.
+func (s *AuthServiceImpl) startSession(user *domain.User, client ClientInfo) (*TokenPair, error) {
+	session, err := s.sessionService.CreateSession(user.ID, client)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokens(user, session.ID)
+}
+
+// issueTokens creates an access token and a new refresh token belonging to
+// the given session.
+func (s *AuthServiceImpl) issueTokens(user *domain.User, sessionID uuid.UUID) (*TokenPair, error) {
+	accessToken, err := s.generateToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	rawRefreshToken, refreshTokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.refreshTokenRepo.Create(&domain.RefreshToken{
+		UserID:    user.ID,
+		SessionID: sessionID,
+		TokenHash: refreshTokenHash,
+		ExpiresAt: now.Add(time.Duration(s.jwtCfg.RefreshTokenExp) * time.Minute),
+		CreatedAt: now,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawRefreshToken,
+	}, nil
+}
+
+func (s *AuthServiceImpl) accessTokenTTL() time.Duration {
+	return time.Duration(s.jwtCfg.AccessTokenExp) * time.Minute
+}
+
+func (s *AuthServiceImpl) mfaChallengeTTL() time.Duration {
+	return time.Duration(s.authCfg.MFAChallengeExp) * time.Minute
+}
+
+func (s *AuthServiceImpl) generateMFAChallengeToken(userID uuid.UUID) (string, error) {
+	now := time.Now()
+
 	claims := jwt.MapClaims{
 		"sub": userID.String(),
-		"exp": time.Now().Add(time.Duration(s.jwtCfg.AccessTokenExp) * time.Minute).Unix(),
+		"jti": uuid.NewString(),
+		"typ": TokenTypeMFAPending,
+		"iat": IssuedAtClaim(now),
+		"exp": now.Add(s.mfaChallengeTTL()).Unix(),
 	}
 
-	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return s.keyring.Sign(claims)
+}
+
+// parseToken validates a token signed by this service and checks its type.
+func (s *AuthServiceImpl) parseToken(tokenString, tokenType string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, s.keyring.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token claims")
+	}
+	if typ, _ := claims["typ"].(string); typ != tokenType {
+		return nil, errors.New("unexpected token type")
+	}
+	if _, ok := claims["iat"].(float64); !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+func (s *AuthServiceImpl) generateToken(user *domain.User, sessionID uuid.UUID) (string, error) {
+	now := time.Now()
+
+	// Create claims
+	claims := jwt.MapClaims{
+		"sub":            user.ID.String(),
+		"jti":            uuid.NewString(),
+		"sid":            sessionID.String(),
+		"typ":            TokenTypeAccess,
+		"iat":            IssuedAtClaim(now),
+		"exp":            now.Add(s.accessTokenTTL()).Unix(),
+		"email_verified": user.IsEmailVerified(),
+		"role":           string(user.Role),
+	}
 
 	// Sign token
-	return token.SignedString([]byte(s.jwtCfg.Secret))
+	return s.keyring.Sign(claims)
+}
+
+// generateOpaqueToken returns a random URL-safe token together with the hash
+// that should be persisted in its place.
+func generateOpaqueToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
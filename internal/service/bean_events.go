@@ -0,0 +1,272 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	maxBeanEventNoteLength = 200
+	maxBeanPortions        = 50
+)
+
+var (
+	ErrInvalidBeanEvent     = errors.New("kind must be one of purchased, opened, frozen, thawed or finished")
+	ErrBeanEventNoteTooLong = fmt.Errorf("note must be at most %d characters", maxBeanEventNoteLength)
+	ErrInvalidEventDate     = errors.New("event date cannot be in the future, before the roast date or before the bean's latest event")
+	ErrInvalidPortions      = fmt.Errorf("give between 1 and %d portions of at least 1 gram each", maxBeanPortions)
+	ErrBeanFinished         = errors.New("the bean is finished")
+	ErrBeanAlreadyOpened    = errors.New("the bean was already opened")
+	ErrBeanAlreadyFrozen    = errors.New("the bean is already frozen")
+	ErrBeanNotFrozen        = errors.New("the bean is not frozen")
+	ErrPortionsTooLarge     = errors.New("the portions hold more coffee than the bean has left")
+)
+
+// NewBeanEvent is an event to add to a bean's timeline. Date defaults to
+// the user's current day.
+type NewBeanEvent struct {
+	Kind domain.BeanEventKind
+	Date *time.Time
+	Note string
+}
+
+// BeanPortions splits a bag into portions of the given sizes. Freeze puts
+// the portions in the freezer today, e.g. when freezing beans in doses.
+type BeanPortions struct {
+	QuantitiesGrams []int
+	Freeze          bool
+}
+
+func (s *BeanServiceImpl) AddEvent(userID, beanID uuid.UUID, input NewBeanEvent) (*domain.CoffeeBean, *domain.BeanEvent, error) {
+	if !input.Kind.Valid() {
+		return nil, nil, ErrInvalidBeanEvent
+	}
+	note := strings.TrimSpace(input.Note)
+	if utf8.RuneCountInString(note) > maxBeanEventNoteLength {
+		return nil, nil, ErrBeanEventNoteTooLong
+	}
+	if _, err := s.GetBean(userID, beanID); err != nil {
+		return nil, nil, err
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	today := userToday(user, now)
+	date := today
+	if input.Date != nil {
+		date = *input.Date
+	}
+	if date.After(today) {
+		return nil, nil, ErrInvalidEventDate
+	}
+
+	bean, event, err := s.eventRepo.AddEvent(beanID, func(bean *domain.CoffeeBean, latest *domain.BeanEvent) (*domain.BeanEvent, error) {
+		if bean.FinishedOn != nil {
+			return nil, ErrBeanFinished
+		}
+		if (latest != nil && date.Before(latest.Date)) || (bean.RoastDate != nil && date.Before(*bean.RoastDate)) {
+			return nil, ErrInvalidEventDate
+		}
+
+		switch input.Kind {
+		case domain.BeanPurchased:
+			bean.PurchaseDate = &date
+		case domain.BeanOpened:
+			if bean.OpenedOn != nil {
+				return nil, ErrBeanAlreadyOpened
+			}
+			bean.OpenedOn = &date
+		case domain.BeanFrozen:
+			if bean.FrozenOn != nil {
+				return nil, ErrBeanAlreadyFrozen
+			}
+			bean.FrozenOn = &date
+		case domain.BeanThawed:
+			if bean.FrozenOn == nil {
+				return nil, ErrBeanNotFrozen
+			}
+			thaw(bean, date)
+			// The bean is further from going stale than when it was warned
+			bean.StaleWarnedAt = nil
+		case domain.BeanFinished:
+			if bean.FrozenOn != nil {
+				thaw(bean, date)
+			}
+			bean.FinishedOn = &date
+		}
+		bean.UpdatedAt = now
+
+		return &domain.BeanEvent{
+			BeanID:    bean.ID,
+			Kind:      input.Kind,
+			Date:      date,
+			Note:      note,
+			CreatedAt: now,
+		}, nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrBeanNotFound
+		}
+		return nil, nil, err
+	}
+	return bean, event, nil
+}
+
+func (s *BeanServiceImpl) ListEvents(userID, beanID uuid.UUID, offset, limit int) ([]domain.BeanEvent, int64, error) {
+	if _, err := s.GetBean(userID, beanID); err != nil {
+		return nil, 0, err
+	}
+	return s.eventRepo.ListForBean(beanID, offset, limit)
+}
+
+func (s *BeanServiceImpl) SplitBean(userID, beanID uuid.UUID, split BeanPortions) (*domain.CoffeeBean, []domain.CoffeeBean, error) {
+	if len(split.QuantitiesGrams) == 0 || len(split.QuantitiesGrams) > maxBeanPortions {
+		return nil, nil, ErrInvalidPortions
+	}
+	total := 0
+	for _, grams := range split.QuantitiesGrams {
+		if grams < 1 || grams > maxBeanQuantityGrams {
+			return nil, nil, ErrInvalidPortions
+		}
+		total += grams
+	}
+	if _, err := s.GetBean(userID, beanID); err != nil {
+		return nil, nil, err
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	threshold := user.Preferences.OrDefault().LowStockThresholdGrams
+	// Portions are numbered on from the ones split off before
+	_, earlier, err := s.beanRepo.Search(repository.BeanSearch{UserID: userID, ParentID: &beanID, Limit: 1})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	today := userToday(user, now)
+	var portions []domain.CoffeeBean
+	notify := false
+	bean, err := s.stockRepo.SplitBean(beanID, func(bean *domain.CoffeeBean) (*repository.BeanSplit, error) {
+		if bean.FinishedOn != nil {
+			return nil, ErrBeanFinished
+		}
+		if bean.QuantityGrams == nil {
+			return nil, ErrStockNotTracked
+		}
+		if total > *bean.QuantityGrams {
+			return nil, ErrPortionsTooLarge
+		}
+
+		quantity := *bean.QuantityGrams - total
+		bean.QuantityGrams = &quantity
+		bean.UpdatedAt = now
+		notify = markLowStock(bean, threshold, now)
+		result := &repository.BeanSplit{
+			Adjustments: []domain.BeanStockAdjustment{{
+				BeanID:        bean.ID,
+				Kind:          domain.StockChangeSplit,
+				ChangeGrams:   -total,
+				QuantityAfter: quantity,
+				Note:          fmt.Sprintf("Split into %d portions", len(split.QuantitiesGrams)),
+				CreatedAt:     now,
+			}},
+		}
+
+		for i, grams := range split.QuantitiesGrams {
+			portion := newPortion(bean, fmt.Sprintf("%s (portion %d)", bean.Name, int(earlier)+i+1), grams, now)
+			// Small portions are made on purpose, so they do not warn
+			if portion.IsLowOnStock(threshold) {
+				portion.LowStockNotifiedAt = &now
+			}
+			result.Adjustments = append(result.Adjustments, domain.BeanStockAdjustment{
+				BeanID:        portion.ID,
+				Kind:          domain.StockChangeSplit,
+				ChangeGrams:   grams,
+				QuantityAfter: grams,
+				Note:          "Split from " + bean.Name,
+				CreatedAt:     now,
+			})
+			if split.Freeze && portion.FrozenOn == nil {
+				portion.FrozenOn = &today
+				result.Events = append(result.Events, domain.BeanEvent{
+					ID:        uuid.New(),
+					BeanID:    portion.ID,
+					Kind:      domain.BeanFrozen,
+					Date:      today,
+					CreatedAt: now,
+				})
+			}
+			result.Portions = append(result.Portions, *portion)
+		}
+		portions = result.Portions
+		return result, nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrBeanNotFound
+		}
+		return nil, nil, err
+	}
+
+	if notify {
+		s.sendLowStockEmail(user, bean, threshold)
+	}
+	return bean, portions, nil
+}
+
+// newPortion makes a portion of grams of the bag. It is the same coffee, so
+// it shares the bag's details, age and freshness notifications, but not its
+// price or images.
+func newPortion(bag *domain.CoffeeBean, name string, grams int, now time.Time) *domain.CoffeeBean {
+	return &domain.CoffeeBean{
+		ID:               uuid.New(),
+		UserID:           bag.UserID,
+		ParentID:         &bag.ID,
+		Name:             name,
+		Origin:           bag.Origin,
+		Roaster:          bag.Roaster,
+		RoastDate:        bag.RoastDate,
+		RoastLevel:       bag.RoastLevel,
+		FlavorNotes:      slices.Clone(bag.FlavorNotes),
+		BeanSpecies:      bag.BeanSpecies,
+		ProcessingMethod: bag.ProcessingMethod,
+		Altitude:         bag.Altitude,
+		PurchaseDate:     bag.PurchaseDate,
+		QuantityGrams:    &grams,
+		ImageURLs:        []string{},
+		ImageKeys:        []string{},
+		IsActive:         true,
+		IsFavorite:       bag.IsFavorite,
+		RoasterID:        bag.RoasterID,
+		CoffeeID:         bag.CoffeeID,
+		OpenedOn:         bag.OpenedOn,
+		FrozenOn:         bag.FrozenOn,
+		FrozenDays:       bag.FrozenDays,
+		PeakNotifiedAt:   bag.PeakNotifiedAt,
+		StaleWarnedAt:    bag.StaleWarnedAt,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+}
+
+// thaw takes the bean out of the freezer on date, adding the stay to its
+// frozen days.
+func thaw(bean *domain.CoffeeBean, date time.Time) {
+	bean.FrozenDays += domain.DaysBetween(*bean.FrozenOn, date)
+	bean.FrozenOn = nil
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/pkg/mailer"
+	"gorm.io/gorm"
+)
+
+// BeanFreshness is how fresh a bean is for a brew method. Days the bean
+// spent frozen do not count towards its age.
+type BeanFreshness struct {
+	DaysOffRoast int
+	FrozenDays   int
+	IsFrozen     bool
+	State        domain.FreshnessState
+	// The days the bean peaks, fades and goes stale on, if it is not frozen
+	// again or, while frozen, if it is thawed today
+	PeakDate   time.Time
+	FadingDate time.Time
+	StaleDate  time.Time
+}
+
+// FreshnessRules judge the freshness of a user's beans for one brew method,
+// as of the user's current day.
+type FreshnessRules struct {
+	BrewMethod string
+	// today is the user's current date at midnight UTC, like roast dates
+	today   time.Time
+	windows map[domain.RoastLevel]config.FreshnessWindowConfig
+}
+
+// Of returns the bean's freshness, or nil when the bean has no roast date or
+// no window applies to it.
+func (r *FreshnessRules) Of(bean *domain.CoffeeBean) *BeanFreshness {
+	if bean.RoastDate == nil {
+		return nil
+	}
+	window, ok := r.windows[bean.RoastLevel]
+	if !ok {
+		return nil
+	}
+
+	// The freezer stops the clock, so the bean ages as if roasted later
+	frozenDays := bean.FrozenDaysUntil(r.today)
+	year, month, day := bean.RoastDate.Date()
+	roasted := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).AddDate(0, 0, frozenDays)
+	freshness := &BeanFreshness{
+		DaysOffRoast: domain.DaysBetween(roasted, r.today),
+		FrozenDays:   frozenDays,
+		IsFrozen:     bean.FrozenOn != nil,
+		PeakDate:     roasted.AddDate(0, 0, window.PeakFrom),
+		FadingDate:   roasted.AddDate(0, 0, window.FadingFrom),
+		StaleDate:    roasted.AddDate(0, 0, window.StaleFrom),
+	}
+	switch {
+	case freshness.DaysOffRoast < window.PeakFrom:
+		freshness.State = domain.FreshnessResting
+	case freshness.DaysOffRoast < window.FadingFrom:
+		freshness.State = domain.FreshnessPeak
+	case freshness.DaysOffRoast < window.StaleFrom:
+		freshness.State = domain.FreshnessFading
+	default:
+		freshness.State = domain.FreshnessStale
+	}
+	return freshness
+}
+
+// RoastDates returns the roast dates of beans in the state, for filtering a
+// bean search, along with the day frozen days count up to. The result is
+// never nil, so a state no bean can be in matches nothing.
+func (r *FreshnessRules) RoastDates(state domain.FreshnessState) ([]repository.RoastDateRange, time.Time) {
+	// daysAgo(n) is the roast date of beans n days off roast
+	daysAgo := func(n int) *time.Time {
+		date := r.today.AddDate(0, 0, -n)
+		return &date
+	}
+
+	ranges := []repository.RoastDateRange{}
+	for level, window := range r.windows {
+		dates := repository.RoastDateRange{RoastLevel: level}
+		switch state {
+		case domain.FreshnessResting:
+			dates.From = daysAgo(window.PeakFrom - 1)
+		case domain.FreshnessPeak:
+			dates.From, dates.To = daysAgo(window.FadingFrom-1), daysAgo(window.PeakFrom)
+		case domain.FreshnessFading:
+			dates.From, dates.To = daysAgo(window.StaleFrom-1), daysAgo(window.FadingFrom)
+		case domain.FreshnessStale:
+			dates.To = daysAgo(window.StaleFrom)
+		}
+		ranges = append(ranges, dates)
+	}
+	return ranges, r.today
+}
+
+func (s *BeanServiceImpl) FreshnessRules(userID uuid.UUID, brewMethod string) (*FreshnessRules, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.freshnessRules(user, brewMethod, time.Now()), nil
+}
+
+func (s *BeanServiceImpl) NotifyFreshness() (int, error) {
+	if len(s.beansCfg.Freshness) == 0 {
+		return 0, nil
+	}
+	longest := 0
+	for _, window := range s.beansCfg.Freshness {
+		longest = max(longest, window.StaleFrom)
+	}
+
+	now := time.Now()
+	// A day of margin for the time zones ahead of UTC. Frozen beans do not
+	// age, so they are left out.
+	beans, err := s.beanRepo.ListFreshnessCandidates(now.AddDate(0, 0, -longest-1))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for start := 0; start < len(beans); {
+		end := start + 1
+		for end < len(beans) && beans[end].UserID == beans[start].UserID {
+			end++
+		}
+		notified, err := s.notifyFreshness(beans[start:end], now)
+		if err != nil {
+			return sent, err
+		}
+		if notified {
+			sent++
+		}
+		start = end
+	}
+	return sent, nil
+}
+
+// notifyFreshness tells the owner of beans which of them just reached their
+// peak and which go stale soon, in one email. Beans are marked either way,
+// so turning the emails on later does not bring up old news.
+func (s *BeanServiceImpl) notifyFreshness(beans []domain.CoffeeBean, now time.Time) (bool, error) {
+	user, err := s.userRepo.GetByID(beans[0].UserID)
+	if err != nil {
+		// The account was deleted since the beans were listed
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	preferences := user.Preferences.OrDefault()
+	rules := s.freshnessRules(user, preferences.DefaultBrewMethod, now)
+
+	var peaked, goingStale []uuid.UUID
+	var peakedList, goingStaleList strings.Builder
+	for i := range beans {
+		bean := &beans[i]
+		freshness := rules.Of(bean)
+		if freshness == nil || freshness.IsFrozen || freshness.State == domain.FreshnessStale {
+			continue
+		}
+		if freshness.State == domain.FreshnessPeak && bean.PeakNotifiedAt == nil {
+			peaked = append(peaked, bean.ID)
+			fmt.Fprintf(&peakedList, "- %s, roasted %d days ago\n", bean.Name, freshness.DaysOffRoast)
+		}
+		if int(freshness.StaleDate.Sub(rules.today).Hours()/24) <= s.beansCfg.StaleWarningDays {
+			goingStale = append(goingStale, bean.ID)
+			fmt.Fprintf(&goingStaleList, "- %s, stale on %s\n", bean.Name, freshness.StaleDate.Format("January 2"))
+		}
+	}
+	if len(peaked) == 0 && len(goingStale) == 0 {
+		return false, nil
+	}
+
+	if len(peaked) > 0 {
+		if err := s.beanRepo.MarkPeakNotified(peaked, now); err != nil {
+			return false, err
+		}
+	}
+	if len(goingStale) > 0 {
+		if err := s.beanRepo.MarkStaleWarned(goingStale, now); err != nil {
+			return false, err
+		}
+	}
+
+	if user.EmailAddress() == "" || !preferences.Notifications.Email || !preferences.Notifications.BeanFreshness {
+		return false, nil
+	}
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n", user.DisplayName)
+	if len(peaked) > 0 {
+		fmt.Fprintf(&body, "\nThese beans are at their best now:\n%s", peakedList.String())
+	}
+	if len(goingStale) > 0 {
+		fmt.Fprintf(&body, "\nThese beans go stale soon, so brew them while you can:\n%s", goingStaleList.String())
+	}
+	fmt.Fprintf(&body, "\nYou can turn these emails off with the bean freshness notifications in your %s settings.\n", s.appCfg.Name)
+
+	// Sending is best effort, like the low-stock warning
+	_ = s.mailer.Send(mailer.Message{
+		To:      user.EmailAddress(),
+		Subject: "How fresh your coffee beans are",
+		Body:    body.String(),
+	})
+	return true, nil
+}
+
+// freshnessRules picks, for every roast level, the first configured window
+// matching it and the brew method. An empty brew method stands for the
+// user's default one.
+func (s *BeanServiceImpl) freshnessRules(user *domain.User, brewMethod string, now time.Time) *FreshnessRules {
+	if brewMethod == "" {
+		brewMethod = user.Preferences.OrDefault().DefaultBrewMethod
+	}
+	rules := &FreshnessRules{
+		BrewMethod: brewMethod,
+		today:      userToday(user, now),
+		windows:    map[domain.RoastLevel]config.FreshnessWindowConfig{},
+	}
+	levels := []domain.RoastLevel{domain.RoastLight, domain.RoastMedium, domain.RoastMediumDark, domain.RoastDark, domain.RoastUnknown}
+	for _, level := range levels {
+		for _, window := range s.beansCfg.Freshness {
+			if (window.RoastLevel == "" || window.RoastLevel == string(level)) &&
+				(len(window.BrewMethods) == 0 || slices.Contains(window.BrewMethods, brewMethod)) {
+				rules.windows[level] = window
+				break
+			}
+		}
+	}
+	return rules
+}
+
+// userToday returns the user's current date, at midnight UTC like the
+// dates of beans.
+func userToday(user *domain.User, now time.Time) time.Time {
+	// Time zones are checked when preferences are saved
+	location, err := time.LoadLocation(user.Preferences.OrDefault().Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	year, month, day := now.In(location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
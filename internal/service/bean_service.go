@@ -0,0 +1,520 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/pkg/mailer"
+	"github.com/yashkadam007/brewkar/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// Limits of bean fields
+const (
+	maxBeanNameLength     = 100
+	maxBeanTextLength     = 100
+	maxFlavorNotes        = 20
+	maxFlavorNoteLength   = 50
+	maxBeanPrice          = 99999999.99
+	maxBeanQuantityGrams  = 1000000
+	roastDateFutureMargin = 24 * time.Hour
+)
+
+var (
+	ErrBeanNotFound       = errors.New("coffee bean not found")
+	ErrInvalidBeanName    = fmt.Errorf("name must be between 1 and %d characters", maxBeanNameLength)
+	ErrInvalidBeanText    = fmt.Errorf("origin, roaster, processing method and altitude must be at most %d characters", maxBeanTextLength)
+	ErrInvalidRoastLevel  = errors.New("roast level must be one of light, medium, medium-dark, dark or unknown")
+	ErrInvalidBeanSpecies = errors.New("bean species must be one of arabica, robusta, blend, other or unknown")
+	ErrInvalidFlavorNotes = fmt.Errorf("at most %d flavor notes of at most %d characters each", maxFlavorNotes, maxFlavorNoteLength)
+	ErrInvalidQuantity    = errors.New("quantity must be a positive number of grams")
+	ErrInvalidPrice       = errors.New("price must not be negative")
+	ErrInvalidRoastDate   = errors.New("roast date cannot be in the future")
+	ErrInvalidBeanImage   = errors.New("images must be JPEG, PNG or WebP")
+	ErrBeanImageTooLarge  = errors.New("image is too large")
+	ErrTooManyBeanImages  = errors.New("too many images for one bean")
+)
+
+// BeanFields holds the bean fields to set. Nil fields are left as they are;
+// a new bean needs at least a name.
+type BeanFields struct {
+	Name             *string
+	Origin           *string
+	Roaster          *string
+	RoastDate        *time.Time
+	RoastLevel       *domain.RoastLevel
+	FlavorNotes      []string
+	BeanSpecies      *domain.BeanSpecies
+	ProcessingMethod *string
+	Altitude         *string
+	PurchaseDate     *time.Time
+	Price            *float64
+	QuantityGrams    *int
+	IsFavorite       *bool
+	IsActive         *bool
+	// RoasterID and CoffeeID link the bean to the catalog, or unlink it when
+	// uuid.Nil. Text fields left empty are filled in from the catalog, and
+	// a coffee links its roaster too.
+	RoasterID *uuid.UUID
+	CoffeeID  *uuid.UUID
+}
+
+type BeanService interface {
+	// ListBeans returns one page of the user's beans matching search, and
+	// the total number of matches.
+	ListBeans(userID uuid.UUID, search repository.BeanSearch) ([]domain.CoffeeBean, int64, error)
+	CreateBean(userID uuid.UUID, fields BeanFields) (*domain.CoffeeBean, error)
+	GetBean(userID, beanID uuid.UUID) (*domain.CoffeeBean, error)
+	UpdateBean(userID, beanID uuid.UUID, fields BeanFields) (*domain.CoffeeBean, error)
+	// DeleteBean deactivates the bean. It stays in brew logs and can be
+	// activated again.
+	DeleteBean(userID, beanID uuid.UUID) error
+	// UploadImages adds images of the packaging or the beans themselves.
+	UploadImages(userID, beanID uuid.UUID, images []io.Reader) (*domain.CoffeeBean, error)
+
+	// GetStock returns the bean's stock with estimates of how long it lasts.
+	GetStock(userID, beanID uuid.UUID) (*BeanStock, error)
+	// AdjustStock changes the bean's stock by hand, e.g. after weighing the
+	// bag, and records the change in its audit trail.
+	AdjustStock(userID, beanID uuid.UUID, change StockChange) (*domain.CoffeeBean, *domain.BeanStockAdjustment, error)
+	// ListStockAdjustments returns one page of the bean's audit trail,
+	// newest first, and its total length.
+	ListStockAdjustments(userID, beanID uuid.UUID, offset, limit int) ([]domain.BeanStockAdjustment, int64, error)
+	// RecordBrewUsage sets how many grams of the bean a brew log uses,
+	// deducting the difference from the stock or crediting it back. Brew
+	// logs call it when they are created or edited, and with 0 when they
+	// are deleted or stop using the bean. It commits on its own; a brew log
+	// saved in a transaction changes the stock with the repository's
+	// UpdateStockTx in that transaction instead.
+	RecordBrewUsage(userID, beanID, brewLogID uuid.UUID, doseGrams float64) (*domain.CoffeeBean, error)
+
+	// FreshnessRules returns the rules judging the freshness of the user's
+	// beans for the brew method, or for their default one when it is empty.
+	FreshnessRules(userID uuid.UUID, brewMethod string) (*FreshnessRules, error)
+	// NotifyFreshness emails the users whose beans reached their peak or go
+	// stale soon, and returns the number of emails sent. It runs daily.
+	NotifyFreshness() (int, error)
+
+	// AddEvent adds an event to the bean's timeline and updates the bean,
+	// e.g. taking it out of the freezer. Events are added in date order.
+	AddEvent(userID, beanID uuid.UUID, event NewBeanEvent) (*domain.CoffeeBean, *domain.BeanEvent, error)
+	// ListEvents returns one page of the bean's timeline, oldest first, and
+	// its total length.
+	ListEvents(userID, beanID uuid.UUID, offset, limit int) ([]domain.BeanEvent, int64, error)
+	// SplitBean moves coffee from the bag into new portions that keep their
+	// own stock, and returns the bag and the portions.
+	SplitBean(userID, beanID uuid.UUID, portions BeanPortions) (*domain.CoffeeBean, []domain.CoffeeBean, error)
+}
+
+type BeanServiceImpl struct {
+	beanRepo    repository.BeanRepository
+	stockRepo   repository.BeanStockRepository
+	eventRepo   repository.BeanEventRepository
+	catalogRepo repository.CatalogRepository
+	userRepo    repository.UserRepository
+	storage     storage.Storage
+	mailer      mailer.Mailer
+	appCfg      config.AppConfig
+	beansCfg    config.BeansConfig
+}
+
+func NewBeanService(
+	beanRepo repository.BeanRepository,
+	stockRepo repository.BeanStockRepository,
+	eventRepo repository.BeanEventRepository,
+	catalogRepo repository.CatalogRepository,
+	userRepo repository.UserRepository,
+	store storage.Storage,
+	m mailer.Mailer,
+	appCfg config.AppConfig,
+	beansCfg config.BeansConfig,
+) BeanService {
+	return &BeanServiceImpl{
+		beanRepo:    beanRepo,
+		stockRepo:   stockRepo,
+		eventRepo:   eventRepo,
+		catalogRepo: catalogRepo,
+		userRepo:    userRepo,
+		storage:     store,
+		mailer:      m,
+		appCfg:      appCfg,
+		beansCfg:    beansCfg,
+	}
+}
+
+func (s *BeanServiceImpl) ListBeans(userID uuid.UUID, search repository.BeanSearch) ([]domain.CoffeeBean, int64, error) {
+	search.UserID = userID
+	return s.beanRepo.Search(search)
+}
+
+func (s *BeanServiceImpl) CreateBean(userID uuid.UUID, fields BeanFields) (*domain.CoffeeBean, error) {
+	// A coffee from the catalog can name the bean
+	if fields.Name == nil && fields.CoffeeID == nil {
+		return nil, ErrInvalidBeanName
+	}
+	link, err := s.beanCatalogLink(nil, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	bean := &domain.CoffeeBean{
+		UserID:      userID,
+		RoastLevel:  domain.RoastUnknown,
+		BeanSpecies: domain.SpeciesUnknown,
+		FlavorNotes: []string{},
+		ImageURLs:   []string{},
+		ImageKeys:   []string{},
+		IsActive:    true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := applyBeanFields(bean, fields); err != nil {
+		return nil, err
+	}
+	link.apply(bean)
+	if bean.Name == "" {
+		return nil, ErrInvalidBeanName
+	}
+	if err := s.beanRepo.Create(bean); err != nil {
+		return nil, err
+	}
+	return bean, nil
+}
+
+func (s *BeanServiceImpl) GetBean(userID, beanID uuid.UUID) (*domain.CoffeeBean, error) {
+	bean, err := s.beanRepo.GetByID(beanID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBeanNotFound
+		}
+		return nil, err
+	}
+	// Other users' beans do not exist as far as the caller can tell
+	if bean.UserID != userID {
+		return nil, ErrBeanNotFound
+	}
+	return bean, nil
+}
+
+func (s *BeanServiceImpl) UpdateBean(userID, beanID uuid.UUID, fields BeanFields) (*domain.CoffeeBean, error) {
+	current, err := s.GetBean(userID, beanID)
+	if err != nil {
+		return nil, err
+	}
+	link, err := s.beanCatalogLink(current, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	// A new quantity goes through the stock so it shows in the audit trail
+	bean, _, err := s.updateStock(userID, beanID, func(bean *domain.CoffeeBean) (*domain.BeanStockAdjustment, error) {
+		before := bean.QuantityGrams
+		roastDate, roastLevel := bean.RoastDate, bean.RoastLevel
+		if err := applyBeanFields(bean, fields); err != nil {
+			return nil, err
+		}
+		link.apply(bean)
+		bean.UpdatedAt = time.Now()
+		// A bean roasted on another day ripens on another day
+		if !sameDate(roastDate, bean.RoastDate) || roastLevel != bean.RoastLevel {
+			bean.PeakNotifiedAt = nil
+			bean.StaleWarnedAt = nil
+		}
+		if fields.QuantityGrams == nil || (before != nil && *before == *bean.QuantityGrams) {
+			return nil, nil
+		}
+		return &domain.BeanStockAdjustment{
+			Kind:          domain.StockChangeEdit,
+			ChangeGrams:   *bean.QuantityGrams - stockGrams(before),
+			QuantityAfter: *bean.QuantityGrams,
+		}, nil
+	})
+	return bean, err
+}
+
+func (s *BeanServiceImpl) DeleteBean(userID, beanID uuid.UUID) error {
+	bean, err := s.GetBean(userID, beanID)
+	if err != nil {
+		return err
+	}
+	if !bean.IsActive {
+		return nil
+	}
+	return s.beanRepo.Deactivate(bean.ID, time.Now())
+}
+
+func (s *BeanServiceImpl) UploadImages(userID, beanID uuid.UUID, images []io.Reader) (*domain.CoffeeBean, error) {
+	bean, err := s.GetBean(userID, beanID)
+	if err != nil {
+		return nil, err
+	}
+	if len(bean.ImageURLs)+len(images) > s.beansCfg.MaxImages {
+		return nil, ErrTooManyBeanImages
+	}
+
+	// Check every image before storing any, so a bad one stores nothing
+	type upload struct {
+		data        []byte
+		contentType string
+		ext         string
+	}
+	uploads := make([]upload, 0, len(images))
+	for _, image := range images {
+		// Read one byte more than allowed to tell whether the image is too large
+		data, err := io.ReadAll(io.LimitReader(image, int64(s.beansCfg.ImageMaxSize)+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > s.beansCfg.ImageMaxSize {
+			return nil, ErrBeanImageTooLarge
+		}
+		contentType := http.DetectContentType(data)
+		ext, ok := imageTypes[contentType]
+		if !ok {
+			return nil, ErrInvalidBeanImage
+		}
+		uploads = append(uploads, upload{data: data, contentType: contentType, ext: ext})
+	}
+
+	var stored, urls []string
+	for _, u := range uploads {
+		suffix := make([]byte, 8)
+		if _, err := rand.Read(suffix); err != nil {
+			s.deleteFiles(stored)
+			return nil, err
+		}
+		key := "beans/" + userID.String() + "/" + bean.ID.String() + "/" + hex.EncodeToString(suffix) + u.ext
+		url, err := s.storage.Put(key, bytes.NewReader(u.data), u.contentType)
+		if err != nil {
+			s.deleteFiles(stored)
+			return nil, err
+		}
+		stored = append(stored, key)
+		urls = append(urls, url)
+	}
+
+	// The bean is locked while the images are added, so neither a stock
+	// change nor another upload made meanwhile is lost
+	bean, err = s.stockRepo.UpdateStock(bean.ID, func(bean *domain.CoffeeBean) (*domain.BeanStockAdjustment, error) {
+		if len(bean.ImageURLs)+len(urls) > s.beansCfg.MaxImages {
+			return nil, ErrTooManyBeanImages
+		}
+		bean.ImageURLs = append(bean.ImageURLs, urls...)
+		bean.ImageKeys = append(bean.ImageKeys, stored...)
+		bean.UpdatedAt = time.Now()
+		return nil, nil
+	})
+	if err != nil {
+		s.deleteFiles(stored)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBeanNotFound
+		}
+		return nil, err
+	}
+	return bean, nil
+}
+
+// deleteFiles removes files stored for a change that failed. A leftover file
+// is harmless, so failures are ignored.
+func (s *BeanServiceImpl) deleteFiles(keys []string) {
+	for _, key := range keys {
+		_ = s.storage.Delete(key)
+	}
+}
+
+// applyBeanFields validates fields and sets them on bean.
+func applyBeanFields(bean *domain.CoffeeBean, fields BeanFields) error {
+	if fields.Name != nil {
+		name := strings.TrimSpace(*fields.Name)
+		if name == "" || utf8.RuneCountInString(name) > maxBeanNameLength {
+			return ErrInvalidBeanName
+		}
+		bean.Name = name
+	}
+	for _, text := range []struct {
+		value *string
+		field *string
+	}{
+		{fields.Origin, &bean.Origin},
+		{fields.Roaster, &bean.Roaster},
+		{fields.ProcessingMethod, &bean.ProcessingMethod},
+		{fields.Altitude, &bean.Altitude},
+	} {
+		if text.value == nil {
+			continue
+		}
+		value := strings.TrimSpace(*text.value)
+		if utf8.RuneCountInString(value) > maxBeanTextLength {
+			return ErrInvalidBeanText
+		}
+		*text.field = value
+	}
+	if fields.RoastLevel != nil {
+		if !fields.RoastLevel.Valid() {
+			return ErrInvalidRoastLevel
+		}
+		bean.RoastLevel = *fields.RoastLevel
+	}
+	if fields.BeanSpecies != nil {
+		if !fields.BeanSpecies.Valid() {
+			return ErrInvalidBeanSpecies
+		}
+		bean.BeanSpecies = *fields.BeanSpecies
+	}
+	if fields.FlavorNotes != nil {
+		notes, err := normalizeFlavorNotes(fields.FlavorNotes)
+		if err != nil {
+			return err
+		}
+		bean.FlavorNotes = notes
+	}
+	if fields.RoastDate != nil {
+		// Allow a day for time zones ahead of the server's
+		if fields.RoastDate.After(time.Now().Add(roastDateFutureMargin)) {
+			return ErrInvalidRoastDate
+		}
+		bean.RoastDate = fields.RoastDate
+	}
+	if fields.PurchaseDate != nil {
+		bean.PurchaseDate = fields.PurchaseDate
+	}
+	if fields.Price != nil {
+		if *fields.Price < 0 || *fields.Price > maxBeanPrice {
+			return ErrInvalidPrice
+		}
+		bean.Price = fields.Price
+	}
+	if fields.QuantityGrams != nil {
+		if *fields.QuantityGrams <= 0 || *fields.QuantityGrams > maxBeanQuantityGrams {
+			return ErrInvalidQuantity
+		}
+		bean.QuantityGrams = fields.QuantityGrams
+	}
+	if fields.IsFavorite != nil {
+		bean.IsFavorite = *fields.IsFavorite
+	}
+	if fields.IsActive != nil {
+		bean.IsActive = *fields.IsActive
+	}
+	return nil
+}
+
+// beanCatalogLink is what the fields of a bean link it to in the catalog.
+// Nil entries unlink the bean.
+type beanCatalogLink struct {
+	setRoaster bool
+	roaster    *domain.Roaster
+	setCoffee  bool
+	coffee     *domain.Coffee
+}
+
+// beanCatalogLink looks up the catalog entries fields link a bean to,
+// following merges. Current is the bean being updated, if any.
+func (s *BeanServiceImpl) beanCatalogLink(current *domain.CoffeeBean, fields BeanFields) (*beanCatalogLink, error) {
+	link := &beanCatalogLink{}
+	if fields.CoffeeID != nil {
+		link.setCoffee = true
+		if *fields.CoffeeID != uuid.Nil {
+			coffee, err := resolveCoffee(s.catalogRepo, *fields.CoffeeID)
+			if err != nil {
+				return nil, err
+			}
+			link.coffee = coffee
+		}
+	}
+
+	roasterID := fields.RoasterID
+	if roasterID == nil && link.coffee != nil && link.coffee.RoasterID != nil {
+		roasterID = link.coffee.RoasterID
+	}
+	if roasterID != nil {
+		link.setRoaster = true
+		if *roasterID != uuid.Nil {
+			roaster, err := resolveRoaster(s.catalogRepo, *roasterID)
+			if err != nil {
+				return nil, err
+			}
+			link.roaster = roaster
+		}
+	}
+
+	if link.coffee != nil && link.coffee.RoasterID != nil &&
+		(link.roaster == nil || link.roaster.ID != *link.coffee.RoasterID) {
+		return nil, ErrCoffeeRoasterMismatch
+	}
+	// A new roaster unlinks the bean's coffee of another roaster
+	if link.setRoaster && !link.setCoffee && current != nil && current.CoffeeID != nil {
+		coffee, err := resolveCoffee(s.catalogRepo, *current.CoffeeID)
+		if err != nil && !errors.Is(err, ErrCoffeeNotFound) {
+			return nil, err
+		}
+		if err != nil || (coffee.RoasterID != nil && (link.roaster == nil || link.roaster.ID != *coffee.RoasterID)) {
+			link.setCoffee = true
+		}
+	}
+	return link, nil
+}
+
+// apply links the bean and fills in its empty text fields from the catalog.
+// Text the user wrote stays, so it can differ from the catalog.
+func (l *beanCatalogLink) apply(bean *domain.CoffeeBean) {
+	if l.setRoaster {
+		bean.RoasterID = nil
+		if l.roaster != nil {
+			bean.RoasterID = &l.roaster.ID
+			fillEmpty(&bean.Roaster, l.roaster.Name)
+		}
+	}
+	if l.setCoffee {
+		bean.CoffeeID = nil
+		if l.coffee != nil {
+			bean.CoffeeID = &l.coffee.ID
+			fillEmpty(&bean.Name, l.coffee.Name)
+			fillEmpty(&bean.Origin, l.coffee.Origin())
+			fillEmpty(&bean.ProcessingMethod, l.coffee.Process)
+		}
+	}
+}
+
+// normalizeFlavorNotes trims the notes and drops empty and repeated ones,
+// ignoring case.
+func normalizeFlavorNotes(notes []string) ([]string, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, note := range notes {
+		note = strings.TrimSpace(note)
+		if note == "" || seen[strings.ToLower(note)] {
+			continue
+		}
+		if utf8.RuneCountInString(note) > maxFlavorNoteLength {
+			return nil, ErrInvalidFlavorNotes
+		}
+		seen[strings.ToLower(note)] = true
+		normalized = append(normalized, note)
+	}
+	if len(normalized) > maxFlavorNotes {
+		return nil, ErrInvalidFlavorNotes
+	}
+	return normalized, nil
+}
+
+// sameDate reports whether a and b are the same date, or both unknown.
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Format(time.DateOnly) == b.Format(time.DateOnly)
+}
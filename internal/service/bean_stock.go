@@ -0,0 +1,274 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/pkg/mailer"
+	"gorm.io/gorm"
+)
+
+const (
+	// stockUsageDays is how far back brews count towards the estimates
+	stockUsageDays     = 14
+	maxStockNoteLength = 200
+	gramsPerOunce      = 28.349523125
+)
+
+var (
+	ErrInvalidStockChange = errors.New("give either changeGrams or quantityGrams")
+	ErrStockNoteTooLong   = fmt.Errorf("note must be at most %d characters", maxStockNoteLength)
+	ErrStockNotTracked    = errors.New("the bean's quantity is unknown, set quantityGrams first")
+	ErrStockBelowZero     = errors.New("stock cannot go below zero")
+	ErrInvalidDose        = errors.New("dose must not be negative")
+)
+
+// StockChange is a manual stock adjustment: either a change in grams or the
+// new quantity, e.g. after weighing the bag.
+type StockChange struct {
+	ChangeGrams   *int
+	QuantityGrams *int
+	Note          string
+}
+
+// BeanStock is a bean's stock with estimates based on the brews of the last
+// stockUsageDays days. Estimates are nil when the quantity is unknown or the
+// bean was not brewed recently.
+type BeanStock struct {
+	QuantityGrams          *int
+	LowStockThresholdGrams int
+	IsLow                  bool
+	RecentBrews            int
+	RecentGrams            int
+	CupsRemaining          *int
+	DaysUntilEmpty         *int
+}
+
+func (s *BeanServiceImpl) GetStock(userID, beanID uuid.UUID) (*BeanStock, error) {
+	bean, err := s.GetBean(userID, beanID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.estimateStock(bean, user.Preferences.OrDefault().LowStockThresholdGrams)
+}
+
+func (s *BeanServiceImpl) AdjustStock(userID, beanID uuid.UUID, change StockChange) (*domain.CoffeeBean, *domain.BeanStockAdjustment, error) {
+	if (change.ChangeGrams == nil) == (change.QuantityGrams == nil) ||
+		(change.ChangeGrams != nil && *change.ChangeGrams == 0) {
+		return nil, nil, ErrInvalidStockChange
+	}
+	note := strings.TrimSpace(change.Note)
+	if utf8.RuneCountInString(note) > maxStockNoteLength {
+		return nil, nil, ErrStockNoteTooLong
+	}
+	if _, err := s.GetBean(userID, beanID); err != nil {
+		return nil, nil, err
+	}
+
+	return s.updateStock(userID, beanID, func(bean *domain.CoffeeBean) (*domain.BeanStockAdjustment, error) {
+		quantity := 0
+		if change.QuantityGrams != nil {
+			quantity = *change.QuantityGrams
+		} else {
+			if bean.QuantityGrams == nil {
+				return nil, ErrStockNotTracked
+			}
+			quantity = *bean.QuantityGrams + *change.ChangeGrams
+		}
+		if quantity < 0 {
+			return nil, ErrStockBelowZero
+		}
+		if quantity > maxBeanQuantityGrams {
+			return nil, ErrInvalidQuantity
+		}
+
+		adjustment := &domain.BeanStockAdjustment{
+			Kind:          domain.StockChangeManual,
+			ChangeGrams:   quantity - stockGrams(bean.QuantityGrams),
+			QuantityAfter: quantity,
+			Note:          note,
+		}
+		bean.QuantityGrams = &quantity
+		return adjustment, nil
+	})
+}
+
+func (s *BeanServiceImpl) ListStockAdjustments(userID, beanID uuid.UUID, offset, limit int) ([]domain.BeanStockAdjustment, int64, error) {
+	if _, err := s.GetBean(userID, beanID); err != nil {
+		return nil, 0, err
+	}
+	return s.stockRepo.ListForBean(beanID, offset, limit)
+}
+
+func (s *BeanServiceImpl) RecordBrewUsage(userID, beanID, brewLogID uuid.UUID, doseGrams float64) (*domain.CoffeeBean, error) {
+	if doseGrams < 0 {
+		return nil, ErrInvalidDose
+	}
+	bean, err := s.GetBean(userID, beanID)
+	if err != nil {
+		return nil, err
+	}
+	// Beans without a known quantity are not tracked
+	if bean.QuantityGrams == nil {
+		return bean, nil
+	}
+
+	bean, _, err = s.updateStock(userID, beanID, func(bean *domain.CoffeeBean) (*domain.BeanStockAdjustment, error) {
+		if bean.QuantityGrams == nil {
+			return nil, nil
+		}
+		// The bean is locked, so this sees every earlier change of the log
+		recorded, err := s.stockRepo.BrewLogGrams(bean.ID, brewLogID)
+		if err != nil {
+			return nil, err
+		}
+		change := -int(math.Round(doseGrams)) - recorded
+		// A bag cannot hold less than nothing. Recording only what was
+		// deducted keeps a later credit from adding coffee that never was.
+		if *bean.QuantityGrams+change < 0 {
+			change = -*bean.QuantityGrams
+		}
+		if change == 0 {
+			return nil, nil
+		}
+
+		quantity := *bean.QuantityGrams + change
+		bean.QuantityGrams = &quantity
+		return &domain.BeanStockAdjustment{
+			Kind:          domain.StockChangeBrew,
+			ChangeGrams:   change,
+			QuantityAfter: quantity,
+			BrewLogID:     &brewLogID,
+		}, nil
+	})
+	return bean, err
+}
+
+// updateStock changes the bean through the stock repository, recording the
+// adjustment update returns, and tells the owner when the change leaves the
+// bean running low.
+func (s *BeanServiceImpl) updateStock(userID, beanID uuid.UUID, update func(bean *domain.CoffeeBean) (*domain.BeanStockAdjustment, error)) (*domain.CoffeeBean, *domain.BeanStockAdjustment, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	threshold := user.Preferences.OrDefault().LowStockThresholdGrams
+
+	now := time.Now()
+	var adjustment *domain.BeanStockAdjustment
+	notify := false
+	bean, err := s.stockRepo.UpdateStock(beanID, func(bean *domain.CoffeeBean) (*domain.BeanStockAdjustment, error) {
+		var err error
+		adjustment, err = update(bean)
+		if err != nil {
+			return nil, err
+		}
+		if adjustment != nil {
+			adjustment.BeanID = bean.ID
+			adjustment.CreatedAt = now
+			bean.UpdatedAt = now
+		}
+		notify = markLowStock(bean, threshold, now)
+		return adjustment, nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrBeanNotFound
+		}
+		return nil, nil, err
+	}
+
+	if notify {
+		s.sendLowStockEmail(user, bean, threshold)
+	}
+	return bean, adjustment, nil
+}
+
+// markLowStock keeps the bean's LowStockNotifiedAt in step with its stock.
+// It reports whether the bean just started running low, so the owner is
+// told once per bag rather than after every brew.
+func markLowStock(bean *domain.CoffeeBean, threshold int, now time.Time) bool {
+	if !bean.IsLowOnStock(threshold) {
+		bean.LowStockNotifiedAt = nil
+		return false
+	}
+	if bean.LowStockNotifiedAt != nil || !bean.IsActive {
+		return false
+	}
+	bean.LowStockNotifiedAt = &now
+	return true
+}
+
+func (s *BeanServiceImpl) estimateStock(bean *domain.CoffeeBean, threshold int) (*BeanStock, error) {
+	stock := &BeanStock{
+		QuantityGrams:          bean.QuantityGrams,
+		LowStockThresholdGrams: threshold,
+		IsLow:                  bean.IsLowOnStock(threshold),
+	}
+
+	usage, err := s.stockRepo.RecentBrewUsage(bean.ID, time.Now().AddDate(0, 0, -stockUsageDays))
+	if err != nil {
+		return nil, err
+	}
+	stock.RecentBrews = usage.Brews
+	stock.RecentGrams = usage.Grams
+	if bean.QuantityGrams == nil || usage.Brews == 0 || usage.Grams == 0 {
+		return stock, nil
+	}
+
+	quantity := float64(*bean.QuantityGrams)
+	cups := int(quantity / (float64(usage.Grams) / float64(usage.Brews)))
+	days := int(quantity / (float64(usage.Grams) / stockUsageDays))
+	stock.CupsRemaining = &cups
+	stock.DaysUntilEmpty = &days
+	return stock, nil
+}
+
+// sendLowStockEmail tells the owner the bean is running low, if they want
+// to hear about it. Sending is best effort.
+func (s *BeanServiceImpl) sendLowStockEmail(user *domain.User, bean *domain.CoffeeBean, threshold int) {
+	preferences := user.Preferences.OrDefault()
+	if user.EmailAddress() == "" || !preferences.Notifications.Email || !preferences.Notifications.LowStock {
+		return
+	}
+
+	pace := ""
+	if stock, err := s.estimateStock(bean, threshold); err == nil && stock.CupsRemaining != nil {
+		pace = fmt.Sprintf(" At your recent pace that is about %d cups, enough for %d days.", *stock.CupsRemaining, *stock.DaysUntilEmpty)
+	}
+
+	_ = s.mailer.Send(mailer.Message{
+		To:      user.EmailAddress(),
+		Subject: fmt.Sprintf("You are running low on %s", bean.Name),
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nYou have %s of %s left.%s\n\nYou can change when %s warns you with the low-stock threshold in your settings.\n",
+			user.DisplayName, formatWeight(*bean.QuantityGrams, preferences.Units.Weight), bean.Name, pace, s.appCfg.Name,
+		),
+	})
+}
+
+// stockGrams is the quantity of a bean, counting an unknown one as empty.
+func stockGrams(quantity *int) int {
+	if quantity == nil {
+		return 0
+	}
+	return *quantity
+}
+
+// formatWeight writes grams in the user's weight unit.
+func formatWeight(grams int, unit domain.WeightUnit) string {
+	if unit == domain.WeightOunces {
+		return fmt.Sprintf("%.1f oz", float64(grams)/gramsPerOunce)
+	}
+	return fmt.Sprintf("%d g", grams)
+}
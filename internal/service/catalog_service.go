@@ -0,0 +1,412 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Limits of catalog fields
+const (
+	maxCatalogNameLength    = 100
+	maxCatalogTextLength    = 100
+	maxCatalogWebsiteLength = 255
+	maxCatalogSuggestions   = 5
+)
+
+var (
+	ErrRoasterNotFound       = errors.New("roaster not found")
+	ErrCoffeeNotFound        = errors.New("coffee not found")
+	ErrInvalidCatalogName    = fmt.Errorf("name must be between 1 and %d characters", maxCatalogNameLength)
+	ErrInvalidCatalogText    = fmt.Errorf("country, region, farm, variety and process must be at most %d characters", maxCatalogTextLength)
+	ErrInvalidWebsite        = fmt.Errorf("website must be an http or https URL of at most %d characters", maxCatalogWebsiteLength)
+	ErrMergeIntoSelf         = errors.New("an entry cannot be merged into itself")
+	ErrAlreadyMerged         = errors.New("the entry was already merged into another one")
+	ErrCoffeeRoasterMismatch = errors.New("the coffee is from another roaster")
+	ErrCoffeeNameTaken       = errors.New("the roaster already has a coffee of this name")
+)
+
+// RoasterFields are the details of a new roaster.
+type RoasterFields struct {
+	Name    string
+	Country string
+	Website string
+}
+
+// CoffeeFields are the details of a new coffee. RoasterID is optional.
+type CoffeeFields struct {
+	RoasterID     *uuid.UUID
+	Name          string
+	OriginCountry string
+	Region        string
+	Farm          string
+	Variety       string
+	Process       string
+}
+
+// CatalogSuggestions are the catalog entries most like what a user typed
+// for a bean, best first.
+type CatalogSuggestions struct {
+	Roasters []domain.Roaster
+	Coffees  []domain.Coffee
+}
+
+type CatalogService interface {
+	// SearchRoasters returns one page of the roasters matching search, and
+	// the total number of matches. Similar names match too.
+	SearchRoasters(search repository.CatalogSearch) ([]domain.Roaster, int64, error)
+	// GetRoaster returns the roaster, or the one it was merged into.
+	GetRoaster(roasterID uuid.UUID) (*domain.Roaster, error)
+	// CreateRoaster adds a roaster to the catalog, unless one of the same
+	// name already is in it. It returns the roaster and whether it is new.
+	CreateRoaster(userID uuid.UUID, fields RoasterFields) (*domain.Roaster, bool, error)
+
+	SearchCoffees(search repository.CatalogSearch) ([]domain.Coffee, int64, error)
+	GetCoffee(coffeeID uuid.UUID) (*domain.Coffee, error)
+	// CreateCoffee is CreateRoaster for coffees. Coffees of the same name
+	// from different roasters are different coffees.
+	CreateCoffee(userID uuid.UUID, fields CoffeeFields) (*domain.Coffee, bool, error)
+
+	// Suggest returns the roasters and coffees a bean with the roaster and
+	// name as typed by the user probably is, to link it to them. Coffees
+	// of a roaster with exactly the typed name come first.
+	Suggest(roaster, name string) (*CatalogSuggestions, error)
+
+	// MergeRoasters merges the duplicate roaster into target, moving its
+	// coffees and beans over, and returns target. Details target lacks are
+	// taken from the duplicate.
+	MergeRoasters(duplicateID, targetID uuid.UUID) (*domain.Roaster, error)
+	// MergeCoffees is MergeRoasters for coffees.
+	MergeCoffees(duplicateID, targetID uuid.UUID) (*domain.Coffee, error)
+}
+
+type CatalogServiceImpl struct {
+	catalogRepo repository.CatalogRepository
+}
+
+func NewCatalogService(catalogRepo repository.CatalogRepository) CatalogService {
+	return &CatalogServiceImpl{
+		catalogRepo: catalogRepo,
+	}
+}
+
+func (s *CatalogServiceImpl) SearchRoasters(search repository.CatalogSearch) ([]domain.Roaster, int64, error) {
+	search.Query = normalizeCatalogName(search.Query)
+	return s.catalogRepo.SearchRoasters(search)
+}
+
+func (s *CatalogServiceImpl) GetRoaster(roasterID uuid.UUID) (*domain.Roaster, error) {
+	return resolveRoaster(s.catalogRepo, roasterID)
+}
+
+func (s *CatalogServiceImpl) CreateRoaster(userID uuid.UUID, fields RoasterFields) (*domain.Roaster, bool, error) {
+	name, err := catalogName(fields.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	country, err := catalogText(fields.Country)
+	if err != nil {
+		return nil, false, err
+	}
+	website, err := catalogWebsite(fields.Website)
+	if err != nil {
+		return nil, false, err
+	}
+
+	normalized := normalizeCatalogName(name)
+	existing, err := s.catalogRepo.FindRoaster(normalized)
+	if err == nil {
+		return existing, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	now := time.Now()
+	roaster := &domain.Roaster{
+		Name:           name,
+		NormalizedName: normalized,
+		Country:        country,
+		Website:        website,
+		CreatedByID:    &userID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.catalogRepo.CreateRoaster(roaster); err != nil {
+		// Someone added the roaster since
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			if existing, err = s.catalogRepo.FindRoaster(normalized); err != nil {
+				return nil, false, err
+			}
+			return existing, false, nil
+		}
+		return nil, false, err
+	}
+	return roaster, true, nil
+}
+
+func (s *CatalogServiceImpl) SearchCoffees(search repository.CatalogSearch) ([]domain.Coffee, int64, error) {
+	search.Query = normalizeCatalogName(search.Query)
+	return s.catalogRepo.SearchCoffees(search)
+}
+
+func (s *CatalogServiceImpl) GetCoffee(coffeeID uuid.UUID) (*domain.Coffee, error) {
+	return resolveCoffee(s.catalogRepo, coffeeID)
+}
+
+func (s *CatalogServiceImpl) CreateCoffee(userID uuid.UUID, fields CoffeeFields) (*domain.Coffee, bool, error) {
+	name, err := catalogName(fields.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	coffee := &domain.Coffee{
+		Name:           name,
+		NormalizedName: normalizeCatalogName(name),
+		CreatedByID:    &userID,
+	}
+	for _, text := range []struct {
+		value string
+		field *string
+	}{
+		{fields.OriginCountry, &coffee.OriginCountry},
+		{fields.Region, &coffee.Region},
+		{fields.Farm, &coffee.Farm},
+		{fields.Variety, &coffee.Variety},
+		{fields.Process, &coffee.Process},
+	} {
+		if *text.field, err = catalogText(text.value); err != nil {
+			return nil, false, err
+		}
+	}
+	if fields.RoasterID != nil {
+		roaster, err := resolveRoaster(s.catalogRepo, *fields.RoasterID)
+		if err != nil {
+			return nil, false, err
+		}
+		coffee.RoasterID = &roaster.ID
+	}
+
+	existing, err := s.catalogRepo.FindCoffee(coffee.RoasterID, coffee.NormalizedName)
+	if err == nil {
+		return existing, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	coffee.CreatedAt = time.Now()
+	coffee.UpdatedAt = coffee.CreatedAt
+	if err := s.catalogRepo.CreateCoffee(coffee); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			if existing, err = s.catalogRepo.FindCoffee(coffee.RoasterID, coffee.NormalizedName); err != nil {
+				return nil, false, err
+			}
+			return existing, false, nil
+		}
+		return nil, false, err
+	}
+	return coffee, true, nil
+}
+
+func (s *CatalogServiceImpl) Suggest(roaster, name string) (*CatalogSuggestions, error) {
+	suggestions := &CatalogSuggestions{
+		Roasters: []domain.Roaster{},
+		Coffees:  []domain.Coffee{},
+	}
+
+	roaster = normalizeCatalogName(roaster)
+	if roaster != "" {
+		roasters, _, err := s.catalogRepo.SearchRoasters(repository.CatalogSearch{Query: roaster, Limit: maxCatalogSuggestions})
+		if err != nil {
+			return nil, err
+		}
+		suggestions.Roasters = roasters
+	}
+
+	name = normalizeCatalogName(name)
+	if name == "" {
+		return suggestions, nil
+	}
+	// The user's roaster is known, so its coffees are the likeliest
+	if len(suggestions.Roasters) > 0 && suggestions.Roasters[0].NormalizedName == roaster {
+		coffees, _, err := s.catalogRepo.SearchCoffees(repository.CatalogSearch{
+			Query:     name,
+			RoasterID: &suggestions.Roasters[0].ID,
+			Limit:     maxCatalogSuggestions,
+		})
+		if err != nil {
+			return nil, err
+		}
+		suggestions.Coffees = coffees
+	}
+	if len(suggestions.Coffees) < maxCatalogSuggestions {
+		coffees, _, err := s.catalogRepo.SearchCoffees(repository.CatalogSearch{Query: name, Limit: maxCatalogSuggestions})
+		if err != nil {
+			return nil, err
+		}
+		for _, coffee := range coffees {
+			if len(suggestions.Coffees) == maxCatalogSuggestions {
+				break
+			}
+			duplicate := slices.ContainsFunc(suggestions.Coffees, func(c domain.Coffee) bool { return c.ID == coffee.ID })
+			if !duplicate {
+				suggestions.Coffees = append(suggestions.Coffees, coffee)
+			}
+		}
+	}
+	return suggestions, nil
+}
+
+func (s *CatalogServiceImpl) MergeRoasters(duplicateID, targetID uuid.UUID) (*domain.Roaster, error) {
+	duplicate, err := s.catalogRepo.GetRoaster(duplicateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoasterNotFound
+		}
+		return nil, err
+	}
+	if duplicate.MergedIntoID != nil {
+		return nil, ErrAlreadyMerged
+	}
+	target, err := resolveRoaster(s.catalogRepo, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == duplicate.ID {
+		return nil, ErrMergeIntoSelf
+	}
+
+	now := time.Now()
+	fillEmpty(&target.Country, duplicate.Country)
+	fillEmpty(&target.Website, duplicate.Website)
+	target.UpdatedAt = now
+	if err := s.catalogRepo.MergeRoaster(duplicate, target, now); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+func (s *CatalogServiceImpl) MergeCoffees(duplicateID, targetID uuid.UUID) (*domain.Coffee, error) {
+	duplicate, err := s.catalogRepo.GetCoffee(duplicateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCoffeeNotFound
+		}
+		return nil, err
+	}
+	if duplicate.MergedIntoID != nil {
+		return nil, ErrAlreadyMerged
+	}
+	target, err := resolveCoffee(s.catalogRepo, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == duplicate.ID {
+		return nil, ErrMergeIntoSelf
+	}
+
+	now := time.Now()
+	if target.RoasterID == nil {
+		target.RoasterID = duplicate.RoasterID
+	}
+	fillEmpty(&target.OriginCountry, duplicate.OriginCountry)
+	fillEmpty(&target.Region, duplicate.Region)
+	fillEmpty(&target.Farm, duplicate.Farm)
+	fillEmpty(&target.Variety, duplicate.Variety)
+	fillEmpty(&target.Process, duplicate.Process)
+	target.UpdatedAt = now
+	if err := s.catalogRepo.MergeCoffee(duplicate, target, now); err != nil {
+		// The target took a roaster that has a coffee of its name
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrCoffeeNameTaken
+		}
+		return nil, err
+	}
+	return target, nil
+}
+
+// resolveRoaster returns the roaster, or the one it was merged into.
+func resolveRoaster(catalogRepo repository.CatalogRepository, roasterID uuid.UUID) (*domain.Roaster, error) {
+	roaster, err := catalogRepo.GetRoaster(roasterID)
+	// Merges point straight at a roaster that was not merged
+	if err == nil && roaster.MergedIntoID != nil {
+		roaster, err = catalogRepo.GetRoaster(*roaster.MergedIntoID)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoasterNotFound
+		}
+		return nil, err
+	}
+	return roaster, nil
+}
+
+// resolveCoffee returns the coffee, or the one it was merged into.
+func resolveCoffee(catalogRepo repository.CatalogRepository, coffeeID uuid.UUID) (*domain.Coffee, error) {
+	coffee, err := catalogRepo.GetCoffee(coffeeID)
+	if err == nil && coffee.MergedIntoID != nil {
+		coffee, err = catalogRepo.GetCoffee(*coffee.MergedIntoID)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCoffeeNotFound
+		}
+		return nil, err
+	}
+	return coffee, nil
+}
+
+// normalizeCatalogName reduces a name to what tells entries apart: lower
+// case letters and digits, separated by single spaces. "Onyx Coffee-Lab"
+// and "onyx coffee lab" are the same roaster.
+func normalizeCatalogName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+func catalogName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if normalizeCatalogName(name) == "" || utf8.RuneCountInString(name) > maxCatalogNameLength {
+		return "", ErrInvalidCatalogName
+	}
+	return name, nil
+}
+
+func catalogText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) > maxCatalogTextLength {
+		return "", ErrInvalidCatalogText
+	}
+	return text, nil
+}
+
+func catalogWebsite(website string) (string, error) {
+	website = strings.TrimSpace(website)
+	if website == "" {
+		return "", nil
+	}
+	parsed, err := url.Parse(website)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+		len(website) > maxCatalogWebsiteLength {
+		return "", ErrInvalidWebsite
+	}
+	return website, nil
+}
+
+func fillEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
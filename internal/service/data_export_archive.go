@@ -0,0 +1,355 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/pkg/storage"
+	"gorm.io/gorm"
+)
+
+const exportReadme = `This archive holds a copy of your data.
+
+Every table comes as a JSON file and as a CSV file with the same content:
+
+  profile             your account and profile
+  equipment           your brewing equipment
+  beans               your coffee beans, including ones you removed
+  bean_events         what happened to your beans, such as freezing them
+  bean_stock          every change of your beans' stock
+  sessions            the devices you are signed in on
+  connected_accounts  social logins linked to your account
+  api_keys            your API keys (the keys themselves are never stored)
+  handle_changes      handles you used before
+
+preferences.json holds your settings and onboarding.json your answers to the
+questions asked when you signed up. Uploaded images are in the images folder,
+those of your beans in images/beans/<bean id>/.
+
+Times are in UTC.
+`
+
+// exportTable is one table of the archive, written as both JSON and CSV.
+type exportTable struct {
+	name   string
+	header []string
+	rows   [][]string
+	// records is what the JSON file holds
+	records interface{}
+}
+
+// writeArchive writes the ZIP archive of the user's data to w.
+func (s *DataExportServiceImpl) writeArchive(w io.Writer, user *domain.User) error {
+	zw := zip.NewWriter(w)
+
+	if err := writeZipFile(zw, "README.txt", strings.NewReader(exportReadme)); err != nil {
+		return err
+	}
+
+	tables, err := s.exportTables(user)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if err := writeZipJSON(zw, table.name+".json", table.records); err != nil {
+			return err
+		}
+		if err := writeZipCSV(zw, table.name+".csv", table.header, table.rows); err != nil {
+			return err
+		}
+	}
+
+	if err := writeZipJSON(zw, "preferences.json", user.Preferences.OrDefault()); err != nil {
+		return err
+	}
+	onboarding, err := s.onboardingRepo.GetByUserID(user.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if onboarding != nil {
+		if err := writeZipJSON(zw, "onboarding.json", onboarding); err != nil {
+			return err
+		}
+	}
+
+	if err := s.writeImages(zw, user); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func (s *DataExportServiceImpl) exportTables(user *domain.User) ([]exportTable, error) {
+	profile := exportTable{
+		name:   "profile",
+		header: []string{"id", "email", "emailVerifiedAt", "handle", "displayName", "bio", "role", "createdAt", "lastLoginAt"},
+		rows: [][]string{{
+			user.ID.String(), user.EmailAddress(), formatExportTime(user.EmailVerifiedAt), user.HandleName(),
+			user.DisplayName, user.Bio, string(user.Role), formatExportTime(&user.CreatedAt), formatExportTime(user.LastLoginAt),
+		}},
+		records: map[string]interface{}{
+			"id":              user.ID,
+			"email":           user.EmailAddress(),
+			"emailVerifiedAt": user.EmailVerifiedAt,
+			"handle":          user.HandleName(),
+			"displayName":     user.DisplayName,
+			"bio":             user.Bio,
+			"role":            user.Role,
+			"createdAt":       user.CreatedAt,
+			"lastLoginAt":     user.LastLoginAt,
+		},
+	}
+
+	equipment, err := s.equipmentRepo.ListForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	equipmentTable := exportTable{
+		name:    "equipment",
+		header:  []string{"id", "kind", "name", "createdAt"},
+		records: nonNil(equipment),
+	}
+	for _, item := range equipment {
+		equipmentTable.rows = append(equipmentTable.rows, []string{
+			item.ID.String(), string(item.Kind), item.Name, formatExportTime(&item.CreatedAt),
+		})
+	}
+
+	beans, err := s.beanRepo.ListForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	beanTable := exportTable{
+		name: "beans",
+		header: []string{
+			"id", "name", "origin", "roaster", "roastDate", "roastLevel", "flavorNotes", "beanSpecies", "processingMethod",
+			"altitude", "purchaseDate", "price", "quantityGrams", "roasterId", "coffeeId", "parentId", "openedOn",
+			"frozenOn", "frozenDays", "finishedOn", "isActive", "isFavorite", "createdAt",
+		},
+		records: nonNil(beans),
+	}
+	for _, bean := range beans {
+		beanTable.rows = append(beanTable.rows, []string{
+			bean.ID.String(), bean.Name, bean.Origin, bean.Roaster, formatExportDate(bean.RoastDate), string(bean.RoastLevel),
+			strings.Join(bean.FlavorNotes, "; "), string(bean.BeanSpecies), bean.ProcessingMethod, bean.Altitude,
+			formatExportDate(bean.PurchaseDate), formatExportNumber(bean.Price), formatExportNumber(bean.QuantityGrams),
+			formatExportID(bean.RoasterID), formatExportID(bean.CoffeeID), formatExportID(bean.ParentID), formatExportDate(bean.OpenedOn), formatExportDate(bean.FrozenOn), strconv.Itoa(bean.FrozenDays),
+			formatExportDate(bean.FinishedOn), strconv.FormatBool(bean.IsActive), strconv.FormatBool(bean.IsFavorite),
+			formatExportTime(&bean.CreatedAt),
+		})
+	}
+
+	events, err := s.eventRepo.ListForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	eventTable := exportTable{
+		name:    "bean_events",
+		header:  []string{"id", "beanId", "kind", "date", "note", "createdAt"},
+		records: nonNil(events),
+	}
+	for _, event := range events {
+		eventTable.rows = append(eventTable.rows, []string{
+			event.ID.String(), event.BeanID.String(), string(event.Kind), formatExportDate(&event.Date), event.Note,
+			formatExportTime(&event.CreatedAt),
+		})
+	}
+
+	adjustments, err := s.stockRepo.ListForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	stockTable := exportTable{
+		name:    "bean_stock",
+		header:  []string{"id", "beanId", "kind", "changeGrams", "quantityAfter", "brewLogId", "note", "createdAt"},
+		records: nonNil(adjustments),
+	}
+	for _, adjustment := range adjustments {
+		brewLogID := ""
+		if adjustment.BrewLogID != nil {
+			brewLogID = adjustment.BrewLogID.String()
+		}
+		stockTable.rows = append(stockTable.rows, []string{
+			adjustment.ID.String(), adjustment.BeanID.String(), string(adjustment.Kind), strconv.Itoa(adjustment.ChangeGrams),
+			strconv.Itoa(adjustment.QuantityAfter), brewLogID, adjustment.Note, formatExportTime(&adjustment.CreatedAt),
+		})
+	}
+
+	sessions, err := s.sessionRepo.ListActiveForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	sessionTable := exportTable{
+		name:    "sessions",
+		header:  []string{"id", "deviceName", "userAgent", "ipAddress", "createdAt", "lastSeenAt"},
+		records: nonNil(sessions),
+	}
+	for _, session := range sessions {
+		sessionTable.rows = append(sessionTable.rows, []string{
+			session.ID.String(), session.DeviceName, session.UserAgent, session.IPAddress,
+			formatExportTime(&session.CreatedAt), formatExportTime(&session.LastSeenAt),
+		})
+	}
+
+	identities, err := s.identityRepo.ListForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	identityTable := exportTable{
+		name:    "connected_accounts",
+		header:  []string{"id", "provider", "email", "createdAt"},
+		records: nonNil(identities),
+	}
+	for _, identity := range identities {
+		identityTable.rows = append(identityTable.rows, []string{
+			identity.ID.String(), identity.Provider, identity.Email, formatExportTime(&identity.CreatedAt),
+		})
+	}
+
+	keys, err := s.apiKeyRepo.ListActiveForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	keyTable := exportTable{
+		name:    "api_keys",
+		header:  []string{"id", "name", "prefix", "scopes", "createdAt", "expiresAt", "lastUsedAt"},
+		records: nonNil(keys),
+	}
+	for _, key := range keys {
+		scopes := make([]string, len(key.Scopes))
+		for i, scope := range key.Scopes {
+			scopes[i] = string(scope)
+		}
+		keyTable.rows = append(keyTable.rows, []string{
+			key.ID.String(), key.Name, key.Prefix, strings.Join(scopes, " "),
+			formatExportTime(&key.CreatedAt), formatExportTime(key.ExpiresAt), formatExportTime(key.LastUsedAt),
+		})
+	}
+
+	changes, err := s.handleChangeRepo.ListForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	changeTable := exportTable{
+		name:    "handle_changes",
+		header:  []string{"id", "oldHandle", "changedAt"},
+		records: nonNil(changes),
+	}
+	for _, change := range changes {
+		changeTable.rows = append(changeTable.rows, []string{
+			change.ID.String(), change.OldHandle, formatExportTime(&change.ChangedAt),
+		})
+	}
+
+	return []exportTable{profile, equipmentTable, beanTable, eventTable, stockTable, sessionTable, identityTable, keyTable, changeTable}, nil
+}
+
+// writeImages copies the user's uploaded images into the images folder.
+// Images missing from the storage are left out.
+func (s *DataExportServiceImpl) writeImages(zw *zip.Writer, user *domain.User) error {
+	if user.AvatarKey != "" {
+		if err := s.writeImage(zw, "images/avatar"+path.Ext(user.AvatarKey), user.AvatarKey); err != nil {
+			return err
+		}
+	}
+
+	beans, err := s.beanRepo.ListForUser(user.ID)
+	if err != nil {
+		return err
+	}
+	for _, bean := range beans {
+		for i, key := range bean.ImageKeys {
+			name := fmt.Sprintf("images/beans/%s/%d%s", bean.ID, i+1, path.Ext(key))
+			if err := s.writeImage(zw, name, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *DataExportServiceImpl) writeImage(zw *zip.Writer, name, key string) error {
+	image, err := s.storage.Open(key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer image.Close()
+	return writeZipFile(zw, name, image)
+}
+
+func writeZipFile(zw *zip.Writer, name string, r io.Reader) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeZipFile(zw, name, strings.NewReader(string(data)+"\n"))
+}
+
+func writeZipCSV(zw *zip.Writer, name string, header []string, rows [][]string) error {
+	var b strings.Builder
+	cw := csv.NewWriter(&b)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return writeZipFile(zw, name, strings.NewReader(b.String()))
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatExportDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func formatExportID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func formatExportNumber[T int | float64](n *T) string {
+	if n == nil {
+		return ""
+	}
+	return fmt.Sprint(*n)
+}
+
+// nonNil makes empty tables come out as [] rather than null in JSON.
+func nonNil[T any](records []T) []T {
+	if records == nil {
+		return []T{}
+	}
+	return records
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/pkg/mailer"
+	"github.com/yashkadam007/brewkar/pkg/storage"
+	"gorm.io/gorm"
+)
+
+const (
+	// exportBatchSize is how many pending exports one run of the export job
+	// builds.
+	exportBatchSize = 10
+	// exportClaimTimeout is how long an export may take to build before
+	// another run takes it over
+	exportClaimTimeout = time.Hour
+)
+
+var (
+	ErrExportNotFound    = errors.New("no data export requested")
+	ErrInvalidExportLink = errors.New("invalid or expired download link")
+)
+
+type DataExportService interface {
+	// RequestExport queues an export of the user's data. A request while an
+	// export is still being built returns that export.
+	RequestExport(userID uuid.UUID) (*domain.DataExport, error)
+	// GetLatestExport returns the user's most recent export.
+	GetLatestExport(userID uuid.UUID) (*domain.DataExport, error)
+	// OpenExport returns the archive of a ready export for the token of its
+	// download link. The caller closes the reader.
+	OpenExport(id uuid.UUID, token string) (*domain.DataExport, io.ReadCloser, error)
+	// ProcessPendingExports builds queued exports and emails their download
+	// links. It returns how many were built.
+	ProcessPendingExports() (int, error)
+	// DeleteExpiredExports deletes archives whose download link expired.
+	DeleteExpiredExports() (int, error)
+}
+
+type DataExportServiceImpl struct {
+	exportRepo       repository.DataExportRepository
+	userRepo         repository.UserRepository
+	sessionRepo      repository.SessionRepository
+	identityRepo     repository.UserIdentityRepository
+	apiKeyRepo       repository.APIKeyRepository
+	handleChangeRepo repository.HandleChangeRepository
+	equipmentRepo    repository.EquipmentRepository
+	onboardingRepo   repository.OnboardingRepository
+	beanRepo         repository.BeanRepository
+	stockRepo        repository.BeanStockRepository
+	eventRepo        repository.BeanEventRepository
+	rateLimitRepo    repository.RateLimitRepository
+	storage          storage.Storage
+	mailer           mailer.Mailer
+	appCfg           config.AppConfig
+	exportsCfg       config.ExportsConfig
+}
+
+func NewDataExportService(
+	exportRepo repository.DataExportRepository,
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	identityRepo repository.UserIdentityRepository,
+	apiKeyRepo repository.APIKeyRepository,
+	handleChangeRepo repository.HandleChangeRepository,
+	equipmentRepo repository.EquipmentRepository,
+	onboardingRepo repository.OnboardingRepository,
+	beanRepo repository.BeanRepository,
+	stockRepo repository.BeanStockRepository,
+	eventRepo repository.BeanEventRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	store storage.Storage,
+	m mailer.Mailer,
+	appCfg config.AppConfig,
+	exportsCfg config.ExportsConfig,
+) DataExportService {
+	return &DataExportServiceImpl{
+		exportRepo:       exportRepo,
+		userRepo:         userRepo,
+		sessionRepo:      sessionRepo,
+		identityRepo:     identityRepo,
+		apiKeyRepo:       apiKeyRepo,
+		handleChangeRepo: handleChangeRepo,
+		equipmentRepo:    equipmentRepo,
+		onboardingRepo:   onboardingRepo,
+		beanRepo:         beanRepo,
+		stockRepo:        stockRepo,
+		eventRepo:        eventRepo,
+		rateLimitRepo:    rateLimitRepo,
+		storage:          store,
+		mailer:           m,
+		appCfg:           appCfg,
+		exportsCfg:       exportsCfg,
+	}
+}
+
+func (s *DataExportServiceImpl) RequestExport(userID uuid.UUID) (*domain.DataExport, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	// The download link is emailed
+	if user.IsGuest {
+		return nil, ErrGuestAccount
+	}
+
+	latest, err := s.exportRepo.GetLatestForUser(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if latest != nil && latest.IsInProgress() {
+		return latest, nil
+	}
+
+	allowed, retryAfter, err := s.rateLimitRepo.Allow("data-export:"+userID.String(), s.exportsCfg.RequestLimit, 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, &RateLimitError{RetryAfter: retryAfter}
+	}
+
+	export := &domain.DataExport{
+		UserID:    userID,
+		Status:    domain.DataExportPending,
+		CreatedAt: time.Now(),
+	}
+	if err := s.exportRepo.Create(export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+func (s *DataExportServiceImpl) GetLatestExport(userID uuid.UUID) (*domain.DataExport, error) {
+	export, err := s.exportRepo.GetLatestForUser(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+	return export, nil
+}
+
+func (s *DataExportServiceImpl) OpenExport(id uuid.UUID, token string) (*domain.DataExport, io.ReadCloser, error) {
+	export, err := s.exportRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidExportLink
+		}
+		return nil, nil, err
+	}
+	if !export.IsDownloadable(time.Now()) ||
+		subtle.ConstantTimeCompare([]byte(export.TokenHash), []byte(hashToken(token))) != 1 {
+		return nil, nil, ErrInvalidExportLink
+	}
+
+	archive, err := s.storage.Open(export.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrInvalidExportLink
+		}
+		return nil, nil, err
+	}
+	return export, archive, nil
+}
+
+func (s *DataExportServiceImpl) ProcessPendingExports() (int, error) {
+	now := time.Now()
+	exports, err := s.exportRepo.ClaimPending(now, now.Add(-exportClaimTimeout), exportBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	built := 0
+	var errs []error
+	for i := range exports {
+		if err := s.process(&exports[i]); err != nil {
+			errs = append(errs, fmt.Errorf("export %s: %w", exports[i].ID, err))
+			continue
+		}
+		built++
+	}
+	return built, errors.Join(errs...)
+}
+
+// process builds one export. Exports that fail are marked as failed so they
+// are not retried forever; the user can request a new one.
+func (s *DataExportServiceImpl) process(export *domain.DataExport) error {
+	user, err := s.userRepo.GetByID(export.UserID)
+	if err != nil {
+		return s.fail(export, err)
+	}
+
+	key := fmt.Sprintf("%sexports/%s/%s.zip", storage.PrivatePrefix, user.ID, export.ID)
+	size, err := s.store(key, user)
+	if err != nil {
+		return s.fail(export, err)
+	}
+
+	rawToken, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		_ = s.storage.Delete(key)
+		return s.fail(export, err)
+	}
+
+	// The email goes out first: the raw token only exists in it, so an
+	// export is only ready once its link was sent
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(s.exportsCfg.LinkExpiryHours) * time.Hour)
+	if err := s.mailer.Send(mailer.Message{
+		To:      user.EmailAddress(),
+		Subject: fmt.Sprintf("Your %s data export is ready", s.appCfg.Name),
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nThe copy of your %s data you asked for is ready. Download it here:\n\n%s/exports/%s/download?token=%s\n\nThe link expires in %s. If you did not request an export, please change your password.\n",
+			user.DisplayName, s.appCfg.Name, s.appCfg.BaseURL, export.ID, rawToken, formatMinutes(s.exportsCfg.LinkExpiryHours*60),
+		),
+	}); err != nil {
+		_ = s.storage.Delete(key)
+		return s.fail(export, err)
+	}
+
+	export.Status = domain.DataExportReady
+	export.StorageKey = key
+	export.Size = size
+	export.TokenHash = tokenHash
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	if err := s.exportRepo.Update(export); err != nil {
+		// The emailed link cannot work, and the export must not be built
+		// and sent again on every run
+		_ = s.storage.Delete(key)
+		export.StorageKey = ""
+		export.Size = 0
+		export.TokenHash = ""
+		export.ExpiresAt = nil
+		return s.fail(export, err)
+	}
+	return nil
+}
+
+// store builds the user's archive in a temporary file and puts it into the
+// storage under key. It returns the size of the archive.
+func (s *DataExportServiceImpl) store(key string, user *domain.User) (int64, error) {
+	tmp, err := os.CreateTemp("", "brewkar-export-*.zip")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := s.writeArchive(tmp, user); err != nil {
+		return 0, err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := s.storage.Put(key, tmp, "application/zip"); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+func (s *DataExportServiceImpl) fail(export *domain.DataExport, cause error) error {
+	now := time.Now()
+	export.Status = domain.DataExportFailed
+	export.CompletedAt = &now
+	if err := s.exportRepo.Update(export); err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
+
+func (s *DataExportServiceImpl) DeleteExpiredExports() (int, error) {
+	exports, err := s.exportRepo.ListExpired(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for i := range exports {
+		export := &exports[i]
+		if err := s.storage.Delete(export.StorageKey); err != nil {
+			return deleted, err
+		}
+		export.Status = domain.DataExportExpired
+		export.StorageKey = ""
+		export.TokenHash = ""
+		if err := s.exportRepo.Update(export); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/pkg/mailer"
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+	ErrEmailAlreadyVerified     = errors.New("email address is already verified")
+)
+
+type EmailVerificationService interface {
+	SendVerification(user *domain.User) error
+	ResendVerification(userID uuid.UUID) error
+	VerifyEmail(token string) error
+}
+
+type EmailVerificationServiceImpl struct {
+	userRepo        repository.UserRepository
+	actionTokenRepo repository.ActionTokenRepository
+	rateLimitRepo   repository.RateLimitRepository
+	mailer          mailer.Mailer
+	appCfg          config.AppConfig
+	authCfg         config.AuthConfig
+}
+
+func NewEmailVerificationService(
+	userRepo repository.UserRepository,
+	actionTokenRepo repository.ActionTokenRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	m mailer.Mailer,
+	appCfg config.AppConfig,
+	authCfg config.AuthConfig,
+) EmailVerificationService {
+	return &EmailVerificationServiceImpl{
+		userRepo:        userRepo,
+		actionTokenRepo: actionTokenRepo,
+		rateLimitRepo:   rateLimitRepo,
+		mailer:          m,
+		appCfg:          appCfg,
+		authCfg:         authCfg,
+	}
+}
+
+// SendVerification emails a fresh verification link to the user. Links sent
+// earlier stop working.
+func (s *EmailVerificationServiceImpl) SendVerification(user *domain.User) error {
+	if err := s.actionTokenRepo.InvalidateForUser(user.ID, domain.ActionTokenEmailVerification); err != nil {
+		return err
+	}
+
+	rawToken, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expiry := time.Duration(s.authCfg.EmailVerificationTokenExp) * time.Minute
+	if err := s.actionTokenRepo.Create(&domain.ActionToken{
+		UserID:    user.ID,
+		Purpose:   domain.ActionTokenEmailVerification,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(expiry),
+		CreatedAt: now,
+	}); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(mailer.Message{
+		To:      user.EmailAddress(),
+		Subject: fmt.Sprintf("Verify your %s email address", s.appCfg.Name),
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s/verify-email?token=%s\n\nThe link expires in %s.\n",
+			user.DisplayName, s.appCfg.BaseURL, rawToken, formatMinutes(s.authCfg.EmailVerificationTokenExp),
+		),
+	})
+}
+
+// ResendVerification sends another verification link, throttled per user.
+func (s *EmailVerificationServiceImpl) ResendVerification(userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.IsGuest {
+		return ErrGuestAccount
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	allowed, retryAfter, err := s.rateLimitRepo.Allow("verify-email:"+userID.String(), s.authCfg.EmailSendLimit, time.Hour)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return &RateLimitError{RetryAfter: retryAfter}
+	}
+
+	return s.SendVerification(user)
+}
+
+func (s *EmailVerificationServiceImpl) VerifyEmail(token string) error {
+	stored, err := s.actionTokenRepo.GetByHash(domain.ActionTokenEmailVerification, hashToken(token))
+	if err != nil || stored.UsedAt != nil {
+		return ErrInvalidVerificationToken
+	}
+
+	now := time.Now()
+	if now.After(stored.ExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+
+	marked, err := s.actionTokenRepo.MarkUsed(stored.ID, now)
+	if err != nil {
+		return err
+	}
+	if !marked {
+		return ErrInvalidVerificationToken
+	}
+
+	user, err := s.userRepo.GetByID(stored.UserID)
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+		user.UpdatedAt = now
+		return s.userRepo.Update(user)
+	}
+	return nil
+}
+
+// formatMinutes renders a link lifetime for use in email copy.
+func formatMinutes(minutes int) string {
+	switch {
+	case minutes == 60:
+		return "1 hour"
+	case minutes > 60 && minutes%60 == 0:
+		return fmt.Sprintf("%d hours", minutes/60)
+	case minutes == 1:
+		return "1 minute"
+	default:
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+)
+
+type EquipmentService interface {
+	ListEquipment(userID uuid.UUID) ([]domain.Equipment, error)
+}
+
+type EquipmentServiceImpl struct {
+	equipmentRepo repository.EquipmentRepository
+}
+
+func NewEquipmentService(equipmentRepo repository.EquipmentRepository) EquipmentService {
+	return &EquipmentServiceImpl{
+		equipmentRepo: equipmentRepo,
+	}
+}
+
+func (s *EquipmentServiceImpl) ListEquipment(userID uuid.UUID) ([]domain.Equipment, error) {
+	return s.equipmentRepo.ListForUser(userID)
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// RateLimitError is returned when an action was attempted too often. The
+// action may be retried after RetryAfter.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("too many requests, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginLockedError is returned when too many logins failed for an account or
+// IP address. Logging in may be retried after RetryAfter.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// MFARequiredError is returned by a login that passed the first factor for a
+// user with two-factor authentication enabled. ChallengeToken must be
+// exchanged together with a code for real tokens.
+type MFARequiredError struct {
+	ChallengeToken string
+}
+
+func (e *MFARequiredError) Error() string {
+	return "two-factor authentication required"
+}
+
+// HandleMovedError is returned when a profile is looked up by a handle its
+// owner has since changed. Handle is the owner's current handle.
+type HandleMovedError struct {
+	Handle string
+}
+
+func (e *HandleMovedError) Error() string {
+	return fmt.Sprintf("handle has moved to %s", e.Handle)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/pkg/passwords"
+)
+
+// Display name of new guests until they choose one
+const guestDisplayName = "Guest"
+
+var (
+	ErrNotGuest          = errors.New("account is not a guest account")
+	ErrGuestAccount      = errors.New("guest accounts have no email address; upgrade the account first")
+	ErrEmailAlreadyInUse = errors.New("user with this email already exists")
+)
+
+type GuestService interface {
+	// CreateGuest signs up an anonymous user and logs them in.
+	CreateGuest(client ClientInfo) (*domain.User, *TokenPair, error)
+	// Upgrade turns a guest into a full account with an email address and
+	// password. The user keeps their ID and with it everything they created.
+	Upgrade(userID uuid.UUID, email, password, displayName string) (*domain.User, error)
+}
+
+type GuestServiceImpl struct {
+	userRepo        repository.UserRepository
+	rateLimitRepo   repository.RateLimitRepository
+	authService     AuthService
+	verificationSvc EmailVerificationService
+	hasher          PasswordHasher
+	policy          *passwords.Policy
+	authCfg         config.AuthConfig
+}
+
+func NewGuestService(
+	userRepo repository.UserRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	authService AuthService,
+	verificationSvc EmailVerificationService,
+	hasher PasswordHasher,
+	policy *passwords.Policy,
+	authCfg config.AuthConfig,
+) GuestService {
+	return &GuestServiceImpl{
+		userRepo:        userRepo,
+		rateLimitRepo:   rateLimitRepo,
+		authService:     authService,
+		verificationSvc: verificationSvc,
+		hasher:          hasher,
+		policy:          policy,
+		authCfg:         authCfg,
+	}
+}
+
+func (s *GuestServiceImpl) CreateGuest(client ClientInfo) (*domain.User, *TokenPair, error) {
+	// Creating guests needs no credentials, so limit how many one address
+	// can create
+	allowed, retryAfter, err := s.rateLimitRepo.Allow("guest:"+client.IP, s.authCfg.GuestCreateLimit, time.Hour)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !allowed {
+		return nil, nil, &RateLimitError{RetryAfter: retryAfter}
+	}
+
+	now := time.Now()
+	user := &domain.User{
+		DisplayName: guestDisplayName,
+		IsGuest:     true,
+		Role:        domain.RoleUser,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, nil, err
+	}
+
+	return s.authService.LoginUser(user, client)
+}
+
+func (s *GuestServiceImpl) Upgrade(userID uuid.UUID, email, password, displayName string) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsGuest {
+		return nil, ErrNotGuest
+	}
+	if err := validatePassword(s.policy, password); err != nil {
+		return nil, err
+	}
+
+	if existing, err := s.userRepo.GetByEmail(email); err == nil && existing != nil {
+		return nil, ErrEmailAlreadyInUse
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Email = &email
+	user.PasswordHash = hashedPassword
+	user.IsGuest = false
+	if displayName != "" {
+		user.DisplayName = displayName
+	}
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	// As in Register, a failed send must not fail the upgrade
+	_ = s.verificationSvc.SendVerification(user)
+
+	return user, nil
+}
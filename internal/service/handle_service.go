@@ -0,0 +1,168 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/pkg/handles"
+	"gorm.io/gorm"
+)
+
+// Handle changes allowed per user per day. Every change reserves the old
+// handle, so this keeps a user from holding on to many handles. Choosing the
+// first handle and changing its case do not count.
+const handleChangeLimit = 3
+
+var (
+	ErrInvalidHandle   = errors.New("invalid handle")
+	ErrHandleTaken     = errors.New("handle is already taken")
+	ErrProfileNotFound = errors.New("profile not found")
+)
+
+// PublicProfile is what anybody can see of a user. It must never include the
+// email address, preferences or anything else private.
+type PublicProfile struct {
+	Handle      string
+	DisplayName string
+	Bio         string
+	AvatarURL   string
+	// Public recipes and brew logs only
+	RecipeCount   int64
+	BrewLogCount  int64
+	FollowerCount int64
+}
+
+type HandleService interface {
+	// SetHandle gives the user a new handle. The old one keeps redirecting
+	// to the user for a while.
+	SetHandle(userID uuid.UUID, handle string) (*domain.User, error)
+	// GetPublicProfile looks up a profile by handle, ignoring case. A handle
+	// the owner has changed returns a *HandleMovedError.
+	GetPublicProfile(handle string) (*PublicProfile, error)
+}
+
+type HandleServiceImpl struct {
+	userRepo         repository.UserRepository
+	handleChangeRepo repository.HandleChangeRepository
+	rateLimitRepo    repository.RateLimitRepository
+	policy           *handles.Policy
+	usersCfg         config.UsersConfig
+}
+
+func NewHandleService(
+	userRepo repository.UserRepository,
+	handleChangeRepo repository.HandleChangeRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	policy *handles.Policy,
+	usersCfg config.UsersConfig,
+) HandleService {
+	return &HandleServiceImpl{
+		userRepo:         userRepo,
+		handleChangeRepo: handleChangeRepo,
+		rateLimitRepo:    rateLimitRepo,
+		policy:           policy,
+		usersCfg:         usersCfg,
+	}
+}
+
+func (s *HandleServiceImpl) SetHandle(userID uuid.UUID, handle string) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsGuest {
+		return nil, ErrGuestAccount
+	}
+
+	handle = handles.Normalize(handle)
+	if err := s.policy.Validate(handle); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHandle, err)
+	}
+	oldHandle := user.HandleName()
+	if handle == oldHandle {
+		return user, nil
+	}
+
+	if existing, err := s.userRepo.GetByHandle(handle); err == nil && existing != nil && existing.ID != user.ID {
+		return nil, ErrHandleTaken
+	}
+	// Somebody else's old handle still points at them
+	if change, err := s.handleChangeRepo.GetLatestByOldHandle(handle); err == nil && change != nil &&
+		change.UserID != user.ID && s.redirects(change) {
+		return nil, ErrHandleTaken
+	}
+
+	// Changing only the case keeps the same address, so there is nothing
+	// to redirect
+	var change *domain.HandleChange
+	if oldHandle != "" && !strings.EqualFold(oldHandle, handle) {
+		allowed, retryAfter, err := s.rateLimitRepo.Allow("handle-change:"+user.ID.String(), handleChangeLimit, 24*time.Hour)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, &RateLimitError{RetryAfter: retryAfter}
+		}
+		change = &domain.HandleChange{
+			UserID:    user.ID,
+			OldHandle: oldHandle,
+			ChangedAt: time.Now(),
+		}
+	}
+	// Somebody may have taken the handle since it was checked
+	if err := s.handleChangeRepo.ChangeHandle(user, handle, change); errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, ErrHandleTaken
+	} else if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *HandleServiceImpl) GetPublicProfile(handle string) (*PublicProfile, error) {
+	handle = handles.Normalize(handle)
+
+	if user, err := s.userRepo.GetByHandle(handle); err == nil && user != nil {
+		if !isPublic(user) {
+			return nil, ErrProfileNotFound
+		}
+		return s.publicProfile(user), nil
+	}
+
+	change, err := s.handleChangeRepo.GetLatestByOldHandle(handle)
+	if err != nil || change == nil || !s.redirects(change) {
+		return nil, ErrProfileNotFound
+	}
+	owner, err := s.userRepo.GetByID(change.UserID)
+	if err != nil || owner.Handle == nil || !isPublic(owner) {
+		return nil, ErrProfileNotFound
+	}
+	return nil, &HandleMovedError{Handle: *owner.Handle}
+}
+
+func (s *HandleServiceImpl) publicProfile(user *domain.User) *PublicProfile {
+	// Recipes, brew logs and follows are not stored yet, so their counts
+	// stay 0 until those modules exist
+	return &PublicProfile{
+		Handle:      user.HandleName(),
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarURL:   user.AvatarURL,
+	}
+}
+
+// redirects reports whether an old handle still points at its former owner.
+func (s *HandleServiceImpl) redirects(change *domain.HandleChange) bool {
+	return time.Since(change.ChangedAt) < time.Duration(s.usersCfg.HandleRedirectDays)*24*time.Hour
+}
+
+// isPublic reports whether anybody may see the user's profile.
+func isPublic(user *domain.User) bool {
+	return !user.IsGuest && !user.IsSuspended() && !user.IsPendingDeletion() &&
+		user.Preferences.OrDefault().Privacy.ProfileVisibility != domain.VisibilityPrivate
+}
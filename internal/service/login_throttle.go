@@ -0,0 +1,91 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/repository"
+)
+
+// loginThrottle locks accounts and IP addresses out of login after repeated
+// failures. Each failure past the limit doubles the lockout, up to a maximum;
+// counters reset once no failure happened for a whole window.
+type loginThrottle struct {
+	repo repository.LoginAttemptRepository
+	cfg  config.AuthConfig
+}
+
+func accountLoginKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// check returns a LoginLockedError if the account or the IP address is
+// currently locked.
+func (t *loginThrottle) check(email, ip string) error {
+	keys := []string{accountLoginKey(email)}
+	if ip != "" {
+		keys = append(keys, ipLoginKey(ip))
+	}
+
+	for _, key := range keys {
+		remaining, err := t.repo.LockedFor(key)
+		if err != nil {
+			return err
+		}
+		if remaining > 0 {
+			return &LoginLockedError{RetryAfter: remaining}
+		}
+	}
+	return nil
+}
+
+// recordFailure counts a failed login against the account and the IP address
+// and locks whichever went over its limit.
+func (t *loginThrottle) recordFailure(email, ip string) error {
+	if err := t.fail(accountLoginKey(email), t.cfg.LoginMaxFailures); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return t.fail(ipLoginKey(ip), t.cfg.LoginIPMaxFailures)
+}
+
+func (t *loginThrottle) fail(key string, limit int) error {
+	window := time.Duration(t.cfg.LoginFailureWindow) * time.Minute
+	failures, err := t.repo.RecordFailure(key, window)
+	if err != nil {
+		return err
+	}
+	if failures < int64(limit) {
+		return nil
+	}
+	return t.repo.Lock(key, t.lockoutDuration(failures-int64(limit)))
+}
+
+// lockoutDuration doubles the base lockout for every failure past the limit.
+func (t *loginThrottle) lockoutDuration(excess int64) time.Duration {
+	base := time.Duration(t.cfg.LoginLockoutBase) * time.Minute
+	max := time.Duration(t.cfg.LoginLockoutMax) * time.Minute
+
+	duration := base
+	for i := int64(0); i < excess && duration < max; i++ {
+		duration *= 2
+	}
+	if duration > max {
+		duration = max
+	}
+	return duration
+}
+
+// reset clears the failures and lock of an account, e.g. after a successful
+// login or a password reset. IP counters are left alone so an attacker cannot
+// clear them by logging into an account of their own.
+func (t *loginThrottle) reset(email string) error {
+	return t.repo.Reset(accountLoginKey(email))
+}
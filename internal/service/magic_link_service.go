@@ -0,0 +1,153 @@
+package service
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/pkg/mailer"
+)
+
+var ErrInvalidMagicLink = errors.New("invalid or expired login link")
+
+type MagicLinkService interface {
+	// RequestLink emails a login link if an account exists for the email and
+	// returns the nonce the link is bound to. The nonce is returned for
+	// unknown emails too so accounts cannot be enumerated.
+	RequestLink(email string) (string, error)
+	// Login logs in with the token from a login link and the nonce returned
+	// to the device that requested it.
+	Login(token, nonce string, client ClientInfo) (*domain.User, *TokenPair, error)
+}
+
+type MagicLinkServiceImpl struct {
+	userRepo        repository.UserRepository
+	actionTokenRepo repository.ActionTokenRepository
+	rateLimitRepo   repository.RateLimitRepository
+	authService     AuthService
+	mailer          mailer.Mailer
+	appCfg          config.AppConfig
+	authCfg         config.AuthConfig
+}
+
+func NewMagicLinkService(
+	userRepo repository.UserRepository,
+	actionTokenRepo repository.ActionTokenRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	authService AuthService,
+	m mailer.Mailer,
+	appCfg config.AppConfig,
+	authCfg config.AuthConfig,
+) MagicLinkService {
+	return &MagicLinkServiceImpl{
+		userRepo:        userRepo,
+		actionTokenRepo: actionTokenRepo,
+		rateLimitRepo:   rateLimitRepo,
+		authService:     authService,
+		mailer:          m,
+		appCfg:          appCfg,
+		authCfg:         authCfg,
+	}
+}
+
+func (s *MagicLinkServiceImpl) RequestLink(email string) (string, error) {
+	// Throttle by email, whether or not an account exists for it
+	allowed, retryAfter, err := s.rateLimitRepo.Allow("magic-link:"+strings.ToLower(email), s.authCfg.EmailSendLimit, time.Hour)
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "", &RateLimitError{RetryAfter: retryAfter}
+	}
+
+	nonce, nonceHash, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.userRepo.GetByEmail(email)
+	if err != nil {
+		return nonce, nil
+	}
+
+	rawToken, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	expiry := time.Duration(s.authCfg.MagicLinkTokenExp) * time.Minute
+	if err := s.actionTokenRepo.Create(&domain.ActionToken{
+		UserID:    user.ID,
+		Purpose:   domain.ActionTokenMagicLink,
+		TokenHash: tokenHash,
+		NonceHash: nonceHash,
+		ExpiresAt: now.Add(expiry),
+		CreatedAt: now,
+	}); err != nil {
+		return "", err
+	}
+
+	// Like for unknown emails, whether sending worked is not reported
+	_ = s.mailer.Send(mailer.Message{
+		To:      user.EmailAddress(),
+		Subject: fmt.Sprintf("Your %s login link", s.appCfg.Name),
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to log in to %s:\n\n%s/magic-link?token=%s\n\nOpen it on the device where you requested it. The link expires in %s and can only be used once. If you did not request it, you can ignore this email.\n",
+			user.DisplayName, s.appCfg.Name, s.appCfg.BaseURL, rawToken, formatMinutes(s.authCfg.MagicLinkTokenExp),
+		),
+	})
+	return nonce, nil
+}
+
+func (s *MagicLinkServiceImpl) Login(token, nonce string, client ClientInfo) (*domain.User, *TokenPair, error) {
+	stored, err := s.actionTokenRepo.GetByHash(domain.ActionTokenMagicLink, hashToken(token))
+	if err != nil || stored.UsedAt != nil {
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	now := time.Now()
+	if now.After(stored.ExpiresAt) {
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	// A link opened on another device is rejected without using it up, so
+	// the user can still open it on the device that asked for it
+	if subtle.ConstantTimeCompare([]byte(stored.NonceHash), []byte(hashToken(nonce))) != 1 {
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	marked, err := s.actionTokenRepo.MarkUsed(stored.ID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !marked {
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	user, err := s.userRepo.GetByID(stored.UserID)
+	if err != nil {
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	// Other login links sent before this one must not work anymore
+	if err := s.actionTokenRepo.InvalidateForUser(user.ID, domain.ActionTokenMagicLink); err != nil {
+		return nil, nil, err
+	}
+
+	// Following the link proves the user controls the address
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+		user.UpdatedAt = now
+		if err := s.userRepo.Update(user); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return s.authService.LoginUser(user, client)
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/pkg/totp"
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeAlphabet = "0123456789abcdefghjkmnpqrstvwxyz" // Crockford base32
+	// Accept codes from one period before or after the current one
+	totpSkew = 1
+	// Codes a user may try per hour when turning two-factor authentication
+	// on or off
+	mfaCodeAttemptLimit = 5
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication is not set up")
+	ErrInvalidMFACode    = errors.New("invalid authentication code")
+)
+
+// MFAEnrollment is returned when a user starts setting up an authenticator.
+type MFAEnrollment struct {
+	Secret string
+	URI    string
+}
+
+type MFAService interface {
+	Enroll(userID uuid.UUID) (*MFAEnrollment, error)
+	// Confirm and Disable take the user's current password as well as a
+	// code, so a stolen access token alone cannot change how they log in.
+	Confirm(userID uuid.UUID, password, code string) ([]string, error)
+	Disable(userID uuid.UUID, password, code string) error
+	IsEnabled(userID uuid.UUID) (bool, error)
+	// VerifyCode accepts either a current TOTP code or an unused recovery code.
+	VerifyCode(userID uuid.UUID, code string) error
+}
+
+type MFAServiceImpl struct {
+	userRepo      repository.UserRepository
+	mfaRepo       repository.MFARepository
+	rateLimitRepo repository.RateLimitRepository
+	hasher        PasswordHasher
+	appCfg        config.AppConfig
+}
+
+func NewMFAService(
+	userRepo repository.UserRepository,
+	mfaRepo repository.MFARepository,
+	rateLimitRepo repository.RateLimitRepository,
+	hasher PasswordHasher,
+	appCfg config.AppConfig,
+) MFAService {
+	return &MFAServiceImpl{
+		userRepo:      userRepo,
+		mfaRepo:       mfaRepo,
+		rateLimitRepo: rateLimitRepo,
+		hasher:        hasher,
+		appCfg:        appCfg,
+	}
+}
+
+// Enroll creates a new, unconfirmed TOTP secret. Enrolling again before
+// confirming replaces the previous secret.
+func (s *MFAServiceImpl) Enroll(userID uuid.UUID) (*MFAEnrollment, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.mfaRepo.GetFactor(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if existing != nil && existing.IsConfirmed() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.mfaRepo.SaveFactor(&domain.MFAFactor{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}); err != nil {
+		return nil, err
+	}
+
+	// Authenticator apps show the account name next to the code; guests
+	// have no email address to show
+	accountName := user.EmailAddress()
+	if accountName == "" {
+		accountName = user.DisplayName
+	}
+
+	return &MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.appCfg.Name, accountName, secret),
+	}, nil
+}
+
+// Confirm enables two-factor authentication once the user proves their
+// authenticator produces valid codes, and returns fresh recovery codes.
+func (s *MFAServiceImpl) Confirm(userID uuid.UUID, password, code string) ([]string, error) {
+	factor, err := s.mfaRepo.GetFactor(userID)
+	if err != nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if factor.IsConfirmed() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if err := s.confirmUser(userID, password); err != nil {
+		return nil, err
+	}
+
+	step, ok := totp.Validate(factor.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	now := time.Now()
+	factor.ConfirmedAt = &now
+	factor.LastUsedStep = step
+	factor.UpdatedAt = now
+	if err := s.mfaRepo.SaveFactor(factor); err != nil {
+		return nil, err
+	}
+
+	return s.regenerateRecoveryCodes(userID)
+}
+
+func (s *MFAServiceImpl) Disable(userID uuid.UUID, password, code string) error {
+	if err := s.confirmUser(userID, password); err != nil {
+		return err
+	}
+	if err := s.VerifyCode(userID, code); err != nil {
+		return err
+	}
+	return s.mfaRepo.DeleteFactor(userID)
+}
+
+// confirmUser checks the user's password and counts an attempt at a code,
+// so codes cannot be guessed until one fits.
+func (s *MFAServiceImpl) confirmUser(userID uuid.UUID, password string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := confirmPassword(s.rateLimitRepo, s.hasher, user, password); err != nil {
+		return err
+	}
+
+	allowed, retryAfter, err := s.rateLimitRepo.Allow("mfa-code:"+userID.String(), mfaCodeAttemptLimit, time.Hour)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return &RateLimitError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+func (s *MFAServiceImpl) IsEnabled(userID uuid.UUID) (bool, error) {
+	factor, err := s.mfaRepo.GetFactor(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return factor.IsConfirmed(), nil
+}
+
+func (s *MFAServiceImpl) VerifyCode(userID uuid.UUID, code string) error {
+	factor, err := s.mfaRepo.GetFactor(userID)
+	if err != nil || !factor.IsConfirmed() {
+		return ErrMFANotEnrolled
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := totp.Validate(factor.Secret, code, time.Now(), totpSkew); ok {
+		// Each code may only be used once
+		advanced, err := s.mfaRepo.AdvanceStep(userID, step)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.mfaRepo.UseRecoveryCode(userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAServiceImpl) regenerateRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]domain.RecoveryCode, 0, recoveryCodeCount)
+	now := time.Now()
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, domain.RecoveryCode{
+			UserID:    userID,
+			CodeHash:  hashToken(normalizeRecoveryCode(code)),
+			CreatedAt: now,
+		})
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(userID, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode returns a code formatted as xxxxx-xxxxx.
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for i, b := range buf {
+		if i == 5 {
+			sb.WriteByte('-')
+		}
+		sb.WriteByte(recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+	}
+	return sb.String(), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"gorm.io/gorm"
+)
+
+const maxEquipmentNameLength = 100
+
+var (
+	ErrUnknownOnboardingStep    = errors.New("unknown onboarding step")
+	ErrOnboardingStepOutOfOrder = errors.New("onboarding steps must be answered in order")
+	ErrOnboardingComplete       = errors.New("onboarding is already complete")
+	ErrInvalidOnboardingAnswers = errors.New("invalid onboarding answers")
+)
+
+// Answers to each step. Unknown fields are rejected.
+type (
+	brewMethodsAnswers struct {
+		Methods []string `json:"methods"`
+	}
+	beanTypesAnswers struct {
+		RoastLevels []string `json:"roastLevels"`
+	}
+	unitsAnswers struct {
+		Units    json.RawMessage `json:"units"`
+		Timezone *string         `json:"timezone"`
+	}
+	equipmentAnswers struct {
+		Grinder string `json:"grinder"`
+		Kettle  string `json:"kettle"`
+		Scale   string `json:"scale"`
+	}
+)
+
+type OnboardingService interface {
+	// GetOnboarding returns the user's progress, starting the questionnaire
+	// on the first call.
+	GetOnboarding(userID uuid.UUID) (*domain.Onboarding, error)
+	// AnswerStep applies the answers to the current step and moves on to the
+	// next one.
+	AnswerStep(userID uuid.UUID, step domain.OnboardingStep, answers json.RawMessage) (*domain.Onboarding, error)
+	SkipStep(userID uuid.UUID, step domain.OnboardingStep) (*domain.Onboarding, error)
+	// SkipRemaining skips every step not answered yet, finishing onboarding.
+	SkipRemaining(userID uuid.UUID) (*domain.Onboarding, error)
+}
+
+type OnboardingServiceImpl struct {
+	onboardingRepo repository.OnboardingRepository
+	userService    UserService
+}
+
+func NewOnboardingService(
+	onboardingRepo repository.OnboardingRepository,
+	userService UserService,
+) OnboardingService {
+	return &OnboardingServiceImpl{
+		onboardingRepo: onboardingRepo,
+		userService:    userService,
+	}
+}
+
+func (s *OnboardingServiceImpl) GetOnboarding(userID uuid.UUID) (*domain.Onboarding, error) {
+	onboarding, err := s.onboardingRepo.GetByUserID(userID)
+	if err == nil {
+		return onboarding, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	onboarding = &domain.Onboarding{
+		UserID:         userID,
+		CurrentStep:    domain.OnboardingSteps[0],
+		CompletedSteps: []domain.OnboardingStep{},
+		SkippedSteps:   []domain.OnboardingStep{},
+		Answers:        map[string]json.RawMessage{},
+		StartedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.onboardingRepo.Save(onboarding); err != nil {
+		return nil, err
+	}
+	return onboarding, nil
+}
+
+func (s *OnboardingServiceImpl) AnswerStep(userID uuid.UUID, step domain.OnboardingStep, answers json.RawMessage) (*domain.Onboarding, error) {
+	onboarding, err := s.currentStep(userID, step)
+	if err != nil {
+		return nil, err
+	}
+
+	// Preferences are patched right away, which is harmless to repeat;
+	// equipment is only added when the step is saved
+	var equipment []domain.Equipment
+	switch step {
+	case domain.OnboardingBrewMethods:
+		err = s.answerBrewMethods(userID, answers)
+	case domain.OnboardingBeanTypes:
+		err = s.answerBeanTypes(userID, answers)
+	case domain.OnboardingUnits:
+		err = s.answerUnits(userID, answers)
+	case domain.OnboardingEquipment:
+		equipment, err = s.answerEquipment(userID, answers)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	onboarding.Answers[string(step)] = answers
+	onboarding.CompletedSteps = append(onboarding.CompletedSteps, step)
+	return s.advance(onboarding, stepIndex(step)+1, equipment)
+}
+
+func (s *OnboardingServiceImpl) SkipStep(userID uuid.UUID, step domain.OnboardingStep) (*domain.Onboarding, error) {
+	onboarding, err := s.currentStep(userID, step)
+	if err != nil {
+		return nil, err
+	}
+	onboarding.SkippedSteps = append(onboarding.SkippedSteps, step)
+	return s.advance(onboarding, stepIndex(step)+1, nil)
+}
+
+func (s *OnboardingServiceImpl) SkipRemaining(userID uuid.UUID) (*domain.Onboarding, error) {
+	onboarding, err := s.GetOnboarding(userID)
+	if err != nil {
+		return nil, err
+	}
+	if onboarding.IsComplete() {
+		return nil, ErrOnboardingComplete
+	}
+
+	for i := stepIndex(onboarding.CurrentStep); i < len(domain.OnboardingSteps); i++ {
+		onboarding.SkippedSteps = append(onboarding.SkippedSteps, domain.OnboardingSteps[i])
+	}
+	return s.advance(onboarding, len(domain.OnboardingSteps), nil)
+}
+
+// currentStep loads the user's progress and checks that step is the one to
+// answer next.
+func (s *OnboardingServiceImpl) currentStep(userID uuid.UUID, step domain.OnboardingStep) (*domain.Onboarding, error) {
+	if stepIndex(step) < 0 {
+		return nil, ErrUnknownOnboardingStep
+	}
+	onboarding, err := s.GetOnboarding(userID)
+	if err != nil {
+		return nil, err
+	}
+	if onboarding.IsComplete() {
+		return nil, ErrOnboardingComplete
+	}
+	if onboarding.CurrentStep != step {
+		return nil, ErrOnboardingStepOutOfOrder
+	}
+	return onboarding, nil
+}
+
+// advance moves on from the current step to the one at index next,
+// finishing onboarding past the last, and saves the equipment the answer
+// adds with it. Answers to a step that was moved on from meanwhile are
+// rejected, so equipment is never added twice.
+func (s *OnboardingServiceImpl) advance(onboarding *domain.Onboarding, next int, equipment []domain.Equipment) (*domain.Onboarding, error) {
+	step := onboarding.CurrentStep
+	now := time.Now()
+	if next < len(domain.OnboardingSteps) {
+		onboarding.CurrentStep = domain.OnboardingSteps[next]
+	} else {
+		onboarding.CurrentStep = ""
+		onboarding.CompletedAt = &now
+	}
+	onboarding.UpdatedAt = now
+
+	advanced, err := s.onboardingRepo.Advance(onboarding, step, equipment)
+	if err != nil {
+		return nil, err
+	}
+	if !advanced {
+		return nil, ErrOnboardingStepOutOfOrder
+	}
+	return onboarding, nil
+}
+
+func (s *OnboardingServiceImpl) answerBrewMethods(userID uuid.UUID, raw json.RawMessage) error {
+	var answers brewMethodsAnswers
+	if err := decodeAnswers(raw, &answers); err != nil {
+		return err
+	}
+	methods := make([]string, 0, len(answers.Methods))
+	for _, method := range answers.Methods {
+		methods = append(methods, strings.ToLower(strings.TrimSpace(method)))
+	}
+	if len(methods) == 0 {
+		return fmt.Errorf("%w: choose at least one brew method or skip the step", ErrInvalidOnboardingAnswers)
+	}
+
+	return s.updatePreferences(userID, map[string]interface{}{
+		"favoriteBrewMethods": methods,
+		"defaultBrewMethod":   methods[0],
+	})
+}
+
+func (s *OnboardingServiceImpl) answerBeanTypes(userID uuid.UUID, raw json.RawMessage) error {
+	var answers beanTypesAnswers
+	if err := decodeAnswers(raw, &answers); err != nil {
+		return err
+	}
+	if len(answers.RoastLevels) == 0 {
+		return fmt.Errorf("%w: choose at least one roast level or skip the step", ErrInvalidOnboardingAnswers)
+	}
+
+	return s.updatePreferences(userID, map[string]interface{}{
+		"favoriteRoastLevels": answers.RoastLevels,
+	})
+}
+
+func (s *OnboardingServiceImpl) answerUnits(userID uuid.UUID, raw json.RawMessage) error {
+	var answers unitsAnswers
+	if err := decodeAnswers(raw, &answers); err != nil {
+		return err
+	}
+
+	patch := map[string]interface{}{}
+	if answers.Units != nil {
+		patch["units"] = answers.Units
+	}
+	if answers.Timezone != nil {
+		patch["timezone"] = *answers.Timezone
+	}
+	return s.updatePreferences(userID, patch)
+}
+
+// answerEquipment returns a brewer for every favorite brew method and the
+// gear the user named, to be added to their equipment.
+func (s *OnboardingServiceImpl) answerEquipment(userID uuid.UUID, raw json.RawMessage) ([]domain.Equipment, error) {
+	var answers equipmentAnswers
+	if err := decodeAnswers(raw, &answers); err != nil {
+		return nil, err
+	}
+	named := []struct {
+		kind domain.EquipmentKind
+		name string
+	}{
+		{domain.EquipmentGrinder, strings.TrimSpace(answers.Grinder)},
+		{domain.EquipmentKettle, strings.TrimSpace(answers.Kettle)},
+		{domain.EquipmentScale, strings.TrimSpace(answers.Scale)},
+	}
+	for _, item := range named {
+		if utf8.RuneCountInString(item.name) > maxEquipmentNameLength {
+			return nil, fmt.Errorf("%w: %s name must be at most %d characters", ErrInvalidOnboardingAnswers, item.kind, maxEquipmentNameLength)
+		}
+	}
+
+	preferences, err := s.userService.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	if named[0].name != "" {
+		if err := s.updatePreferences(userID, map[string]interface{}{"defaultGrinder": named[0].name}); err != nil {
+			return nil, err
+		}
+	}
+
+	var items []domain.Equipment
+	for _, method := range preferences.FavoriteBrewMethods {
+		items = append(items, domain.Equipment{Kind: domain.EquipmentBrewer, Name: brewMethodName(method)})
+	}
+	for _, item := range named {
+		if item.name != "" {
+			items = append(items, domain.Equipment{Kind: item.kind, Name: item.name})
+		}
+	}
+
+	now := time.Now()
+	for i := range items {
+		items[i].UserID = userID
+		items[i].CreatedAt = now
+	}
+	return items, nil
+}
+
+func (s *OnboardingServiceImpl) updatePreferences(userID uuid.UUID, patch map[string]interface{}) error {
+	raw, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = s.userService.UpdatePreferences(userID, raw)
+	return err
+}
+
+// decodeAnswers decodes a step's answers, rejecting fields the step does not
+// ask for.
+func decodeAnswers(raw json.RawMessage, answers interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(answers); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidOnboardingAnswers, err)
+	}
+	return nil
+}
+
+func stepIndex(step domain.OnboardingStep) int {
+	for i, s := range domain.OnboardingSteps {
+		if s == step {
+			return i
+		}
+	}
+	return -1
+}
+
+// brewMethodName turns a brew method slug into a name for the brewer, e.g.
+// "french-press" into "French Press".
+func brewMethodName(method string) string {
+	words := strings.Split(method, "-")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
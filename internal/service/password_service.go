@@ -0,0 +1,184 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/pkg/mailer"
+	"github.com/yashkadam007/brewkar/pkg/passwords"
+)
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	// ErrWeakPassword wraps the password policy violation explaining why a
+	// new password was rejected.
+	ErrWeakPassword = errors.New("password not accepted")
+)
+
+// PasswordHasher hashes new passwords and verifies stored hashes, which may
+// use an older algorithm. *passwords.Hasher implements it.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether password matches encoded and whether encoded
+	// should be replaced by a fresh hash.
+	Verify(password, encoded string) (match, needsRehash bool, err error)
+}
+
+type PasswordService interface {
+	ForgotPassword(email string) error
+	ResetPassword(token, newPassword string) error
+}
+
+type PasswordServiceImpl struct {
+	userRepo        repository.UserRepository
+	actionTokenRepo repository.ActionTokenRepository
+	rateLimitRepo   repository.RateLimitRepository
+	loginThrottle   *loginThrottle
+	authService     AuthService
+	hasher          PasswordHasher
+	policy          *passwords.Policy
+	mailer          mailer.Mailer
+	appCfg          config.AppConfig
+	authCfg         config.AuthConfig
+}
+
+func NewPasswordService(
+	userRepo repository.UserRepository,
+	actionTokenRepo repository.ActionTokenRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	authService AuthService,
+	hasher PasswordHasher,
+	policy *passwords.Policy,
+	m mailer.Mailer,
+	appCfg config.AppConfig,
+	authCfg config.AuthConfig,
+) PasswordService {
+	return &PasswordServiceImpl{
+		userRepo:        userRepo,
+		actionTokenRepo: actionTokenRepo,
+		rateLimitRepo:   rateLimitRepo,
+		loginThrottle:   &loginThrottle{repo: loginAttemptRepo, cfg: authCfg},
+		authService:     authService,
+		hasher:          hasher,
+		policy:          policy,
+		mailer:          m,
+		appCfg:          appCfg,
+		authCfg:         authCfg,
+	}
+}
+
+// ForgotPassword emails a reset link if an account exists for the email. It
+// reports success for unknown emails so accounts cannot be enumerated, and
+// for the same reason does not report whether sending worked.
+func (s *PasswordServiceImpl) ForgotPassword(email string) error {
+	// Throttle by email, whether or not an account exists for it
+	allowed, retryAfter, err := s.rateLimitRepo.Allow("forgot-password:"+strings.ToLower(email), s.authCfg.EmailSendLimit, time.Hour)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return &RateLimitError{RetryAfter: retryAfter}
+	}
+
+	user, err := s.userRepo.GetByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	rawToken, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expiry := time.Duration(s.authCfg.PasswordResetTokenExp) * time.Minute
+	if err := s.actionTokenRepo.Create(&domain.ActionToken{
+		UserID:    user.ID,
+		Purpose:   domain.ActionTokenPasswordReset,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(expiry),
+		CreatedAt: now,
+	}); err != nil {
+		return err
+	}
+
+	_ = s.mailer.Send(mailer.Message{
+		To:      user.EmailAddress(),
+		Subject: fmt.Sprintf("Reset your %s password", s.appCfg.Name),
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nWe received a request to reset your password. Use the link below to choose a new one:\n\n%s/reset-password?token=%s\n\nThe link expires in %s and can only be used once. If you did not request a reset, you can ignore this email.\n",
+			user.DisplayName, s.appCfg.BaseURL, rawToken, formatMinutes(s.authCfg.PasswordResetTokenExp),
+		),
+	})
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token, lifts any login
+// lockout and signs the user out everywhere.
+func (s *PasswordServiceImpl) ResetPassword(token, newPassword string) error {
+	// Check the password first so a rejected one does not use up the token
+	if err := validatePassword(s.policy, newPassword); err != nil {
+		return err
+	}
+
+	stored, err := s.actionTokenRepo.GetByHash(domain.ActionTokenPasswordReset, hashToken(token))
+	if err != nil || stored.UsedAt != nil {
+		return ErrInvalidResetToken
+	}
+
+	now := time.Now()
+	if now.After(stored.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	marked, err := s.actionTokenRepo.MarkUsed(stored.ID, now)
+	if err != nil {
+		return err
+	}
+	if !marked {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.userRepo.GetByID(stored.UserID)
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = hashedPassword
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	// Other reset links sent before this one must not work anymore
+	if err := s.actionTokenRepo.InvalidateForUser(user.ID, domain.ActionTokenPasswordReset); err != nil {
+		return err
+	}
+
+	// Whoever holds the reset link controls the account, so there is no
+	// reason to keep it locked
+	if err := s.loginThrottle.reset(user.EmailAddress()); err != nil {
+		return err
+	}
+
+	return s.authService.LogoutAll(user.ID)
+}
+
+// validatePassword applies the password policy to a password a user chose.
+func validatePassword(policy *passwords.Policy, password string) error {
+	if err := policy.Validate(password); err != nil {
+		return fmt.Errorf("%w: %v", ErrWeakPassword, err)
+	}
+	return nil
+}
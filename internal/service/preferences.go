@@ -0,0 +1,117 @@
+package service
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/yashkadam007/brewkar/internal/domain"
+)
+
+//go:embed preferences.schema.json
+var preferencesSchemaJSON string
+
+// preferencesSchema is the JSON schema every preferences change is validated
+// against
+var preferencesSchema = jsonschema.MustCompileString("preferences.schema.json", preferencesSchemaJSON)
+
+// applyPreferencesPatch applies a JSON Merge Patch (RFC 7386) to base and
+// validates the result. Settings the patch removes with null go back to their
+// defaults.
+func applyPreferencesPatch(base domain.Preferences, patch json.RawMessage) (domain.Preferences, error) {
+	patchDoc, err := decodeJSON(patch)
+	if err != nil {
+		return domain.Preferences{}, fmt.Errorf("%w: not valid JSON", ErrInvalidPreferences)
+	}
+	if _, ok := patchDoc.(map[string]interface{}); !ok {
+		return domain.Preferences{}, fmt.Errorf("%w: must be a JSON object", ErrInvalidPreferences)
+	}
+
+	current, err := json.Marshal(base)
+	if err != nil {
+		return domain.Preferences{}, err
+	}
+	doc, err := decodeJSON(current)
+	if err != nil {
+		return domain.Preferences{}, err
+	}
+
+	merged := mergePatch(doc, patchDoc)
+	if err := preferencesSchema.Validate(merged); err != nil {
+		var validationErr *jsonschema.ValidationError
+		if errors.As(err, &validationErr) {
+			return domain.Preferences{}, fmt.Errorf("%w: %s", ErrInvalidPreferences, describeValidationError(validationErr))
+		}
+		return domain.Preferences{}, err
+	}
+
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return domain.Preferences{}, err
+	}
+	prefs := domain.DefaultPreferences()
+	if err := json.Unmarshal(raw, &prefs); err != nil {
+		return domain.Preferences{}, fmt.Errorf("%w: %v", ErrInvalidPreferences, err)
+	}
+	prefs.Version = domain.PreferencesVersion
+
+	// The schema cannot tell real time zones from made-up ones
+	if _, err := time.LoadLocation(prefs.Timezone); err != nil || prefs.Timezone == "Local" {
+		return domain.Preferences{}, fmt.Errorf("%w: /timezone: unknown time zone %q", ErrInvalidPreferences, prefs.Timezone)
+	}
+	return prefs, nil
+}
+
+// mergePatch implements the MergePatch function of RFC 7386.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for name, value := range patchObj {
+		if value == nil {
+			delete(targetObj, name)
+			continue
+		}
+		targetObj[name] = mergePatch(targetObj[name], value)
+	}
+	return targetObj
+}
+
+// decodeJSON decodes a single JSON value the way the schema validator expects.
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+// describeValidationError reports the first failing setting, e.g.
+// "/units/weight: value must be one of "g", "oz"".
+func describeValidationError(err *jsonschema.ValidationError) string {
+	leaf := err
+	for len(leaf.Causes) > 0 {
+		leaf = leaf.Causes[0]
+	}
+	location := leaf.InstanceLocation
+	if location == "" {
+		location = "/"
+	}
+	return location + ": " + strings.TrimSpace(leaf.Message)
+}
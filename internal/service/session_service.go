@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+)
+
+// Longest device name kept from the client
+const maxDeviceNameLength = 100
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type SessionService interface {
+	CreateSession(userID uuid.UUID, client ClientInfo) (*domain.Session, error)
+	// TouchSession records that the session was just used from client.
+	TouchSession(sessionID uuid.UUID, client ClientInfo) error
+	ListSessions(userID uuid.UUID) ([]domain.Session, error)
+	// RevokeSession signs the device out: its refresh tokens stop working
+	// and its access tokens are rejected by AuthMiddleware.
+	RevokeSession(userID, sessionID uuid.UUID) error
+	RevokeAllSessions(userID uuid.UUID) error
+}
+
+type SessionServiceImpl struct {
+	sessionRepo      repository.SessionRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	revocationRepo   repository.TokenRevocationRepository
+	jwtCfg           config.JWTConfig
+}
+
+func NewSessionService(
+	sessionRepo repository.SessionRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	revocationRepo repository.TokenRevocationRepository,
+	jwtCfg config.JWTConfig,
+) SessionService {
+	return &SessionServiceImpl{
+		sessionRepo:      sessionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		revocationRepo:   revocationRepo,
+		jwtCfg:           jwtCfg,
+	}
+}
+
+func (s *SessionServiceImpl) CreateSession(userID uuid.UUID, client ClientInfo) (*domain.Session, error) {
+	deviceName := client.DeviceName
+	if len(deviceName) > maxDeviceNameLength {
+		deviceName = deviceName[:maxDeviceNameLength]
+	}
+
+	now := time.Now()
+	session := &domain.Session{
+		ID:         uuid.New(),
+		UserID:     userID,
+		DeviceName: deviceName,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *SessionServiceImpl) TouchSession(sessionID uuid.UUID, client ClientInfo) error {
+	return s.sessionRepo.Touch(sessionID, client.IP, time.Now())
+}
+
+func (s *SessionServiceImpl) ListSessions(userID uuid.UUID) ([]domain.Session, error) {
+	return s.sessionRepo.ListActiveForUser(userID)
+}
+
+func (s *SessionServiceImpl) RevokeSession(userID, sessionID uuid.UUID) error {
+	session, err := s.sessionRepo.GetByID(sessionID)
+	if err != nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	revoked, err := s.sessionRepo.Revoke(sessionID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+
+	if err := s.refreshTokenRepo.RevokeSession(sessionID); err != nil {
+		return err
+	}
+
+	// Access tokens of the session stay valid until they expire unless
+	// AuthMiddleware knows about the revocation
+	return s.revocationRepo.RevokeSession(sessionID, s.accessTokenTTL())
+}
+
+// RevokeAllSessions signs the user out everywhere.
+func (s *SessionServiceImpl) RevokeAllSessions(userID uuid.UUID) error {
+	now := time.Now()
+	if err := s.sessionRepo.RevokeAllForUser(userID, now); err != nil {
+		return err
+	}
+	if err := s.refreshTokenRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	return s.revocationRepo.RevokeUserTokens(userID, now, s.accessTokenTTL())
+}
+
+func (s *SessionServiceImpl) accessTokenTTL() time.Duration {
+	return time.Duration(s.jwtCfg.AccessTokenExp) * time.Minute
+}
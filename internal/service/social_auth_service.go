@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/pkg/oidc"
+)
+
+// Longest display name taken over from a provider profile
+const maxProviderDisplayNameLength = 50
+
+var (
+	ErrUnknownProvider       = errors.New("unknown identity provider")
+	ErrInvalidOAuthState     = errors.New("invalid or expired authorization state")
+	ErrOAuthFailed           = errors.New("could not sign in with the identity provider")
+	ErrProviderEmailMissing  = errors.New("the identity provider did not share an email address")
+	ErrIdentityAlreadyLinked = errors.New("this identity is already linked to another account")
+	// ErrAccountExists is returned when the provider's email belongs to an
+	// account that cannot be linked automatically. The user has to log in and
+	// link the identity from their account.
+	ErrAccountExists = errors.New("an account with this email already exists; log in to link this identity")
+)
+
+// IdentityProvider is an external login provider using the authorization code
+// flow with PKCE. *oidc.Provider implements it.
+type IdentityProvider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, redirectURI, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, redirectURI, code, codeVerifier, nonce string) (*oidc.Identity, error)
+}
+
+type SocialAuthService interface {
+	// StartLogin returns the provider URL to send the user to and a binding
+	// value the client keeps for CompleteLogin.
+	StartLogin(provider string) (string, string, error)
+	// CompleteLogin finishes a login started with StartLogin by the client
+	// holding binding. Unknown identities sign up a new user, or are linked
+	// to the account with the same email when both sides have verified it.
+	CompleteLogin(provider, state, binding, code string, client ClientInfo) (*domain.User, *TokenPair, error)
+	// StartLink is StartLogin for a signed-in user adding an identity.
+	StartLink(provider string, userID uuid.UUID) (string, error)
+	CompleteLink(provider string, userID uuid.UUID, state, code string) (*domain.UserIdentity, error)
+	ListIdentities(userID uuid.UUID) ([]domain.UserIdentity, error)
+}
+
+type SocialAuthServiceImpl struct {
+	userRepo        repository.UserRepository
+	identityRepo    repository.UserIdentityRepository
+	stateRepo       repository.OAuthStateRepository
+	authService     AuthService
+	verificationSvc EmailVerificationService
+	providers       map[string]IdentityProvider
+	appCfg          config.AppConfig
+	oauthCfg        config.OAuthConfig
+}
+
+func NewSocialAuthService(
+	userRepo repository.UserRepository,
+	identityRepo repository.UserIdentityRepository,
+	stateRepo repository.OAuthStateRepository,
+	authService AuthService,
+	verificationSvc EmailVerificationService,
+	providers []IdentityProvider,
+	appCfg config.AppConfig,
+	oauthCfg config.OAuthConfig,
+) SocialAuthService {
+	byName := make(map[string]IdentityProvider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+	return &SocialAuthServiceImpl{
+		userRepo:        userRepo,
+		identityRepo:    identityRepo,
+		stateRepo:       stateRepo,
+		authService:     authService,
+		verificationSvc: verificationSvc,
+		providers:       byName,
+		appCfg:          appCfg,
+		oauthCfg:        oauthCfg,
+	}
+}
+
+func (s *SocialAuthServiceImpl) StartLogin(provider string) (string, string, error) {
+	// Without the binding, anyone could send a victim the callback of a
+	// login they started and sign the victim in to the attacker's account
+	binding, bindingHash, err := generateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	authURL, err := s.start(provider, bindingHash, uuid.Nil)
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, binding, nil
+}
+
+func (s *SocialAuthServiceImpl) StartLink(provider string, userID uuid.UUID) (string, error) {
+	return s.start(provider, "", userID)
+}
+
+func (s *SocialAuthServiceImpl) CompleteLogin(provider, state, binding, code string, client ClientInfo) (*domain.User, *TokenPair, error) {
+	identity, err := s.finish(provider, state, binding, code, uuid.Nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existing, err := s.identityRepo.GetByProviderSubject(provider, identity.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetByID(existing.UserID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return s.authService.LoginUser(user, client)
+	}
+
+	if identity.Email == "" {
+		return nil, nil, ErrProviderEmailMissing
+	}
+
+	user, err := s.userRepo.GetByEmail(identity.Email)
+	if err == nil {
+		// Linking on email alone is only safe when both the provider and we
+		// know the address belongs to the person. Otherwise whoever
+		// registered the address first could take over the other account.
+		if !identity.EmailVerified || !user.IsEmailVerified() {
+			return nil, nil, ErrAccountExists
+		}
+		if err := s.link(provider, identity, user.ID); err != nil {
+			return nil, nil, err
+		}
+		return s.authService.LoginUser(user, client)
+	}
+
+	user, err = s.signUp(provider, identity)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.authService.LoginUser(user, client)
+}
+
+func (s *SocialAuthServiceImpl) CompleteLink(provider string, userID uuid.UUID, state, code string) (*domain.UserIdentity, error) {
+	identity, err := s.finish(provider, state, "", code, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.identityRepo.GetByProviderSubject(provider, identity.Subject)
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, ErrIdentityAlreadyLinked
+		}
+		return existing, nil
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	// Linking an identity to a guest account turns it into a full account
+	if user.IsGuest {
+		if err := s.upgradeGuest(user, identity); err != nil {
+			return nil, err
+		}
+	}
+
+	linked := &domain.UserIdentity{
+		UserID:    userID,
+		Provider:  provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: time.Now(),
+	}
+	if err := s.identityRepo.Create(linked); err != nil {
+		return nil, err
+	}
+	return linked, nil
+}
+
+func (s *SocialAuthServiceImpl) ListIdentities(userID uuid.UUID) ([]domain.UserIdentity, error) {
+	return s.identityRepo.ListForUser(userID)
+}
+
+// start remembers a new authorization request and returns the provider URL
+// for it. For logins bindingHash is the hash of the client's binding value and
+// linkUserID is uuid.Nil.
+func (s *SocialAuthServiceImpl) start(provider, bindingHash string, linkUserID uuid.UUID) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
+	state, err := oidc.GenerateVerifier()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.GenerateVerifier()
+	if err != nil {
+		return "", err
+	}
+	codeVerifier, err := oidc.GenerateVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := p.AuthCodeURL(context.Background(), s.redirectURI(provider), state, nonce, oidc.ChallengeS256(codeVerifier))
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.stateRepo.Save(state, &repository.OAuthState{
+		Provider:     provider,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		BindingHash:  bindingHash,
+		LinkUserID:   linkUserID,
+	}, s.stateTTL()); err != nil {
+		return "", err
+	}
+
+	return authURL, nil
+}
+
+// finish consumes the state of an authorization request and exchanges the
+// code for the provider identity. The state must have been started for the
+// same provider and, for logins, by the client holding binding or, for links,
+// by the same user.
+func (s *SocialAuthServiceImpl) finish(provider, state, binding, code string, linkUserID uuid.UUID) (*oidc.Identity, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	stored, err := s.stateRepo.Consume(state)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || stored.Provider != provider || stored.LinkUserID != linkUserID {
+		return nil, ErrInvalidOAuthState
+	}
+	if linkUserID == uuid.Nil && subtle.ConstantTimeCompare([]byte(stored.BindingHash), []byte(hashToken(binding))) != 1 {
+		return nil, ErrInvalidOAuthState
+	}
+
+	identity, err := p.Exchange(context.Background(), s.redirectURI(provider), code, stored.CodeVerifier, stored.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOAuthFailed, err)
+	}
+	return identity, nil
+}
+
+func (s *SocialAuthServiceImpl) link(provider string, identity *oidc.Identity, userID uuid.UUID) error {
+	return s.identityRepo.Create(&domain.UserIdentity{
+		UserID:    userID,
+		Provider:  provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: time.Now(),
+	})
+}
+
+// signUp creates a user for a new provider identity. The user has no password
+// until they set one through the password reset flow.
+func (s *SocialAuthServiceImpl) signUp(provider string, identity *oidc.Identity) (*domain.User, error) {
+	now := time.Now()
+	user := &domain.User{
+		Email:       &identity.Email,
+		DisplayName: providerDisplayName(identity),
+		Role:        domain.RoleUser,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if identity.EmailVerified {
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	if err := s.link(provider, identity, user.ID); err != nil {
+		return nil, err
+	}
+
+	if !user.IsEmailVerified() {
+		// As in Register, a failed send must not fail the sign-up
+		_ = s.verificationSvc.SendVerification(user)
+	}
+
+	return user, nil
+}
+
+// upgradeGuest gives a guest the email address of the identity being linked.
+func (s *SocialAuthServiceImpl) upgradeGuest(user *domain.User, identity *oidc.Identity) error {
+	if identity.Email == "" {
+		return ErrProviderEmailMissing
+	}
+	if _, err := s.userRepo.GetByEmail(identity.Email); err == nil {
+		return ErrAccountExists
+	}
+
+	now := time.Now()
+	email := identity.Email
+	user.Email = &email
+	user.IsGuest = false
+	if identity.EmailVerified {
+		user.EmailVerifiedAt = &now
+	}
+	if user.DisplayName == guestDisplayName {
+		user.DisplayName = providerDisplayName(identity)
+	}
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	if !user.IsEmailVerified() {
+		_ = s.verificationSvc.SendVerification(user)
+	}
+	return nil
+}
+
+func (s *SocialAuthServiceImpl) redirectURI(provider string) string {
+	return strings.TrimSuffix(s.appCfg.BaseURL, "/") + "/oauth/" + provider + "/callback"
+}
+
+func (s *SocialAuthServiceImpl) stateTTL() time.Duration {
+	return time.Duration(s.oauthCfg.StateExp) * time.Minute
+}
+
+// providerDisplayName picks a display name from the provider profile, falling
+// back to the local part of the email address.
+func providerDisplayName(identity *oidc.Identity) string {
+	name := strings.TrimSpace(identity.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+	if utf8.RuneCountInString(name) > maxProviderDisplayNameLength {
+		name = string([]rune(name)[:maxProviderDisplayNameLength])
+	}
+	return name
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/pkg/mailer"
+	"github.com/yashkadam007/brewkar/pkg/passwords"
+	"github.com/yashkadam007/brewkar/pkg/storage"
+)
+
+// Limits of profile fields, as documented in docs/models.md
+const (
+	minDisplayNameLength = 3
+	maxDisplayNameLength = 50
+	maxBioLength         = 500
+)
+
+// Password checks allowed per user per hour when changing the email address
+// or password, so a stolen access token cannot be used to guess the password
+const passwordCheckLimit = 5
+
+// Image formats accepted for uploads, by sniffed content type, with their file
+// extension
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+var (
+	ErrInvalidDisplayName = fmt.Errorf("display name must be between %d and %d characters", minDisplayNameLength, maxDisplayNameLength)
+	ErrBioTooLong         = fmt.Errorf("bio must be at most %d characters", maxBioLength)
+	ErrInvalidPreferences = errors.New("invalid preferences")
+	ErrInvalidAvatar      = errors.New("avatar must be a JPEG, PNG or WebP image")
+	ErrAvatarTooLarge     = errors.New("avatar is too large")
+	ErrIncorrectPassword  = errors.New("current password is incorrect")
+)
+
+// ProfileUpdate holds the profile fields to change. Nil fields are left as
+// they are. Preferences, when given, replace all settings; settings left out
+// of them go back to their defaults.
+type ProfileUpdate struct {
+	DisplayName *string
+	Bio         *string
+	Preferences json.RawMessage
+}
+
+type UserService interface {
+	GetProfile(userID uuid.UUID) (*domain.User, error)
+	UpdateProfile(userID uuid.UUID, update ProfileUpdate) (*domain.User, error)
+	// UploadAvatar stores a new avatar image, replacing the previous one.
+	UploadAvatar(userID uuid.UUID, r io.Reader) (*domain.User, error)
+	RemoveAvatar(userID uuid.UUID) (*domain.User, error)
+	GetPreferences(userID uuid.UUID) (*domain.Preferences, error)
+	// UpdatePreferences applies a JSON Merge Patch to the user's preferences.
+	UpdatePreferences(userID uuid.UUID, patch json.RawMessage) (*domain.Preferences, error)
+	// ChangeEmail moves the account to a new address, which has to be
+	// verified again.
+	ChangeEmail(userID uuid.UUID, newEmail, currentPassword string) (*domain.User, error)
+	// ChangePassword sets a new password and signs out every session but
+	// the current one.
+	ChangePassword(userID, sessionID uuid.UUID, currentPassword, newPassword string) error
+}
+
+type UserServiceImpl struct {
+	userRepo        repository.UserRepository
+	actionTokenRepo repository.ActionTokenRepository
+	rateLimitRepo   repository.RateLimitRepository
+	verificationSvc EmailVerificationService
+	sessionService  SessionService
+	hasher          PasswordHasher
+	policy          *passwords.Policy
+	storage         storage.Storage
+	mailer          mailer.Mailer
+	appCfg          config.AppConfig
+	usersCfg        config.UsersConfig
+}
+
+func NewUserService(
+	userRepo repository.UserRepository,
+	actionTokenRepo repository.ActionTokenRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	verificationSvc EmailVerificationService,
+	sessionService SessionService,
+	hasher PasswordHasher,
+	policy *passwords.Policy,
+	store storage.Storage,
+	m mailer.Mailer,
+	appCfg config.AppConfig,
+	usersCfg config.UsersConfig,
+) UserService {
+	return &UserServiceImpl{
+		userRepo:        userRepo,
+		actionTokenRepo: actionTokenRepo,
+		rateLimitRepo:   rateLimitRepo,
+		verificationSvc: verificationSvc,
+		sessionService:  sessionService,
+		hasher:          hasher,
+		policy:          policy,
+		storage:         store,
+		mailer:          m,
+		appCfg:          appCfg,
+		usersCfg:        usersCfg,
+	}
+}
+
+func (s *UserServiceImpl) GetProfile(userID uuid.UUID) (*domain.User, error) {
+	return s.userRepo.GetByID(userID)
+}
+
+func (s *UserServiceImpl) UpdateProfile(userID uuid.UUID, update ProfileUpdate) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.DisplayName != nil {
+		displayName := strings.TrimSpace(*update.DisplayName)
+		if err := validateDisplayName(displayName); err != nil {
+			return nil, err
+		}
+		user.DisplayName = displayName
+	}
+	if update.Bio != nil {
+		bio := strings.TrimSpace(*update.Bio)
+		if utf8.RuneCountInString(bio) > maxBioLength {
+			return nil, ErrBioTooLong
+		}
+		user.Bio = bio
+	}
+	if update.Preferences != nil {
+		preferences, err := applyPreferencesPatch(domain.DefaultPreferences(), update.Preferences)
+		if err != nil {
+			return nil, err
+		}
+		user.Preferences = preferences
+	}
+
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *UserServiceImpl) UploadAvatar(userID uuid.UUID, r io.Reader) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read one byte more than allowed to tell whether the image is too large
+	data, err := io.ReadAll(io.LimitReader(r, int64(s.usersCfg.AvatarMaxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > s.usersCfg.AvatarMaxSize {
+		return nil, ErrAvatarTooLarge
+	}
+
+	// Trust the content, not the file name or the client's content type
+	contentType := http.DetectContentType(data)
+	ext, ok := imageTypes[contentType]
+	if !ok {
+		return nil, ErrInvalidAvatar
+	}
+
+	// A new key for every upload keeps caches from serving the old image
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	key := "avatars/" + user.ID.String() + "/" + hex.EncodeToString(suffix) + ext
+	url, err := s.storage.Put(key, bytes.NewReader(data), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	oldKey := user.AvatarKey
+	user.AvatarURL = url
+	user.AvatarKey = key
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		_ = s.storage.Delete(key)
+		return nil, err
+	}
+
+	// A leftover file is harmless, so failing to delete it is not an error
+	if oldKey != "" {
+		_ = s.storage.Delete(oldKey)
+	}
+	return user, nil
+}
+
+func (s *UserServiceImpl) RemoveAvatar(userID uuid.UUID) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.AvatarURL == "" {
+		return user, nil
+	}
+
+	oldKey := user.AvatarKey
+	user.AvatarURL = ""
+	user.AvatarKey = ""
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	if oldKey != "" {
+		_ = s.storage.Delete(oldKey)
+	}
+	return user, nil
+}
+
+func (s *UserServiceImpl) GetPreferences(userID uuid.UUID) (*domain.Preferences, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	preferences := user.Preferences.OrDefault()
+	return &preferences, nil
+}
+
+func (s *UserServiceImpl) UpdatePreferences(userID uuid.UUID, patch json.RawMessage) (*domain.Preferences, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences, err := applyPreferencesPatch(user.Preferences.OrDefault(), patch)
+	if err != nil {
+		return nil, err
+	}
+	user.Preferences = preferences
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	return &preferences, nil
+}
+
+func (s *UserServiceImpl) ChangeEmail(userID uuid.UUID, newEmail, currentPassword string) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsGuest {
+		return nil, ErrGuestAccount
+	}
+	if err := s.checkPassword(user, currentPassword); err != nil {
+		return nil, err
+	}
+
+	oldEmail := user.EmailAddress()
+	if strings.EqualFold(newEmail, oldEmail) {
+		return user, nil
+	}
+	if existing, err := s.userRepo.GetByEmail(newEmail); err == nil && existing != nil {
+		return nil, ErrEmailAlreadyInUse
+	}
+
+	now := time.Now()
+	user.Email = &newEmail
+	user.EmailVerifiedAt = nil
+	user.UpdatedAt = now
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	// Links mailed to the old address must not act on the new one
+	for _, purpose := range []string{domain.ActionTokenPasswordReset, domain.ActionTokenMagicLink} {
+		if err := s.actionTokenRepo.InvalidateForUser(user.ID, purpose); err != nil {
+			return nil, err
+		}
+	}
+
+	// A failed send must not undo the change; the user can ask for another
+	// verification link. The old address is told in case the change was not
+	// made by its owner.
+	_ = s.verificationSvc.SendVerification(user)
+	_ = s.mailer.Send(mailer.Message{
+		To:      oldEmail,
+		Subject: fmt.Sprintf("Your %s email address was changed", s.appCfg.Name),
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nThe email address of your %s account was changed to %s. If you did not make this change, please contact support right away.\n",
+			user.DisplayName, s.appCfg.Name, newEmail,
+		),
+	})
+
+	return user, nil
+}
+
+func (s *UserServiceImpl) ChangePassword(userID, sessionID uuid.UUID, currentPassword, newPassword string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.IsGuest {
+		return ErrGuestAccount
+	}
+	if err := s.checkPassword(user, currentPassword); err != nil {
+		return err
+	}
+	if err := validatePassword(s.policy, newPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hashedPassword
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	// Reset links sent before the change must not undo it
+	if err := s.actionTokenRepo.InvalidateForUser(user.ID, domain.ActionTokenPasswordReset); err != nil {
+		return err
+	}
+
+	sessions, err := s.sessionService.ListSessions(user.ID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			continue
+		}
+		if err := s.sessionService.RevokeSession(user.ID, session.ID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPassword confirms a sensitive change with the user's password. Users
+// without a password, e.g. from a social login, have to set one through a
+// password reset first.
+func (s *UserServiceImpl) checkPassword(user *domain.User, password string) error {
+	return confirmPassword(s.rateLimitRepo, s.hasher, user, password)
+}
+
+// confirmPassword checks the password a user entered to confirm a change.
+// Checks are limited per user so the password cannot be guessed this way.
+func confirmPassword(rateLimitRepo repository.RateLimitRepository, hasher PasswordHasher, user *domain.User, password string) error {
+	allowed, retryAfter, err := rateLimitRepo.Allow("password-check:"+user.ID.String(), passwordCheckLimit, time.Hour)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return &RateLimitError{RetryAfter: retryAfter}
+	}
+
+	match, _, err := hasher.Verify(password, user.PasswordHash)
+	if err != nil {
+		return err
+	}
+	if !match {
+		return ErrIncorrectPassword
+	}
+	return nil
+}
+
+func validateDisplayName(displayName string) error {
+	length := utf8.RuneCountInString(displayName)
+	if length < minDisplayNameLength || length > maxDisplayNameLength {
+		return ErrInvalidDisplayName
+	}
+	return nil
+}
@@ -27,11 +27,33 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	// The catalog suggests roasters and coffees with similar names
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		log.Fatalf("Failed to enable pg_trgm: %v", err)
+	}
+
 	// Auto migrate the schema
 	fmt.Println("Creating database tables...")
 
 	err = db.AutoMigrate(
 		&domain.User{},
+		&domain.Session{},
+		&domain.UserIdentity{},
+		&domain.RefreshToken{},
+		&domain.ActionToken{},
+		&domain.MFAFactor{},
+		&domain.RecoveryCode{},
+		&domain.APIKey{},
+		&domain.HandleChange{},
+		&domain.Equipment{},
+		&domain.Onboarding{},
+		&domain.DataExport{},
+		&domain.AccountTombstone{},
+		&domain.Roaster{},
+		&domain.Coffee{},
+		&domain.CoffeeBean{},
+		&domain.BeanStockAdjustment{},
+		&domain.BeanEvent{},
 		// Add other models here as needed
 	)
 
@@ -39,5 +61,41 @@ func main() {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	for _, table := range []string{"roasters", "coffees"} {
+		err = db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_name_trgm ON %s USING gin (normalized_name gin_trgm_ops)", table, table)).Error
+		if err != nil {
+			log.Fatalf("Failed to index catalog names: %v", err)
+		}
+	}
+	// Entries are unique by name, coffees per roaster, so adding the same one
+	// at the same time cannot make a duplicate
+	for _, index := range []string{
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_roasters_unique_name ON roasters (normalized_name) WHERE merged_into_id IS NULL",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_coffees_unique_name ON coffees (COALESCE(roaster_id, '00000000-0000-0000-0000-000000000000'), normalized_name) WHERE merged_into_id IS NULL",
+	} {
+		if err := db.Exec(index).Error; err != nil {
+			log.Fatalf("Failed to index catalog names: %v", err)
+		}
+	}
+
+	// Preferences are upgraded when a user is loaded, but rewriting the rows
+	// saved before they were versioned keeps the column queryable
+	fmt.Println("Upgrading user preferences...")
+	var users []domain.User
+	err = db.Select("id", "preferences").
+		Where("preferences IS NOT NULL AND NOT jsonb_exists(preferences, 'version')").
+		FindInBatches(&users, 500, func(tx *gorm.DB, batch int) error {
+			for _, user := range users {
+				if err := tx.Model(&domain.User{}).Where("id = ?", user.ID).Update("preferences", user.Preferences).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+
+	if err != nil {
+		log.Fatalf("Failed to upgrade user preferences: %v", err)
+	}
+
 	fmt.Println("Migration completed successfully")
 }
@@ -0,0 +1,124 @@
+// Package handles checks the public handles users are addressed by, such as
+// @coffee_lover. Handles keep the case the user chose but are unique ignoring
+// case.
+package handles
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	MinLength = 3
+	MaxLength = 30
+)
+
+var (
+	ErrInvalid  = fmt.Errorf("handle must be %d-%d letters, digits or underscores and contain a letter", MinLength, MaxLength)
+	ErrReserved = errors.New("handle is reserved")
+	ErrBlocked  = errors.New("handle contains a word that is not allowed")
+)
+
+var (
+	handlePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	letterPattern = regexp.MustCompile(`[A-Za-z]`)
+)
+
+// reserved are handles that could be mistaken for the service itself, its
+// staff or one of its pages.
+var reserved = []string{
+	"about", "account", "admin", "administrator", "api", "app", "auth",
+	"beans", "blog", "brewkar", "community", "contact", "help", "login",
+	"logout", "me", "mod", "moderator", "null", "oauth", "official",
+	"privacy", "recipes", "register", "root", "security", "settings",
+	"signup", "staff", "support", "system", "team", "terms", "undefined",
+	"user", "users",
+}
+
+// Similar looking digits, so "h4ndle" is checked like "handle"
+var lookalikes = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b")
+
+// Normalize trims the whitespace and a leading @ users tend to type.
+func Normalize(handle string) string {
+	return strings.TrimPrefix(strings.TrimSpace(handle), "@")
+}
+
+// Policy decides which handles users may choose.
+type Policy struct {
+	reserved map[string]struct{}
+	blocked  []string
+}
+
+// NewPolicy creates a policy rejecting the built-in reserved handles and
+// handles containing one of the blocked words.
+func NewPolicy(blocked []string) *Policy {
+	set := make(map[string]struct{}, len(reserved))
+	for _, word := range reserved {
+		set[word] = struct{}{}
+	}
+	words := make([]string, 0, len(blocked))
+	for _, word := range blocked {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			words = append(words, word)
+		}
+	}
+	return &Policy{reserved: set, blocked: words}
+}
+
+// Validate returns ErrInvalid, ErrReserved or ErrBlocked when handle is not
+// allowed. Underscores are ignored when matching reserved handles, and
+// blocked words are found anywhere in the handle, also when spelled with
+// lookalike digits.
+func (p *Policy) Validate(handle string) error {
+	handle = Normalize(handle)
+	if len(handle) < MinLength || len(handle) > MaxLength ||
+		!handlePattern.MatchString(handle) || !letterPattern.MatchString(handle) {
+		return ErrInvalid
+	}
+
+	key := strings.ReplaceAll(strings.ToLower(handle), "_", "")
+	if _, ok := p.reserved[key]; ok {
+		return ErrReserved
+	}
+
+	letters := lookalikes.Replace(key)
+	for _, word := range p.blocked {
+		if strings.Contains(key, word) || strings.Contains(letters, word) {
+			return ErrBlocked
+		}
+	}
+	return nil
+}
+
+// ReadList reads a word list with one word per line. Blank lines and lines
+// starting with # are skipped.
+func ReadList(r io.Reader) ([]string, error) {
+	var list []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list = append(list, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// LoadList reads a word list file, see ReadList.
+func LoadList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open word list: %w", err)
+	}
+	defer f.Close()
+	return ReadList(f)
+}
@@ -0,0 +1,215 @@
+// Package keyring holds the asymmetric keys used to sign and verify JWTs.
+//
+// Tokens are signed with a single active key and carry its ID in the "kid"
+// header. Verification accepts any key on the ring, so a key can be rotated
+// by adding the new key, making it the signing key and removing the old one
+// once every token it signed has expired. Keys without a private part are
+// verify-only, which is how a retired key stays on the ring.
+package keyring
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Supported algorithms, named as in the JWT "alg" header
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Minimum RSA modulus size accepted for signing keys
+const minRSABits = 2048
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Key is a single signing or verification key.
+type Key struct {
+	ID        string
+	Algorithm string
+	Public    crypto.PublicKey
+	// Private is nil for verify-only keys.
+	Private crypto.Signer
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	if k.Algorithm == AlgEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+type Keyring struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// New builds a keyring from keys. signingKeyID selects the key new tokens
+// are signed with; it may be empty when exactly one key has a private part.
+func New(keys []*Key, signingKeyID string) (*Keyring, error) {
+	kr := &Keyring{keys: make(map[string]*Key, len(keys))}
+
+	var candidates []*Key
+	for _, key := range keys {
+		if _, exists := kr.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		kr.keys[key.ID] = key
+		if key.Private != nil {
+			candidates = append(candidates, key)
+		}
+	}
+
+	if signingKeyID == "" {
+		if len(candidates) != 1 {
+			return nil, errors.New("signing key id must be set when there is not exactly one private key")
+		}
+		kr.signing = candidates[0]
+		return kr, nil
+	}
+
+	signing, ok := kr.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q not found", signingKeyID)
+	}
+	if signing.Private == nil {
+		return nil, fmt.Errorf("signing key %q has no private key", signingKeyID)
+	}
+	kr.signing = signing
+	return kr, nil
+}
+
+// SigningKeyID returns the ID of the key new tokens are signed with.
+func (kr *Keyring) SigningKeyID() string {
+	return kr.signing.ID
+}
+
+// Sign signs claims with the active key and sets the "kid" header.
+func (kr *Keyring) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(kr.signing.method(), claims)
+	token.Header["kid"] = kr.signing.ID
+	return token.SignedString(kr.signing.Private)
+}
+
+// Keyfunc resolves the verification key for a token by its "kid" header.
+// It is meant to be passed to jwt.Parse.
+func (kr *Keyring) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := kr.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	// Never let the token pick a different algorithm than the key's
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %q for key %q", token.Method.Alg(), kid)
+	}
+	return key.Public, nil
+}
+
+// Keys returns every key on the ring ordered by ID.
+func (kr *Keyring) Keys() []*Key {
+	keys := make([]*Key, 0, len(kr.keys))
+	for _, key := range kr.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+// ParseKey parses a PEM encoded RSA or Ed25519 key. Private keys may be
+// PKCS#8 or PKCS#1 (RSA); public keys must be PKIX.
+func ParseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %q: no PEM data found", id)
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("key %q: unsupported private key type %T", id, parsed)
+		}
+		return newKey(id, signer.Public(), signer)
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		return newKey(id, parsed.Public(), parsed)
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		return newKey(id, parsed, nil)
+	default:
+		return nil, fmt.Errorf("key %q: unsupported PEM block %q", id, block.Type)
+	}
+}
+
+func newKey(id string, public crypto.PublicKey, private crypto.Signer) (*Key, error) {
+	if id == "" {
+		return nil, errors.New("key id must not be empty")
+	}
+
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("key %q: RSA keys must be at least %d bits", id, minRSABits)
+		}
+		return &Key{ID: id, Algorithm: AlgRS256, Public: pub, Private: private}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Algorithm: AlgEdDSA, Public: pub, Private: private}, nil
+	default:
+		return nil, fmt.Errorf("key %q: unsupported key type %T", id, public)
+	}
+}
+
+// LoadDir parses every *.pem file in dir. The file name without extension
+// is used as the key ID.
+func LoadDir(dir string) ([]*Key, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*Key, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParseKey(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// GenerateEd25519 creates a new random Ed25519 key.
+func GenerateEd25519(id string) (*Key, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newKey(id, public, private)
+}
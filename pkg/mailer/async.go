@@ -0,0 +1,27 @@
+package mailer
+
+// asyncMailer sends every message in the background.
+type asyncMailer struct {
+	mailer  Mailer
+	onError func(msg Message, err error)
+}
+
+// Async wraps m so Send returns right away and delivers the message in the
+// background. Callers neither wait for the mail server nor learn whether
+// delivery worked, which keeps responses that send email only to existing
+// accounts from telling them apart. Failures are passed to onError.
+func Async(m Mailer, onError func(msg Message, err error)) Mailer {
+	if onError == nil {
+		onError = func(Message, error) {}
+	}
+	return &asyncMailer{mailer: m, onError: onError}
+}
+
+func (a *asyncMailer) Send(msg Message) error {
+	go func() {
+		if err := a.mailer.Send(msg); err != nil {
+			a.onError(msg, err)
+		}
+	}()
+	return nil
+}
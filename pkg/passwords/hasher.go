@@ -0,0 +1,162 @@
+// Package passwords hashes passwords and checks them against a password
+// policy. New hashes use Argon2id in the PHC string format
+// ($argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>); bcrypt hashes from before
+// the switch can still be verified so they can be upgraded on the next login.
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownAlgorithm is returned for hashes in a format no supported
+// algorithm recognises.
+var ErrUnknownAlgorithm = errors.New("unknown password hash format")
+
+// ErrMalformedHash is returned for Argon2id hashes that cannot be parsed.
+var ErrMalformedHash = errors.New("malformed password hash")
+
+var b64 = base64.RawStdEncoding
+
+// Argon2idParams are the cost parameters of new hashes. Memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams follow the OWASP recommendation for Argon2id.
+var DefaultParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Hasher creates Argon2id hashes and verifies Argon2id and bcrypt hashes.
+type Hasher struct {
+	params Argon2idParams
+}
+
+// NewHasher creates a hasher using params for new hashes. Zero fields take
+// their value from DefaultParams.
+func NewHasher(params Argon2idParams) *Hasher {
+	if params.Memory == 0 {
+		params.Memory = DefaultParams.Memory
+	}
+	if params.Iterations == 0 {
+		params.Iterations = DefaultParams.Iterations
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = DefaultParams.Parallelism
+	}
+	if params.SaltLength == 0 {
+		params.SaltLength = DefaultParams.SaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = DefaultParams.KeyLength
+	}
+	return &Hasher{params: params}
+}
+
+// Hash returns the PHC encoded Argon2id hash of password with a random salt.
+func (h *Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+	return encodeArgon2id(h.params, salt, key), nil
+}
+
+// Verify reports whether password matches encoded. needsRehash is set for
+// matching hashes made with another algorithm or with other parameters than
+// the hasher's; callers should then store a fresh hash. An empty encoded hash,
+// as for accounts without a password, never matches.
+func (h *Hasher) Verify(password, encoded string) (match, needsRehash bool, err error) {
+	switch {
+	case encoded == "":
+		return false, false, nil
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		params, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, false, err
+		}
+		computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(computed, key) != 1 {
+			return false, false, nil
+		}
+		return true, params != h.params, nil
+	case isBcrypt(encoded):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		return true, true, nil
+	default:
+		return false, false, ErrUnknownAlgorithm
+	}
+}
+
+func isBcrypt(encoded string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(encoded, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeArgon2id(params Argon2idParams, salt, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		b64.EncodeToString(salt), b64.EncodeToString(key))
+}
+
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("%w: unsupported argon2 version %d", ErrMalformedHash, version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrMalformedHash
+	}
+
+	salt, err := b64.DecodeString(parts[4])
+	if err != nil || len(salt) == 0 {
+		return params, nil, nil, ErrMalformedHash
+	}
+	key, err := b64.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrMalformedHash
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
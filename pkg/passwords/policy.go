@@ -0,0 +1,79 @@
+package passwords
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrTooShort = errors.New("password is too short")
+	ErrTooLong  = errors.New("password is too long")
+	ErrBreached = errors.New("password appears in a list of breached passwords; choose another one")
+)
+
+// Policy decides which passwords users may choose.
+type Policy struct {
+	minLength int
+	maxLength int
+	breached  map[string]struct{}
+}
+
+// NewPolicy creates a policy requiring minLength to maxLength characters and
+// rejecting the breached passwords. A maxLength of 0 means no limit.
+func NewPolicy(minLength, maxLength int, breached []string) *Policy {
+	set := make(map[string]struct{}, len(breached))
+	for _, p := range breached {
+		set[strings.ToLower(p)] = struct{}{}
+	}
+	return &Policy{minLength: minLength, maxLength: maxLength, breached: set}
+}
+
+// Validate returns an error wrapping ErrTooShort, ErrTooLong or ErrBreached
+// when password is not allowed. Breached passwords are matched ignoring case,
+// since changing the case of a leaked password barely slows down guessing.
+func (p *Policy) Validate(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.minLength {
+		return fmt.Errorf("%w: use at least %d characters", ErrTooShort, p.minLength)
+	}
+	if p.maxLength > 0 && length > p.maxLength {
+		return fmt.Errorf("%w: use at most %d characters", ErrTooLong, p.maxLength)
+	}
+	if _, ok := p.breached[strings.ToLower(password)]; ok {
+		return ErrBreached
+	}
+	return nil
+}
+
+// ReadList reads a password list with one password per line. Blank lines and
+// lines starting with # are skipped.
+func ReadList(r io.Reader) ([]string, error) {
+	var list []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list = append(list, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// LoadList reads a password list file, see ReadList.
+func LoadList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open password list: %w", err)
+	}
+	defer f.Close()
+	return ReadList(f)
+}
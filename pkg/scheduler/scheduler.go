@@ -0,0 +1,114 @@
+// Package scheduler runs background jobs at fixed intervals. When several
+// instances of the application run, a Locker makes sure each run of a job
+// happens on only one of them.
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Job is a task that runs every Interval.
+type Job struct {
+	// Name identifies the job in locks and error reports.
+	Name     string
+	Interval time.Duration
+	// Aligned jobs run on the clock, At past each multiple of Interval
+	// since midnight UTC, e.g. daily at 08:00 UTC. Restarting the
+	// application does not put off their next run, unlike the others,
+	// which first run one Interval after Start.
+	Aligned bool
+	At      time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// NextRun returns when job runs next after now.
+func NextRun(job Job, now time.Time) time.Time {
+	if !job.Aligned {
+		return now.Add(job.Interval)
+	}
+	// The zero time is midnight UTC, so truncating counts from there
+	next := now.Truncate(job.Interval).Add(job.At)
+	for !next.After(now) {
+		next = next.Add(job.Interval)
+	}
+	return next
+}
+
+// Locker hands out expiring locks shared by all instances.
+type Locker interface {
+	// TryLock acquires key for ttl. It reports false when another holder
+	// has it.
+	TryLock(key string, ttl time.Duration) (bool, error)
+}
+
+type Scheduler struct {
+	locker  Locker
+	onError func(job string, err error)
+	jobs    []Job
+	wg      sync.WaitGroup
+}
+
+// New creates a scheduler. locker may be nil when only one instance runs;
+// onError is called with failures of jobs and of the locker.
+func New(locker Locker, onError func(job string, err error)) *Scheduler {
+	if onError == nil {
+		onError = func(string, error) {}
+	}
+	return &Scheduler{locker: locker, onError: onError}
+}
+
+// Add registers a job. Jobs added after Start are not run.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every job at its NextRun and then repeatedly until ctx is
+// cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			timer := time.NewTimer(time.Until(NextRun(job, time.Now())))
+			defer timer.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-timer.C:
+					s.RunNow(ctx, job)
+					timer.Reset(time.Until(NextRun(job, time.Now())))
+				}
+			}
+		}(job)
+	}
+}
+
+// Wait blocks until all jobs have stopped after the context passed to Start
+// was cancelled.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// RunNow runs job once unless another instance holds its lock.
+func (s *Scheduler) RunNow(ctx context.Context, job Job) {
+	if s.locker != nil {
+		// Holding the lock for most of the interval also stops other
+		// instances from running the job again in the same interval. It
+		// expires early so this instance's next tick finds it released.
+		acquired, err := s.locker.TryLock(job.Name, job.Interval*9/10)
+		if err != nil {
+			s.onError(job.Name, err)
+			return
+		}
+		if !acquired {
+			return
+		}
+	}
+
+	if err := job.Run(ctx); err != nil {
+		s.onError(job.Name, err)
+	}
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage keeps files in a directory on disk. It serves them itself as
+// an http.Handler, so it suits development and single-instance deployments.
+type LocalStorage struct {
+	dir       string
+	publicURL string
+	files     http.Handler
+}
+
+// NewLocalStorage stores files below dir. publicURL is where the handler is
+// mounted, e.g. "http://localhost:8080/uploads".
+func NewLocalStorage(dir, publicURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{
+		dir:       dir,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		files:     http.FileServer(http.Dir(dir)),
+	}, nil
+}
+
+func (s *LocalStorage) Put(key string, r io.Reader, contentType string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	name := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file first so readers never see half a file
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return "", err
+	}
+
+	return s.publicURL + "/" + key, nil
+}
+
+func (s *LocalStorage) Open(key string) (io.ReadCloser, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// ServeHTTP serves the stored files. Mount it with the mount path stripped
+// from the request URL. Directory listings and private files are not served.
+func (s *LocalStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/") || strings.Contains(r.URL.Path, "/.") ||
+		strings.HasPrefix(path.Clean(r.URL.Path), "/"+PrivatePrefix) {
+		http.NotFound(w, r)
+		return
+	}
+	s.files.ServeHTTP(w, r)
+}
@@ -0,0 +1,48 @@
+// Package storage keeps uploaded files such as avatars. Files are addressed
+// by slash-separated keys like "avatars/<user>/<name>.png" and served from a
+// public URL.
+package storage
+
+import (
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// PrivatePrefix starts the keys of files that are never served from the
+// public URL, such as data exports. Read them with Open.
+const PrivatePrefix = "private/"
+
+var (
+	// ErrInvalidKey is returned for keys that are empty, absolute or leave
+	// the storage root.
+	ErrInvalidKey = errors.New("invalid storage key")
+	// ErrNotFound is returned by Open when no file is stored under the key.
+	ErrNotFound = errors.New("file not found")
+)
+
+// Storage stores files. Implementations must be safe for concurrent use.
+type Storage interface {
+	// Put stores the contents of r under key, replacing any file already
+	// there, and returns the URL the file is served from.
+	Put(key string, r io.Reader, contentType string) (string, error)
+	// Open returns the contents of the file under key. The caller closes
+	// it.
+	Open(key string) (io.ReadCloser, error)
+	// Delete removes the file under key. Deleting a missing file is not an
+	// error.
+	Delete(key string) error
+}
+
+// cleanKey validates key and returns it in canonical form.
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/yashkadam007/brewkar/internal/controller"
 	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
 
 	"time"
 
@@ -21,43 +22,63 @@ import (
 type TestAuthService struct{}
 
 // Register implements the Register method for AuthService
-func (s *TestAuthService) Register(email, password, displayName string) (*domain.User, string, error) {
+func (s *TestAuthService) Register(email, password, displayName string, client service.ClientInfo) (*domain.User, *service.TokenPair, error) {
 	// For simplicity, always return a successful result
 	// In a real test, we could add logic to return different results based on inputs
 	userID := uuid.New()
 	now := time.Now()
 	user := &domain.User{
 		ID:           userID,
-		Email:        email,
+		Email:        &email,
 		PasswordHash: "hashed-password", // Not testing the actual hashing
 		DisplayName:  displayName,
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
-	return user, "test-jwt-token", nil
+	return user, &service.TokenPair{AccessToken: "test-jwt-token", RefreshToken: "test-refresh-token"}, nil
 }
 
 // Login implements the Login method for AuthService
-func (s *TestAuthService) Login(email, password string) (*domain.User, string, error) {
+func (s *TestAuthService) Login(email, password string, client service.ClientInfo) (*domain.User, *service.TokenPair, error) {
 	// For simplicity, always return a successful result
 	userID := uuid.New()
 	now := time.Now()
 	user := &domain.User{
 		ID:           userID,
-		Email:        email,
+		Email:        &email,
 		PasswordHash: "hashed-password", // Not testing the actual hashing
 		DisplayName:  "Test User",
 		CreatedAt:    now,
 		UpdatedAt:    now,
 		LastLoginAt:  &now,
 	}
-	return user, "test-jwt-token", nil
+	return user, &service.TokenPair{AccessToken: "test-jwt-token", RefreshToken: "test-refresh-token"}, nil
+}
+
+// Logout implements the Logout method for AuthService
+func (s *TestAuthService) Logout(userID, sessionID uuid.UUID) error {
+	return nil
+}
+
+// LogoutAll implements the LogoutAll method for AuthService
+func (s *TestAuthService) LogoutAll(userID uuid.UUID) error {
+	return nil
+}
+
+// VerifyMFAChallenge implements the VerifyMFAChallenge method for AuthService
+func (s *TestAuthService) VerifyMFAChallenge(challengeToken, code string, client service.ClientInfo) (*domain.User, *service.TokenPair, error) {
+	return s.Login("test@example.com", "", client)
+}
+
+// LoginUser implements the LoginUser method for AuthService
+func (s *TestAuthService) LoginUser(user *domain.User, client service.ClientInfo) (*domain.User, *service.TokenPair, error) {
+	return s.Login(user.EmailAddress(), "", client)
 }
 
 // RefreshToken implements the RefreshToken method for AuthService
-func (s *TestAuthService) RefreshToken(refreshToken string) (string, string, error) {
+func (s *TestAuthService) RefreshToken(refreshToken string, client service.ClientInfo) (*service.TokenPair, error) {
 	// For simplicity, always return new tokens
-	return "new-access-token", "new-refresh-token", nil
+	return &service.TokenPair{AccessToken: "new-access-token", RefreshToken: "new-refresh-token"}, nil
 }
 
 func setupTest() (*gin.Engine, *controller.AuthController) {
@@ -140,6 +161,7 @@ func TestRegisterEndpoint(t *testing.T) {
 				data, _ := response["data"].(map[string]interface{})
 				assert.NotNil(t, data["user"])
 				assert.NotNil(t, data["token"])
+				assert.Equal(t, "test-refresh-token", data["refreshToken"])
 
 				user, _ := data["user"].(map[string]interface{})
 				assert.Equal(t, tt.requestBody["email"], user["email"])
@@ -209,6 +231,7 @@ func TestLoginEndpoint(t *testing.T) {
 				data, _ := response["data"].(map[string]interface{})
 				assert.NotNil(t, data["user"])
 				assert.NotNil(t, data["token"])
+				assert.Equal(t, "test-refresh-token", data["refreshToken"])
 
 				user, _ := data["user"].(map[string]interface{})
 				assert.Equal(t, tt.requestBody["email"], user["email"])
@@ -278,3 +301,18 @@ func TestRefreshEndpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestLogoutEndpoint(t *testing.T) {
+	router, authController := setupTest()
+	router.POST("/logout", func(c *gin.Context) {
+		c.Set("userID", uuid.New())
+		c.Set("sessionID", uuid.New())
+		c.Next()
+	}, authController.Logout)
+
+	req, _ := http.NewRequest(http.MethodPost, "/logout", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
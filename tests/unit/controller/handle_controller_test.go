@@ -0,0 +1,79 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/controller"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+// Simple test implementation of HandleService
+type TestHandleService struct{}
+
+func (s *TestHandleService) SetHandle(userID uuid.UUID, handle string) (*domain.User, error) {
+	return nil, nil
+}
+
+func (s *TestHandleService) GetPublicProfile(handle string) (*service.PublicProfile, error) {
+	switch handle {
+	case "coffee_lover":
+		return &service.PublicProfile{Handle: "coffee_lover", DisplayName: "Coffee Lover", Bio: "Pour-over fan", FollowerCount: 3}, nil
+	case "old_handle":
+		return nil, &service.HandleMovedError{Handle: "coffee_lover"}
+	}
+	return nil, service.ErrProfileNotFound
+}
+
+func setupHandleTest() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	handleController := controller.NewHandleController(&TestHandleService{})
+	router.GET("/v1/users/:handle", handleController.GetPublicProfile)
+	return router
+}
+
+func TestGetPublicProfileEndpoint(t *testing.T) {
+	router := setupHandleTest()
+
+	req, _ := http.NewRequest(http.MethodGet, "/v1/users/coffee_lover", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var body struct {
+		Data struct {
+			Profile map[string]interface{} `json:"profile"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, "Coffee Lover", body.Data.Profile["displayName"])
+	assert.Equal(t, map[string]interface{}{"recipes": 0.0, "brewLogs": 0.0, "followers": 3.0}, body.Data.Profile["counts"])
+	// Only the public projection is returned
+	for _, field := range []string{"id", "email", "preferences", "role"} {
+		assert.NotContains(t, body.Data.Profile, field)
+	}
+}
+
+func TestGetPublicProfileRedirectsOldHandles(t *testing.T) {
+	router := setupHandleTest()
+
+	req, _ := http.NewRequest(http.MethodGet, "/v1/users/old_handle", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusFound, resp.Code)
+	assert.Equal(t, "/v1/users/coffee_lover", resp.Header().Get("Location"))
+
+	req, _ = http.NewRequest(http.MethodGet, "/v1/users/nobody_here", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
@@ -0,0 +1,127 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/yashkadam007/brewkar/internal/controller"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+// Simple test implementation of PasswordService
+type TestPasswordService struct{}
+
+func (s *TestPasswordService) ForgotPassword(email string) error {
+	return nil
+}
+
+func (s *TestPasswordService) ResetPassword(token, newPassword string) error {
+	if token != "valid-reset-token" {
+		return service.ErrInvalidResetToken
+	}
+	return nil
+}
+
+func setupPasswordTest() (*gin.Engine, *controller.PasswordController) {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	passwordController := controller.NewPasswordController(&TestPasswordService{})
+	return router, passwordController
+}
+
+func TestForgotPasswordEndpoint(t *testing.T) {
+	router, passwordController := setupPasswordTest()
+	router.POST("/password/forgot", passwordController.ForgotPassword)
+
+	tests := []struct {
+		name           string
+		requestBody    map[string]interface{}
+		expectedStatus int
+	}{
+		{
+			name:           "Valid Email",
+			requestBody:    map[string]interface{}{"email": "test@example.com"},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Invalid Email",
+			requestBody:    map[string]interface{}{"email": "invalid-email"},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestJSON, _ := json.Marshal(tt.requestBody)
+			req, _ := http.NewRequest(http.MethodPost, "/password/forgot", bytes.NewBuffer(requestJSON))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
+
+func TestResetPasswordEndpoint(t *testing.T) {
+	router, passwordController := setupPasswordTest()
+	router.POST("/password/reset", passwordController.ResetPassword)
+
+	tests := []struct {
+		name           string
+		requestBody    map[string]interface{}
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "Valid Token",
+			requestBody: map[string]interface{}{
+				"token":    "valid-reset-token",
+				"password": "newpassword123",
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Invalid Token",
+			requestBody: map[string]interface{}{
+				"token":    "expired-reset-token",
+				"password": "newpassword123",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_TOKEN",
+		},
+		{
+			name: "Short Password",
+			requestBody: map[string]interface{}{
+				"token":    "valid-reset-token",
+				"password": "short",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_REQUEST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestJSON, _ := json.Marshal(tt.requestBody)
+			req, _ := http.NewRequest(http.MethodPost, "/password/reset", bytes.NewBuffer(requestJSON))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+
+			if tt.expectedCode != "" {
+				var response map[string]interface{}
+				json.Unmarshal(resp.Body.Bytes(), &response)
+				errorData, _ := response["error"].(map[string]interface{})
+				assert.Equal(t, tt.expectedCode, errorData["code"])
+			}
+		})
+	}
+}
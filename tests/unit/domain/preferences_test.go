@@ -0,0 +1,71 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/domain"
+)
+
+func TestParsePreferencesEmpty(t *testing.T) {
+	for _, raw := range []string{"", "null"} {
+		preferences, err := domain.ParsePreferences([]byte(raw))
+		require.NoError(t, err)
+		assert.Equal(t, domain.DefaultPreferences(), preferences)
+	}
+}
+
+func TestParsePreferencesFillsDefaults(t *testing.T) {
+	preferences, err := domain.ParsePreferences([]byte(`{"version":1,"units":{"weight":"oz"},"theme":"dark"}`))
+	require.NoError(t, err)
+
+	expected := domain.DefaultPreferences()
+	expected.Units.Weight = domain.WeightOunces
+	expected.Theme = domain.ThemeDark
+	assert.Equal(t, expected, preferences)
+}
+
+func TestParsePreferencesMigratesLegacyBlobs(t *testing.T) {
+	preferences, err := domain.ParsePreferences([]byte(`{
+		"favoriteBrewMethods": ["AeroPress", "French Press", "aeropress"],
+		"defaultTemperature": "93",
+		"temperatureUnit": "fahrenheit",
+		"weightUnit": "ounces",
+		"somethingElse": true
+	}`))
+	require.NoError(t, err)
+
+	expected := domain.DefaultPreferences()
+	expected.Units.Weight = domain.WeightOunces
+	expected.Units.Temperature = domain.TemperatureFahrenheit
+	// Method names become the slugs the schema allows
+	expected.FavoriteBrewMethods = []string{"aeropress", "french-press"}
+	expected.DefaultBrewMethod = "aeropress"
+	assert.Equal(t, expected, preferences)
+
+	// Values without a counterpart fall back to the defaults
+	preferences, err = domain.ParsePreferences([]byte(`{"weightUnit":"stone","favoriteBrewMethods":[42]}`))
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultPreferences(), preferences)
+}
+
+func TestPreferencesScanAndValue(t *testing.T) {
+	var preferences domain.Preferences
+	require.NoError(t, preferences.Scan([]byte(`{"weightUnit":"grams","temperatureUnit":"fahrenheit"}`)))
+	assert.Equal(t, domain.TemperatureFahrenheit, preferences.Units.Temperature)
+
+	value, err := preferences.Value()
+	require.NoError(t, err)
+	reparsed, err := domain.ParsePreferences(value.([]byte))
+	require.NoError(t, err)
+	assert.Equal(t, preferences, reparsed)
+
+	require.NoError(t, preferences.Scan(nil))
+	assert.Equal(t, domain.DefaultPreferences(), preferences)
+
+	// Preferences that were never set are stored as NULL
+	value, err = domain.Preferences{}.Value()
+	require.NoError(t, err)
+	assert.Nil(t, value)
+}
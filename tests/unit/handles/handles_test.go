@@ -0,0 +1,54 @@
+package handles_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/pkg/handles"
+)
+
+func TestValidate(t *testing.T) {
+	policy := handles.NewPolicy([]string{"darn"})
+
+	tests := []struct {
+		handle string
+		err    error
+	}{
+		{handle: "coffee_lover", err: nil},
+		{handle: "@Barista42", err: nil},
+		{handle: "ab", err: handles.ErrInvalid},
+		{handle: strings.Repeat("a", 31), err: handles.ErrInvalid},
+		{handle: "coffee-lover", err: handles.ErrInvalid},
+		{handle: "café", err: handles.ErrInvalid},
+		{handle: "12345", err: handles.ErrInvalid},
+		{handle: "Admin", err: handles.ErrReserved},
+		{handle: "sup_port", err: handles.ErrReserved},
+		{handle: "me", err: handles.ErrInvalid},
+		{handle: "darnit", err: handles.ErrBlocked},
+		{handle: "D4RN_beans", err: handles.ErrBlocked},
+		{handle: "da_rn", err: handles.ErrBlocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.handle, func(t *testing.T) {
+			err := policy.Validate(tt.handle)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "Coffee_Lover", handles.Normalize(" @Coffee_Lover "))
+}
+
+func TestReadList(t *testing.T) {
+	list, err := handles.ReadList(strings.NewReader("# comment\n\ndarn\n  heck \r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"darn", "heck"}, list)
+}
@@ -0,0 +1,136 @@
+package keyring_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/pkg/keyring"
+)
+
+func generateRSAPEM(t *testing.T) ([]byte, []byte) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+}
+
+func TestSignAndVerify(t *testing.T) {
+	privatePEM, _ := generateRSAPEM(t)
+	rsaKey, err := keyring.ParseKey("rsa-1", privatePEM)
+	require.NoError(t, err)
+	assert.Equal(t, keyring.AlgRS256, rsaKey.Algorithm)
+
+	edKey, err := keyring.GenerateEd25519("ed-1")
+	require.NoError(t, err)
+
+	for _, signingKeyID := range []string{"rsa-1", "ed-1"} {
+		t.Run(signingKeyID, func(t *testing.T) {
+			kr, err := keyring.New([]*keyring.Key{rsaKey, edKey}, signingKeyID)
+			require.NoError(t, err)
+
+			signed, err := kr.Sign(jwt.MapClaims{"sub": "user"})
+			require.NoError(t, err)
+
+			token, err := jwt.Parse(signed, kr.Keyfunc)
+			require.NoError(t, err)
+			assert.True(t, token.Valid)
+			assert.Equal(t, signingKeyID, token.Header["kid"])
+		})
+	}
+}
+
+func TestRotationKeepsOldTokensValid(t *testing.T) {
+	oldKey, err := keyring.GenerateEd25519("2024-01")
+	require.NoError(t, err)
+	newKey, err := keyring.GenerateEd25519("2024-02")
+	require.NoError(t, err)
+
+	before, err := keyring.New([]*keyring.Key{oldKey}, "")
+	require.NoError(t, err)
+	oldToken, err := before.Sign(jwt.MapClaims{"sub": "user"})
+	require.NoError(t, err)
+
+	// The old key stays on the ring for verification only
+	retired := &keyring.Key{ID: oldKey.ID, Algorithm: oldKey.Algorithm, Public: oldKey.Public}
+	after, err := keyring.New([]*keyring.Key{retired, newKey}, "2024-02")
+	require.NoError(t, err)
+
+	_, err = jwt.Parse(oldToken, after.Keyfunc)
+	assert.NoError(t, err)
+
+	newToken, err := after.Sign(jwt.MapClaims{"sub": "user"})
+	require.NoError(t, err)
+
+	// A keyring that does not know the new key rejects its tokens
+	_, err = jwt.Parse(newToken, before.Keyfunc)
+	assert.Error(t, err)
+
+	assert.Len(t, after.JWKS().Keys, 2)
+}
+
+func TestKeyfuncRejectsAlgorithmMismatch(t *testing.T) {
+	key, err := keyring.GenerateEd25519("ed-1")
+	require.NoError(t, err)
+	kr, err := keyring.New([]*keyring.Key{key}, "")
+	require.NoError(t, err)
+
+	// An HMAC token naming a known kid must not be verified at all
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user"})
+	token.Header["kid"] = "ed-1"
+	signed, err := token.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = jwt.Parse(signed, kr.Keyfunc)
+	assert.Error(t, err)
+}
+
+func TestNewRequiresSigningKey(t *testing.T) {
+	_, publicPEM := generateRSAPEM(t)
+	public, err := keyring.ParseKey("rsa-public", publicPEM)
+	require.NoError(t, err)
+
+	_, err = keyring.New([]*keyring.Key{public}, "")
+	assert.Error(t, err)
+
+	_, err = keyring.New([]*keyring.Key{public}, "rsa-public")
+	assert.Error(t, err)
+}
+
+func TestLoadDirAndJWKS(t *testing.T) {
+	dir := t.TempDir()
+	privatePEM, publicPEM := generateRSAPEM(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "current.pem"), privatePEM, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "retired.pem"), publicPEM, 0o644))
+
+	keys, err := keyring.LoadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+
+	kr, err := keyring.New(keys, "")
+	require.NoError(t, err)
+	assert.Equal(t, "current", kr.SigningKeyID())
+
+	jwks := kr.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	for _, jwk := range jwks.Keys {
+		assert.Equal(t, "RSA", jwk.KeyType)
+		assert.Equal(t, "RS256", jwk.Algorithm)
+		assert.Equal(t, "sig", jwk.Use)
+		assert.Equal(t, "AQAB", jwk.E)
+		assert.NotEmpty(t, jwk.N)
+	}
+}
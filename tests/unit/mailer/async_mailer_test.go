@@ -0,0 +1,40 @@
+package mailer_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/pkg/mailer"
+)
+
+// Mailer that blocks until released and then fails
+type blockingMailer struct {
+	release chan struct{}
+}
+
+func (m *blockingMailer) Send(msg mailer.Message) error {
+	<-m.release
+	return errors.New("smtp unavailable")
+}
+
+func TestAsyncMailerReportsFailuresInTheBackground(t *testing.T) {
+	inner := &blockingMailer{release: make(chan struct{})}
+	failed := make(chan mailer.Message, 1)
+	m := mailer.Async(inner, func(msg mailer.Message, err error) {
+		failed <- msg
+	})
+
+	// Send returns before the message is delivered
+	require.NoError(t, m.Send(mailer.Message{To: "test@example.com", Subject: "Reset your Brewkar password"}))
+	close(inner.release)
+
+	select {
+	case msg := <-failed:
+		assert.Equal(t, "test@example.com", msg.To)
+	case <-time.After(time.Second):
+		t.Fatal("the failure was not reported")
+	}
+}
@@ -0,0 +1,34 @@
+package mailer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/pkg/mailer"
+)
+
+func TestFileMailerWritesEml(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m, err := mailer.NewFileMailer(dir, "Brewkar <no-reply@brewkar.com>")
+	require.NoError(t, err)
+
+	err = m.Send(mailer.Message{
+		To:      "test@example.com",
+		Subject: "Reset your Brewkar password",
+		Body:    "Hello from the tests",
+	})
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "To: test@example.com\r\n")
+	assert.Contains(t, string(content), "From: Brewkar <no-reply@brewkar.com>\r\n")
+	assert.Contains(t, string(content), "Hello from the tests")
+}
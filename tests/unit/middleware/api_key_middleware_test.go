@@ -0,0 +1,114 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/middleware"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+// Simple APIKeyService for testing that knows a single key
+type TestAPIKeyService struct {
+	rawKey string
+	key    *domain.APIKey
+}
+
+func (s *TestAPIKeyService) CreateKey(userID uuid.UUID, name string, scopes []domain.Scope, expiresAt *time.Time) (*domain.APIKey, string, error) {
+	return nil, "", nil
+}
+
+func (s *TestAPIKeyService) ListKeys(userID uuid.UUID) ([]domain.APIKey, error) {
+	return nil, nil
+}
+
+func (s *TestAPIKeyService) RevokeKey(userID, keyID uuid.UUID) error {
+	return nil
+}
+
+func (s *TestAPIKeyService) Authenticate(rawKey string, scope domain.Scope) (*domain.APIKey, error) {
+	if rawKey == "bk_overquota_secret" {
+		return nil, &service.RateLimitError{RetryAfter: time.Hour}
+	}
+	if rawKey != s.rawKey {
+		return nil, service.ErrInvalidAPIKey
+	}
+	if !s.key.HasScope(scope) {
+		return nil, service.ErrInsufficientScope
+	}
+	return s.key, nil
+}
+
+func TestAPIKeyAuthScoped(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	revocationRepo := &TestRevocationRepo{revokedTokens: map[string]bool{}}
+	tokenAuth := middleware.AuthMiddleware(testKeyring, revocationRepo, noSuspensions())
+
+	userID := uuid.New()
+	apiKeyService := &TestAPIKeyService{
+		rawKey: "bk_0123456789ab_secret",
+		key: &domain.APIKey{
+			ID:     uuid.New(),
+			UserID: userID,
+			User:   &domain.User{ID: userID},
+			Scopes: []domain.Scope{domain.ScopeBeansRead},
+		},
+	}
+	apiKeyAuth := middleware.NewAPIKeyAuth(tokenAuth, apiKeyService)
+
+	router := gin.New()
+	router.GET("/beans", apiKeyAuth.Scoped(domain.ScopeBeansRead), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"userID": c.MustGet("userID"), "role": c.MustGet("role")})
+	})
+	router.POST("/beans", apiKeyAuth.Scoped(domain.ScopeBeansWrite), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	now := time.Now()
+	bearer := "Bearer " + signTestToken(t, jwt.MapClaims{
+		"sub": uuid.NewString(),
+		"jti": uuid.NewString(),
+		"sid": uuid.NewString(),
+		"typ": "access",
+		"iat": now.Unix(),
+		"exp": now.Add(15 * time.Minute).Unix(),
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		header         string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "Key With Scope", method: http.MethodGet, header: "ApiKey bk_0123456789ab_secret", expectedStatus: http.StatusOK},
+		{name: "Key Without Scope", method: http.MethodPost, header: "ApiKey bk_0123456789ab_secret", expectedStatus: http.StatusForbidden, expectedCode: "INSUFFICIENT_SCOPE"},
+		{name: "Unknown Key", method: http.MethodGet, header: "ApiKey bk_0123456789ab_wrong", expectedStatus: http.StatusUnauthorized, expectedCode: "INVALID_API_KEY"},
+		{name: "Quota Exceeded", method: http.MethodGet, header: "ApiKey bk_overquota_secret", expectedStatus: http.StatusTooManyRequests, expectedCode: "RATE_LIMIT_EXCEEDED"},
+		{name: "Bearer Token", method: http.MethodPost, header: bearer, expectedStatus: http.StatusOK},
+		{name: "Missing Header", method: http.MethodGet, header: "", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, "/beans", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.expectedCode != "" {
+				assert.Contains(t, resp.Body.String(), tt.expectedCode)
+			}
+		})
+	}
+}
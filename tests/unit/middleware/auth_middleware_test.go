@@ -0,0 +1,276 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/middleware"
+	"github.com/yashkadam007/brewkar/pkg/keyring"
+)
+
+// Simple TokenRevocationRepository for testing
+type TestRevocationRepo struct {
+	revokedTokens map[string]bool
+}
+
+func (r *TestRevocationRepo) RevokeToken(tokenID string, ttl time.Duration) error {
+	r.revokedTokens[tokenID] = true
+	return nil
+}
+
+func (r *TestRevocationRepo) RevokeSession(sessionID uuid.UUID, ttl time.Duration) error {
+	r.revokedTokens[sessionID.String()] = true
+	return nil
+}
+
+func (r *TestRevocationRepo) RevokeUserTokens(userID uuid.UUID, before time.Time, ttl time.Duration) error {
+	return nil
+}
+
+func (r *TestRevocationRepo) IsRevoked(tokenID string, sessionID uuid.UUID, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	return r.revokedTokens[tokenID] || r.revokedTokens[sessionID.String()], nil
+}
+
+// Simple SuspensionRepository for testing
+type TestSuspensionRepo struct {
+	suspended map[uuid.UUID]bool
+}
+
+func (r *TestSuspensionRepo) Suspend(userID uuid.UUID) error {
+	r.suspended[userID] = true
+	return nil
+}
+
+func (r *TestSuspensionRepo) Unsuspend(userID uuid.UUID) error {
+	delete(r.suspended, userID)
+	return nil
+}
+
+func (r *TestSuspensionRepo) IsSuspended(userID uuid.UUID) (bool, error) {
+	return r.suspended[userID], nil
+}
+
+var testKeyring = newTestKeyring()
+
+func newTestKeyring() *keyring.Keyring {
+	key, err := keyring.GenerateEd25519("test")
+	if err != nil {
+		panic(err)
+	}
+	kr, err := keyring.New([]*keyring.Key{key}, "")
+	if err != nil {
+		panic(err)
+	}
+	return kr
+}
+
+func noSuspensions() *TestSuspensionRepo {
+	return &TestSuspensionRepo{suspended: map[uuid.UUID]bool{}}
+}
+
+func signTestToken(t *testing.T, claims jwt.MapClaims) string {
+	token, err := testKeyring.Sign(claims)
+	assert.NoError(t, err)
+	return token
+}
+
+func setupTest(revocationRepo *TestRevocationRepo) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/protected", middleware.AuthMiddleware(testKeyring, revocationRepo, noSuspensions()), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"userID": c.MustGet("userID")})
+	})
+	return router
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	revokedSessionID := uuid.New()
+	revocationRepo := &TestRevocationRepo{revokedTokens: map[string]bool{"revoked-jti": true, revokedSessionID.String(): true}}
+	router := setupTest(revocationRepo)
+
+	now := time.Now()
+	validClaims := func(jti string) jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub": uuid.NewString(),
+			"jti": jti,
+			"sid": uuid.NewString(),
+			"typ": "access",
+			"iat": now.Unix(),
+			"exp": now.Add(15 * time.Minute).Unix(),
+		}
+	}
+
+	tests := []struct {
+		name           string
+		header         string
+		expectedStatus int
+	}{
+		{
+			name:           "Missing Header",
+			header:         "",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Valid Token",
+			header:         "Bearer " + signTestToken(t, validClaims("valid-jti")),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Revoked Token",
+			header:         "Bearer " + signTestToken(t, validClaims("revoked-jti")),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Token Without ID",
+			header: "Bearer " + signTestToken(t, jwt.MapClaims{
+				"sub": uuid.NewString(),
+				"sid": uuid.NewString(),
+				"typ": "access",
+				"exp": now.Add(15 * time.Minute).Unix(),
+			}),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	revocationRepo := &TestRevocationRepo{revokedTokens: map[string]bool{}}
+	router := setupTest(revocationRepo)
+	router.GET("/publish", middleware.AuthMiddleware(testKeyring, revocationRepo, noSuspensions()), middleware.RequireVerifiedEmail(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	now := time.Now()
+	tests := []struct {
+		name           string
+		emailVerified  bool
+		expectedStatus int
+	}{
+		{
+			name:           "Verified Email",
+			emailVerified:  true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Unverified Email",
+			emailVerified:  false,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signTestToken(t, jwt.MapClaims{
+				"sub":            uuid.NewString(),
+				"jti":            uuid.NewString(),
+				"sid":            uuid.NewString(),
+				"typ":            "access",
+				"iat":            now.Unix(),
+				"exp":            now.Add(15 * time.Minute).Unix(),
+				"email_verified": tt.emailVerified,
+			})
+			req, _ := http.NewRequest(http.MethodGet, "/publish", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
+
+func TestAuthMiddlewareRejectsSuspendedUsers(t *testing.T) {
+	suspendedUserID := uuid.New()
+	suspensionRepo := &TestSuspensionRepo{suspended: map[uuid.UUID]bool{suspendedUserID: true}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/protected", middleware.AuthMiddleware(testKeyring, &TestRevocationRepo{revokedTokens: map[string]bool{}}, suspensionRepo), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	now := time.Now()
+	token := signTestToken(t, jwt.MapClaims{
+		"sub": suspendedUserID.String(),
+		"jti": uuid.NewString(),
+		"sid": uuid.NewString(),
+		"typ": "access",
+		"iat": now.Unix(),
+		"exp": now.Add(15 * time.Minute).Unix(),
+	})
+	req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Contains(t, resp.Body.String(), "ACCOUNT_SUSPENDED")
+}
+
+func TestRequirePermission(t *testing.T) {
+	revocationRepo := &TestRevocationRepo{revokedTokens: map[string]bool{}}
+	router := setupTest(revocationRepo)
+	router.GET("/admin", middleware.AuthMiddleware(testKeyring, revocationRepo, noSuspensions()), middleware.RequirePermission(domain.PermissionUsersRoles), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/moderation", middleware.AuthMiddleware(testKeyring, revocationRepo, noSuspensions()), middleware.RequirePermission(domain.PermissionUsersSuspend), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	now := time.Now()
+	tests := []struct {
+		name           string
+		role           interface{}
+		path           string
+		expectedStatus int
+	}{
+		{name: "Admin On Admin Route", role: "admin", path: "/admin", expectedStatus: http.StatusOK},
+		{name: "Moderator On Admin Route", role: "moderator", path: "/admin", expectedStatus: http.StatusForbidden},
+		{name: "Moderator On Moderation Route", role: "moderator", path: "/moderation", expectedStatus: http.StatusOK},
+		{name: "User On Moderation Route", role: "user", path: "/moderation", expectedStatus: http.StatusForbidden},
+		{name: "Token Without Role", role: nil, path: "/moderation", expectedStatus: http.StatusForbidden},
+		{name: "Unknown Role", role: "superuser", path: "/admin", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := jwt.MapClaims{
+				"sub": uuid.NewString(),
+				"jti": uuid.NewString(),
+				"sid": uuid.NewString(),
+				"typ": "access",
+				"iat": now.Unix(),
+				"exp": now.Add(15 * time.Minute).Unix(),
+			}
+			if tt.role != nil {
+				claims["role"] = tt.role
+			}
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+signTestToken(t, claims))
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+		})
+	}
+}
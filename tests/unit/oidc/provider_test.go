@@ -0,0 +1,162 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/pkg/oidc"
+	"github.com/yashkadam007/brewkar/tests/fakeoidc"
+)
+
+const redirectURI = "http://localhost:3000/oauth/test/callback"
+
+var testUser = fakeoidc.User{
+	Subject:       "1234567890",
+	Email:         "jane@example.com",
+	EmailVerified: true,
+	Name:          "Jane Doe",
+}
+
+func newProvider(server *fakeoidc.Server) *oidc.Provider {
+	return oidc.New(oidc.Config{
+		Name:         "test",
+		IssuerURL:    server.Issuer(),
+		ClientID:     fakeoidc.ClientID,
+		ClientSecret: fakeoidc.ClientSecret,
+	}, server.Client())
+}
+
+// login runs the authorization code flow as the fake provider's user.
+func login(t *testing.T, server *fakeoidc.Server, provider *oidc.Provider, nonce string) (*oidc.Identity, error) {
+	t.Helper()
+	verifier, err := oidc.GenerateVerifier()
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), redirectURI, "state-1", nonce, oidc.ChallengeS256(verifier))
+	require.NoError(t, err)
+
+	code, state, err := server.Authorize(authURL, testUser)
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+
+	return provider.Exchange(context.Background(), redirectURI, code, verifier, nonce)
+}
+
+func TestAuthCodeURLUsesPKCE(t *testing.T) {
+	server := fakeoidc.New()
+	defer server.Close()
+
+	authURL, err := newProvider(server).AuthCodeURL(context.Background(), redirectURI, "state-1", "nonce-1", oidc.ChallengeS256("verifier"))
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+	assert.Equal(t, server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, oidc.ChallengeS256("verifier"), query.Get("code_challenge"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "nonce-1", query.Get("nonce"))
+}
+
+func TestChallengeS256(t *testing.T) {
+	// Example from RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", oidc.ChallengeS256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func TestExchangeReturnsVerifiedIdentity(t *testing.T) {
+	server := fakeoidc.New()
+	defer server.Close()
+
+	identity, err := login(t, server, newProvider(server), "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, testUser.Subject, identity.Subject)
+	assert.Equal(t, testUser.Email, identity.Email)
+	assert.True(t, identity.EmailVerified)
+	assert.Equal(t, testUser.Name, identity.Name)
+}
+
+func TestExchangeRejectsWrongCodeVerifier(t *testing.T) {
+	server := fakeoidc.New()
+	defer server.Close()
+	provider := newProvider(server)
+
+	verifier, _ := oidc.GenerateVerifier()
+	authURL, err := provider.AuthCodeURL(context.Background(), redirectURI, "state-1", "nonce-1", oidc.ChallengeS256(verifier))
+	require.NoError(t, err)
+	code, _, err := server.Authorize(authURL, testUser)
+	require.NoError(t, err)
+
+	_, err = provider.Exchange(context.Background(), redirectURI, code, "some-other-verifier", "nonce-1")
+	assert.Error(t, err)
+}
+
+func TestExchangeRejectsInvalidIDTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(claims jwt.MapClaims)
+	}{
+		{
+			name:   "Wrong Nonce",
+			modify: func(claims jwt.MapClaims) { claims["nonce"] = "other" },
+		},
+		{
+			name:   "Wrong Audience",
+			modify: func(claims jwt.MapClaims) { claims["aud"] = "someone-else" },
+		},
+		{
+			name:   "Wrong Issuer",
+			modify: func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+		},
+		{
+			name:   "Expired",
+			modify: func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+		},
+		{
+			name:   "Missing Subject",
+			modify: func(claims jwt.MapClaims) { delete(claims, "sub") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fakeoidc.New()
+			defer server.Close()
+			server.IDTokenClaims = tt.modify
+
+			_, err := login(t, server, newProvider(server), "nonce-1")
+			assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+		})
+	}
+}
+
+func TestExchangeAcceptsStringEmailVerified(t *testing.T) {
+	server := fakeoidc.New()
+	defer server.Close()
+	// Apple sends booleans as strings
+	server.IDTokenClaims = func(claims jwt.MapClaims) { claims["email_verified"] = "true" }
+
+	identity, err := login(t, server, newProvider(server), "nonce-1")
+	require.NoError(t, err)
+	assert.True(t, identity.EmailVerified)
+}
+
+func TestExchangeRefetchesKeysAfterRotation(t *testing.T) {
+	server := fakeoidc.New()
+	defer server.Close()
+	provider := newProvider(server)
+
+	_, err := login(t, server, provider, "nonce-1")
+	require.NoError(t, err)
+
+	server.RotateKey()
+
+	_, err = login(t, server, provider, "nonce-2")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,97 @@
+package passwords_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/pkg/passwords"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var fastParams = passwords.Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHashIsPHCEncodedArgon2id(t *testing.T) {
+	hasher := passwords.NewHasher(fastParams)
+
+	encoded, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$"), encoded)
+
+	// Salts are random
+	again, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	assert.NotEqual(t, encoded, again)
+
+	match, needsRehash, err := hasher.Verify("correct horse", encoded)
+	require.NoError(t, err)
+	assert.True(t, match)
+	assert.False(t, needsRehash)
+
+	match, _, err = hasher.Verify("wrong horse", encoded)
+	require.NoError(t, err)
+	assert.False(t, match)
+}
+
+func TestVerifyFlagsOutdatedParameters(t *testing.T) {
+	old, err := passwords.NewHasher(fastParams).Hash("correct horse")
+	require.NoError(t, err)
+
+	stronger := fastParams
+	stronger.Iterations = 2
+	match, needsRehash, err := passwords.NewHasher(stronger).Verify("correct horse", old)
+	require.NoError(t, err)
+	assert.True(t, match)
+	assert.True(t, needsRehash)
+}
+
+func TestVerifyBcrypt(t *testing.T) {
+	hasher := passwords.NewHasher(fastParams)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	match, needsRehash, err := hasher.Verify("correct horse", string(legacy))
+	require.NoError(t, err)
+	assert.True(t, match)
+	assert.True(t, needsRehash)
+
+	match, needsRehash, err = hasher.Verify("wrong horse", string(legacy))
+	require.NoError(t, err)
+	assert.False(t, match)
+	assert.False(t, needsRehash)
+}
+
+func TestVerifyRejectsUnusableHashes(t *testing.T) {
+	hasher := passwords.NewHasher(fastParams)
+
+	// Accounts without a password never match
+	match, _, err := hasher.Verify("", "")
+	require.NoError(t, err)
+	assert.False(t, match)
+
+	_, _, err = hasher.Verify("secret", "$md5$abc")
+	assert.ErrorIs(t, err, passwords.ErrUnknownAlgorithm)
+
+	_, _, err = hasher.Verify("secret", "$argon2id$v=19$m=1024,t=1$c2FsdA$aGFzaA")
+	assert.ErrorIs(t, err, passwords.ErrMalformedHash)
+}
+
+func TestPolicy(t *testing.T) {
+	list, err := passwords.ReadList(strings.NewReader("# common passwords\npassword123\n\nLetMeIn99\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"password123", "LetMeIn99"}, list)
+
+	policy := passwords.NewPolicy(8, 20, list)
+
+	assert.NoError(t, policy.Validate("a fine passphrase"))
+	assert.ErrorIs(t, policy.Validate("short"), passwords.ErrTooShort)
+	assert.ErrorIs(t, policy.Validate(strings.Repeat("x", 21)), passwords.ErrTooLong)
+	assert.ErrorIs(t, policy.Validate("password123"), passwords.ErrBreached)
+	// Breached passwords are matched ignoring case
+	assert.ErrorIs(t, policy.Validate("PASSWORD123"), passwords.ErrBreached)
+	assert.ErrorIs(t, policy.Validate("letmein99"), passwords.ErrBreached)
+
+	// Length counts characters, not bytes
+	assert.NoError(t, policy.Validate("ü"+strings.Repeat("x", 7)))
+}
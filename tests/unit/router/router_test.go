@@ -2,42 +2,522 @@ package router_test
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/config"
 	"github.com/yashkadam007/brewkar/internal/controller"
 	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/middleware"
+	"github.com/yashkadam007/brewkar/internal/repository"
 	"github.com/yashkadam007/brewkar/internal/router"
+	"github.com/yashkadam007/brewkar/internal/service"
+	"github.com/yashkadam007/brewkar/pkg/keyring"
 )
 
 // Simple AuthService for testing
 type TestAuthService struct{}
 
-func (s *TestAuthService) Register(email, password, displayName string) (*domain.User, string, error) {
-	return nil, "", nil
+func (s *TestAuthService) Register(email, password, displayName string, client service.ClientInfo) (*domain.User, *service.TokenPair, error) {
+	return nil, nil, nil
 }
 
-func (s *TestAuthService) Login(email, password string) (*domain.User, string, error) {
-	return nil, "", nil
+func (s *TestAuthService) Login(email, password string, client service.ClientInfo) (*domain.User, *service.TokenPair, error) {
+	return nil, nil, nil
+}
+
+func (s *TestAuthService) RefreshToken(refreshToken string, client service.ClientInfo) (*service.TokenPair, error) {
+	return nil, nil
 }
 
-func (s *TestAuthService) RefreshToken(refreshToken string) (string, string, error) {
+func (s *TestAuthService) Logout(userID, sessionID uuid.UUID) error {
+	return nil
+}
+
+func (s *TestAuthService) LogoutAll(userID uuid.UUID) error {
+	return nil
+}
+
+func (s *TestAuthService) VerifyMFAChallenge(challengeToken, code string, client service.ClientInfo) (*domain.User, *service.TokenPair, error) {
+	return nil, nil, nil
+}
+
+func (s *TestAuthService) LoginUser(user *domain.User, client service.ClientInfo) (*domain.User, *service.TokenPair, error) {
+	return nil, nil, nil
+}
+
+// Simple PasswordService for testing
+type TestPasswordService struct{}
+
+func (s *TestPasswordService) ForgotPassword(email string) error {
+	return nil
+}
+
+func (s *TestPasswordService) ResetPassword(token, newPassword string) error {
+	return nil
+}
+
+// Simple EmailVerificationService for testing
+type TestEmailVerificationService struct{}
+
+func (s *TestEmailVerificationService) SendVerification(user *domain.User) error {
+	return nil
+}
+
+func (s *TestEmailVerificationService) ResendVerification(userID uuid.UUID) error {
+	return nil
+}
+
+func (s *TestEmailVerificationService) VerifyEmail(token string) error {
+	return nil
+}
+
+// Simple MFAService for testing
+type TestMFAService struct{}
+
+func (s *TestMFAService) Enroll(userID uuid.UUID) (*service.MFAEnrollment, error) {
+	return nil, nil
+}
+
+func (s *TestMFAService) Confirm(userID uuid.UUID, password, code string) ([]string, error) {
+	return nil, nil
+}
+
+func (s *TestMFAService) Disable(userID uuid.UUID, password, code string) error {
+	return nil
+}
+
+func (s *TestMFAService) IsEnabled(userID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func (s *TestMFAService) VerifyCode(userID uuid.UUID, code string) error {
+	return nil
+}
+
+// Simple SessionService for testing
+type TestSessionService struct{}
+
+func (s *TestSessionService) CreateSession(userID uuid.UUID, client service.ClientInfo) (*domain.Session, error) {
+	return nil, nil
+}
+
+func (s *TestSessionService) TouchSession(sessionID uuid.UUID, client service.ClientInfo) error {
+	return nil
+}
+
+func (s *TestSessionService) ListSessions(userID uuid.UUID) ([]domain.Session, error) {
+	return nil, nil
+}
+
+func (s *TestSessionService) RevokeSession(userID, sessionID uuid.UUID) error {
+	return nil
+}
+
+func (s *TestSessionService) RevokeAllSessions(userID uuid.UUID) error {
+	return nil
+}
+
+// Simple SocialAuthService for testing
+type TestSocialAuthService struct{}
+
+func (s *TestSocialAuthService) StartLogin(provider string) (string, string, error) {
 	return "", "", nil
 }
 
+func (s *TestSocialAuthService) CompleteLogin(provider, state, binding, code string, client service.ClientInfo) (*domain.User, *service.TokenPair, error) {
+	return nil, nil, nil
+}
+
+func (s *TestSocialAuthService) StartLink(provider string, userID uuid.UUID) (string, error) {
+	return "", nil
+}
+
+func (s *TestSocialAuthService) CompleteLink(provider string, userID uuid.UUID, state, code string) (*domain.UserIdentity, error) {
+	return nil, nil
+}
+
+func (s *TestSocialAuthService) ListIdentities(userID uuid.UUID) ([]domain.UserIdentity, error) {
+	return nil, nil
+}
+
+// Simple GuestService for testing
+type TestGuestService struct{}
+
+func (s *TestGuestService) CreateGuest(client service.ClientInfo) (*domain.User, *service.TokenPair, error) {
+	return &domain.User{IsGuest: true}, &service.TokenPair{}, nil
+}
+
+func (s *TestGuestService) Upgrade(userID uuid.UUID, email, password, displayName string) (*domain.User, error) {
+	return nil, nil
+}
+
+// Simple MagicLinkService for testing
+type TestMagicLinkService struct{}
+
+func (s *TestMagicLinkService) RequestLink(email string) (string, error) {
+	return "", nil
+}
+
+func (s *TestMagicLinkService) Login(token, nonce string, client service.ClientInfo) (*domain.User, *service.TokenPair, error) {
+	return nil, nil, nil
+}
+
+// Simple AdminService for testing
+type TestAdminService struct{}
+
+func (s *TestAdminService) SearchUsers(search repository.UserSearch) ([]domain.User, int64, error) {
+	return nil, 0, nil
+}
+
+func (s *TestAdminService) SuspendUser(actorID, userID uuid.UUID, reason string) (*domain.User, error) {
+	return nil, nil
+}
+
+func (s *TestAdminService) UnsuspendUser(actorID, userID uuid.UUID) (*domain.User, error) {
+	return nil, nil
+}
+
+func (s *TestAdminService) SetRole(actorID, userID uuid.UUID, role domain.Role) (*domain.User, error) {
+	return nil, nil
+}
+
+// Simple APIKeyService for testing
+type TestAPIKeyService struct{}
+
+func (s *TestAPIKeyService) CreateKey(userID uuid.UUID, name string, scopes []domain.Scope, expiresAt *time.Time) (*domain.APIKey, string, error) {
+	return nil, "", nil
+}
+
+func (s *TestAPIKeyService) ListKeys(userID uuid.UUID) ([]domain.APIKey, error) {
+	return nil, nil
+}
+
+func (s *TestAPIKeyService) RevokeKey(userID, keyID uuid.UUID) error {
+	return nil
+}
+
+func (s *TestAPIKeyService) Authenticate(rawKey string, scope domain.Scope) (*domain.APIKey, error) {
+	return nil, service.ErrInvalidAPIKey
+}
+
+// Simple UserService for testing
+type TestUserService struct{}
+
+func (s *TestUserService) GetProfile(userID uuid.UUID) (*domain.User, error) {
+	return nil, nil
+}
+
+func (s *TestUserService) UpdateProfile(userID uuid.UUID, update service.ProfileUpdate) (*domain.User, error) {
+	return nil, nil
+}
+
+func (s *TestUserService) UploadAvatar(userID uuid.UUID, r io.Reader) (*domain.User, error) {
+	return nil, nil
+}
+
+func (s *TestUserService) RemoveAvatar(userID uuid.UUID) (*domain.User, error) {
+	return nil, nil
+}
+
+func (s *TestUserService) GetPreferences(userID uuid.UUID) (*domain.Preferences, error) {
+	return nil, nil
+}
+
+func (s *TestUserService) UpdatePreferences(userID uuid.UUID, patch json.RawMessage) (*domain.Preferences, error) {
+	return nil, nil
+}
+
+func (s *TestUserService) ChangeEmail(userID uuid.UUID, newEmail, currentPassword string) (*domain.User, error) {
+	return nil, nil
+}
+
+func (s *TestUserService) ChangePassword(userID, sessionID uuid.UUID, currentPassword, newPassword string) error {
+	return nil
+}
+
+// Simple HandleService for testing
+type TestHandleService struct{}
+
+func (s *TestHandleService) SetHandle(userID uuid.UUID, handle string) (*domain.User, error) {
+	return nil, nil
+}
+
+func (s *TestHandleService) GetPublicProfile(handle string) (*service.PublicProfile, error) {
+	return &service.PublicProfile{Handle: handle}, nil
+}
+
+// Simple OnboardingService for testing
+type TestOnboardingService struct{}
+
+func (s *TestOnboardingService) GetOnboarding(userID uuid.UUID) (*domain.Onboarding, error) {
+	return nil, nil
+}
+
+func (s *TestOnboardingService) AnswerStep(userID uuid.UUID, step domain.OnboardingStep, answers json.RawMessage) (*domain.Onboarding, error) {
+	return nil, nil
+}
+
+func (s *TestOnboardingService) SkipStep(userID uuid.UUID, step domain.OnboardingStep) (*domain.Onboarding, error) {
+	return nil, nil
+}
+
+func (s *TestOnboardingService) SkipRemaining(userID uuid.UUID) (*domain.Onboarding, error) {
+	return nil, nil
+}
+
+// Simple EquipmentService for testing
+type TestEquipmentService struct{}
+
+func (s *TestEquipmentService) ListEquipment(userID uuid.UUID) ([]domain.Equipment, error) {
+	return nil, nil
+}
+
+// Simple DataExportService for testing
+type TestDataExportService struct{}
+
+func (s *TestDataExportService) RequestExport(userID uuid.UUID) (*domain.DataExport, error) {
+	return nil, nil
+}
+
+func (s *TestDataExportService) GetLatestExport(userID uuid.UUID) (*domain.DataExport, error) {
+	return nil, nil
+}
+
+func (s *TestDataExportService) OpenExport(id uuid.UUID, token string) (*domain.DataExport, io.ReadCloser, error) {
+	return nil, nil, service.ErrInvalidExportLink
+}
+
+func (s *TestDataExportService) ProcessPendingExports() (int, error) {
+	return 0, nil
+}
+
+func (s *TestDataExportService) DeleteExpiredExports() (int, error) {
+	return 0, nil
+}
+
+// Simple AccountDeletionService for testing
+type TestAccountDeletionService struct{}
+
+func (s *TestAccountDeletionService) RequestDeletion(userID uuid.UUID, password, code string) (*domain.User, error) {
+	return nil, nil
+}
+
+func (s *TestAccountDeletionService) DeleteScheduledAccounts() (int, error) {
+	return 0, nil
+}
+
+func (s *TestAccountDeletionService) DeleteAbandonedGuests() (int, error) {
+	return 0, nil
+}
+
+// Simple BeanService for testing
+type TestBeanService struct{}
+
+func (s *TestBeanService) ListBeans(userID uuid.UUID, search repository.BeanSearch) ([]domain.CoffeeBean, int64, error) {
+	return nil, 0, nil
+}
+
+func (s *TestBeanService) CreateBean(userID uuid.UUID, fields service.BeanFields) (*domain.CoffeeBean, error) {
+	return nil, nil
+}
+
+func (s *TestBeanService) GetBean(userID, beanID uuid.UUID) (*domain.CoffeeBean, error) {
+	return nil, nil
+}
+
+func (s *TestBeanService) UpdateBean(userID, beanID uuid.UUID, fields service.BeanFields) (*domain.CoffeeBean, error) {
+	return nil, nil
+}
+
+func (s *TestBeanService) DeleteBean(userID, beanID uuid.UUID) error {
+	return nil
+}
+
+func (s *TestBeanService) UploadImages(userID, beanID uuid.UUID, images []io.Reader) (*domain.CoffeeBean, error) {
+	return nil, nil
+}
+
+func (s *TestBeanService) GetStock(userID, beanID uuid.UUID) (*service.BeanStock, error) {
+	return nil, nil
+}
+
+func (s *TestBeanService) AdjustStock(userID, beanID uuid.UUID, change service.StockChange) (*domain.CoffeeBean, *domain.BeanStockAdjustment, error) {
+	return nil, nil, nil
+}
+
+func (s *TestBeanService) ListStockAdjustments(userID, beanID uuid.UUID, offset, limit int) ([]domain.BeanStockAdjustment, int64, error) {
+	return nil, 0, nil
+}
+
+func (s *TestBeanService) RecordBrewUsage(userID, beanID, brewLogID uuid.UUID, doseGrams float64) (*domain.CoffeeBean, error) {
+	return nil, nil
+}
+
+func (s *TestBeanService) FreshnessRules(userID uuid.UUID, brewMethod string) (*service.FreshnessRules, error) {
+	return nil, nil
+}
+
+func (s *TestBeanService) NotifyFreshness() (int, error) {
+	return 0, nil
+}
+
+func (s *TestBeanService) AddEvent(userID, beanID uuid.UUID, event service.NewBeanEvent) (*domain.CoffeeBean, *domain.BeanEvent, error) {
+	return nil, nil, nil
+}
+
+func (s *TestBeanService) ListEvents(userID, beanID uuid.UUID, offset, limit int) ([]domain.BeanEvent, int64, error) {
+	return nil, 0, nil
+}
+
+func (s *TestBeanService) SplitBean(userID, beanID uuid.UUID, portions service.BeanPortions) (*domain.CoffeeBean, []domain.CoffeeBean, error) {
+	return nil, nil, nil
+}
+
+// Simple CatalogService for testing
+type TestCatalogService struct{}
+
+func (s *TestCatalogService) SearchRoasters(search repository.CatalogSearch) ([]domain.Roaster, int64, error) {
+	return nil, 0, nil
+}
+
+func (s *TestCatalogService) GetRoaster(roasterID uuid.UUID) (*domain.Roaster, error) {
+	return nil, nil
+}
+
+func (s *TestCatalogService) CreateRoaster(userID uuid.UUID, fields service.RoasterFields) (*domain.Roaster, bool, error) {
+	return &domain.Roaster{}, false, nil
+}
+
+func (s *TestCatalogService) SearchCoffees(search repository.CatalogSearch) ([]domain.Coffee, int64, error) {
+	return nil, 0, nil
+}
+
+func (s *TestCatalogService) GetCoffee(coffeeID uuid.UUID) (*domain.Coffee, error) {
+	return nil, nil
+}
+
+func (s *TestCatalogService) CreateCoffee(userID uuid.UUID, fields service.CoffeeFields) (*domain.Coffee, bool, error) {
+	return &domain.Coffee{}, false, nil
+}
+
+func (s *TestCatalogService) Suggest(roaster, name string) (*service.CatalogSuggestions, error) {
+	return nil, nil
+}
+
+func (s *TestCatalogService) MergeRoasters(duplicateID, targetID uuid.UUID) (*domain.Roaster, error) {
+	return nil, nil
+}
+
+func (s *TestCatalogService) MergeCoffees(duplicateID, targetID uuid.UUID) (*domain.Coffee, error) {
+	return nil, nil
+}
+
+// Rejects every request so protected routes can be told apart from missing ones
+func testAuthMiddleware(c *gin.Context) {
+	c.AbortWithStatus(http.StatusUnauthorized)
+}
+
+func setupRouter() *gin.Engine {
+	r, err := setupRouterWithConfig(&config.Config{}, testAuthMiddleware)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func setupRouterWithConfig(cfg *config.Config, auth gin.HandlerFunc) (*gin.Engine, error) {
+	authController := controller.NewAuthController(&TestAuthService{})
+	passwordController := controller.NewPasswordController(&TestPasswordService{})
+	magicLinkController := controller.NewMagicLinkController(&TestMagicLinkService{})
+	emailVerificationController := controller.NewEmailVerificationController(&TestEmailVerificationService{})
+	mfaController := controller.NewMFAController(&TestMFAService{})
+	sessionController := controller.NewSessionController(&TestSessionService{})
+	key, _ := keyring.GenerateEd25519("test")
+	kr, _ := keyring.New([]*keyring.Key{key}, "")
+	jwksController := controller.NewJWKSController(kr)
+	oauthController := controller.NewOAuthController(&TestSocialAuthService{})
+	guestController := controller.NewGuestController(&TestGuestService{})
+	adminController := controller.NewAdminController(&TestAdminService{})
+	apiKeyController := controller.NewAPIKeyController(&TestAPIKeyService{})
+	apiKeyAuth := middleware.NewAPIKeyAuth(auth, &TestAPIKeyService{})
+	userController := controller.NewUserController(&TestUserService{})
+	handleController := controller.NewHandleController(&TestHandleService{})
+	onboardingController := controller.NewOnboardingController(&TestOnboardingService{})
+	equipmentController := controller.NewEquipmentController(&TestEquipmentService{})
+	dataExportController := controller.NewDataExportController(&TestDataExportService{})
+	accountDeletionController := controller.NewAccountDeletionController(&TestAccountDeletionService{})
+	beanController := controller.NewBeanController(&TestBeanService{})
+	catalogController := controller.NewCatalogController(&TestCatalogService{})
+	uploads := http.NotFoundHandler()
+	return router.SetupRouter(cfg, uploads, auth, apiKeyAuth, authController, passwordController, magicLinkController, emailVerificationController, mfaController, sessionController, jwksController, oauthController, guestController, adminController, apiKeyController, userController, handleController, onboardingController, equipmentController, dataExportController, accountDeletionController, beanController, catalogController)
+}
+
+func TestClientIPTrustsConfiguredProxiesOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	clientIP := func(r *gin.Engine, remoteAddr string) string {
+		r.GET("/client-ip", func(c *gin.Context) {
+			c.String(http.StatusOK, c.ClientIP())
+		})
+		req, _ := http.NewRequest(http.MethodGet, "/client-ip", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp.Body.String()
+	}
+
+	// No proxies are trusted by default, so the header is ignored
+	assert.Equal(t, "198.51.100.1", clientIP(setupRouter(), "198.51.100.1:4321"))
+
+	cfg := &config.Config{Server: config.ServerConfig{TrustedProxies: []string{"10.0.0.0/8"}}}
+	r, err := setupRouterWithConfig(cfg, testAuthMiddleware)
+	assert.NoError(t, err)
+	assert.Equal(t, "203.0.113.7", clientIP(r, "10.1.2.3:4321"))
+
+	_, err = setupRouterWithConfig(&config.Config{Server: config.ServerConfig{TrustedProxies: []string{"not-an-ip"}}}, testAuthMiddleware)
+	assert.Error(t, err)
+}
+
+func TestCatalogCreationRequiresVerifiedEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	create := func(emailVerified bool, path string) *httptest.ResponseRecorder {
+		r, err := setupRouterWithConfig(&config.Config{}, func(c *gin.Context) {
+			c.Set("userID", uuid.New())
+			c.Set("emailVerified", emailVerified)
+		})
+		require.NoError(t, err)
+		req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(`{"name":"Geometry"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+
+	for _, path := range []string{"/v1/catalog/roasters", "/v1/catalog/coffees"} {
+		resp := create(false, path)
+		assert.Equal(t, http.StatusForbidden, resp.Code, path)
+		assert.Contains(t, resp.Body.String(), "EMAIL_NOT_VERIFIED")
+
+		assert.Equal(t, http.StatusOK, create(true, path).Code, path)
+	}
+}
+
 func TestPingEndpoint(t *testing.T) {
 	// Setup Gin in test mode
 	gin.SetMode(gin.TestMode)
 
-	// Create an auth controller using the test service
-	authService := &TestAuthService{}
-	authController := controller.NewAuthController(authService)
-
-	// Setup the router with the auth controller
-	r := router.SetupRouter(authController)
+	// Setup the router with controllers backed by test services
+	r := setupRouter()
 
 	// Create a test request to the ping endpoint
 	req, _ := http.NewRequest(http.MethodGet, "/ping", nil)
@@ -65,12 +545,8 @@ func TestAPIEndpointPaths(t *testing.T) {
 	// Setup Gin in test mode
 	gin.SetMode(gin.TestMode)
 
-	// Create an auth controller using the test service
-	authService := &TestAuthService{}
-	authController := controller.NewAuthController(authService)
-
-	// Setup the router with the auth controller
-	r := router.SetupRouter(authController)
+	// Setup the router with controllers backed by test services
+	r := setupRouter()
 
 	// Table driven tests for API path existence
 	tests := []struct {
@@ -78,6 +554,11 @@ func TestAPIEndpointPaths(t *testing.T) {
 		path   string
 		method string
 	}{
+		{
+			name:   "JWKS Endpoint",
+			path:   "/.well-known/jwks.json",
+			method: http.MethodGet,
+		},
 		{
 			name:   "Auth Register Endpoint",
 			path:   "/v1/auth/register",
@@ -88,11 +569,351 @@ func TestAPIEndpointPaths(t *testing.T) {
 			path:   "/v1/auth/login",
 			method: http.MethodPost,
 		},
+		{
+			name:   "Guest Endpoint",
+			path:   "/v1/auth/guest",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Guest Upgrade Endpoint",
+			path:   "/v1/auth/guest/upgrade",
+			method: http.MethodPost,
+		},
 		{
 			name:   "Auth Refresh Endpoint",
 			path:   "/v1/auth/refresh",
 			method: http.MethodPost,
 		},
+		{
+			name:   "Auth Logout Endpoint",
+			path:   "/v1/auth/logout",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Auth Logout All Endpoint",
+			path:   "/v1/auth/logout-all",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Forgot Password Endpoint",
+			path:   "/v1/auth/password/forgot",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Reset Password Endpoint",
+			path:   "/v1/auth/password/reset",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Magic Link Endpoint",
+			path:   "/v1/auth/magic-link",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Magic Link Verify Endpoint",
+			path:   "/v1/auth/magic-link/verify",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Verify Email Endpoint",
+			path:   "/v1/auth/email/verify",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Resend Verification Endpoint",
+			path:   "/v1/auth/email/resend",
+			method: http.MethodPost,
+		},
+		{
+			name:   "MFA Verify Endpoint",
+			path:   "/v1/auth/mfa/verify",
+			method: http.MethodPost,
+		},
+		{
+			name:   "MFA Enroll Endpoint",
+			path:   "/v1/auth/mfa/enroll",
+			method: http.MethodPost,
+		},
+		{
+			name:   "MFA Confirm Endpoint",
+			path:   "/v1/auth/mfa/confirm",
+			method: http.MethodPost,
+		},
+		{
+			name:   "MFA Disable Endpoint",
+			path:   "/v1/auth/mfa/disable",
+			method: http.MethodPost,
+		},
+		{
+			name:   "List Sessions Endpoint",
+			path:   "/v1/auth/sessions",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Revoke Session Endpoint",
+			path:   "/v1/auth/sessions/" + uuid.NewString(),
+			method: http.MethodDelete,
+		},
+		{
+			name:   "OAuth Start Endpoint",
+			path:   "/v1/auth/oauth/google/start",
+			method: http.MethodPost,
+		},
+		{
+			name:   "OAuth Callback Endpoint",
+			path:   "/v1/auth/oauth/google/callback",
+			method: http.MethodPost,
+		},
+		{
+			name:   "OAuth Link Endpoint",
+			path:   "/v1/auth/oauth/google/link",
+			method: http.MethodPost,
+		},
+		{
+			name:   "OAuth Link Callback Endpoint",
+			path:   "/v1/auth/oauth/google/link/callback",
+			method: http.MethodPost,
+		},
+		{
+			name:   "List Identities Endpoint",
+			path:   "/v1/auth/identities",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Admin Search Users Endpoint",
+			path:   "/v1/admin/users",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Admin Suspend User Endpoint",
+			path:   "/v1/admin/users/" + uuid.NewString() + "/suspend",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Admin Unsuspend User Endpoint",
+			path:   "/v1/admin/users/" + uuid.NewString() + "/unsuspend",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Admin Set Role Endpoint",
+			path:   "/v1/admin/users/" + uuid.NewString() + "/role",
+			method: http.MethodPut,
+		},
+		{
+			name:   "Admin Merge Roasters Endpoint",
+			path:   "/v1/admin/catalog/roasters/" + uuid.NewString() + "/merge",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Admin Merge Coffees Endpoint",
+			path:   "/v1/admin/catalog/coffees/" + uuid.NewString() + "/merge",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Get Profile Endpoint",
+			path:   "/v1/users/me",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Update Profile Endpoint",
+			path:   "/v1/users/me",
+			method: http.MethodPut,
+		},
+		{
+			name:   "Upload Avatar Endpoint",
+			path:   "/v1/users/me/avatar",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Remove Avatar Endpoint",
+			path:   "/v1/users/me/avatar",
+			method: http.MethodDelete,
+		},
+		{
+			name:   "Get Preferences Endpoint",
+			path:   "/v1/users/me/preferences",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Update Preferences Endpoint",
+			path:   "/v1/users/me/preferences",
+			method: http.MethodPatch,
+		},
+		{
+			name:   "Change Email Endpoint",
+			path:   "/v1/users/me/email",
+			method: http.MethodPut,
+		},
+		{
+			name:   "Set Handle Endpoint",
+			path:   "/v1/users/me/handle",
+			method: http.MethodPut,
+		},
+		{
+			name:   "Public Profile Endpoint",
+			path:   "/v1/users/coffee_lover",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Change Password Endpoint",
+			path:   "/v1/users/me/password",
+			method: http.MethodPut,
+		},
+		{
+			name:   "Delete Account Endpoint",
+			path:   "/v1/users/me",
+			method: http.MethodDelete,
+		},
+		{
+			name:   "Request Data Export Endpoint",
+			path:   "/v1/users/me/export",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Get Data Export Endpoint",
+			path:   "/v1/users/me/export",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Download Data Export Endpoint",
+			path:   "/v1/exports/" + uuid.NewString() + "/download",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Get Onboarding Endpoint",
+			path:   "/v1/onboarding",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Answer Onboarding Step Endpoint",
+			path:   "/v1/onboarding/steps/brew_methods",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Skip Onboarding Step Endpoint",
+			path:   "/v1/onboarding/steps/brew_methods/skip",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Skip Onboarding Endpoint",
+			path:   "/v1/onboarding/skip",
+			method: http.MethodPost,
+		},
+		{
+			name:   "List Equipment Endpoint",
+			path:   "/v1/equipment",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Create API Key Endpoint",
+			path:   "/v1/api-keys",
+			method: http.MethodPost,
+		},
+		{
+			name:   "List API Keys Endpoint",
+			path:   "/v1/api-keys",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Revoke API Key Endpoint",
+			path:   "/v1/api-keys/" + uuid.NewString(),
+			method: http.MethodDelete,
+		},
+		{
+			name:   "List Beans Endpoint",
+			path:   "/v1/beans",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Create Bean Endpoint",
+			path:   "/v1/beans",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Get Bean Endpoint",
+			path:   "/v1/beans/" + uuid.NewString(),
+			method: http.MethodGet,
+		},
+		{
+			name:   "Update Bean Endpoint",
+			path:   "/v1/beans/" + uuid.NewString(),
+			method: http.MethodPut,
+		},
+		{
+			name:   "Delete Bean Endpoint",
+			path:   "/v1/beans/" + uuid.NewString(),
+			method: http.MethodDelete,
+		},
+		{
+			name:   "Upload Bean Images Endpoint",
+			path:   "/v1/beans/" + uuid.NewString() + "/images",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Bean Stock Endpoint",
+			path:   "/v1/beans/" + uuid.NewString() + "/stock",
+			method: http.MethodGet,
+		},
+		{
+			name:   "List Stock Adjustments Endpoint",
+			path:   "/v1/beans/" + uuid.NewString() + "/stock/adjustments",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Adjust Stock Endpoint",
+			path:   "/v1/beans/" + uuid.NewString() + "/stock/adjustments",
+			method: http.MethodPost,
+		},
+		{
+			name:   "List Bean Events Endpoint",
+			path:   "/v1/beans/" + uuid.NewString() + "/events",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Add Bean Event Endpoint",
+			path:   "/v1/beans/" + uuid.NewString() + "/events",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Split Bean Endpoint",
+			path:   "/v1/beans/" + uuid.NewString() + "/portions",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Catalog Suggestions Endpoint",
+			path:   "/v1/catalog/suggestions",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Search Roasters Endpoint",
+			path:   "/v1/catalog/roasters",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Create Roaster Endpoint",
+			path:   "/v1/catalog/roasters",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Get Roaster Endpoint",
+			path:   "/v1/catalog/roasters/" + uuid.NewString(),
+			method: http.MethodGet,
+		},
+		{
+			name:   "Search Coffees Endpoint",
+			path:   "/v1/catalog/coffees",
+			method: http.MethodGet,
+		},
+		{
+			name:   "Create Coffee Endpoint",
+			path:   "/v1/catalog/coffees",
+			method: http.MethodPost,
+		},
+		{
+			name:   "Get Coffee Endpoint",
+			path:   "/v1/catalog/coffees/" + uuid.NewString(),
+			method: http.MethodGet,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,107 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yashkadam007/brewkar/pkg/scheduler"
+)
+
+// Locker shared by several schedulers, standing in for Redis
+type memLocker struct {
+	mu    sync.Mutex
+	locks map[string]time.Time
+}
+
+func (l *memLocker) TryLock(key string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until, ok := l.locks[key]; ok && time.Now().Before(until) {
+		return false, nil
+	}
+	l.locks[key] = time.Now().Add(ttl)
+	return true, nil
+}
+
+func TestSchedulerRunsJobsRepeatedly(t *testing.T) {
+	var mu sync.Mutex
+	runs := 0
+
+	s := scheduler.New(&memLocker{locks: map[string]time.Time{}}, nil)
+	s.Add(scheduler.Job{
+		Name:     "count",
+		Interval: 20 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			mu.Lock()
+			runs++
+			mu.Unlock()
+			return nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	time.Sleep(110 * time.Millisecond)
+	cancel()
+	s.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.GreaterOrEqual(t, runs, 3)
+}
+
+func TestSchedulerRunsJobOnOneInstance(t *testing.T) {
+	locker := &memLocker{locks: map[string]time.Time{}}
+	runs := 0
+	job := scheduler.Job{
+		Name:     "once",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			runs++
+			return nil
+		},
+	}
+
+	first := scheduler.New(locker, nil)
+	second := scheduler.New(locker, nil)
+	first.RunNow(context.Background(), job)
+	second.RunNow(context.Background(), job)
+
+	assert.Equal(t, 1, runs)
+}
+
+func TestSchedulerReportsErrors(t *testing.T) {
+	var failedJob string
+	var failure error
+	s := scheduler.New(nil, func(job string, err error) {
+		failedJob, failure = job, err
+	})
+
+	boom := errors.New("boom")
+	s.RunNow(context.Background(), scheduler.Job{
+		Name:     "failing",
+		Interval: time.Hour,
+		Run:      func(ctx context.Context) error { return boom },
+	})
+
+	assert.Equal(t, "failing", failedJob)
+	assert.ErrorIs(t, failure, boom)
+}
+
+func TestNextRunOfAlignedJob(t *testing.T) {
+	daily := scheduler.Job{Name: "daily", Interval: 24 * time.Hour, Aligned: true, At: 8 * time.Hour}
+
+	// Later the same day, or the next day once the time has passed
+	assert.Equal(t, time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC), scheduler.NextRun(daily, time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC), scheduler.NextRun(daily, time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC), scheduler.NextRun(daily, time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC)))
+
+	// Other jobs wait an interval, wherever it ends
+	hourly := scheduler.Job{Name: "hourly", Interval: time.Hour}
+	now := time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC)
+	assert.Equal(t, now.Add(time.Hour), scheduler.NextRun(hourly, now))
+}
@@ -0,0 +1,179 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func TestRequestDeletionSchedulesDeletion(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	_, err = env.deletionService.RequestDeletion(user.ID, "wrong-password", "")
+	assert.ErrorIs(t, err, service.ErrIncorrectPassword)
+
+	user, err = env.deletionService.RequestDeletion(user.ID, "password123", "")
+	require.NoError(t, err)
+	require.True(t, user.IsPendingDeletion())
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), *user.DeletionScheduledAt, time.Minute)
+
+	// Every device is signed out
+	sessions, err := env.sessionService.ListSessions(user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	assert.Contains(t, env.mailer.sent[len(env.mailer.sent)-1].Subject, "will be deleted")
+
+	// Nothing is deleted before the grace period is over
+	deleted, err := env.deletionService.DeleteScheduledAccounts()
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+}
+
+func TestLoginCancelsDeletion(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, err = env.deletionService.RequestDeletion(user.ID, "password123", "")
+	require.NoError(t, err)
+
+	user, _, err = env.authService.Login("test@example.com", "password123", testClient)
+	require.NoError(t, err)
+	assert.False(t, user.IsPendingDeletion())
+	assert.Nil(t, user.DeletionRequestedAt)
+}
+
+func TestRequestDeletionNeedsMFACode(t *testing.T) {
+	env := newTestEnv()
+	user, _, recoveryCodes := enableMFA(t, env)
+
+	_, err := env.deletionService.RequestDeletion(user.ID, "password123", "")
+	assert.ErrorIs(t, err, service.ErrInvalidMFACode)
+
+	user, err = env.deletionService.RequestDeletion(user.ID, "password123", recoveryCodes[0])
+	require.NoError(t, err)
+	assert.True(t, user.IsPendingDeletion())
+}
+
+func TestDeleteScheduledAccounts(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	other, _, err := env.authService.Register("other@example.com", "password123", "Other User", testClient)
+	require.NoError(t, err)
+
+	user.AvatarKey = "avatars/" + user.ID.String() + "/a.png"
+	env.storage.files[user.AvatarKey] = []byte("image data")
+	_, err = env.exportService.RequestExport(user.ID)
+	require.NoError(t, err)
+	_, err = env.exportService.ProcessPendingExports()
+	require.NoError(t, err)
+	require.Len(t, env.storage.files, 2)
+
+	user, err = env.deletionService.RequestDeletion(user.ID, "password123", "")
+	require.NoError(t, err)
+	requestedAt := *user.DeletionRequestedAt
+	due := time.Now().Add(-time.Minute)
+	user.DeletionScheduledAt = &due
+
+	deleted, err := env.deletionService.DeleteScheduledAccounts()
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	_, err = env.userRepo.GetByID(user.ID)
+	assert.Error(t, err)
+	_, err = env.userRepo.GetByID(other.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, env.storage.files)
+
+	require.Len(t, env.userRepo.tombstones, 1)
+	tombstone := env.userRepo.tombstones[0]
+	assert.Equal(t, user.ID, tombstone.UserID)
+	assert.Equal(t, requestedAt, *tombstone.DeletionRequestedAt)
+	assert.Equal(t, 2, tombstone.FilesDeleted)
+}
+
+func TestGuestDeletedImmediately(t *testing.T) {
+	env := newTestEnv()
+	guest, _, err := env.guestService.CreateGuest(testClient)
+	require.NoError(t, err)
+
+	guest, err = env.deletionService.RequestDeletion(guest.ID, "", "")
+	require.NoError(t, err)
+	assert.True(t, guest.IsGuest)
+
+	_, err = env.userRepo.GetByID(guest.ID)
+	assert.Error(t, err)
+	require.Len(t, env.userRepo.tombstones, 1)
+	assert.True(t, env.userRepo.tombstones[0].WasGuest)
+}
+
+func TestDeleteAbandonedGuests(t *testing.T) {
+	env := newTestEnv()
+
+	abandoned, _, err := env.guestService.CreateGuest(testClient)
+	require.NoError(t, err)
+	longAgo := time.Now().AddDate(0, 0, -31)
+	abandoned.CreatedAt = longAgo
+	abandoned.LastLoginAt = &longAgo
+	abandoned.AvatarKey = "avatars/" + abandoned.ID.String() + "/a.png"
+	env.storage.files[abandoned.AvatarKey] = []byte("image data")
+
+	active, _, err := env.guestService.CreateGuest(testClient)
+	require.NoError(t, err)
+
+	registered, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	registered.CreatedAt = longAgo
+
+	deleted, err := env.deletionService.DeleteAbandonedGuests()
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.NotContains(t, env.userRepo.users, abandoned.ID)
+	assert.Contains(t, env.userRepo.users, active.ID)
+	assert.Contains(t, env.userRepo.users, registered.ID)
+
+	// Guests are deleted like any other account, files and all
+	assert.Empty(t, env.storage.files)
+	require.Len(t, env.userRepo.tombstones, 1)
+	assert.True(t, env.userRepo.tombstones[0].WasGuest)
+	assert.Equal(t, 1, env.userRepo.tombstones[0].FilesDeleted)
+}
+
+func TestAPIKeysSuspendedDuringDeletion(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, rawKey, err := env.apiKeyService.CreateKey(user.ID, "key", []domain.Scope{domain.ScopeBeansRead}, nil)
+	require.NoError(t, err)
+
+	_, err = env.deletionService.RequestDeletion(user.ID, "password123", "")
+	require.NoError(t, err)
+	_, err = env.apiKeyService.Authenticate(rawKey, domain.ScopeBeansRead)
+	assert.ErrorIs(t, err, service.ErrAccountPendingDeletion)
+
+	// Logging in cancels the deletion and the key works again
+	_, _, err = env.authService.Login("test@example.com", "password123", testClient)
+	require.NoError(t, err)
+	_, err = env.apiKeyService.Authenticate(rawKey, domain.ScopeBeansRead)
+	assert.NoError(t, err)
+}
+
+func TestPublicProfileHiddenDuringDeletion(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, err = env.handleService.SetHandle(user.ID, "coffee_lover")
+	require.NoError(t, err)
+
+	_, err = env.deletionService.RequestDeletion(user.ID, "password123", "")
+	require.NoError(t, err)
+
+	_, err = env.handleService.GetPublicProfile("coffee_lover")
+	assert.ErrorIs(t, err, service.ErrProfileNotFound)
+}
@@ -0,0 +1,136 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+// registerWithRole registers a user and gives them role directly, as an
+// operator bootstrapping the first admin would.
+func registerWithRole(t *testing.T, env *testEnv, email string, role domain.Role) *domain.User {
+	user, _, err := env.authService.Register(email, "password123", "Test User", testClient)
+	require.NoError(t, err)
+	user.Role = role
+	return user
+}
+
+func TestSuspendUser(t *testing.T) {
+	env := newTestEnv()
+	moderator := registerWithRole(t, env, "mod@example.com", domain.RoleModerator)
+	user := registerWithRole(t, env, "user@example.com", domain.RoleUser)
+	_, tokens, err := env.authService.Login("user@example.com", "password123", testClient)
+	require.NoError(t, err)
+
+	suspended, err := env.adminService.SuspendUser(moderator.ID, user.ID, "spam")
+	require.NoError(t, err)
+	assert.True(t, suspended.IsSuspended())
+	assert.Equal(t, "spam", suspended.SuspendedReason)
+	assert.True(t, env.suspensionRepo.suspended[user.ID])
+
+	// Suspended users can neither log in nor refresh their tokens
+	_, _, err = env.authService.Login("user@example.com", "password123", testClient)
+	assert.ErrorIs(t, err, service.ErrAccountSuspended)
+	_, err = env.authService.RefreshToken(tokens.RefreshToken, testClient)
+	assert.ErrorIs(t, err, service.ErrAccountSuspended)
+
+	// Lifting the suspension restores the existing session
+	_, err = env.adminService.UnsuspendUser(moderator.ID, user.ID)
+	require.NoError(t, err)
+	assert.False(t, env.suspensionRepo.suspended[user.ID])
+	_, err = env.authService.RefreshToken(tokens.RefreshToken, testClient)
+	assert.NoError(t, err)
+}
+
+func TestSuspendUserRequiresHigherRole(t *testing.T) {
+	env := newTestEnv()
+	admin := registerWithRole(t, env, "admin@example.com", domain.RoleAdmin)
+	moderator := registerWithRole(t, env, "mod@example.com", domain.RoleModerator)
+	otherModerator := registerWithRole(t, env, "mod2@example.com", domain.RoleModerator)
+
+	_, err := env.adminService.SuspendUser(moderator.ID, otherModerator.ID, "")
+	assert.ErrorIs(t, err, service.ErrInsufficientRank)
+	_, err = env.adminService.SuspendUser(moderator.ID, admin.ID, "")
+	assert.ErrorIs(t, err, service.ErrInsufficientRank)
+	_, err = env.adminService.SuspendUser(moderator.ID, moderator.ID, "")
+	assert.ErrorIs(t, err, service.ErrCannotManageSelf)
+	_, err = env.adminService.SuspendUser(admin.ID, uuid.New(), "")
+	assert.ErrorIs(t, err, service.ErrUserNotFound)
+
+	_, err = env.adminService.SuspendUser(admin.ID, otherModerator.ID, "")
+	assert.NoError(t, err)
+}
+
+func TestSetRole(t *testing.T) {
+	env := newTestEnv()
+	admin := registerWithRole(t, env, "admin@example.com", domain.RoleAdmin)
+	user := registerWithRole(t, env, "user@example.com", domain.RoleUser)
+
+	updated, err := env.adminService.SetRole(admin.ID, user.ID, domain.RoleModerator)
+	require.NoError(t, err)
+	assert.Equal(t, domain.RoleModerator, updated.Role)
+	// Access tokens carrying the old role were revoked
+	assert.Contains(t, env.revocationRepo.users, user.ID)
+
+	_, err = env.adminService.SetRole(admin.ID, user.ID, "superuser")
+	assert.ErrorIs(t, err, service.ErrInvalidRole)
+	_, err = env.adminService.SetRole(admin.ID, admin.ID, domain.RoleUser)
+	assert.ErrorIs(t, err, service.ErrCannotManageSelf)
+
+	guest, _, err := env.guestService.CreateGuest(testClient)
+	require.NoError(t, err)
+	_, err = env.adminService.SetRole(admin.ID, guest.ID, domain.RoleModerator)
+	assert.ErrorIs(t, err, service.ErrGuestCannotBeStaff)
+}
+
+func TestSetRoleRequiresHigherRole(t *testing.T) {
+	env := newTestEnv()
+	admin := registerWithRole(t, env, "admin@example.com", domain.RoleAdmin)
+	otherAdmin := registerWithRole(t, env, "admin2@example.com", domain.RoleAdmin)
+	moderator := registerWithRole(t, env, "mod@example.com", domain.RoleModerator)
+	user := registerWithRole(t, env, "user@example.com", domain.RoleUser)
+
+	// Admins cannot demote each other
+	_, err := env.adminService.SetRole(admin.ID, otherAdmin.ID, domain.RoleUser)
+	assert.ErrorIs(t, err, service.ErrInsufficientRank)
+	assert.Equal(t, domain.RoleAdmin, otherAdmin.Role)
+
+	// Nor can anyone give a role above their own
+	_, err = env.adminService.SetRole(moderator.ID, user.ID, domain.RoleAdmin)
+	assert.ErrorIs(t, err, service.ErrInsufficientRank)
+
+	_, err = env.adminService.SetRole(admin.ID, moderator.ID, domain.RoleAdmin)
+	assert.NoError(t, err)
+}
+
+func TestSearchUsers(t *testing.T) {
+	env := newTestEnv()
+	admin := registerWithRole(t, env, "admin@example.com", domain.RoleAdmin)
+	registerWithRole(t, env, "barista@example.com", domain.RoleUser)
+	spammer := registerWithRole(t, env, "spammer@example.com", domain.RoleUser)
+	_, err := env.adminService.SuspendUser(admin.ID, spammer.ID, "")
+	require.NoError(t, err)
+
+	users, total, err := env.adminService.SearchUsers(repository.UserSearch{Query: "BARISTA", Limit: 20})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "barista@example.com", users[0].EmailAddress())
+
+	suspended := true
+	users, total, err = env.adminService.SearchUsers(repository.UserSearch{Suspended: &suspended, Limit: 20})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, spammer.ID, users[0].ID)
+
+	_, total, err = env.adminService.SearchUsers(repository.UserSearch{Role: domain.RoleAdmin, Limit: 20})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	_, _, err = env.adminService.SearchUsers(repository.UserSearch{Role: "superuser"})
+	assert.ErrorIs(t, err, service.ErrInvalidRole)
+}
@@ -0,0 +1,144 @@
+package service_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func TestCreateAndAuthenticateAPIKey(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	key, rawKey, err := env.apiKeyService.CreateKey(user.ID, "Home Assistant", []domain.Scope{domain.ScopeBeansRead, domain.ScopeBeansRead}, nil)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(rawKey, key.Prefix+"_"))
+	assert.Equal(t, []domain.Scope{domain.ScopeBeansRead}, key.Scopes)
+	// Only a hash of the key is stored
+	assert.NotContains(t, key.KeyHash, rawKey)
+
+	authenticated, err := env.apiKeyService.Authenticate(rawKey, domain.ScopeBeansRead)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, authenticated.UserID)
+	assert.True(t, authenticated.HasScope(domain.ScopeBeansRead))
+	assert.False(t, authenticated.HasScope(domain.ScopeBeansWrite))
+	assert.NotNil(t, env.apiKeyRepo.keys[key.ID].LastUsedAt)
+
+	_, err = env.apiKeyService.Authenticate(rawKey+"x", domain.ScopeBeansRead)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+}
+
+func TestCreateAPIKeyValidation(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	_, _, err = env.apiKeyService.CreateKey(user.ID, "key", nil, nil)
+	assert.ErrorIs(t, err, service.ErrNoScopes)
+	_, _, err = env.apiKeyService.CreateKey(user.ID, "key", []domain.Scope{"beans:delete"}, nil)
+	assert.ErrorIs(t, err, service.ErrInvalidScope)
+	past := time.Now().Add(-time.Minute)
+	_, _, err = env.apiKeyService.CreateKey(user.ID, "key", []domain.Scope{domain.ScopeBeansRead}, &past)
+	assert.ErrorIs(t, err, service.ErrInvalidExpiry)
+
+	// The test config allows three keys per user
+	for i := 0; i < 3; i++ {
+		_, _, err = env.apiKeyService.CreateKey(user.ID, "key", []domain.Scope{domain.ScopeBeansRead}, nil)
+		require.NoError(t, err)
+	}
+	_, _, err = env.apiKeyService.CreateKey(user.ID, "key", []domain.Scope{domain.ScopeBeansRead}, nil)
+	assert.ErrorIs(t, err, service.ErrAPIKeyLimitReached)
+}
+
+func TestExpiredAndRevokedAPIKeysAreRejected(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour)
+	expiring, rawExpiring, err := env.apiKeyService.CreateKey(user.ID, "expiring", []domain.Scope{domain.ScopeBeansRead}, &expiresAt)
+	require.NoError(t, err)
+	expired := time.Now().Add(-time.Second)
+	env.apiKeyRepo.keys[expiring.ID].ExpiresAt = &expired
+	_, err = env.apiKeyService.Authenticate(rawExpiring, domain.ScopeBeansRead)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+
+	revoked, rawRevoked, err := env.apiKeyService.CreateKey(user.ID, "revoked", []domain.Scope{domain.ScopeBeansRead}, nil)
+	require.NoError(t, err)
+	require.NoError(t, env.apiKeyService.RevokeKey(user.ID, revoked.ID))
+	_, err = env.apiKeyService.Authenticate(rawRevoked, domain.ScopeBeansRead)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+	assert.ErrorIs(t, env.apiKeyService.RevokeKey(user.ID, revoked.ID), service.ErrAPIKeyNotFound)
+
+	keys, err := env.apiKeyService.ListKeys(user.ID)
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
+
+func TestRevokeAPIKeyOfAnotherUser(t *testing.T) {
+	env := newTestEnv()
+	owner, _, err := env.authService.Register("owner@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	other, _, err := env.authService.Register("other@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	key, _, err := env.apiKeyService.CreateKey(owner.ID, "key", []domain.Scope{domain.ScopeBeansRead}, nil)
+	require.NoError(t, err)
+	assert.ErrorIs(t, env.apiKeyService.RevokeKey(other.ID, key.ID), service.ErrAPIKeyNotFound)
+	assert.Nil(t, env.apiKeyRepo.keys[key.ID].RevokedAt)
+}
+
+func TestAPIKeyDailyQuota(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, rawKey, err := env.apiKeyService.CreateKey(user.ID, "key", []domain.Scope{domain.ScopeBeansRead}, nil)
+	require.NoError(t, err)
+
+	// The test config allows five requests per day
+	for i := 0; i < 5; i++ {
+		_, err = env.apiKeyService.Authenticate(rawKey, domain.ScopeBeansRead)
+		require.NoError(t, err)
+	}
+
+	_, err = env.apiKeyService.Authenticate(rawKey, domain.ScopeBeansRead)
+	var rateLimitErr *service.RateLimitError
+	require.True(t, errors.As(err, &rateLimitErr))
+	assert.True(t, rateLimitErr.RetryAfter > 0 && rateLimitErr.RetryAfter <= 24*time.Hour)
+}
+
+func TestAPIKeyScopeCheckedBeforeQuota(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, rawKey, err := env.apiKeyService.CreateKey(user.ID, "key", []domain.Scope{domain.ScopeBeansRead}, nil)
+	require.NoError(t, err)
+
+	// Requests outside the key's scopes are refused without using up the quota
+	for i := 0; i < 10; i++ {
+		_, err = env.apiKeyService.Authenticate(rawKey, domain.ScopeBeansWrite)
+		require.ErrorIs(t, err, service.ErrInsufficientScope)
+	}
+	_, err = env.apiKeyService.Authenticate(rawKey, domain.ScopeBeansRead)
+	assert.NoError(t, err)
+}
+
+func TestAPIKeyOfSuspendedUser(t *testing.T) {
+	env := newTestEnv()
+	admin := registerWithRole(t, env, "admin@example.com", domain.RoleAdmin)
+	user := registerWithRole(t, env, "user@example.com", domain.RoleUser)
+	_, rawKey, err := env.apiKeyService.CreateKey(user.ID, "key", []domain.Scope{domain.ScopeBeansRead}, nil)
+	require.NoError(t, err)
+
+	_, err = env.adminService.SuspendUser(admin.ID, user.ID, "")
+	require.NoError(t, err)
+	_, err = env.apiKeyService.Authenticate(rawKey, domain.ScopeBeansRead)
+	assert.ErrorIs(t, err, service.ErrAccountSuspended)
+}
@@ -0,0 +1,121 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/service"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func setupAuthService() service.AuthService {
+	return newTestEnv().authService
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	authService := setupAuthService()
+
+	_, tokens, err := authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	rotated, err := authService.RefreshToken(tokens.RefreshToken, testClient)
+	require.NoError(t, err)
+	assert.NotEmpty(t, rotated.AccessToken)
+	assert.NotEqual(t, tokens.RefreshToken, rotated.RefreshToken)
+
+	// The rotated token keeps working
+	_, err = authService.RefreshToken(rotated.RefreshToken, testClient)
+	assert.NoError(t, err)
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	authService := setupAuthService()
+
+	_, tokens, err := authService.Login("missing@example.com", "password123", testClient)
+	assert.Error(t, err)
+	assert.Nil(t, tokens)
+
+	_, tokens, err = authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	rotated, err := authService.RefreshToken(tokens.RefreshToken, testClient)
+	require.NoError(t, err)
+
+	// Presenting the already used token again is reuse
+	_, err = authService.RefreshToken(tokens.RefreshToken, testClient)
+	assert.ErrorIs(t, err, service.ErrRefreshTokenReused)
+
+	// The whole session is revoked, including the newest token
+	_, err = authService.RefreshToken(rotated.RefreshToken, testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+}
+
+func TestRefreshTokenUnknown(t *testing.T) {
+	authService := setupAuthService()
+
+	_, err := authService.RefreshToken("not-a-real-token", testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+}
+
+func TestLogoutAllRevokesRefreshTokens(t *testing.T) {
+	authService := setupAuthService()
+
+	user, tokens, err := authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	require.NoError(t, authService.LogoutAll(user.ID))
+
+	_, err = authService.RefreshToken(tokens.RefreshToken, testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+}
+
+func TestIssuedAtKeepsMilliseconds(t *testing.T) {
+	issued := time.Date(2026, 10, 17, 9, 30, 0, 250*int(time.Millisecond), time.UTC)
+	assert.True(t, issued.Equal(service.IssuedAt(service.IssuedAtClaim(issued))))
+
+	// A token issued in the same second as, but after, a revocation is
+	// told apart from one issued before it
+	revokedAt := issued.Add(-100 * time.Millisecond)
+	assert.True(t, service.IssuedAt(service.IssuedAtClaim(issued)).After(revokedAt))
+}
+
+func TestRegisterAppliesPasswordPolicy(t *testing.T) {
+	authService := setupAuthService()
+
+	_, _, err := authService.Register("test@example.com", breachedPassword, "Test User", testClient)
+	assert.ErrorIs(t, err, service.ErrWeakPassword)
+
+	_, _, err = authService.Register("test@example.com", "short", "Test User", testClient)
+	assert.ErrorIs(t, err, service.ErrWeakPassword)
+}
+
+func TestLoginUpgradesLegacyBcryptHash(t *testing.T) {
+	env := newTestEnv()
+
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user.PasswordHash = string(legacy)
+
+	_, _, err = env.authService.Login("test@example.com", "password123", testClient)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(user.PasswordHash, "$argon2id$"), user.PasswordHash)
+
+	// The new hash keeps working and is not replaced again
+	upgraded := user.PasswordHash
+	_, _, err = env.authService.Login("test@example.com", "password123", testClient)
+	require.NoError(t, err)
+	assert.Equal(t, upgraded, user.PasswordHash)
+
+	// A wrong password leaves a legacy hash alone
+	user.PasswordHash = string(legacy)
+	_, _, err = env.authService.Login("test@example.com", "wrongpassword", testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+	assert.Equal(t, string(legacy), user.PasswordHash)
+}
@@ -0,0 +1,197 @@
+package service_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func addBeanEvent(t *testing.T, env *testEnv, userID, beanID uuid.UUID, kind domain.BeanEventKind, days int) *domain.CoffeeBean {
+	bean, _, err := env.beanService.AddEvent(userID, beanID, service.NewBeanEvent{Kind: kind, Date: roastedDaysAgo(days)})
+	require.NoError(t, err)
+	return bean
+}
+
+func TestBeanEvents(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	bean := newFreshnessBean(t, env, user.ID, "Kenya AA", domain.RoastMedium, 30)
+
+	addBeanEvent(t, env, user.ID, bean.ID, domain.BeanOpened, 25)
+	addBeanEvent(t, env, user.ID, bean.ID, domain.BeanFrozen, 20)
+	updated, event, err := env.beanService.AddEvent(user.ID, bean.ID, service.NewBeanEvent{Kind: domain.BeanThawed, Date: roastedDaysAgo(5), Note: " out of the freezer "})
+	require.NoError(t, err)
+	assert.Equal(t, "out of the freezer", event.Note)
+	assert.Equal(t, 15, updated.FrozenDays)
+	assert.Nil(t, updated.FrozenOn)
+	assert.Equal(t, roastedDaysAgo(25).Format("2006-01-02"), updated.OpenedOn.Format("2006-01-02"))
+
+	// The days in the freezer do not count against freshness
+	rules, err := env.beanService.FreshnessRules(user.ID, "")
+	require.NoError(t, err)
+	freshness := rules.Of(updated)
+	assert.Equal(t, 15, freshness.DaysOffRoast)
+	assert.Equal(t, 15, freshness.FrozenDays)
+	assert.Equal(t, domain.FreshnessPeak, freshness.State)
+
+	events, total, err := env.beanService.ListEvents(user.ID, bean.ID, 0, 20)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, total)
+	require.Len(t, events, 3)
+	assert.Equal(t, domain.BeanOpened, events[0].Kind)
+	assert.Equal(t, domain.BeanThawed, events[2].Kind)
+
+	tests := []struct {
+		name  string
+		event service.NewBeanEvent
+		err   error
+	}{
+		{"unknown kind", service.NewBeanEvent{Kind: "roasted"}, service.ErrInvalidBeanEvent},
+		{"future", service.NewBeanEvent{Kind: domain.BeanFrozen, Date: roastedDaysAgo(-2)}, service.ErrInvalidEventDate},
+		{"before the latest event", service.NewBeanEvent{Kind: domain.BeanFrozen, Date: roastedDaysAgo(6)}, service.ErrInvalidEventDate},
+		{"opened twice", service.NewBeanEvent{Kind: domain.BeanOpened}, service.ErrBeanAlreadyOpened},
+		{"thawed twice", service.NewBeanEvent{Kind: domain.BeanThawed}, service.ErrBeanNotFrozen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := env.beanService.AddEvent(user.ID, bean.ID, tt.event)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	// Nothing happens to a finished bag
+	finished := addBeanEvent(t, env, user.ID, bean.ID, domain.BeanFinished, 0)
+	assert.NotNil(t, finished.FinishedOn)
+	_, _, err = env.beanService.AddEvent(user.ID, bean.ID, service.NewBeanEvent{Kind: domain.BeanFrozen})
+	assert.ErrorIs(t, err, service.ErrBeanFinished)
+
+	_, _, err = env.beanService.AddEvent(uuid.New(), bean.ID, service.NewBeanEvent{Kind: domain.BeanOpened})
+	assert.ErrorIs(t, err, service.ErrBeanNotFound)
+}
+
+func TestEventBeforeRoastDate(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	bean := newFreshnessBean(t, env, user.ID, "Kenya AA", domain.RoastMedium, 10)
+
+	_, _, err = env.beanService.AddEvent(user.ID, bean.ID, service.NewBeanEvent{Kind: domain.BeanPurchased, Date: roastedDaysAgo(12)})
+	assert.ErrorIs(t, err, service.ErrInvalidEventDate)
+
+	updated := addBeanEvent(t, env, user.ID, bean.ID, domain.BeanPurchased, 8)
+	assert.Equal(t, roastedDaysAgo(8).Format("2006-01-02"), updated.PurchaseDate.Format("2006-01-02"))
+}
+
+func TestFrozenBeanDoesNotAge(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	bean := newFreshnessBean(t, env, user.ID, "Kenya AA", domain.RoastMedium, 10)
+	frozen := addBeanEvent(t, env, user.ID, bean.ID, domain.BeanFrozen, 8)
+
+	rules, err := env.beanService.FreshnessRules(user.ID, "")
+	require.NoError(t, err)
+	freshness := rules.Of(frozen)
+	assert.True(t, freshness.IsFrozen)
+	assert.Equal(t, 2, freshness.DaysOffRoast)
+	assert.Equal(t, domain.FreshnessResting, freshness.State)
+
+	search := repository.BeanSearch{Limit: 20}
+	search.RoastDates, search.Today = rules.RoastDates(domain.FreshnessResting)
+	beans, _, err := env.beanService.ListBeans(user.ID, search)
+	require.NoError(t, err)
+	require.Len(t, beans, 1)
+
+	// Frozen beans are left out of the freshness emails
+	sent := len(env.mailer.sent)
+	emails, err := env.beanService.NotifyFreshness()
+	require.NoError(t, err)
+	assert.Equal(t, 0, emails)
+	assert.Len(t, env.mailer.sent, sent)
+}
+
+func TestSplitBean(t *testing.T) {
+	env := newTestEnv()
+	userID, bean := newStockBean(t, env, 250)
+	addBeanEvent(t, env, userID, bean.ID, domain.BeanOpened, 0)
+	sent := len(env.mailer.sent)
+
+	updated, portions, err := env.beanService.SplitBean(userID, bean.ID, service.BeanPortions{QuantitiesGrams: []int{30, 30, 30}, Freeze: true})
+	require.NoError(t, err)
+	assert.Equal(t, 160, *updated.QuantityGrams)
+	require.Len(t, portions, 3)
+	for i, portion := range portions {
+		assert.Equal(t, fmt.Sprintf("Kenya AA (portion %d)", i+1), portion.Name)
+		assert.Equal(t, 30, *portion.QuantityGrams)
+		assert.Equal(t, bean.ID, *portion.ParentID)
+		assert.NotNil(t, portion.OpenedOn)
+		assert.NotNil(t, portion.FrozenOn)
+	}
+	// Small portions are made on purpose and do not warn about low stock
+	assert.Len(t, env.mailer.sent, sent)
+
+	// Each portion keeps its own stock and timeline
+	_, _, err = env.beanService.AdjustStock(userID, portions[0].ID, service.StockChange{ChangeGrams: ptr(-12)})
+	require.NoError(t, err)
+	stored, err := env.beanService.GetBean(userID, portions[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, 30, *stored.QuantityGrams)
+	events, _, err := env.beanService.ListEvents(userID, portions[0].ID, 0, 20)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, domain.BeanOpened, events[0].Kind)
+	assert.Equal(t, domain.BeanFrozen, events[1].Kind)
+
+	adjustments, _, err := env.beanService.ListStockAdjustments(userID, bean.ID, 0, 20)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StockChangeSplit, adjustments[0].Kind)
+	assert.Equal(t, -90, adjustments[0].ChangeGrams)
+
+	// Later portions are numbered on
+	_, more, err := env.beanService.SplitBean(userID, bean.ID, service.BeanPortions{QuantitiesGrams: []int{60}})
+	require.NoError(t, err)
+	assert.Equal(t, "Kenya AA (portion 4)", more[0].Name)
+	assert.Nil(t, more[0].FrozenOn)
+
+	children, total, err := env.beanService.ListBeans(userID, repository.BeanSearch{ParentID: &bean.ID, Limit: 20})
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, total)
+	assert.Len(t, children, 4)
+
+	tests := []struct {
+		name       string
+		quantities []int
+		err        error
+	}{
+		{"no portions", nil, service.ErrInvalidPortions},
+		{"empty portion", []int{50, 0}, service.ErrInvalidPortions},
+		{"more than is left", []int{50, 60}, service.ErrPortionsTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := env.beanService.SplitBean(userID, bean.ID, service.BeanPortions{QuantitiesGrams: tt.quantities})
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+	stored, err = env.beanService.GetBean(userID, bean.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 100, *stored.QuantityGrams)
+}
+
+func TestSplitUntrackedBean(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	bean, err := env.beanService.CreateBean(user.ID, service.BeanFields{Name: ptr("Kenya AA")})
+	require.NoError(t, err)
+
+	_, _, err = env.beanService.SplitBean(user.ID, bean.ID, service.BeanPortions{QuantitiesGrams: []int{50}})
+	assert.ErrorIs(t, err, service.ErrStockNotTracked)
+}
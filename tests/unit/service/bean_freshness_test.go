@@ -0,0 +1,173 @@
+package service_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+// roastedDaysAgo returns the date days ago, at midnight UTC like the roast
+// dates the API takes
+func roastedDaysAgo(days int) *time.Time {
+	year, month, day := time.Now().UTC().AddDate(0, 0, -days).Date()
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &date
+}
+
+func newFreshnessBean(t *testing.T, env *testEnv, userID uuid.UUID, name string, level domain.RoastLevel, days int) *domain.CoffeeBean {
+	bean, err := env.beanService.CreateBean(userID, service.BeanFields{Name: ptr(name), RoastLevel: ptr(level), RoastDate: roastedDaysAgo(days)})
+	require.NoError(t, err)
+	return bean
+}
+
+func TestBeanFreshness(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	rules, err := env.beanService.FreshnessRules(user.ID, "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		level domain.RoastLevel
+		days  int
+		state domain.FreshnessState
+	}{
+		{"just roasted", domain.RoastMedium, 1, domain.FreshnessResting},
+		{"peak starts", domain.RoastMedium, 3, domain.FreshnessPeak},
+		{"peak", domain.RoastLight, 10, domain.FreshnessPeak},
+		{"fading", domain.RoastUnknown, 25, domain.FreshnessFading},
+		{"stale", domain.RoastMedium, 40, domain.FreshnessStale},
+		{"dark fades sooner", domain.RoastDark, 16, domain.FreshnessFading},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bean := newFreshnessBean(t, env, user.ID, tt.name, tt.level, tt.days)
+			freshness := rules.Of(bean)
+			require.NotNil(t, freshness)
+			assert.Equal(t, tt.days, freshness.DaysOffRoast)
+			assert.Equal(t, tt.state, freshness.State)
+		})
+	}
+
+	// Espresso rests longer than filter
+	bean := newFreshnessBean(t, env, user.ID, "Kenya AA", domain.RoastMedium, 5)
+	assert.Equal(t, domain.FreshnessPeak, rules.Of(bean).State)
+	espresso, err := env.beanService.FreshnessRules(user.ID, "espresso")
+	require.NoError(t, err)
+	freshness := espresso.Of(bean)
+	assert.Equal(t, domain.FreshnessResting, freshness.State)
+	assert.Equal(t, roastedDaysAgo(-2).Format(time.DateOnly), freshness.PeakDate.Format(time.DateOnly))
+
+	// The user's default brew method applies when none is given
+	_, err = env.userService.UpdatePreferences(user.ID, json.RawMessage(`{"defaultBrewMethod":"espresso"}`))
+	require.NoError(t, err)
+	rules, err = env.beanService.FreshnessRules(user.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, "espresso", rules.BrewMethod)
+	assert.Equal(t, domain.FreshnessResting, rules.Of(bean).State)
+
+	unroasted, err := env.beanService.CreateBean(user.ID, service.BeanFields{Name: ptr("Mystery bag")})
+	require.NoError(t, err)
+	assert.Nil(t, rules.Of(unroasted))
+}
+
+func TestFilterBeansByFreshness(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	newFreshnessBean(t, env, user.ID, "Resting", domain.RoastMedium, 1)
+	newFreshnessBean(t, env, user.ID, "Peak", domain.RoastMedium, 3)
+	newFreshnessBean(t, env, user.ID, "Peak dark", domain.RoastDark, 13)
+	newFreshnessBean(t, env, user.ID, "Fading dark", domain.RoastDark, 14)
+	newFreshnessBean(t, env, user.ID, "Stale", domain.RoastMedium, 40)
+	_, err = env.beanService.CreateBean(user.ID, service.BeanFields{Name: ptr("No roast date")})
+	require.NoError(t, err)
+
+	rules, err := env.beanService.FreshnessRules(user.ID, "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		state domain.FreshnessState
+		names []string
+	}{
+		{domain.FreshnessResting, []string{"Resting"}},
+		{domain.FreshnessPeak, []string{"Peak", "Peak dark"}},
+		{domain.FreshnessFading, []string{"Fading dark"}},
+		{domain.FreshnessStale, []string{"Stale"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.state), func(t *testing.T) {
+			search := repository.BeanSearch{Sort: repository.BeanSortName, Asc: true, Limit: 20}
+			search.RoastDates, search.Today = rules.RoastDates(tt.state)
+			beans, _, err := env.beanService.ListBeans(user.ID, search)
+			require.NoError(t, err)
+			var names []string
+			for _, bean := range beans {
+				names = append(names, bean.Name)
+				assert.Equal(t, tt.state, rules.Of(&bean).State)
+			}
+			assert.Equal(t, tt.names, names)
+		})
+	}
+}
+
+func TestNotifyFreshness(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	peak := newFreshnessBean(t, env, user.ID, "Kenya AA", domain.RoastMedium, 5)
+	newFreshnessBean(t, env, user.ID, "Ethiopia Guji", domain.RoastMedium, 38)
+	newFreshnessBean(t, env, user.ID, "Colombia Huila", domain.RoastMedium, 1)
+	newFreshnessBean(t, env, user.ID, "Old bag", domain.RoastMedium, 50)
+	sent := len(env.mailer.sent)
+
+	emails, err := env.beanService.NotifyFreshness()
+	require.NoError(t, err)
+	assert.Equal(t, 1, emails)
+	require.Len(t, env.mailer.sent, sent+1)
+	msg := env.mailer.sent[sent]
+	assert.Equal(t, "test@example.com", msg.To)
+	assert.Contains(t, msg.Body, "Kenya AA, roasted 5 days ago")
+	assert.Contains(t, msg.Body, "Ethiopia Guji, stale on")
+	assert.NotContains(t, msg.Body, "Colombia Huila")
+	assert.NotContains(t, msg.Body, "Old bag")
+
+	// Every bean is only mentioned once
+	emails, err = env.beanService.NotifyFreshness()
+	require.NoError(t, err)
+	assert.Equal(t, 0, emails)
+
+	// A new roast date starts over
+	_, err = env.beanService.UpdateBean(user.ID, peak.ID, service.BeanFields{RoastDate: roastedDaysAgo(4)})
+	require.NoError(t, err)
+	emails, err = env.beanService.NotifyFreshness()
+	require.NoError(t, err)
+	assert.Equal(t, 1, emails)
+	assert.Contains(t, env.mailer.sent[len(env.mailer.sent)-1].Body, "Kenya AA, roasted 4 days ago")
+}
+
+func TestNotifyFreshnessRespectsPreferences(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, err = env.userService.UpdatePreferences(user.ID, json.RawMessage(`{"notifications":{"beanFreshness":false}}`))
+	require.NoError(t, err)
+	bean := newFreshnessBean(t, env, user.ID, "Kenya AA", domain.RoastMedium, 5)
+	sent := len(env.mailer.sent)
+
+	emails, err := env.beanService.NotifyFreshness()
+	require.NoError(t, err)
+	assert.Equal(t, 0, emails)
+	assert.Len(t, env.mailer.sent, sent)
+	// The bean still counts as notified
+	assert.NotNil(t, env.beanRepo.beans[bean.ID].PeakNotifiedAt)
+}
@@ -0,0 +1,154 @@
+package service_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestCreateBean(t *testing.T) {
+	env := newTestEnv()
+	userID := uuid.New()
+
+	bean, err := env.beanService.CreateBean(userID, service.BeanFields{
+		Name:          ptr("  Ethiopia Yirgacheffe "),
+		Roaster:       ptr("Stumptown"),
+		RoastLevel:    ptr(domain.RoastLight),
+		FlavorNotes:   []string{"floral", " Citrus", "", "citrus"},
+		Price:         ptr(18.99),
+		QuantityGrams: ptr(250),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Ethiopia Yirgacheffe", bean.Name)
+	assert.Equal(t, domain.RoastLight, bean.RoastLevel)
+	assert.Equal(t, domain.SpeciesUnknown, bean.BeanSpecies)
+	assert.Equal(t, []string{"floral", "Citrus"}, bean.FlavorNotes)
+	assert.True(t, bean.IsActive)
+	assert.Empty(t, bean.ImageURLs)
+
+	fetched, err := env.beanService.GetBean(userID, bean.ID)
+	require.NoError(t, err)
+	assert.Equal(t, bean.ID, fetched.ID)
+
+	// Someone else's bean cannot be seen
+	_, err = env.beanService.GetBean(uuid.New(), bean.ID)
+	assert.ErrorIs(t, err, service.ErrBeanNotFound)
+}
+
+func TestCreateBeanValidation(t *testing.T) {
+	env := newTestEnv()
+	userID := uuid.New()
+
+	tests := []struct {
+		name   string
+		fields service.BeanFields
+		err    error
+	}{
+		{"missing name", service.BeanFields{}, service.ErrInvalidBeanName},
+		{"blank name", service.BeanFields{Name: ptr("   ")}, service.ErrInvalidBeanName},
+		{"roast level", service.BeanFields{Name: ptr("Kenya"), RoastLevel: ptr(domain.RoastLevel("blonde"))}, service.ErrInvalidRoastLevel},
+		{"species", service.BeanFields{Name: ptr("Kenya"), BeanSpecies: ptr(domain.BeanSpecies("liberica"))}, service.ErrInvalidBeanSpecies},
+		{"zero quantity", service.BeanFields{Name: ptr("Kenya"), QuantityGrams: ptr(0)}, service.ErrInvalidQuantity},
+		{"negative quantity", service.BeanFields{Name: ptr("Kenya"), QuantityGrams: ptr(-10)}, service.ErrInvalidQuantity},
+		{"negative price", service.BeanFields{Name: ptr("Kenya"), Price: ptr(-1.0)}, service.ErrInvalidPrice},
+		{"future roast date", service.BeanFields{Name: ptr("Kenya"), RoastDate: ptr(time.Now().AddDate(0, 0, 7))}, service.ErrInvalidRoastDate},
+		{"long flavor note", service.BeanFields{Name: ptr("Kenya"), FlavorNotes: []string{strings.Repeat("x", 51)}}, service.ErrInvalidFlavorNotes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.beanService.CreateBean(userID, tt.fields)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+	assert.Empty(t, env.beanRepo.beans)
+}
+
+func TestUpdateAndDeleteBean(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	userID := user.ID
+	bean, err := env.beanService.CreateBean(userID, service.BeanFields{Name: ptr("Colombia Huila"), QuantityGrams: ptr(250)})
+	require.NoError(t, err)
+
+	updated, err := env.beanService.UpdateBean(userID, bean.ID, service.BeanFields{QuantityGrams: ptr(220), IsFavorite: ptr(true)})
+	require.NoError(t, err)
+	assert.Equal(t, "Colombia Huila", updated.Name)
+	assert.Equal(t, 220, *updated.QuantityGrams)
+	assert.True(t, updated.IsFavorite)
+
+	_, err = env.beanService.UpdateBean(userID, bean.ID, service.BeanFields{QuantityGrams: ptr(-5)})
+	assert.ErrorIs(t, err, service.ErrInvalidQuantity)
+	_, err = env.beanService.UpdateBean(uuid.New(), bean.ID, service.BeanFields{IsFavorite: ptr(false)})
+	assert.ErrorIs(t, err, service.ErrBeanNotFound)
+
+	// Deleting only deactivates the bean
+	require.NoError(t, env.beanService.DeleteBean(userID, bean.ID))
+	deleted, err := env.beanService.GetBean(userID, bean.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted.IsActive)
+}
+
+func TestListBeans(t *testing.T) {
+	env := newTestEnv()
+	userID := uuid.New()
+	for _, name := range []string{"Kenya AA", "Ethiopia Guji", "Kenya Peaberry"} {
+		_, err := env.beanService.CreateBean(userID, service.BeanFields{Name: ptr(name)})
+		require.NoError(t, err)
+	}
+	_, err := env.beanService.CreateBean(uuid.New(), service.BeanFields{Name: ptr("Kenya AA")})
+	require.NoError(t, err)
+
+	beans, total, err := env.beanService.ListBeans(userID, repository.BeanSearch{Query: "kenya", Sort: repository.BeanSortName, Asc: true, Limit: 20})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	require.Len(t, beans, 2)
+	assert.Equal(t, "Kenya AA", beans[0].Name)
+	assert.Equal(t, "Kenya Peaberry", beans[1].Name)
+}
+
+func TestUploadBeanImages(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	userID := user.ID
+	bean, err := env.beanService.CreateBean(userID, service.BeanFields{Name: ptr("Kenya AA")})
+	require.NoError(t, err)
+
+	updated, err := env.beanService.UploadImages(userID, bean.ID, []io.Reader{bytes.NewReader(pngAvatar), bytes.NewReader(pngAvatar)})
+	require.NoError(t, err)
+	require.Len(t, updated.ImageURLs, 2)
+	assert.True(t, strings.HasPrefix(updated.ImageURLs[0], "https://files.example.com/beans/"+userID.String()+"/"+bean.ID.String()+"/"))
+	assert.Len(t, env.storage.files, 2)
+
+	// A bad image stores none of the batch
+	_, err = env.beanService.UploadImages(userID, bean.ID, []io.Reader{strings.NewReader("not an image")})
+	assert.ErrorIs(t, err, service.ErrInvalidBeanImage)
+	assert.Len(t, env.storage.files, 2)
+
+	_, err = env.beanService.UploadImages(userID, bean.ID, []io.Reader{bytes.NewReader(pngAvatar), bytes.NewReader(pngAvatar)})
+	assert.ErrorIs(t, err, service.ErrTooManyBeanImages)
+
+	// Deleting the account removes the images
+	user, err = env.deletionService.RequestDeletion(userID, "password123", "")
+	require.NoError(t, err)
+	due := time.Now().Add(-time.Minute)
+	user.DeletionScheduledAt = &due
+	deleted, err := env.deletionService.DeleteScheduledAccounts()
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Empty(t, env.storage.files)
+}
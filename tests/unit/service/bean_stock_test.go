@@ -0,0 +1,193 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func newStockBean(t *testing.T, env *testEnv, quantity int) (uuid.UUID, *domain.CoffeeBean) {
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	bean, err := env.beanService.CreateBean(user.ID, service.BeanFields{Name: ptr("Kenya AA"), QuantityGrams: ptr(quantity)})
+	require.NoError(t, err)
+	return user.ID, bean
+}
+
+func TestAdjustStock(t *testing.T) {
+	env := newTestEnv()
+	userID, bean := newStockBean(t, env, 250)
+
+	updated, adjustment, err := env.beanService.AdjustStock(userID, bean.ID, service.StockChange{ChangeGrams: ptr(-20), Note: " spilled "})
+	require.NoError(t, err)
+	assert.Equal(t, 230, *updated.QuantityGrams)
+	assert.Equal(t, domain.StockChangeManual, adjustment.Kind)
+	assert.Equal(t, -20, adjustment.ChangeGrams)
+	assert.Equal(t, 230, adjustment.QuantityAfter)
+	assert.Equal(t, "spilled", adjustment.Note)
+
+	// Weighing the bag sets the quantity
+	_, adjustment, err = env.beanService.AdjustStock(userID, bean.ID, service.StockChange{QuantityGrams: ptr(240)})
+	require.NoError(t, err)
+	assert.Equal(t, 10, adjustment.ChangeGrams)
+
+	// Editing the quantity is recorded too
+	_, err = env.beanService.UpdateBean(userID, bean.ID, service.BeanFields{QuantityGrams: ptr(200)})
+	require.NoError(t, err)
+
+	adjustments, total, err := env.beanService.ListStockAdjustments(userID, bean.ID, 0, 20)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, total)
+	require.Len(t, adjustments, 3)
+	assert.Equal(t, domain.StockChangeEdit, adjustments[0].Kind)
+	assert.Equal(t, -40, adjustments[0].ChangeGrams)
+	assert.Equal(t, 200, adjustments[0].QuantityAfter)
+
+	tests := []struct {
+		name   string
+		change service.StockChange
+		err    error
+	}{
+		{"nothing", service.StockChange{}, service.ErrInvalidStockChange},
+		{"both", service.StockChange{ChangeGrams: ptr(5), QuantityGrams: ptr(5)}, service.ErrInvalidStockChange},
+		{"zero change", service.StockChange{ChangeGrams: ptr(0)}, service.ErrInvalidStockChange},
+		{"below zero", service.StockChange{ChangeGrams: ptr(-500)}, service.ErrStockBelowZero},
+		{"long note", service.StockChange{ChangeGrams: ptr(5), Note: strings.Repeat("x", 201)}, service.ErrStockNoteTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := env.beanService.AdjustStock(userID, bean.ID, tt.change)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+	assert.Equal(t, 200, *env.beanRepo.beans[bean.ID].QuantityGrams)
+
+	_, _, err = env.beanService.AdjustStock(uuid.New(), bean.ID, service.StockChange{ChangeGrams: ptr(5)})
+	assert.ErrorIs(t, err, service.ErrBeanNotFound)
+}
+
+func TestAdjustStockUntracked(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	bean, err := env.beanService.CreateBean(user.ID, service.BeanFields{Name: ptr("Kenya AA")})
+	require.NoError(t, err)
+
+	_, _, err = env.beanService.AdjustStock(user.ID, bean.ID, service.StockChange{ChangeGrams: ptr(-5)})
+	assert.ErrorIs(t, err, service.ErrStockNotTracked)
+
+	// Brews of an untracked bean change nothing
+	_, err = env.beanService.RecordBrewUsage(user.ID, bean.ID, uuid.New(), 18)
+	require.NoError(t, err)
+	assert.Empty(t, env.stockRepo.adjustments)
+}
+
+func TestRecordBrewUsage(t *testing.T) {
+	env := newTestEnv()
+	userID, bean := newStockBean(t, env, 250)
+	brewLogID := uuid.New()
+
+	updated, err := env.beanService.RecordBrewUsage(userID, bean.ID, brewLogID, 18.4)
+	require.NoError(t, err)
+	assert.Equal(t, 232, *updated.QuantityGrams)
+
+	// Editing the dose only deducts the difference
+	updated, err = env.beanService.RecordBrewUsage(userID, bean.ID, brewLogID, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 230, *updated.QuantityGrams)
+
+	// Recording the same dose again changes nothing
+	_, err = env.beanService.RecordBrewUsage(userID, bean.ID, brewLogID, 20)
+	require.NoError(t, err)
+	assert.Len(t, env.stockRepo.adjustments, 2)
+
+	// Deleting the brew log gives the coffee back
+	updated, err = env.beanService.RecordBrewUsage(userID, bean.ID, brewLogID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 250, *updated.QuantityGrams)
+
+	_, err = env.beanService.RecordBrewUsage(userID, bean.ID, brewLogID, -1)
+	assert.ErrorIs(t, err, service.ErrInvalidDose)
+}
+
+func TestRecordBrewUsageStopsAtZero(t *testing.T) {
+	env := newTestEnv()
+	userID, bean := newStockBean(t, env, 10)
+	brewLogID := uuid.New()
+
+	updated, err := env.beanService.RecordBrewUsage(userID, bean.ID, brewLogID, 18)
+	require.NoError(t, err)
+	assert.Equal(t, 0, *updated.QuantityGrams)
+
+	// Only what was deducted comes back
+	updated, err = env.beanService.RecordBrewUsage(userID, bean.ID, brewLogID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 10, *updated.QuantityGrams)
+}
+
+func TestLowStockEmail(t *testing.T) {
+	env := newTestEnv()
+	userID, bean := newStockBean(t, env, 100)
+	sent := len(env.mailer.sent)
+
+	_, err := env.beanService.RecordBrewUsage(userID, bean.ID, uuid.New(), 40)
+	require.NoError(t, err)
+	assert.Len(t, env.mailer.sent, sent)
+
+	// Dropping below the threshold warns once
+	_, err = env.beanService.RecordBrewUsage(userID, bean.ID, uuid.New(), 18)
+	require.NoError(t, err)
+	require.Len(t, env.mailer.sent, sent+1)
+	msg := env.mailer.sent[sent]
+	assert.Equal(t, "test@example.com", msg.To)
+	assert.Contains(t, msg.Subject, "Kenya AA")
+	assert.Contains(t, msg.Body, "42 g")
+
+	_, err = env.beanService.RecordBrewUsage(userID, bean.ID, uuid.New(), 18)
+	require.NoError(t, err)
+	assert.Len(t, env.mailer.sent, sent+1)
+
+	// A new bag rearms the warning
+	_, _, err = env.beanService.AdjustStock(userID, bean.ID, service.StockChange{QuantityGrams: ptr(250)})
+	require.NoError(t, err)
+	_, _, err = env.beanService.AdjustStock(userID, bean.ID, service.StockChange{QuantityGrams: ptr(30)})
+	require.NoError(t, err)
+	assert.Len(t, env.mailer.sent, sent+2)
+}
+
+func TestGetStock(t *testing.T) {
+	env := newTestEnv()
+	userID, bean := newStockBean(t, env, 250)
+
+	stock, err := env.beanService.GetStock(userID, bean.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 250, *stock.QuantityGrams)
+	assert.Equal(t, 50, stock.LowStockThresholdGrams)
+	assert.False(t, stock.IsLow)
+	assert.Nil(t, stock.CupsRemaining)
+	assert.Nil(t, stock.DaysUntilEmpty)
+
+	for i := 0; i < 4; i++ {
+		_, err := env.beanService.RecordBrewUsage(userID, bean.ID, uuid.New(), 17.5)
+		require.NoError(t, err)
+	}
+	// A deleted brew does not count
+	deleted := uuid.New()
+	_, err = env.beanService.RecordBrewUsage(userID, bean.ID, deleted, 18)
+	require.NoError(t, err)
+	_, err = env.beanService.RecordBrewUsage(userID, bean.ID, deleted, 0)
+	require.NoError(t, err)
+
+	stock, err = env.beanService.GetStock(userID, bean.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 178, *stock.QuantityGrams)
+	assert.Equal(t, 4, stock.RecentBrews)
+	assert.Equal(t, 72, stock.RecentGrams)
+	assert.Equal(t, 9, *stock.CupsRemaining)
+	assert.Equal(t, 34, *stock.DaysUntilEmpty)
+}
@@ -0,0 +1,276 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func newRoaster(t *testing.T, env *testEnv, name string) *domain.Roaster {
+	roaster, created, err := env.catalogService.CreateRoaster(uuid.New(), service.RoasterFields{Name: name})
+	require.NoError(t, err)
+	require.True(t, created)
+	return roaster
+}
+
+func newCoffee(t *testing.T, env *testEnv, roasterID *uuid.UUID, fields service.CoffeeFields) *domain.Coffee {
+	fields.RoasterID = roasterID
+	coffee, created, err := env.catalogService.CreateCoffee(uuid.New(), fields)
+	require.NoError(t, err)
+	require.True(t, created)
+	return coffee
+}
+
+func TestCreateRoasterDeduplicates(t *testing.T) {
+	env := newTestEnv()
+	roaster, created, err := env.catalogService.CreateRoaster(uuid.New(), service.RoasterFields{
+		Name:    " Onyx Coffee Lab ",
+		Country: "USA",
+		Website: "https://onyxcoffeelab.com",
+	})
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "Onyx Coffee Lab", roaster.Name)
+
+	// Spelled differently, but the same roaster
+	again, created, err := env.catalogService.CreateRoaster(uuid.New(), service.RoasterFields{Name: "onyx coffee-lab"})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, roaster.ID, again.ID)
+	assert.Len(t, env.catalogRepo.roasters, 1)
+
+	_, _, err = env.catalogService.CreateRoaster(uuid.New(), service.RoasterFields{Name: "--"})
+	assert.ErrorIs(t, err, service.ErrInvalidCatalogName)
+	_, _, err = env.catalogService.CreateRoaster(uuid.New(), service.RoasterFields{Name: "Tim Wendelboe", Website: "ftp://example.com"})
+	assert.ErrorIs(t, err, service.ErrInvalidWebsite)
+}
+
+func TestCreateCatalogEntryAddedMeanwhile(t *testing.T) {
+	env := newTestEnv()
+	onyx := newRoaster(t, env, "Onyx Coffee Lab")
+	coffee := newCoffee(t, env, &onyx.ID, service.CoffeeFields{Name: "Geometry"})
+
+	// The lookup misses the entry another request just added, and the
+	// unique name makes the insert fail; the entry is returned anyway
+	env.catalogRepo.findMisses = 1
+	roaster, created, err := env.catalogService.CreateRoaster(uuid.New(), service.RoasterFields{Name: "Onyx Coffee Lab"})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, onyx.ID, roaster.ID)
+
+	env.catalogRepo.findMisses = 1
+	same, created, err := env.catalogService.CreateCoffee(uuid.New(), service.CoffeeFields{RoasterID: &onyx.ID, Name: "Geometry"})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, coffee.ID, same.ID)
+	assert.Len(t, env.catalogRepo.roasters, 1)
+	assert.Len(t, env.catalogRepo.coffees, 1)
+}
+
+func TestCreateCoffeePerRoaster(t *testing.T) {
+	env := newTestEnv()
+	onyx := newRoaster(t, env, "Onyx Coffee Lab")
+	sey := newRoaster(t, env, "Sey Coffee")
+
+	coffee := newCoffee(t, env, &onyx.ID, service.CoffeeFields{Name: "Geometry", OriginCountry: "Ethiopia"})
+	same, created, err := env.catalogService.CreateCoffee(uuid.New(), service.CoffeeFields{RoasterID: &onyx.ID, Name: "GEOMETRY"})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, coffee.ID, same.ID)
+
+	// Another roaster's coffee of the same name is another coffee
+	other := newCoffee(t, env, &sey.ID, service.CoffeeFields{Name: "Geometry"})
+	assert.NotEqual(t, coffee.ID, other.ID)
+
+	missing := uuid.New()
+	_, _, err = env.catalogService.CreateCoffee(uuid.New(), service.CoffeeFields{RoasterID: &missing, Name: "Tropical Weather"})
+	assert.ErrorIs(t, err, service.ErrRoasterNotFound)
+}
+
+func TestSearchCatalogMatchesSimilarNames(t *testing.T) {
+	env := newTestEnv()
+	newRoaster(t, env, "Onyx Coffee Lab")
+	newRoaster(t, env, "Tim Wendelboe")
+	newRoaster(t, env, "Square Mile Coffee Roasters")
+
+	// A typo still finds the roaster
+	roasters, total, err := env.catalogService.SearchRoasters(repository.CatalogSearch{Query: "Onix Coffee Lab", Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	assert.Equal(t, "Onyx Coffee Lab", roasters[0].Name)
+
+	roasters, total, err = env.catalogService.SearchRoasters(repository.CatalogSearch{Query: "coffee", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, roasters, 2)
+}
+
+func TestSuggestPrefersTheRoastersCoffees(t *testing.T) {
+	env := newTestEnv()
+	onyx := newRoaster(t, env, "Onyx Coffee Lab")
+	sey := newRoaster(t, env, "Sey Coffee")
+	newCoffee(t, env, &sey.ID, service.CoffeeFields{Name: "Ethiopia Guji"})
+	onyxGuji := newCoffee(t, env, &onyx.ID, service.CoffeeFields{Name: "Ethiopia Guji Hambela"})
+
+	suggestions, err := env.catalogService.Suggest("onyx coffee lab", "Ethiopia Guji")
+	require.NoError(t, err)
+	require.NotEmpty(t, suggestions.Roasters)
+	assert.Equal(t, onyx.ID, suggestions.Roasters[0].ID)
+	require.Len(t, suggestions.Coffees, 2)
+	assert.Equal(t, onyxGuji.ID, suggestions.Coffees[0].ID)
+
+	suggestions, err = env.catalogService.Suggest("", "")
+	require.NoError(t, err)
+	assert.Empty(t, suggestions.Roasters)
+	assert.Empty(t, suggestions.Coffees)
+}
+
+func TestBeanLinksToCatalog(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	onyx := newRoaster(t, env, "Onyx Coffee Lab")
+	coffee := newCoffee(t, env, &onyx.ID, service.CoffeeFields{
+		Name:          "Geometry",
+		OriginCountry: "Ethiopia",
+		Region:        "Guji",
+		Process:       "Washed",
+	})
+
+	// The coffee names the bean and brings its roaster along
+	bean, err := env.beanService.CreateBean(user.ID, service.BeanFields{CoffeeID: &coffee.ID, Origin: ptr("Ethiopia, somewhere")})
+	require.NoError(t, err)
+	assert.Equal(t, "Geometry", bean.Name)
+	assert.Equal(t, coffee.ID, *bean.CoffeeID)
+	assert.Equal(t, onyx.ID, *bean.RoasterID)
+	assert.Equal(t, "Onyx Coffee Lab", bean.Roaster)
+	assert.Equal(t, "Washed", bean.ProcessingMethod)
+	// What the user wrote stays
+	assert.Equal(t, "Ethiopia, somewhere", bean.Origin)
+
+	other := newRoaster(t, env, "Sey Coffee")
+	_, err = env.beanService.UpdateBean(user.ID, bean.ID, service.BeanFields{RoasterID: &other.ID, CoffeeID: &coffee.ID})
+	assert.ErrorIs(t, err, service.ErrCoffeeRoasterMismatch)
+
+	// Another roaster drops the coffee
+	updated, err := env.beanService.UpdateBean(user.ID, bean.ID, service.BeanFields{RoasterID: &other.ID})
+	require.NoError(t, err)
+	assert.Equal(t, other.ID, *updated.RoasterID)
+	assert.Nil(t, updated.CoffeeID)
+	assert.Equal(t, "Onyx Coffee Lab", updated.Roaster)
+
+	updated, err = env.beanService.UpdateBean(user.ID, bean.ID, service.BeanFields{RoasterID: &uuid.Nil})
+	require.NoError(t, err)
+	assert.Nil(t, updated.RoasterID)
+
+	missing := uuid.New()
+	_, err = env.beanService.CreateBean(user.ID, service.BeanFields{Name: ptr("Mystery"), CoffeeID: &missing})
+	assert.ErrorIs(t, err, service.ErrCoffeeNotFound)
+	_, err = env.beanService.CreateBean(user.ID, service.BeanFields{CoffeeID: &uuid.Nil})
+	assert.ErrorIs(t, err, service.ErrInvalidBeanName)
+}
+
+func TestMergeRoasters(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	onyx := newRoaster(t, env, "Onyx Coffee Lab")
+	duplicate, _, err := env.catalogService.CreateRoaster(uuid.New(), service.RoasterFields{Name: "Onyx Coffe Lab", Website: "https://onyxcoffeelab.com"})
+	require.NoError(t, err)
+	coffee := newCoffee(t, env, &duplicate.ID, service.CoffeeFields{Name: "Geometry"})
+	bean, err := env.beanService.CreateBean(user.ID, service.BeanFields{Name: ptr("Geometry"), RoasterID: &duplicate.ID})
+	require.NoError(t, err)
+
+	merged, err := env.catalogService.MergeRoasters(duplicate.ID, onyx.ID)
+	require.NoError(t, err)
+	assert.Equal(t, onyx.ID, merged.ID)
+	assert.Equal(t, "https://onyxcoffeelab.com", merged.Website)
+
+	// Everything moved over, and the duplicate leads to the roaster
+	assert.Equal(t, onyx.ID, *env.beanRepo.beans[bean.ID].RoasterID)
+	assert.Equal(t, onyx.ID, *env.catalogRepo.coffees[coffee.ID].RoasterID)
+	found, err := env.catalogService.GetRoaster(duplicate.ID)
+	require.NoError(t, err)
+	assert.Equal(t, onyx.ID, found.ID)
+	_, total, err := env.catalogService.SearchRoasters(repository.CatalogSearch{Query: "onyx", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	_, err = env.catalogService.MergeRoasters(duplicate.ID, onyx.ID)
+	assert.ErrorIs(t, err, service.ErrAlreadyMerged)
+	_, err = env.catalogService.MergeRoasters(onyx.ID, duplicate.ID)
+	assert.ErrorIs(t, err, service.ErrMergeIntoSelf)
+}
+
+func TestMergeRoastersMergesCoffeesOfTheSameName(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	onyx := newRoaster(t, env, "Onyx Coffee Lab")
+	geometry := newCoffee(t, env, &onyx.ID, service.CoffeeFields{Name: "Geometry"})
+	duplicate := newRoaster(t, env, "Onyx Coffe Lab")
+	clash := newCoffee(t, env, &duplicate.ID, service.CoffeeFields{Name: "Geometry"})
+	bean, err := env.beanService.CreateBean(user.ID, service.BeanFields{CoffeeID: &clash.ID})
+	require.NoError(t, err)
+
+	_, err = env.catalogService.MergeRoasters(duplicate.ID, onyx.ID)
+	require.NoError(t, err)
+
+	// The roaster keeps one Geometry, and the bean follows it there
+	assert.Equal(t, geometry.ID, *env.catalogRepo.coffees[clash.ID].MergedIntoID)
+	assert.Equal(t, geometry.ID, *env.beanRepo.beans[bean.ID].CoffeeID)
+	_, total, err := env.catalogService.SearchCoffees(repository.CatalogSearch{RoasterID: &onyx.ID, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
+
+func TestMergeCoffees(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	onyx := newRoaster(t, env, "Onyx Coffee Lab")
+	target := newCoffee(t, env, &onyx.ID, service.CoffeeFields{Name: "Geometry"})
+	duplicate := newCoffee(t, env, nil, service.CoffeeFields{Name: "Geometry Blend", Variety: "Heirloom"})
+	bean, err := env.beanService.CreateBean(user.ID, service.BeanFields{CoffeeID: &duplicate.ID})
+	require.NoError(t, err)
+	assert.Nil(t, bean.RoasterID)
+
+	merged, err := env.catalogService.MergeCoffees(duplicate.ID, target.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Heirloom", merged.Variety)
+
+	// The bean follows the coffee to its roaster
+	stored := env.beanRepo.beans[bean.ID]
+	assert.Equal(t, target.ID, *stored.CoffeeID)
+	assert.Equal(t, onyx.ID, *stored.RoasterID)
+
+	// Linking a bean to the duplicate links it to the coffee it was merged into
+	linked, err := env.beanService.CreateBean(user.ID, service.BeanFields{CoffeeID: &duplicate.ID})
+	require.NoError(t, err)
+	assert.Equal(t, target.ID, *linked.CoffeeID)
+}
+
+func TestMergeCoffeeTakesTheDuplicatesRoaster(t *testing.T) {
+	env := newTestEnv()
+	onyx := newRoaster(t, env, "Onyx Coffee Lab")
+	target := newCoffee(t, env, nil, service.CoffeeFields{Name: "Geometry"})
+	duplicate := newCoffee(t, env, &onyx.ID, service.CoffeeFields{Name: "Geometry"})
+
+	// The target ends up with the duplicate's roaster and name, which is
+	// fine once the duplicate was merged
+	merged, err := env.catalogService.MergeCoffees(duplicate.ID, target.ID)
+	require.NoError(t, err)
+	assert.Equal(t, onyx.ID, *merged.RoasterID)
+
+	// Unless another of the roaster's coffees has the name
+	other := newCoffee(t, env, nil, service.CoffeeFields{Name: "Geometry"})
+	blend := newCoffee(t, env, &onyx.ID, service.CoffeeFields{Name: "Geometry Blend"})
+	_, err = env.catalogService.MergeCoffees(blend.ID, other.ID)
+	assert.ErrorIs(t, err, service.ErrCoffeeNameTaken)
+	assert.Nil(t, env.catalogRepo.coffees[blend.ID].MergedIntoID)
+}
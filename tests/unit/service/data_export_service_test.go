@@ -0,0 +1,238 @@
+package service_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func TestDataExport(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	require.NoError(t, env.equipmentRepo.Create(&domain.Equipment{UserID: user.ID, Kind: domain.EquipmentGrinder, Name: "Comandante, C40"}))
+	user.AvatarKey = "avatars/" + user.ID.String() + "/a.png"
+	env.storage.files[user.AvatarKey] = []byte("image data")
+	roastDate := time.Date(2023, 7, 25, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, env.beanRepo.Create(&domain.CoffeeBean{
+		UserID: user.ID, Name: "Colombia Huila", RoastDate: &roastDate, RoastLevel: domain.RoastMedium,
+		FlavorNotes: []string{"chocolate", "caramel"}, ImageKeys: []string{"beans/bag.jpg"},
+	}))
+	env.storage.files["beans/bag.jpg"] = []byte("bag image")
+
+	export, err := env.exportService.RequestExport(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DataExportPending, export.Status)
+
+	// Asking again while the export is queued returns the same export
+	again, err := env.exportService.RequestExport(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, export.ID, again.ID)
+
+	built, err := env.exportService.ProcessPendingExports()
+	require.NoError(t, err)
+	assert.Equal(t, 1, built)
+
+	export, err = env.exportService.GetLatestExport(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DataExportReady, export.Status)
+	assert.WithinDuration(t, time.Now().Add(48*time.Hour), *export.ExpiresAt, time.Minute)
+
+	token := lastMailToken(env, "/exports/"+export.ID.String()+"/download")
+	require.NotEmpty(t, token)
+
+	_, _, err = env.exportService.OpenExport(export.ID, "wrong-token")
+	assert.ErrorIs(t, err, service.ErrInvalidExportLink)
+	_, _, err = env.exportService.OpenExport(uuid.New(), token)
+	assert.ErrorIs(t, err, service.ErrInvalidExportLink)
+
+	_, archive, err := env.exportService.OpenExport(export.ID, token)
+	require.NoError(t, err)
+	data, err := io.ReadAll(archive)
+	require.NoError(t, err)
+	archive.Close()
+	assert.EqualValues(t, len(data), export.Size)
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+		files[f.Name] = string(content)
+	}
+
+	for _, name := range []string{"README.txt", "profile.json", "profile.csv", "preferences.json", "sessions.json", "api_keys.csv", "handle_changes.json", "bean_events.csv", "bean_stock.json"} {
+		assert.Contains(t, files, name)
+	}
+	assert.Equal(t, "image data", files["images/avatar.png"])
+	assert.Contains(t, files["equipment.csv"], `grinder,"Comandante, C40"`)
+	assert.Contains(t, files["beans.csv"], "Colombia Huila,,,2023-07-25,medium,chocolate; caramel")
+	var beanImages []string
+	for name, content := range files {
+		if strings.HasPrefix(name, "images/beans/") {
+			beanImages = append(beanImages, content)
+		}
+	}
+	assert.Equal(t, []string{"bag image"}, beanImages)
+
+	var profile map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(files["profile.json"]), &profile))
+	assert.Equal(t, "test@example.com", profile["email"])
+	assert.NotContains(t, files["profile.json"], "password")
+}
+
+func TestDataExportExpires(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	export, err := env.exportService.RequestExport(user.ID)
+	require.NoError(t, err)
+	_, err = env.exportService.ProcessPendingExports()
+	require.NoError(t, err)
+	token := lastMailToken(env, "/download")
+
+	stored, err := env.exportRepo.GetByID(export.ID)
+	require.NoError(t, err)
+	key := stored.StorageKey
+	assert.True(t, strings.HasPrefix(key, "private/"))
+	expired := time.Now().Add(-time.Minute)
+	stored.ExpiresAt = &expired
+
+	_, _, err = env.exportService.OpenExport(export.ID, token)
+	assert.ErrorIs(t, err, service.ErrInvalidExportLink)
+
+	deleted, err := env.exportService.DeleteExpiredExports()
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.NotContains(t, env.storage.files, key)
+	export, err = env.exportService.GetLatestExport(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DataExportExpired, export.Status)
+}
+
+func TestDataExportFailsWithoutEmail(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, err = env.exportService.RequestExport(user.ID)
+	require.NoError(t, err)
+
+	// Without the email nobody could download the archive
+	env.mailer.err = errors.New("smtp unavailable")
+	built, err := env.exportService.ProcessPendingExports()
+	assert.Error(t, err)
+	assert.Equal(t, 0, built)
+
+	export, err := env.exportService.GetLatestExport(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DataExportFailed, export.Status)
+	assert.Empty(t, export.TokenHash)
+	for key := range env.storage.files {
+		assert.NotContains(t, key, "exports/")
+	}
+}
+
+func TestDataExportFailsWhenNotSaved(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, err = env.exportService.RequestExport(user.ID)
+	require.NoError(t, err)
+
+	// The email went out, but the export could not be marked ready
+	env.exportRepo.failUpdates = 1
+	built, err := env.exportService.ProcessPendingExports()
+	assert.Error(t, err)
+	assert.Equal(t, 0, built)
+
+	export, err := env.exportService.GetLatestExport(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DataExportFailed, export.Status)
+	for key := range env.storage.files {
+		assert.NotContains(t, key, "exports/")
+	}
+
+	// So it is not built and emailed again
+	sent := len(env.mailer.sent)
+	built, err = env.exportService.ProcessPendingExports()
+	require.NoError(t, err)
+	assert.Equal(t, 0, built)
+	assert.Len(t, env.mailer.sent, sent)
+}
+
+func TestDataExportBuiltOnce(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	export, err := env.exportService.RequestExport(user.ID)
+	require.NoError(t, err)
+	sent := len(env.mailer.sent)
+
+	// Another instance claimed the export and is still building it
+	claimedAt := time.Now().Add(-time.Minute)
+	export.Status, export.ClaimedAt = domain.DataExportProcessing, &claimedAt
+	built, err := env.exportService.ProcessPendingExports()
+	require.NoError(t, err)
+	assert.Equal(t, 0, built)
+	assert.Len(t, env.mailer.sent, sent)
+
+	// Asking again still returns the export being built
+	again, err := env.exportService.RequestExport(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, export.ID, again.ID)
+
+	// An instance that stopped long ago leaves it to the next run
+	claimedAt = time.Now().Add(-2 * time.Hour)
+	built, err = env.exportService.ProcessPendingExports()
+	require.NoError(t, err)
+	assert.Equal(t, 1, built)
+	assert.Len(t, env.mailer.sent, sent+1)
+	latest, err := env.exportService.GetLatestExport(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DataExportReady, latest.Status)
+}
+
+func TestDataExportRateLimit(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := env.exportService.RequestExport(user.ID)
+		require.NoError(t, err)
+		_, err = env.exportService.ProcessPendingExports()
+		require.NoError(t, err)
+	}
+
+	_, err = env.exportService.RequestExport(user.ID)
+	var rateLimitErr *service.RateLimitError
+	assert.ErrorAs(t, err, &rateLimitErr)
+}
+
+func TestDataExportRejectsGuests(t *testing.T) {
+	env := newTestEnv()
+	guest, _, err := env.guestService.CreateGuest(testClient)
+	require.NoError(t, err)
+
+	_, err = env.exportService.RequestExport(guest.ID)
+	assert.ErrorIs(t, err, service.ErrGuestAccount)
+
+	_, err = env.exportService.GetLatestExport(guest.ID)
+	assert.ErrorIs(t, err, service.ErrExportNotFound)
+}
@@ -0,0 +1,48 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func TestRegisterSendsVerificationEmail(t *testing.T) {
+	env := newTestEnv()
+
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	assert.False(t, user.IsEmailVerified())
+
+	token := lastMailToken(env, "verify-email")
+	require.NotEmpty(t, token)
+
+	require.NoError(t, env.verificationSvc.VerifyEmail(token))
+	assert.True(t, env.userRepo.users[user.ID].IsEmailVerified())
+
+	// Verification links are single-use
+	assert.ErrorIs(t, env.verificationSvc.VerifyEmail(token), service.ErrInvalidVerificationToken)
+
+	// Nothing left to resend
+	assert.ErrorIs(t, env.verificationSvc.ResendVerification(user.ID), service.ErrEmailAlreadyVerified)
+}
+
+func TestResendVerificationThrottled(t *testing.T) {
+	env := newTestEnv()
+
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	firstToken := lastMailToken(env, "verify-email")
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, env.verificationSvc.ResendVerification(user.ID))
+	}
+
+	var rateLimitErr *service.RateLimitError
+	assert.ErrorAs(t, env.verificationSvc.ResendVerification(user.ID), &rateLimitErr)
+
+	// Resending replaces earlier links
+	assert.ErrorIs(t, env.verificationSvc.VerifyEmail(firstToken), service.ErrInvalidVerificationToken)
+	assert.NoError(t, env.verificationSvc.VerifyEmail(lastMailToken(env, "verify-email")))
+}
@@ -0,0 +1,1442 @@
+package service_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/internal/service"
+	"github.com/yashkadam007/brewkar/pkg/handles"
+	"github.com/yashkadam007/brewkar/pkg/keyring"
+	"github.com/yashkadam007/brewkar/pkg/mailer"
+	"github.com/yashkadam007/brewkar/pkg/passwords"
+	"github.com/yashkadam007/brewkar/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// In-memory UserRepository for testing
+type memUserRepo struct {
+	users      map[uuid.UUID]*domain.User
+	tombstones []*domain.AccountTombstone
+}
+
+func (r *memUserRepo) Create(user *domain.User) error {
+	user.ID = uuid.New()
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *memUserRepo) GetByID(id uuid.UUID) (*domain.User, error) {
+	if user, ok := r.users[id]; ok {
+		return user, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memUserRepo) GetByEmail(email string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.Email != nil && *user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *memUserRepo) GetByHandle(handle string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.Handle != nil && strings.EqualFold(*user.Handle, handle) {
+			return user, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *memUserRepo) Update(user *domain.User) error {
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *memUserRepo) Search(search repository.UserSearch) ([]domain.User, int64, error) {
+	var matches []domain.User
+	for _, user := range r.users {
+		if search.Query != "" &&
+			!strings.Contains(strings.ToLower(user.EmailAddress()), strings.ToLower(search.Query)) &&
+			!strings.Contains(strings.ToLower(user.HandleName()), strings.ToLower(search.Query)) &&
+			!strings.Contains(strings.ToLower(user.DisplayName), strings.ToLower(search.Query)) {
+			continue
+		}
+		if search.Role != "" && user.Role != search.Role {
+			continue
+		}
+		if search.Suspended != nil && user.IsSuspended() != *search.Suspended {
+			continue
+		}
+		matches = append(matches, *user)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].CreatedAt.After(matches[j].CreatedAt) })
+
+	total := int64(len(matches))
+	if search.Offset >= len(matches) {
+		return nil, total, nil
+	}
+	matches = matches[search.Offset:]
+	if search.Limit > 0 && len(matches) > search.Limit {
+		matches = matches[:search.Limit]
+	}
+	return matches, total, nil
+}
+
+func (r *memUserRepo) ListAbandonedGuests(cutoff time.Time, limit int) ([]domain.User, error) {
+	var users []domain.User
+	for _, user := range r.users {
+		lastUsed := user.CreatedAt
+		if user.LastLoginAt != nil {
+			lastUsed = *user.LastLoginAt
+		}
+		if user.IsGuest && lastUsed.Before(cutoff) && len(users) < limit {
+			users = append(users, *user)
+		}
+	}
+	return users, nil
+}
+
+func (r *memUserRepo) ListDueForDeletion(now time.Time, limit int) ([]domain.User, error) {
+	var users []domain.User
+	for _, user := range r.users {
+		if user.DeletionScheduledAt != nil && !user.DeletionScheduledAt.After(now) && len(users) < limit {
+			users = append(users, *user)
+		}
+	}
+	return users, nil
+}
+
+func (r *memUserRepo) Delete(user *domain.User, tombstone *domain.AccountTombstone) error {
+	tombstone.ID = uuid.New()
+	r.tombstones = append(r.tombstones, tombstone)
+	delete(r.users, user.ID)
+	return nil
+}
+
+// In-memory HandleChangeRepository for testing
+type memHandleChangeRepo struct {
+	changes []*domain.HandleChange
+	// err fails the next ChangeHandle, e.g. as if another user took the handle
+	err error
+}
+
+func (r *memHandleChangeRepo) ChangeHandle(user *domain.User, handle string, change *domain.HandleChange) error {
+	if err := r.err; err != nil {
+		r.err = nil
+		return err
+	}
+	if change != nil {
+		change.ID = uuid.New()
+		r.changes = append(r.changes, change)
+	}
+	user.Handle = &handle
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *memHandleChangeRepo) GetLatestByOldHandle(handle string) (*domain.HandleChange, error) {
+	var latest *domain.HandleChange
+	for _, change := range r.changes {
+		if strings.EqualFold(change.OldHandle, handle) && (latest == nil || change.ChangedAt.After(latest.ChangedAt)) {
+			latest = change
+		}
+	}
+	if latest == nil {
+		return nil, errors.New("record not found")
+	}
+	return latest, nil
+}
+
+func (r *memHandleChangeRepo) ListForUser(userID uuid.UUID) ([]domain.HandleChange, error) {
+	var changes []domain.HandleChange
+	for _, change := range r.changes {
+		if change.UserID == userID {
+			changes = append(changes, *change)
+		}
+	}
+	return changes, nil
+}
+
+// In-memory EquipmentRepository for testing
+type memEquipmentRepo struct {
+	items []domain.Equipment
+}
+
+func (r *memEquipmentRepo) Create(item *domain.Equipment) error {
+	item.ID = uuid.New()
+	r.items = append(r.items, *item)
+	return nil
+}
+
+func (r *memEquipmentRepo) ListForUser(userID uuid.UUID) ([]domain.Equipment, error) {
+	var items []domain.Equipment
+	for _, item := range r.items {
+		if item.UserID == userID {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// In-memory OnboardingRepository for testing
+type memOnboardingRepo struct {
+	onboardings   map[uuid.UUID]*domain.Onboarding
+	equipmentRepo *memEquipmentRepo
+	// err fails the next Advance, saving nothing
+	err error
+}
+
+// GetByUserID returns a copy, so that only Save and Advance change what is
+// stored, like in the database.
+func (r *memOnboardingRepo) GetByUserID(userID uuid.UUID) (*domain.Onboarding, error) {
+	onboarding, ok := r.onboardings[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	stored := *onboarding
+	stored.CompletedSteps = slices.Clone(onboarding.CompletedSteps)
+	stored.SkippedSteps = slices.Clone(onboarding.SkippedSteps)
+	stored.Answers = maps.Clone(onboarding.Answers)
+	return &stored, nil
+}
+
+func (r *memOnboardingRepo) Save(onboarding *domain.Onboarding) error {
+	stored := *onboarding
+	r.onboardings[onboarding.UserID] = &stored
+	return nil
+}
+
+func (r *memOnboardingRepo) Advance(onboarding *domain.Onboarding, step domain.OnboardingStep, equipment []domain.Equipment) (bool, error) {
+	if err := r.err; err != nil {
+		r.err = nil
+		return false, err
+	}
+	stored, ok := r.onboardings[onboarding.UserID]
+	if !ok || stored.CurrentStep != step {
+		return false, nil
+	}
+	for i := range equipment {
+		if err := r.equipmentRepo.Create(&equipment[i]); err != nil {
+			return false, err
+		}
+	}
+	return true, r.Save(onboarding)
+}
+
+// In-memory BeanRepository for testing
+type memBeanRepo struct {
+	beans map[uuid.UUID]*domain.CoffeeBean
+}
+
+func (r *memBeanRepo) Create(bean *domain.CoffeeBean) error {
+	bean.ID = uuid.New()
+	r.beans[bean.ID] = bean
+	return nil
+}
+
+func (r *memBeanRepo) GetByID(id uuid.UUID) (*domain.CoffeeBean, error) {
+	bean, ok := r.beans[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return bean, nil
+}
+
+func (r *memBeanRepo) Deactivate(id uuid.UUID, at time.Time) error {
+	if bean, ok := r.beans[id]; ok {
+		bean.IsActive = false
+		bean.UpdatedAt = at
+	}
+	return nil
+}
+
+// Search supports sorting by name; anything else sorts by creation time
+func (r *memBeanRepo) Search(search repository.BeanSearch) ([]domain.CoffeeBean, int64, error) {
+	var matches []domain.CoffeeBean
+	query := strings.ToLower(search.Query)
+	for _, bean := range r.beans {
+		if bean.UserID != search.UserID ||
+			(search.RoastLevel != "" && bean.RoastLevel != search.RoastLevel) ||
+			(search.IsActive != nil && bean.IsActive != *search.IsActive) ||
+			(search.ParentID != nil && (bean.ParentID == nil || *bean.ParentID != *search.ParentID)) {
+			continue
+		}
+		if query != "" &&
+			!strings.Contains(strings.ToLower(bean.Name), query) &&
+			!strings.Contains(strings.ToLower(bean.Origin), query) &&
+			!strings.Contains(strings.ToLower(bean.Roaster), query) {
+			continue
+		}
+		if search.RoastDates != nil && !matchesRoastDates(bean, search.RoastDates, search.Today) {
+			continue
+		}
+		matches = append(matches, *bean)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		less := matches[i].CreatedAt.Before(matches[j].CreatedAt)
+		if search.Sort == repository.BeanSortName {
+			less = matches[i].Name < matches[j].Name
+		}
+		if search.Asc {
+			return less
+		}
+		return !less
+	})
+
+	total := int64(len(matches))
+	if search.Offset >= len(matches) {
+		return nil, total, nil
+	}
+	matches = matches[search.Offset:]
+	if search.Limit > 0 && len(matches) > search.Limit {
+		matches = matches[:search.Limit]
+	}
+	return matches, total, nil
+}
+
+func (r *memBeanRepo) ListForUser(userID uuid.UUID) ([]domain.CoffeeBean, error) {
+	var beans []domain.CoffeeBean
+	for _, bean := range r.beans {
+		if bean.UserID == userID {
+			beans = append(beans, *bean)
+		}
+	}
+	sort.Slice(beans, func(i, j int) bool { return beans[i].CreatedAt.Before(beans[j].CreatedAt) })
+	return beans, nil
+}
+
+func matchesRoastDates(bean *domain.CoffeeBean, ranges []repository.RoastDateRange, today time.Time) bool {
+	if bean.RoastDate == nil {
+		return false
+	}
+	aged := bean.RoastDate.AddDate(0, 0, bean.FrozenDaysUntil(today))
+	for _, dates := range ranges {
+		if bean.RoastLevel == dates.RoastLevel &&
+			(dates.From == nil || !aged.Before(*dates.From)) &&
+			(dates.To == nil || !aged.After(*dates.To)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memBeanRepo) ListFreshnessCandidates(roastedSince time.Time) ([]domain.CoffeeBean, error) {
+	var beans []domain.CoffeeBean
+	for _, bean := range r.beans {
+		if bean.IsActive && bean.RoastDate != nil && !bean.RoastDate.AddDate(0, 0, bean.FrozenDays).Before(roastedSince) &&
+			bean.StaleWarnedAt == nil && bean.FrozenOn == nil && bean.FinishedOn == nil &&
+			(bean.QuantityGrams == nil || *bean.QuantityGrams > 0) {
+			beans = append(beans, *bean)
+		}
+	}
+	sort.Slice(beans, func(i, j int) bool {
+		if beans[i].UserID != beans[j].UserID {
+			return beans[i].UserID.String() < beans[j].UserID.String()
+		}
+		return beans[i].RoastDate.Before(*beans[j].RoastDate)
+	})
+	return beans, nil
+}
+
+func (r *memBeanRepo) MarkPeakNotified(beanIDs []uuid.UUID, at time.Time) error {
+	for _, id := range beanIDs {
+		r.beans[id].PeakNotifiedAt = &at
+	}
+	return nil
+}
+
+func (r *memBeanRepo) MarkStaleWarned(beanIDs []uuid.UUID, at time.Time) error {
+	for _, id := range beanIDs {
+		r.beans[id].StaleWarnedAt = &at
+	}
+	return nil
+}
+
+// In-memory BeanEventRepository for testing, changing the beans of beanRepo
+type memBeanEventRepo struct {
+	beanRepo *memBeanRepo
+	events   []domain.BeanEvent
+}
+
+func (r *memBeanEventRepo) AddEvent(beanID uuid.UUID, add func(bean *domain.CoffeeBean, latest *domain.BeanEvent) (*domain.BeanEvent, error)) (*domain.CoffeeBean, *domain.BeanEvent, error) {
+	stored, ok := r.beanRepo.beans[beanID]
+	if !ok {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	var latest *domain.BeanEvent
+	for i := range r.events {
+		if r.events[i].BeanID == beanID {
+			event := r.events[i]
+			latest = &event
+		}
+	}
+	// Changes are made to a copy so a failed update leaves the bean alone
+	bean := *stored
+	event, err := add(&bean, latest)
+	if err != nil {
+		return nil, nil, err
+	}
+	r.beanRepo.beans[beanID] = &bean
+	event.ID = uuid.New()
+	r.events = append(r.events, *event)
+	return &bean, event, nil
+}
+
+func (r *memBeanEventRepo) ListForBean(beanID uuid.UUID, offset, limit int) ([]domain.BeanEvent, int64, error) {
+	var events []domain.BeanEvent
+	for _, event := range r.events {
+		if event.BeanID == beanID {
+			events = append(events, event)
+		}
+	}
+	total := int64(len(events))
+	if offset >= len(events) {
+		return nil, total, nil
+	}
+	events = events[offset:]
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, total, nil
+}
+
+func (r *memBeanEventRepo) ListForUser(userID uuid.UUID) ([]domain.BeanEvent, error) {
+	var events []domain.BeanEvent
+	for _, event := range r.events {
+		if bean, ok := r.beanRepo.beans[event.BeanID]; ok && bean.UserID == userID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// In-memory BeanStockRepository for testing, changing the beans of beanRepo
+// and copying timelines from eventRepo
+type memBeanStockRepo struct {
+	beanRepo    *memBeanRepo
+	eventRepo   *memBeanEventRepo
+	adjustments []domain.BeanStockAdjustment
+}
+
+func (r *memBeanStockRepo) SplitBean(beanID uuid.UUID, split func(bean *domain.CoffeeBean) (*repository.BeanSplit, error)) (*domain.CoffeeBean, error) {
+	stored, ok := r.beanRepo.beans[beanID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	bean := *stored
+	result, err := split(&bean)
+	if err != nil {
+		return nil, err
+	}
+	r.beanRepo.beans[beanID] = &bean
+	var timeline []domain.BeanEvent
+	for _, event := range r.eventRepo.events {
+		if event.BeanID == beanID {
+			timeline = append(timeline, event)
+		}
+	}
+	for i := range result.Portions {
+		portion := result.Portions[i]
+		r.beanRepo.beans[portion.ID] = &portion
+		for _, event := range timeline {
+			event.ID = uuid.New()
+			event.BeanID = portion.ID
+			r.eventRepo.events = append(r.eventRepo.events, event)
+		}
+	}
+	r.eventRepo.events = append(r.eventRepo.events, result.Events...)
+	for _, adjustment := range result.Adjustments {
+		adjustment.ID = uuid.New()
+		r.adjustments = append(r.adjustments, adjustment)
+	}
+	return &bean, nil
+}
+
+func (r *memBeanStockRepo) UpdateStock(beanID uuid.UUID, update func(bean *domain.CoffeeBean) (*domain.BeanStockAdjustment, error)) (*domain.CoffeeBean, error) {
+	stored, ok := r.beanRepo.beans[beanID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	// Changes are made to a copy so a failed update leaves the bean alone
+	bean := *stored
+	adjustment, err := update(&bean)
+	if err != nil {
+		return nil, err
+	}
+	r.beanRepo.beans[beanID] = &bean
+	if adjustment != nil {
+		adjustment.ID = uuid.New()
+		r.adjustments = append(r.adjustments, *adjustment)
+	}
+	return &bean, nil
+}
+
+func (r *memBeanStockRepo) UpdateStockTx(tx *gorm.DB, beanID uuid.UUID, update func(bean *domain.CoffeeBean) (*domain.BeanStockAdjustment, error)) (*domain.CoffeeBean, error) {
+	return r.UpdateStock(beanID, update)
+}
+
+func (r *memBeanStockRepo) ListForBean(beanID uuid.UUID, offset, limit int) ([]domain.BeanStockAdjustment, int64, error) {
+	var adjustments []domain.BeanStockAdjustment
+	for i := len(r.adjustments) - 1; i >= 0; i-- {
+		if r.adjustments[i].BeanID == beanID {
+			adjustments = append(adjustments, r.adjustments[i])
+		}
+	}
+	total := int64(len(adjustments))
+	if offset >= len(adjustments) {
+		return nil, total, nil
+	}
+	adjustments = adjustments[offset:]
+	if len(adjustments) > limit {
+		adjustments = adjustments[:limit]
+	}
+	return adjustments, total, nil
+}
+
+func (r *memBeanStockRepo) ListForUser(userID uuid.UUID) ([]domain.BeanStockAdjustment, error) {
+	var adjustments []domain.BeanStockAdjustment
+	for _, adjustment := range r.adjustments {
+		if bean, ok := r.beanRepo.beans[adjustment.BeanID]; ok && bean.UserID == userID {
+			adjustments = append(adjustments, adjustment)
+		}
+	}
+	return adjustments, nil
+}
+
+func (r *memBeanStockRepo) BrewLogGrams(beanID, brewLogID uuid.UUID) (int, error) {
+	grams := 0
+	for _, adjustment := range r.adjustments {
+		if adjustment.BeanID == beanID && adjustment.BrewLogID != nil && *adjustment.BrewLogID == brewLogID {
+			grams += adjustment.ChangeGrams
+		}
+	}
+	return grams, nil
+}
+
+func (r *memBeanStockRepo) RecentBrewUsage(beanID uuid.UUID, since time.Time) (repository.BrewUsage, error) {
+	perLog := map[uuid.UUID]int{}
+	for _, adjustment := range r.adjustments {
+		if adjustment.BeanID == beanID && adjustment.Kind == domain.StockChangeBrew && !adjustment.CreatedAt.Before(since) {
+			perLog[*adjustment.BrewLogID] += adjustment.ChangeGrams
+		}
+	}
+	var usage repository.BrewUsage
+	for _, grams := range perLog {
+		if grams < 0 {
+			usage.Brews++
+			usage.Grams -= grams
+		}
+	}
+	return usage, nil
+}
+
+// In-memory CatalogRepository for testing, relinking the beans of beanRepo
+// on merges. Similarity works on trigrams like pg_trgm.
+type memCatalogRepo struct {
+	beanRepo *memBeanRepo
+	roasters map[uuid.UUID]*domain.Roaster
+	coffees  map[uuid.UUID]*domain.Coffee
+	// findMisses is how many lookups by name find nothing, as when another
+	// request adds the entry right after
+	findMisses int
+}
+
+// CreateRoaster enforces unique names like the database's index
+func (r *memCatalogRepo) CreateRoaster(roaster *domain.Roaster) error {
+	for _, existing := range r.roasters {
+		if existing.NormalizedName == roaster.NormalizedName && existing.MergedIntoID == nil {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	roaster.ID = uuid.New()
+	r.roasters[roaster.ID] = roaster
+	return nil
+}
+
+func (r *memCatalogRepo) GetRoaster(id uuid.UUID) (*domain.Roaster, error) {
+	roaster, ok := r.roasters[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *roaster
+	return &copied, nil
+}
+
+func (r *memCatalogRepo) FindRoaster(normalizedName string) (*domain.Roaster, error) {
+	if r.findMisses > 0 {
+		r.findMisses--
+		return nil, gorm.ErrRecordNotFound
+	}
+	for _, roaster := range r.roasters {
+		if roaster.NormalizedName == normalizedName && roaster.MergedIntoID == nil {
+			return r.GetRoaster(roaster.ID)
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memCatalogRepo) SearchRoasters(search repository.CatalogSearch) ([]domain.Roaster, int64, error) {
+	var matches []domain.Roaster
+	for _, roaster := range r.roasters {
+		if roaster.MergedIntoID == nil && catalogMatches(roaster.NormalizedName, search.Query) {
+			matches = append(matches, *roaster)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return catalogLess(matches[i].NormalizedName, matches[j].NormalizedName, search.Query)
+	})
+	return catalogPage(matches, search)
+}
+
+func (r *memCatalogRepo) MergeRoaster(source, target *domain.Roaster, at time.Time) error {
+	copied := *target
+	r.roasters[target.ID] = &copied
+	for _, roaster := range r.roasters {
+		if roaster.ID == source.ID || (roaster.MergedIntoID != nil && *roaster.MergedIntoID == source.ID) {
+			roaster.MergedIntoID, roaster.MergedAt = &target.ID, &at
+		}
+	}
+	for _, coffee := range r.coffees {
+		if coffee.RoasterID == nil || *coffee.RoasterID != source.ID || coffee.MergedIntoID != nil {
+			continue
+		}
+		same, err := r.FindCoffee(&target.ID, coffee.NormalizedName)
+		if err != nil {
+			continue
+		}
+		if err := r.MergeCoffee(coffee, same, at); err != nil {
+			return err
+		}
+	}
+	for _, coffee := range r.coffees {
+		if coffee.RoasterID != nil && *coffee.RoasterID == source.ID {
+			coffee.RoasterID = &target.ID
+		}
+	}
+	for _, bean := range r.beanRepo.beans {
+		if bean.RoasterID != nil && *bean.RoasterID == source.ID {
+			bean.RoasterID = &target.ID
+		}
+	}
+	return nil
+}
+
+func (r *memCatalogRepo) CreateCoffee(coffee *domain.Coffee) error {
+	for _, existing := range r.coffees {
+		if existing.NormalizedName == coffee.NormalizedName && existing.MergedIntoID == nil && sameID(existing.RoasterID, coffee.RoasterID) {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	coffee.ID = uuid.New()
+	r.coffees[coffee.ID] = coffee
+	return nil
+}
+
+func (r *memCatalogRepo) GetCoffee(id uuid.UUID) (*domain.Coffee, error) {
+	coffee, ok := r.coffees[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *coffee
+	return &copied, nil
+}
+
+func (r *memCatalogRepo) FindCoffee(roasterID *uuid.UUID, normalizedName string) (*domain.Coffee, error) {
+	if r.findMisses > 0 {
+		r.findMisses--
+		return nil, gorm.ErrRecordNotFound
+	}
+	for _, coffee := range r.coffees {
+		if coffee.NormalizedName == normalizedName && coffee.MergedIntoID == nil && sameID(coffee.RoasterID, roasterID) {
+			return r.GetCoffee(coffee.ID)
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memCatalogRepo) SearchCoffees(search repository.CatalogSearch) ([]domain.Coffee, int64, error) {
+	var matches []domain.Coffee
+	for _, coffee := range r.coffees {
+		if coffee.MergedIntoID == nil && catalogMatches(coffee.NormalizedName, search.Query) &&
+			(search.RoasterID == nil || sameID(coffee.RoasterID, search.RoasterID)) {
+			matches = append(matches, *coffee)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return catalogLess(matches[i].NormalizedName, matches[j].NormalizedName, search.Query)
+	})
+	return catalogPage(matches, search)
+}
+
+// MergeCoffee enforces unique names like the database's index, once source
+// was merged
+func (r *memCatalogRepo) MergeCoffee(source, target *domain.Coffee, at time.Time) error {
+	for _, coffee := range r.coffees {
+		merging := coffee.ID == source.ID || (coffee.MergedIntoID != nil && *coffee.MergedIntoID == source.ID)
+		if coffee.ID != target.ID && !merging && coffee.MergedIntoID == nil &&
+			coffee.NormalizedName == target.NormalizedName && sameID(coffee.RoasterID, target.RoasterID) {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	copied := *target
+	r.coffees[target.ID] = &copied
+	for _, coffee := range r.coffees {
+		if coffee.ID == source.ID || (coffee.MergedIntoID != nil && *coffee.MergedIntoID == source.ID) {
+			coffee.MergedIntoID, coffee.MergedAt = &target.ID, &at
+		}
+	}
+	for _, bean := range r.beanRepo.beans {
+		if bean.CoffeeID != nil && *bean.CoffeeID == source.ID {
+			bean.CoffeeID = &target.ID
+			if target.RoasterID != nil {
+				bean.RoasterID = target.RoasterID
+			}
+		}
+	}
+	return nil
+}
+
+func sameID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func catalogMatches(name, query string) bool {
+	return query == "" || strings.Contains(name, query) || trigramSimilarity(name, query) >= repository.MinCatalogSimilarity
+}
+
+// catalogLess sorts the most similar names first, then by name.
+func catalogLess(a, b, query string) bool {
+	if query != "" {
+		if simA, simB := trigramSimilarity(a, query), trigramSimilarity(b, query); simA != simB {
+			return simA > simB
+		}
+	}
+	return a < b
+}
+
+func catalogPage[T any](matches []T, search repository.CatalogSearch) ([]T, int64, error) {
+	total := int64(len(matches))
+	if search.Offset >= len(matches) {
+		return nil, total, nil
+	}
+	matches = matches[search.Offset:]
+	if search.Limit > 0 && len(matches) > search.Limit {
+		matches = matches[:search.Limit]
+	}
+	return matches, total, nil
+}
+
+// trigramSimilarity is pg_trgm's similarity: the share of trigrams the two
+// strings have in common, with every word padded by two spaces in front and
+// one behind.
+func trigramSimilarity(a, b string) float64 {
+	trigrams := func(s string) map[string]bool {
+		set := map[string]bool{}
+		for _, word := range strings.Fields(s) {
+			padded := []rune("  " + word + " ")
+			for i := 0; i+3 <= len(padded); i++ {
+				set[string(padded[i:i+3])] = true
+			}
+		}
+		return set
+	}
+	setA, setB := trigrams(a), trigrams(b)
+	shared := 0
+	for trigram := range setA {
+		if setB[trigram] {
+			shared++
+		}
+	}
+	all := len(setA) + len(setB) - shared
+	if all == 0 {
+		return 0
+	}
+	return float64(shared) / float64(all)
+}
+
+// In-memory DataExportRepository for testing
+type memDataExportRepo struct {
+	exports []*domain.DataExport
+	// failUpdates is how many of the next updates fail
+	failUpdates int
+}
+
+func (r *memDataExportRepo) Create(export *domain.DataExport) error {
+	export.ID = uuid.New()
+	r.exports = append(r.exports, export)
+	return nil
+}
+
+func (r *memDataExportRepo) Update(export *domain.DataExport) error {
+	if r.failUpdates > 0 {
+		r.failUpdates--
+		return errors.New("database unavailable")
+	}
+	for i, stored := range r.exports {
+		if stored.ID == export.ID {
+			r.exports[i] = export
+		}
+	}
+	return nil
+}
+
+func (r *memDataExportRepo) GetByID(id uuid.UUID) (*domain.DataExport, error) {
+	for _, export := range r.exports {
+		if export.ID == id {
+			return export, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memDataExportRepo) GetLatestForUser(userID uuid.UUID) (*domain.DataExport, error) {
+	for i := len(r.exports) - 1; i >= 0; i-- {
+		if r.exports[i].UserID == userID {
+			return r.exports[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memDataExportRepo) ListForUser(userID uuid.UUID) ([]domain.DataExport, error) {
+	var exports []domain.DataExport
+	for _, export := range r.exports {
+		if export.UserID == userID {
+			exports = append(exports, *export)
+		}
+	}
+	return exports, nil
+}
+
+func (r *memDataExportRepo) ClaimPending(now, staleBefore time.Time, limit int) ([]domain.DataExport, error) {
+	var exports []domain.DataExport
+	for _, export := range r.exports {
+		stale := export.Status == domain.DataExportProcessing && export.ClaimedAt.Before(staleBefore)
+		if (export.Status == domain.DataExportPending || stale) && len(exports) < limit {
+			export.Status = domain.DataExportProcessing
+			export.ClaimedAt = &now
+			exports = append(exports, *export)
+		}
+	}
+	return exports, nil
+}
+
+func (r *memDataExportRepo) ListExpired(now time.Time) ([]domain.DataExport, error) {
+	var exports []domain.DataExport
+	for _, export := range r.exports {
+		if export.Status == domain.DataExportReady && export.ExpiresAt.Before(now) {
+			exports = append(exports, *export)
+		}
+	}
+	return exports, nil
+}
+
+// In-memory RefreshTokenRepository for testing
+type memRefreshTokenRepo struct {
+	tokens map[string]*domain.RefreshToken
+}
+
+func (r *memRefreshTokenRepo) Create(token *domain.RefreshToken) error {
+	token.ID = uuid.New()
+	r.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (r *memRefreshTokenRepo) GetByHash(tokenHash string) (*domain.RefreshToken, error) {
+	if token, ok := r.tokens[tokenHash]; ok {
+		copied := *token
+		return &copied, nil
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *memRefreshTokenRepo) MarkUsed(id uuid.UUID, usedAt time.Time) (bool, error) {
+	for _, token := range r.tokens {
+		if token.ID == id {
+			if token.UsedAt != nil {
+				return false, nil
+			}
+			token.UsedAt = &usedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memRefreshTokenRepo) RevokeSession(sessionID uuid.UUID) error {
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.SessionID == sessionID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *memRefreshTokenRepo) RevokeAllForUser(userID uuid.UUID) error {
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// In-memory TokenRevocationRepository for testing
+// In-memory SuspensionRepository for testing
+type memSuspensionRepo struct {
+	suspended map[uuid.UUID]bool
+}
+
+func (r *memSuspensionRepo) Suspend(userID uuid.UUID) error {
+	r.suspended[userID] = true
+	return nil
+}
+
+func (r *memSuspensionRepo) Unsuspend(userID uuid.UUID) error {
+	delete(r.suspended, userID)
+	return nil
+}
+
+func (r *memSuspensionRepo) IsSuspended(userID uuid.UUID) (bool, error) {
+	return r.suspended[userID], nil
+}
+
+type memRevocationRepo struct {
+	tokens   map[string]bool
+	sessions map[uuid.UUID]bool
+	users    map[uuid.UUID]time.Time
+}
+
+func (r *memRevocationRepo) RevokeToken(tokenID string, ttl time.Duration) error {
+	r.tokens[tokenID] = true
+	return nil
+}
+
+func (r *memRevocationRepo) RevokeSession(sessionID uuid.UUID, ttl time.Duration) error {
+	r.sessions[sessionID] = true
+	return nil
+}
+
+func (r *memRevocationRepo) RevokeUserTokens(userID uuid.UUID, before time.Time, ttl time.Duration) error {
+	r.users[userID] = before
+	return nil
+}
+
+func (r *memRevocationRepo) IsRevoked(tokenID string, sessionID uuid.UUID, userID uuid.UUID, issuedAt time.Time) (bool, error) {
+	if r.tokens[tokenID] || r.sessions[sessionID] {
+		return true, nil
+	}
+	before, ok := r.users[userID]
+	return ok && issuedAt.UnixMilli() < before.UnixMilli(), nil
+}
+
+// In-memory SessionRepository for testing
+type memSessionRepo struct {
+	sessions map[uuid.UUID]*domain.Session
+}
+
+func (r *memSessionRepo) Create(session *domain.Session) error {
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *memSessionRepo) GetByID(id uuid.UUID) (*domain.Session, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, errors.New("session not found")
+	}
+	return session, nil
+}
+
+func (r *memSessionRepo) ListActiveForUser(userID uuid.UUID) ([]domain.Session, error) {
+	var sessions []domain.Session
+	for _, session := range r.sessions {
+		if session.UserID == userID && !session.IsRevoked() {
+			sessions = append(sessions, *session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *memSessionRepo) Touch(id uuid.UUID, ipAddress string, seenAt time.Time) error {
+	if session, ok := r.sessions[id]; ok {
+		session.IPAddress = ipAddress
+		session.LastSeenAt = seenAt
+	}
+	return nil
+}
+
+func (r *memSessionRepo) Revoke(id uuid.UUID, at time.Time) (bool, error) {
+	session, ok := r.sessions[id]
+	if !ok || session.IsRevoked() {
+		return false, nil
+	}
+	session.RevokedAt = &at
+	return true, nil
+}
+
+func (r *memSessionRepo) RevokeAllForUser(userID uuid.UUID, at time.Time) error {
+	for _, session := range r.sessions {
+		if session.UserID == userID && !session.IsRevoked() {
+			session.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+// In-memory ActionTokenRepository for testing
+type memActionTokenRepo struct {
+	tokens map[string]*domain.ActionToken
+}
+
+func (r *memActionTokenRepo) Create(token *domain.ActionToken) error {
+	token.ID = uuid.New()
+	r.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (r *memActionTokenRepo) GetByHash(purpose, tokenHash string) (*domain.ActionToken, error) {
+	if token, ok := r.tokens[tokenHash]; ok && token.Purpose == purpose {
+		copied := *token
+		return &copied, nil
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *memActionTokenRepo) MarkUsed(id uuid.UUID, usedAt time.Time) (bool, error) {
+	for _, token := range r.tokens {
+		if token.ID == id {
+			if token.UsedAt != nil {
+				return false, nil
+			}
+			token.UsedAt = &usedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memActionTokenRepo) InvalidateForUser(userID uuid.UUID, purpose string) error {
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+// In-memory RateLimitRepository for testing
+type memRateLimitRepo struct {
+	counts map[string]int
+}
+
+func (r *memRateLimitRepo) Allow(key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	r.counts[key]++
+	if r.counts[key] > limit {
+		return false, window, nil
+	}
+	return true, 0, nil
+}
+
+// In-memory MFARepository for testing
+type memMFARepo struct {
+	factors       map[uuid.UUID]*domain.MFAFactor
+	recoveryCodes []*domain.RecoveryCode
+}
+
+func (r *memMFARepo) GetFactor(userID uuid.UUID) (*domain.MFAFactor, error) {
+	factor, ok := r.factors[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *factor
+	return &copied, nil
+}
+
+func (r *memMFARepo) SaveFactor(factor *domain.MFAFactor) error {
+	copied := *factor
+	r.factors[factor.UserID] = &copied
+	return nil
+}
+
+func (r *memMFARepo) DeleteFactor(userID uuid.UUID) error {
+	delete(r.factors, userID)
+	return r.ReplaceRecoveryCodes(userID, nil)
+}
+
+func (r *memMFARepo) AdvanceStep(userID uuid.UUID, step int64) (bool, error) {
+	factor, ok := r.factors[userID]
+	if !ok || factor.LastUsedStep >= step {
+		return false, nil
+	}
+	factor.LastUsedStep = step
+	return true, nil
+}
+
+func (r *memMFARepo) ReplaceRecoveryCodes(userID uuid.UUID, codes []domain.RecoveryCode) error {
+	kept := r.recoveryCodes[:0]
+	for _, code := range r.recoveryCodes {
+		if code.UserID != userID {
+			kept = append(kept, code)
+		}
+	}
+	for i := range codes {
+		kept = append(kept, &codes[i])
+	}
+	r.recoveryCodes = kept
+	return nil
+}
+
+func (r *memMFARepo) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	for _, code := range r.recoveryCodes {
+		if code.UserID == userID && code.CodeHash == codeHash && code.UsedAt == nil {
+			now := time.Now()
+			code.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// In-memory LoginAttemptRepository for testing
+type memLoginAttemptRepo struct {
+	failures map[string]int64
+	locks    map[string]time.Time
+}
+
+func (r *memLoginAttemptRepo) LockedFor(key string) (time.Duration, error) {
+	remaining := time.Until(r.locks[key])
+	if remaining < 0 {
+		return 0, nil
+	}
+	return remaining, nil
+}
+
+func (r *memLoginAttemptRepo) RecordFailure(key string, window time.Duration) (int64, error) {
+	r.failures[key]++
+	return r.failures[key], nil
+}
+
+func (r *memLoginAttemptRepo) Lock(key string, duration time.Duration) error {
+	r.locks[key] = time.Now().Add(duration)
+	return nil
+}
+
+func (r *memLoginAttemptRepo) Reset(key string) error {
+	delete(r.failures, key)
+	delete(r.locks, key)
+	return nil
+}
+
+// In-memory UserIdentityRepository for testing
+type memUserIdentityRepo struct {
+	identities []*domain.UserIdentity
+}
+
+func (r *memUserIdentityRepo) Create(identity *domain.UserIdentity) error {
+	identity.ID = uuid.New()
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *memUserIdentityRepo) GetByProviderSubject(provider, subject string) (*domain.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memUserIdentityRepo) ListForUser(userID uuid.UUID) ([]domain.UserIdentity, error) {
+	var identities []domain.UserIdentity
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			identities = append(identities, *identity)
+		}
+	}
+	return identities, nil
+}
+
+// In-memory OAuthStateRepository for testing
+type memOAuthStateRepo struct {
+	states map[string]*repository.OAuthState
+}
+
+func (r *memOAuthStateRepo) Save(state string, data *repository.OAuthState, ttl time.Duration) error {
+	r.states[state] = data
+	return nil
+}
+
+func (r *memOAuthStateRepo) Consume(state string) (*repository.OAuthState, error) {
+	data := r.states[state]
+	delete(r.states, state)
+	return data, nil
+}
+
+// In-memory APIKeyRepository for testing
+type memAPIKeyRepo struct {
+	keys     map[uuid.UUID]*domain.APIKey
+	userRepo *memUserRepo
+}
+
+func (r *memAPIKeyRepo) Create(key *domain.APIKey) error {
+	key.ID = uuid.New()
+	r.keys[key.ID] = key
+	return nil
+}
+
+func (r *memAPIKeyRepo) GetByHash(keyHash string) (*domain.APIKey, error) {
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash {
+			found := *key
+			found.User = r.userRepo.users[key.UserID]
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memAPIKeyRepo) ListActiveForUser(userID uuid.UUID) ([]domain.APIKey, error) {
+	var keys []domain.APIKey
+	for _, key := range r.keys {
+		if key.UserID == userID && key.RevokedAt == nil {
+			keys = append(keys, *key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *memAPIKeyRepo) CountActiveForUser(userID uuid.UUID) (int64, error) {
+	keys, _ := r.ListActiveForUser(userID)
+	return int64(len(keys)), nil
+}
+
+func (r *memAPIKeyRepo) Revoke(id, userID uuid.UUID, at time.Time) (bool, error) {
+	key, ok := r.keys[id]
+	if !ok || key.UserID != userID || key.RevokedAt != nil {
+		return false, nil
+	}
+	key.RevokedAt = &at
+	return true, nil
+}
+
+func (r *memAPIKeyRepo) Touch(id uuid.UUID, usedAt time.Time) error {
+	if key, ok := r.keys[id]; ok {
+		key.LastUsedAt = &usedAt
+	}
+	return nil
+}
+
+// Storage that keeps files in memory
+type memStorage struct {
+	files map[string][]byte
+}
+
+func (s *memStorage) Put(key string, r io.Reader, contentType string) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	s.files[key] = data
+	return "https://files.example.com/" + key, nil
+}
+
+func (s *memStorage) Open(key string) (io.ReadCloser, error) {
+	data, ok := s.files[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memStorage) Delete(key string) error {
+	delete(s.files, key)
+	return nil
+}
+
+// Mailer that keeps sent messages in memory
+type memMailer struct {
+	sent []mailer.Message
+	// err fails every Send while set
+	err error
+}
+
+func (m *memMailer) Send(msg mailer.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func testKeyring() *keyring.Keyring {
+	key, err := keyring.GenerateEd25519("test")
+	if err != nil {
+		panic(err)
+	}
+	kr, err := keyring.New([]*keyring.Key{key}, "")
+	if err != nil {
+		panic(err)
+	}
+	return kr
+}
+
+var testClient = service.ClientInfo{IP: "203.0.113.10"}
+
+// Cheap Argon2id parameters keep the tests fast
+var testHashParams = passwords.Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+// A password the test policy rejects as breached
+const breachedPassword = "letmein123"
+
+type testEnv struct {
+	userRepo         *memUserRepo
+	refreshTokenRepo *memRefreshTokenRepo
+	revocationRepo   *memRevocationRepo
+	suspensionRepo   *memSuspensionRepo
+	sessionRepo      *memSessionRepo
+	actionTokenRepo  *memActionTokenRepo
+	rateLimitRepo    *memRateLimitRepo
+	mfaRepo          *memMFARepo
+	loginAttemptRepo *memLoginAttemptRepo
+	apiKeyRepo       *memAPIKeyRepo
+	handleChangeRepo *memHandleChangeRepo
+	equipmentRepo    *memEquipmentRepo
+	onboardingRepo   *memOnboardingRepo
+	exportRepo       *memDataExportRepo
+	beanRepo         *memBeanRepo
+	stockRepo        *memBeanStockRepo
+	eventRepo        *memBeanEventRepo
+	catalogRepo      *memCatalogRepo
+	mailer           *memMailer
+	storage          *memStorage
+	hasher           *passwords.Hasher
+	authService      service.AuthService
+	passwordService  service.PasswordService
+	magicLinkService service.MagicLinkService
+	verificationSvc  service.EmailVerificationService
+	mfaService       service.MFAService
+	sessionService   service.SessionService
+	guestService     service.GuestService
+	adminService     service.AdminService
+	apiKeyService    service.APIKeyService
+	userService      service.UserService
+	handleService    service.HandleService
+	onboardingSvc    service.OnboardingService
+	exportService    service.DataExportService
+	deletionService  service.AccountDeletionService
+	beanService      service.BeanService
+	catalogService   service.CatalogService
+}
+
+func newTestEnv() *testEnv {
+	env := &testEnv{
+		userRepo:         &memUserRepo{users: map[uuid.UUID]*domain.User{}},
+		refreshTokenRepo: &memRefreshTokenRepo{tokens: map[string]*domain.RefreshToken{}},
+		revocationRepo:   &memRevocationRepo{tokens: map[string]bool{}, sessions: map[uuid.UUID]bool{}, users: map[uuid.UUID]time.Time{}},
+		suspensionRepo:   &memSuspensionRepo{suspended: map[uuid.UUID]bool{}},
+		sessionRepo:      &memSessionRepo{sessions: map[uuid.UUID]*domain.Session{}},
+		actionTokenRepo:  &memActionTokenRepo{tokens: map[string]*domain.ActionToken{}},
+		rateLimitRepo:    &memRateLimitRepo{counts: map[string]int{}},
+		mfaRepo:          &memMFARepo{factors: map[uuid.UUID]*domain.MFAFactor{}},
+		loginAttemptRepo: &memLoginAttemptRepo{failures: map[string]int64{}, locks: map[string]time.Time{}},
+		mailer:           &memMailer{},
+		storage:          &memStorage{files: map[string][]byte{}},
+		handleChangeRepo: &memHandleChangeRepo{},
+		equipmentRepo:    &memEquipmentRepo{},
+		onboardingRepo:   &memOnboardingRepo{onboardings: map[uuid.UUID]*domain.Onboarding{}},
+		exportRepo:       &memDataExportRepo{},
+		beanRepo:         &memBeanRepo{beans: map[uuid.UUID]*domain.CoffeeBean{}},
+		hasher:           passwords.NewHasher(testHashParams),
+	}
+
+	appCfg := config.AppConfig{Name: "Brewkar", BaseURL: "http://localhost:3000"}
+	jwtCfg := config.JWTConfig{AccessTokenExp: 15, RefreshTokenExp: 60}
+	authCfg := config.AuthConfig{
+		PasswordResetTokenExp:     30,
+		EmailVerificationTokenExp: 1440,
+		MagicLinkTokenExp:         15,
+		EmailSendLimit:            3,
+		MFAChallengeExp:           5,
+		LoginMaxFailures:          5,
+		LoginIPMaxFailures:        20,
+		LoginLockoutBase:          1,
+		LoginLockoutMax:           60,
+		LoginFailureWindow:        60,
+		GuestCreateLimit:          10,
+		GuestRetentionDays:        30,
+	}
+
+	env.eventRepo = &memBeanEventRepo{beanRepo: env.beanRepo}
+	env.stockRepo = &memBeanStockRepo{beanRepo: env.beanRepo, eventRepo: env.eventRepo}
+	env.catalogRepo = &memCatalogRepo{beanRepo: env.beanRepo, roasters: map[uuid.UUID]*domain.Roaster{}, coffees: map[uuid.UUID]*domain.Coffee{}}
+	env.apiKeyRepo = &memAPIKeyRepo{keys: map[uuid.UUID]*domain.APIKey{}, userRepo: env.userRepo}
+	apiKeyCfg := config.APIKeyConfig{DailyQuota: 5, MaxPerUser: 3}
+	usersCfg := config.UsersConfig{AvatarMaxSize: 1024, HandleRedirectDays: 90, DeletionGraceDays: 30}
+	exportsCfg := config.ExportsConfig{RequestLimit: 2, LinkExpiryHours: 48}
+	beansCfg := config.BeansConfig{
+		ImageMaxSize: 1024,
+		MaxImages:    3,
+		Freshness: []config.FreshnessWindowConfig{
+			{BrewMethods: []string{"espresso"}, PeakFrom: 7, FadingFrom: 28, StaleFrom: 45},
+			{RoastLevel: "dark", PeakFrom: 2, FadingFrom: 14, StaleFrom: 30},
+			{PeakFrom: 3, FadingFrom: 21, StaleFrom: 40},
+		},
+		StaleWarningDays: 3,
+	}
+
+	policy := passwords.NewPolicy(8, 128, []string{breachedPassword})
+
+	env.verificationSvc = service.NewEmailVerificationService(env.userRepo, env.actionTokenRepo, env.rateLimitRepo, env.mailer, appCfg, authCfg)
+	env.mfaService = service.NewMFAService(env.userRepo, env.mfaRepo, env.rateLimitRepo, env.hasher, appCfg)
+	env.sessionService = service.NewSessionService(env.sessionRepo, env.refreshTokenRepo, env.revocationRepo, jwtCfg)
+	env.authService = service.NewAuthService(env.userRepo, env.refreshTokenRepo, env.revocationRepo, env.rateLimitRepo, env.loginAttemptRepo, env.verificationSvc, env.mfaService, env.sessionService, env.hasher, policy, testKeyring(), jwtCfg, authCfg)
+	env.passwordService = service.NewPasswordService(env.userRepo, env.actionTokenRepo, env.rateLimitRepo, env.loginAttemptRepo, env.authService, env.hasher, policy, env.mailer, appCfg, authCfg)
+	env.magicLinkService = service.NewMagicLinkService(env.userRepo, env.actionTokenRepo, env.rateLimitRepo, env.authService, env.mailer, appCfg, authCfg)
+	env.guestService = service.NewGuestService(env.userRepo, env.rateLimitRepo, env.authService, env.verificationSvc, env.hasher, policy, authCfg)
+	env.adminService = service.NewAdminService(env.userRepo, env.suspensionRepo, env.revocationRepo, jwtCfg)
+	env.apiKeyService = service.NewAPIKeyService(env.apiKeyRepo, env.rateLimitRepo, apiKeyCfg)
+	env.userService = service.NewUserService(env.userRepo, env.actionTokenRepo, env.rateLimitRepo, env.verificationSvc, env.sessionService, env.hasher, policy, env.storage, env.mailer, appCfg, usersCfg)
+	env.handleService = service.NewHandleService(env.userRepo, env.handleChangeRepo, env.rateLimitRepo, handles.NewPolicy([]string{"darn"}), usersCfg)
+	env.onboardingRepo.equipmentRepo = env.equipmentRepo
+	env.onboardingSvc = service.NewOnboardingService(env.onboardingRepo, env.userService)
+	env.exportService = service.NewDataExportService(env.exportRepo, env.userRepo, env.sessionRepo, &memUserIdentityRepo{}, env.apiKeyRepo, env.handleChangeRepo, env.equipmentRepo, env.onboardingRepo, env.beanRepo, env.stockRepo, env.eventRepo, env.rateLimitRepo, env.storage, env.mailer, appCfg, exportsCfg)
+	env.deletionService = service.NewAccountDeletionService(env.userRepo, env.exportRepo, env.beanRepo, env.rateLimitRepo, env.mfaService, env.sessionService, env.hasher, env.storage, env.mailer, appCfg, authCfg, usersCfg)
+	env.beanService = service.NewBeanService(env.beanRepo, env.stockRepo, env.eventRepo, env.catalogRepo, env.userRepo, env.storage, env.mailer, appCfg, beansCfg)
+	env.catalogService = service.NewCatalogService(env.catalogRepo)
+	return env
+}
+
+// lastMailToken extracts the token of the given link path from the most
+// recently sent email.
+func lastMailToken(env *testEnv, path string) string {
+	if len(env.mailer.sent) == 0 {
+		return ""
+	}
+	match := regexp.MustCompile(path + `\?token=([A-Za-z0-9_-]+)`).FindStringSubmatch(env.mailer.sent[len(env.mailer.sent)-1].Body)
+	if len(match) != 2 {
+		return ""
+	}
+	return match[1]
+}
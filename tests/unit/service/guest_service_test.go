@@ -0,0 +1,80 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func TestCreateGuest(t *testing.T) {
+	env := newTestEnv()
+
+	guest, tokens, err := env.guestService.CreateGuest(testClient)
+	require.NoError(t, err)
+	assert.True(t, guest.IsGuest)
+	assert.Nil(t, guest.Email)
+	assert.Empty(t, guest.PasswordHash)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	// Guests keep their session through refreshes like everyone else
+	_, err = env.authService.RefreshToken(tokens.RefreshToken, testClient)
+	assert.NoError(t, err)
+
+	// Guests have no address to send a verification email to
+	assert.ErrorIs(t, env.verificationSvc.ResendVerification(guest.ID), service.ErrGuestAccount)
+}
+
+func TestCreateGuestIsRateLimited(t *testing.T) {
+	env := newTestEnv()
+
+	for i := 0; i < 10; i++ {
+		_, _, err := env.guestService.CreateGuest(testClient)
+		require.NoError(t, err)
+	}
+
+	_, _, err := env.guestService.CreateGuest(testClient)
+	var rateLimitErr *service.RateLimitError
+	assert.True(t, errors.As(err, &rateLimitErr))
+}
+
+func TestUpgradeGuest(t *testing.T) {
+	env := newTestEnv()
+
+	guest, _, err := env.guestService.CreateGuest(testClient)
+	require.NoError(t, err)
+
+	user, err := env.guestService.Upgrade(guest.ID, "guest@example.com", "password123", "Coffee Fan")
+	require.NoError(t, err)
+	// The account keeps its ID and with it everything the guest created
+	assert.Equal(t, guest.ID, user.ID)
+	assert.False(t, user.IsGuest)
+	assert.Equal(t, "guest@example.com", user.EmailAddress())
+	assert.Equal(t, "Coffee Fan", user.DisplayName)
+	assert.NotEmpty(t, lastMailToken(env, "verify-email"))
+
+	// The upgraded account can log in with its password
+	loggedIn, _, err := env.authService.Login("guest@example.com", "password123", testClient)
+	require.NoError(t, err)
+	assert.Equal(t, guest.ID, loggedIn.ID)
+
+	// Only guests can be upgraded
+	_, err = env.guestService.Upgrade(guest.ID, "other@example.com", "password123", "")
+	assert.ErrorIs(t, err, service.ErrNotGuest)
+}
+
+func TestUpgradeGuestEmailInUse(t *testing.T) {
+	env := newTestEnv()
+
+	_, _, err := env.authService.Register("taken@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	guest, _, err := env.guestService.CreateGuest(testClient)
+	require.NoError(t, err)
+
+	_, err = env.guestService.Upgrade(guest.ID, "taken@example.com", "password123", "")
+	assert.ErrorIs(t, err, service.ErrEmailAlreadyInUse)
+	assert.True(t, env.userRepo.users[guest.ID].IsGuest)
+}
@@ -0,0 +1,151 @@
+package service_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/service"
+	"gorm.io/gorm"
+)
+
+func TestSetHandle(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	updated, err := env.handleService.SetHandle(user.ID, " @Coffee_Lover ")
+	require.NoError(t, err)
+	assert.Equal(t, "Coffee_Lover", updated.HandleName())
+
+	// Changing only the case is not a move
+	updated, err = env.handleService.SetHandle(user.ID, "coffee_lover")
+	require.NoError(t, err)
+	assert.Equal(t, "coffee_lover", updated.HandleName())
+	assert.Empty(t, env.handleChangeRepo.changes)
+
+	tests := []struct {
+		name   string
+		handle string
+	}{
+		{name: "Too Short", handle: "ab"},
+		{name: "Reserved", handle: "admin"},
+		{name: "Blocked Word", handle: "darn_it"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.handleService.SetHandle(user.ID, tt.handle)
+			assert.ErrorIs(t, err, service.ErrInvalidHandle)
+		})
+	}
+}
+
+func TestSetHandleTaken(t *testing.T) {
+	env := newTestEnv()
+	alice, _, err := env.authService.Register("alice@example.com", "password123", "Alice", testClient)
+	require.NoError(t, err)
+	bob, _, err := env.authService.Register("bob@example.com", "password123", "Bob", testClient)
+	require.NoError(t, err)
+
+	_, err = env.handleService.SetHandle(alice.ID, "alice")
+	require.NoError(t, err)
+
+	// Handles are unique ignoring case
+	_, err = env.handleService.SetHandle(bob.ID, "ALICE")
+	assert.ErrorIs(t, err, service.ErrHandleTaken)
+
+	// An old handle stays reserved for its former owner
+	_, err = env.handleService.SetHandle(alice.ID, "alice_brews")
+	require.NoError(t, err)
+	_, err = env.handleService.SetHandle(bob.ID, "alice")
+	assert.ErrorIs(t, err, service.ErrHandleTaken)
+	_, err = env.handleService.SetHandle(alice.ID, "alice")
+	assert.NoError(t, err)
+
+	// Once the redirect has expired anybody can take it
+	_, err = env.handleService.SetHandle(alice.ID, "alice_again")
+	require.NoError(t, err)
+	for _, change := range env.handleChangeRepo.changes {
+		change.ChangedAt = time.Now().AddDate(0, 0, -91)
+	}
+	_, err = env.handleService.SetHandle(bob.ID, "alice")
+	assert.NoError(t, err)
+
+	// Losing a race for a handle to its unique index
+	env.handleChangeRepo.err = gorm.ErrDuplicatedKey
+	_, err = env.handleService.SetHandle(bob.ID, "bob")
+	assert.ErrorIs(t, err, service.ErrHandleTaken)
+}
+
+func TestSetHandleIsRateLimited(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	for _, handle := range []string{"first_handle", "second_handle", "third_handle", "fourth_handle"} {
+		_, err = env.handleService.SetHandle(user.ID, handle)
+		require.NoError(t, err)
+	}
+	_, err = env.handleService.SetHandle(user.ID, "fifth_handle")
+	var rateLimitErr *service.RateLimitError
+	assert.True(t, errors.As(err, &rateLimitErr))
+}
+
+func TestGuestsCannotSetHandle(t *testing.T) {
+	env := newTestEnv()
+	guest, _, err := env.guestService.CreateGuest(testClient)
+	require.NoError(t, err)
+
+	_, err = env.handleService.SetHandle(guest.ID, "guest_user")
+	assert.ErrorIs(t, err, service.ErrGuestAccount)
+}
+
+func TestGetPublicProfile(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, err = env.userService.UpdateProfile(user.ID, service.ProfileUpdate{Bio: strPtr("Pour-over fan")})
+	require.NoError(t, err)
+	_, err = env.handleService.SetHandle(user.ID, "Coffee_Lover")
+	require.NoError(t, err)
+
+	profile, err := env.handleService.GetPublicProfile("@COFFEE_LOVER")
+	require.NoError(t, err)
+	assert.Equal(t, "Coffee_Lover", profile.Handle)
+	assert.Equal(t, "Test User", profile.DisplayName)
+	assert.Equal(t, "Pour-over fan", profile.Bio)
+
+	_, err = env.handleService.GetPublicProfile("nobody_here")
+	assert.ErrorIs(t, err, service.ErrProfileNotFound)
+
+	// Old handles point at the new one
+	_, err = env.handleService.SetHandle(user.ID, "bean_counter")
+	require.NoError(t, err)
+	_, err = env.handleService.GetPublicProfile("coffee_lover")
+	var movedErr *service.HandleMovedError
+	require.True(t, errors.As(err, &movedErr))
+	assert.Equal(t, "bean_counter", movedErr.Handle)
+}
+
+func TestGetPublicProfileHidesPrivateAndSuspendedUsers(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, err = env.handleService.SetHandle(user.ID, "coffee_lover")
+	require.NoError(t, err)
+
+	_, err = env.userService.UpdatePreferences(user.ID, json.RawMessage(`{"privacy": {"profileVisibility": "private"}}`))
+	require.NoError(t, err)
+	_, err = env.handleService.GetPublicProfile("coffee_lover")
+	assert.ErrorIs(t, err, service.ErrProfileNotFound)
+
+	_, err = env.userService.UpdatePreferences(user.ID, json.RawMessage(`{"privacy": {"profileVisibility": "public"}}`))
+	require.NoError(t, err)
+	now := time.Now()
+	env.userRepo.users[user.ID].SuspendedAt = &now
+	_, err = env.handleService.GetPublicProfile("coffee_lover")
+	assert.ErrorIs(t, err, service.ErrProfileNotFound)
+}
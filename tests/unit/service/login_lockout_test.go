@@ -0,0 +1,97 @@
+package service_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func TestLoginLocksAccountAfterFailures(t *testing.T) {
+	env := newTestEnv()
+	_, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		_, _, err := env.authService.Login("test@example.com", "wrong-password", testClient)
+		assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+	}
+
+	// The fifth failure locks the account for the base duration
+	_, _, err = env.authService.Login("test@example.com", "wrong-password", testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+
+	_, _, err = env.authService.Login("test@example.com", "password123", testClient)
+	var lockedErr *service.LoginLockedError
+	require.True(t, errors.As(err, &lockedErr))
+	assert.InDelta(t, time.Minute.Seconds(), lockedErr.RetryAfter.Seconds(), 1)
+
+	// Once the lock expires every further failure doubles it
+	delete(env.loginAttemptRepo.locks, "account:test@example.com")
+	_, _, err = env.authService.Login("test@example.com", "wrong-password", testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+
+	_, _, err = env.authService.Login("test@example.com", "password123", testClient)
+	require.True(t, errors.As(err, &lockedErr))
+	assert.InDelta(t, (2 * time.Minute).Seconds(), lockedErr.RetryAfter.Seconds(), 1)
+}
+
+func TestLoginLocksIPAfterFailures(t *testing.T) {
+	env := newTestEnv()
+	_, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	// Spread over many accounts so no single account is locked
+	for i := 0; i < 20; i++ {
+		_, _, err := env.authService.Login(fmt.Sprintf("user%d@example.com", i), "password123", testClient)
+		assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+	}
+
+	_, _, err = env.authService.Login("test@example.com", "password123", testClient)
+	var lockedErr *service.LoginLockedError
+	assert.True(t, errors.As(err, &lockedErr))
+
+	// Other addresses are unaffected
+	_, tokens, err := env.authService.Login("test@example.com", "password123", service.ClientInfo{IP: "198.51.100.7"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+}
+
+func TestSuccessfulLoginResetsFailures(t *testing.T) {
+	env := newTestEnv()
+	_, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		_, _, _ = env.authService.Login("test@example.com", "wrong-password", testClient)
+	}
+	_, _, err = env.authService.Login("test@example.com", "password123", testClient)
+	require.NoError(t, err)
+
+	// The count starts over, so one more failure does not lock
+	_, _, err = env.authService.Login("test@example.com", "wrong-password", testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+	_, _, err = env.authService.Login("test@example.com", "password123", testClient)
+	assert.NoError(t, err)
+}
+
+func TestPasswordResetUnlocksAccount(t *testing.T) {
+	env := newTestEnv()
+	_, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, _, _ = env.authService.Login("test@example.com", "wrong-password", testClient)
+	}
+
+	require.NoError(t, env.passwordService.ForgotPassword("test@example.com"))
+	require.NoError(t, env.passwordService.ResetPassword(lastMailToken(env, "reset-password"), "newpassword123"))
+
+	_, tokens, err := env.authService.Login("test@example.com", "newpassword123", testClient)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+}
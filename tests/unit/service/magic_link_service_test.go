@@ -0,0 +1,128 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func TestMagicLinkLogin(t *testing.T) {
+	env := newTestEnv()
+
+	registered, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	require.Nil(t, registered.EmailVerifiedAt)
+
+	nonce, err := env.magicLinkService.RequestLink("test@example.com")
+	require.NoError(t, err)
+	require.NotEmpty(t, nonce)
+	assert.Equal(t, "test@example.com", env.mailer.sent[len(env.mailer.sent)-1].To)
+	token := lastMailToken(env, "magic-link")
+	require.NotEmpty(t, token)
+
+	user, tokens, err := env.magicLinkService.Login(token, nonce, testClient)
+	require.NoError(t, err)
+	assert.Equal(t, registered.ID, user.ID)
+	assert.NotNil(t, user.LastLoginAt)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	// Following the link proves the user controls the address
+	assert.True(t, user.IsEmailVerified())
+
+	// Links are single-use
+	_, _, err = env.magicLinkService.Login(token, nonce, testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidMagicLink)
+}
+
+func TestMagicLinkIsBoundToRequestingDevice(t *testing.T) {
+	env := newTestEnv()
+
+	_, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	nonce, err := env.magicLinkService.RequestLink("test@example.com")
+	require.NoError(t, err)
+	token := lastMailToken(env, "magic-link")
+
+	// A forwarded link opened on another device has a different nonce
+	_, _, err = env.magicLinkService.Login(token, "someone-elses-nonce", testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidMagicLink)
+	_, _, err = env.magicLinkService.Login(token, "", testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidMagicLink)
+
+	// The failed attempts did not use up the link
+	_, _, err = env.magicLinkService.Login(token, nonce, testClient)
+	assert.NoError(t, err)
+}
+
+func TestMagicLinkExpires(t *testing.T) {
+	env := newTestEnv()
+
+	_, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	nonce, err := env.magicLinkService.RequestLink("test@example.com")
+	require.NoError(t, err)
+	token := lastMailToken(env, "magic-link")
+
+	for _, stored := range env.actionTokenRepo.tokens {
+		stored.ExpiresAt = time.Now().Add(-time.Second)
+	}
+	_, _, err = env.magicLinkService.Login(token, nonce, testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidMagicLink)
+}
+
+func TestNewMagicLinkInvalidatesOlderOnes(t *testing.T) {
+	env := newTestEnv()
+
+	_, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	firstNonce, err := env.magicLinkService.RequestLink("test@example.com")
+	require.NoError(t, err)
+	firstToken := lastMailToken(env, "magic-link")
+	secondNonce, err := env.magicLinkService.RequestLink("test@example.com")
+	require.NoError(t, err)
+	secondToken := lastMailToken(env, "magic-link")
+
+	_, _, err = env.magicLinkService.Login(secondToken, secondNonce, testClient)
+	require.NoError(t, err)
+	_, _, err = env.magicLinkService.Login(firstToken, firstNonce, testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidMagicLink)
+}
+
+func TestMagicLinkUnknownEmail(t *testing.T) {
+	env := newTestEnv()
+
+	// Unknown emails get a nonce too so they look like existing accounts
+	nonce, err := env.magicLinkService.RequestLink("missing@example.com")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, nonce)
+	assert.Empty(t, env.mailer.sent)
+}
+
+func TestMagicLinkHidesSendFailures(t *testing.T) {
+	env := newTestEnv()
+	_, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	// A known email answers like an unknown one even when mail is down
+	env.mailer.err = errors.New("smtp unavailable")
+	nonce, err := env.magicLinkService.RequestLink("test@example.com")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, nonce)
+}
+
+func TestMagicLinkThrottled(t *testing.T) {
+	env := newTestEnv()
+
+	for i := 0; i < 3; i++ {
+		_, err := env.magicLinkService.RequestLink("missing@example.com")
+		require.NoError(t, err)
+	}
+
+	_, err := env.magicLinkService.RequestLink("missing@example.com")
+	var rateLimitErr *service.RateLimitError
+	assert.ErrorAs(t, err, &rateLimitErr)
+}
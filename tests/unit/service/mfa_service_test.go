@@ -0,0 +1,135 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
+	"github.com/yashkadam007/brewkar/pkg/totp"
+)
+
+// enableMFA registers a user and turns on two-factor authentication,
+// returning the user, the TOTP secret and the recovery codes.
+func enableMFA(t *testing.T, env *testEnv) (*domain.User, string, []string) {
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	enrollment, err := env.mfaService.Enroll(user.ID)
+	require.NoError(t, err)
+
+	// Confirm with the previous period's code so the current one stays unused
+	code, err := totp.Code(enrollment.Secret, totp.Step(time.Now())-1)
+	require.NoError(t, err)
+	recoveryCodes, err := env.mfaService.Confirm(user.ID, "password123", code)
+	require.NoError(t, err)
+	require.Len(t, recoveryCodes, 10)
+
+	return user, enrollment.Secret, recoveryCodes
+}
+
+func loginChallenge(t *testing.T, env *testEnv) string {
+	_, tokens, err := env.authService.Login("test@example.com", "password123", testClient)
+	assert.Nil(t, tokens)
+
+	var mfaErr *service.MFARequiredError
+	require.True(t, errors.As(err, &mfaErr))
+	return mfaErr.ChallengeToken
+}
+
+func TestLoginWithTOTP(t *testing.T) {
+	env := newTestEnv()
+	user, secret, _ := enableMFA(t, env)
+
+	challenge := loginChallenge(t, env)
+
+	_, _, err := env.authService.VerifyMFAChallenge(challenge, "000000", testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidMFACode)
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	loggedIn, tokens, err := env.authService.VerifyMFAChallenge(challenge, code, testClient)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, loggedIn.ID)
+	assert.NotEmpty(t, tokens.AccessToken)
+
+	// The challenge cannot be exchanged twice
+	_, _, err = env.authService.VerifyMFAChallenge(challenge, code, testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidMFAChallenge)
+
+	// Nor can the same code be replayed with a new challenge
+	_, _, err = env.authService.VerifyMFAChallenge(loginChallenge(t, env), code, testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidMFACode)
+}
+
+func TestLoginWithRecoveryCode(t *testing.T) {
+	env := newTestEnv()
+	_, _, recoveryCodes := enableMFA(t, env)
+
+	_, tokens, err := env.authService.VerifyMFAChallenge(loginChallenge(t, env), recoveryCodes[0], testClient)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+
+	// Recovery codes are single-use
+	_, _, err = env.authService.VerifyMFAChallenge(loginChallenge(t, env), recoveryCodes[0], testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidMFACode)
+}
+
+func TestMFAChallengeAttemptsLimited(t *testing.T) {
+	env := newTestEnv()
+	enableMFA(t, env)
+
+	challenge := loginChallenge(t, env)
+	for i := 0; i < 5; i++ {
+		_, _, err := env.authService.VerifyMFAChallenge(challenge, "000000", testClient)
+		assert.ErrorIs(t, err, service.ErrInvalidMFACode)
+	}
+
+	_, _, err := env.authService.VerifyMFAChallenge(challenge, "000000", testClient)
+	var rateLimitErr *service.RateLimitError
+	assert.True(t, errors.As(err, &rateLimitErr))
+}
+
+func TestDisableMFA(t *testing.T) {
+	env := newTestEnv()
+	user, _, recoveryCodes := enableMFA(t, env)
+
+	assert.ErrorIs(t, env.mfaService.Disable(user.ID, "password123", "000000"), service.ErrInvalidMFACode)
+	// A valid code is not enough without the password
+	assert.ErrorIs(t, env.mfaService.Disable(user.ID, "wrong-password", recoveryCodes[1]), service.ErrIncorrectPassword)
+	require.NoError(t, env.mfaService.Disable(user.ID, "password123", recoveryCodes[1]))
+
+	enabled, err := env.mfaService.IsEnabled(user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	_, tokens, err := env.authService.Login("test@example.com", "password123", testClient)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+}
+
+func TestDisableMFAAttemptsLimited(t *testing.T) {
+	env := newTestEnv()
+	user, _, recoveryCodes := enableMFA(t, env)
+	// Start from a fresh hour, leaving out the attempt that turned it on
+	env.rateLimitRepo.counts = map[string]int{}
+
+	for i := 0; i < 5; i++ {
+		assert.ErrorIs(t, env.mfaService.Disable(user.ID, "password123", "000000"), service.ErrInvalidMFACode)
+	}
+
+	err := env.mfaService.Disable(user.ID, "password123", "000000")
+	var rateLimitErr *service.RateLimitError
+	assert.True(t, errors.As(err, &rateLimitErr))
+
+	// Nor is a valid code accepted once the attempts are used up
+	err = env.mfaService.Disable(user.ID, "password123", recoveryCodes[0])
+	assert.True(t, errors.As(err, &rateLimitErr))
+
+	enabled, err := env.mfaService.IsEnabled(user.ID)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+}
@@ -0,0 +1,143 @@
+package service_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func TestOnboardingStartsAtFirstStep(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	onboarding, err := env.onboardingSvc.GetOnboarding(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OnboardingBrewMethods, onboarding.CurrentStep)
+	assert.Empty(t, onboarding.CompletedSteps)
+	assert.Empty(t, onboarding.SkippedSteps)
+	assert.False(t, onboarding.IsComplete())
+}
+
+func TestOnboardingSeedsPreferencesAndEquipment(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	_, err = env.onboardingSvc.AnswerStep(user.ID, domain.OnboardingBrewMethods, json.RawMessage(`{"methods": ["v60", "french-press"]}`))
+	require.NoError(t, err)
+	_, err = env.onboardingSvc.AnswerStep(user.ID, domain.OnboardingBeanTypes, json.RawMessage(`{"roastLevels": ["light"]}`))
+	require.NoError(t, err)
+	_, err = env.onboardingSvc.AnswerStep(user.ID, domain.OnboardingUnits, json.RawMessage(`{"units": {"weight": "oz"}, "timezone": "Europe/Berlin"}`))
+	require.NoError(t, err)
+	onboarding, err := env.onboardingSvc.AnswerStep(user.ID, domain.OnboardingEquipment, json.RawMessage(`{"grinder": "Comandante C40", "scale": "Acaia Pearl"}`))
+	require.NoError(t, err)
+
+	assert.True(t, onboarding.IsComplete())
+	assert.Equal(t, domain.OnboardingSteps, onboarding.CompletedSteps)
+
+	preferences, err := env.userService.GetPreferences(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v60", "french-press"}, preferences.FavoriteBrewMethods)
+	assert.Equal(t, "v60", preferences.DefaultBrewMethod)
+	assert.Equal(t, []string{"light"}, preferences.FavoriteRoastLevels)
+	assert.Equal(t, domain.WeightOunces, preferences.Units.Weight)
+	assert.Equal(t, "Europe/Berlin", preferences.Timezone)
+	assert.Equal(t, "Comandante C40", preferences.DefaultGrinder)
+
+	var names []string
+	for _, item := range env.equipmentRepo.items {
+		names = append(names, string(item.Kind)+":"+item.Name)
+	}
+	assert.Equal(t, []string{"brewer:V60", "brewer:French Press", "grinder:Comandante C40", "scale:Acaia Pearl"}, names)
+}
+
+func TestOnboardingEquipmentAddedOnce(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, err = env.onboardingSvc.SkipRemaining(user.ID)
+	require.NoError(t, err)
+	env.onboardingRepo.onboardings[user.ID].CurrentStep = domain.OnboardingEquipment
+	env.onboardingRepo.onboardings[user.ID].CompletedAt = nil
+
+	answers := json.RawMessage(`{"grinder": "Comandante C40"}`)
+
+	// A failed save adds nothing, so the answer can be sent again
+	env.onboardingRepo.err = errors.New("connection reset")
+	_, err = env.onboardingSvc.AnswerStep(user.ID, domain.OnboardingEquipment, answers)
+	require.Error(t, err)
+	assert.Empty(t, env.equipmentRepo.items)
+
+	_, err = env.onboardingSvc.AnswerStep(user.ID, domain.OnboardingEquipment, answers)
+	require.NoError(t, err)
+	assert.Len(t, env.equipmentRepo.items, 1)
+
+	// Sending it once more does not add the grinder twice
+	_, err = env.onboardingSvc.AnswerStep(user.ID, domain.OnboardingEquipment, answers)
+	assert.ErrorIs(t, err, service.ErrOnboardingComplete)
+	assert.Len(t, env.equipmentRepo.items, 1)
+}
+
+func TestOnboardingStepsInOrder(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	_, err = env.onboardingSvc.AnswerStep(user.ID, domain.OnboardingUnits, json.RawMessage(`{"units": {"weight": "oz"}}`))
+	assert.ErrorIs(t, err, service.ErrOnboardingStepOutOfOrder)
+
+	_, err = env.onboardingSvc.SkipStep(user.ID, "favorite_color")
+	assert.ErrorIs(t, err, service.ErrUnknownOnboardingStep)
+}
+
+func TestOnboardingRejectsInvalidAnswers(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	for _, answers := range []string{
+		`{"methods": []}`,
+		`{"methods": ["v60"], "color": "red"}`,
+		`{"methods": ["not a slug!"]}`,
+		`[]`,
+	} {
+		_, err := env.onboardingSvc.AnswerStep(user.ID, domain.OnboardingBrewMethods, json.RawMessage(answers))
+		assert.Error(t, err, answers)
+	}
+
+	// The step can still be answered after a rejected attempt
+	onboarding, err := env.onboardingSvc.GetOnboarding(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OnboardingBrewMethods, onboarding.CurrentStep)
+}
+
+func TestOnboardingSkip(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	onboarding, err := env.onboardingSvc.SkipStep(user.ID, domain.OnboardingBrewMethods)
+	require.NoError(t, err)
+	assert.Equal(t, domain.OnboardingBeanTypes, onboarding.CurrentStep)
+	assert.Equal(t, []domain.OnboardingStep{domain.OnboardingBrewMethods}, onboarding.SkippedSteps)
+
+	_, err = env.onboardingSvc.AnswerStep(user.ID, domain.OnboardingBeanTypes, json.RawMessage(`{"roastLevels": ["dark"]}`))
+	require.NoError(t, err)
+
+	onboarding, err = env.onboardingSvc.SkipRemaining(user.ID)
+	require.NoError(t, err)
+	assert.True(t, onboarding.IsComplete())
+	assert.Equal(t, []domain.OnboardingStep{domain.OnboardingBeanTypes}, onboarding.CompletedSteps)
+	assert.Equal(t, []domain.OnboardingStep{domain.OnboardingBrewMethods, domain.OnboardingUnits, domain.OnboardingEquipment}, onboarding.SkippedSteps)
+
+	_, err = env.onboardingSvc.SkipRemaining(user.ID)
+	assert.ErrorIs(t, err, service.ErrOnboardingComplete)
+	_, err = env.onboardingSvc.AnswerStep(user.ID, domain.OnboardingEquipment, json.RawMessage(`{}`))
+	assert.ErrorIs(t, err, service.ErrOnboardingComplete)
+}
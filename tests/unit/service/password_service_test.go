@@ -0,0 +1,84 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func TestPasswordResetFlow(t *testing.T) {
+	env := newTestEnv()
+
+	_, tokens, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	require.NoError(t, env.passwordService.ForgotPassword("test@example.com"))
+	require.NotEmpty(t, env.mailer.sent)
+	assert.Equal(t, "test@example.com", env.mailer.sent[len(env.mailer.sent)-1].To)
+
+	resetToken := lastMailToken(env, "reset-password")
+	require.NotEmpty(t, resetToken)
+
+	require.NoError(t, env.passwordService.ResetPassword(resetToken, "newpassword123"))
+
+	// The new password works, the old one does not
+	_, _, err = env.authService.Login("test@example.com", "newpassword123", testClient)
+	assert.NoError(t, err)
+	_, _, err = env.authService.Login("test@example.com", "password123", testClient)
+	assert.Error(t, err)
+
+	// Existing sessions were invalidated
+	_, err = env.authService.RefreshToken(tokens.RefreshToken, testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+
+	// Reset tokens are single-use
+	err = env.passwordService.ResetPassword(resetToken, "anotherpassword123")
+	assert.ErrorIs(t, err, service.ErrInvalidResetToken)
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	env := newTestEnv()
+
+	assert.NoError(t, env.passwordService.ForgotPassword("missing@example.com"))
+	assert.Empty(t, env.mailer.sent)
+}
+
+func TestForgotPasswordHidesSendFailures(t *testing.T) {
+	env := newTestEnv()
+	_, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	// A known email answers like an unknown one even when mail is down
+	env.mailer.err = errors.New("smtp unavailable")
+	assert.NoError(t, env.passwordService.ForgotPassword("test@example.com"))
+	assert.NoError(t, env.passwordService.ForgotPassword("missing@example.com"))
+}
+
+func TestForgotPasswordThrottled(t *testing.T) {
+	env := newTestEnv()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, env.passwordService.ForgotPassword("missing@example.com"))
+	}
+
+	var rateLimitErr *service.RateLimitError
+	assert.ErrorAs(t, env.passwordService.ForgotPassword("missing@example.com"), &rateLimitErr)
+}
+
+func TestResetPasswordAppliesPasswordPolicy(t *testing.T) {
+	env := newTestEnv()
+
+	_, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	require.NoError(t, env.passwordService.ForgotPassword("test@example.com"))
+	resetToken := lastMailToken(env, "reset-password")
+
+	err = env.passwordService.ResetPassword(resetToken, breachedPassword)
+	assert.ErrorIs(t, err, service.ErrWeakPassword)
+
+	// The rejected password did not use up the token
+	assert.NoError(t, env.passwordService.ResetPassword(resetToken, "newpassword123"))
+}
@@ -0,0 +1,89 @@
+package service_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func TestGetPreferencesDefaults(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	preferences, err := env.userService.GetPreferences(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultPreferences(), *preferences)
+}
+
+func TestUpdatePreferencesMergePatch(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	preferences, err := env.userService.UpdatePreferences(user.ID, json.RawMessage(`{
+		"units": {"temperature": "f"},
+		"defaultBrewMethod": "aeropress",
+		"timezone": "America/Los_Angeles",
+		"notifications": {"lowStock": false}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, domain.TemperatureFahrenheit, preferences.Units.Temperature)
+	assert.Equal(t, "aeropress", preferences.DefaultBrewMethod)
+	assert.Equal(t, "America/Los_Angeles", preferences.Timezone)
+	assert.False(t, preferences.Notifications.LowStock)
+	// Settings left out of the patch are kept
+	assert.Equal(t, domain.WeightGrams, preferences.Units.Weight)
+	assert.True(t, preferences.Notifications.Email)
+
+	// null removes a setting, which brings back its default
+	preferences, err = env.userService.UpdatePreferences(user.ID, json.RawMessage(`{"units": {"temperature": null}, "theme": "dark"}`))
+	require.NoError(t, err)
+	assert.Equal(t, domain.TemperatureCelsius, preferences.Units.Temperature)
+	assert.Equal(t, domain.ThemeDark, preferences.Theme)
+	assert.Equal(t, "aeropress", preferences.DefaultBrewMethod)
+
+	stored, err := env.userService.GetProfile(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, *preferences, stored.Preferences)
+}
+
+func TestUpdatePreferencesValidation(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, err = env.userService.UpdatePreferences(user.ID, json.RawMessage(`{"theme": "dark"}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{name: "Not JSON", patch: `{"theme":`},
+		{name: "Not An Object", patch: `"dark"`},
+		{name: "Unknown Setting", patch: `{"colour": "blue"}`},
+		{name: "Unknown Unit", patch: `{"units": {"weight": "lb"}}`},
+		{name: "Wrong Type", patch: `{"notifications": {"email": "yes"}}`},
+		{name: "Replacing An Object", patch: `{"privacy": "private"}`},
+		{name: "Brew Method Not A Slug", patch: `{"defaultBrewMethod": "French Press"}`},
+		{name: "Unknown Time Zone", patch: `{"timezone": "Mars/Olympus_Mons"}`},
+		{name: "Future Version", patch: `{"version": 2}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.userService.UpdatePreferences(user.ID, json.RawMessage(tt.patch))
+			assert.ErrorIs(t, err, service.ErrInvalidPreferences)
+		})
+	}
+
+	// Rejected patches leave the preferences alone
+	preferences, err := env.userService.GetPreferences(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ThemeDark, preferences.Theme)
+	assert.Equal(t, domain.WeightGrams, preferences.Units.Weight)
+}
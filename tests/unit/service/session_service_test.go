@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+func TestLoginCreatesSession(t *testing.T) {
+	env := newTestEnv()
+
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	phone := service.ClientInfo{IP: "198.51.100.7", UserAgent: "Brewkar/1.0 (Android)", DeviceName: "Pixel 8"}
+	_, _, err = env.authService.Login("test@example.com", "password123", phone)
+	require.NoError(t, err)
+
+	sessions, err := env.sessionService.ListSessions(user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	var found bool
+	for _, session := range sessions {
+		if session.DeviceName == "Pixel 8" {
+			found = true
+			assert.Equal(t, phone.UserAgent, session.UserAgent)
+			assert.Equal(t, phone.IP, session.IPAddress)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestRevokeSession(t *testing.T) {
+	env := newTestEnv()
+
+	user, webTokens, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, phoneTokens, err := env.authService.Login("test@example.com", "password123", service.ClientInfo{DeviceName: "Pixel 8"})
+	require.NoError(t, err)
+
+	sessions, err := env.sessionService.ListSessions(user.ID)
+	require.NoError(t, err)
+	var phoneSessionID uuid.UUID
+	for _, session := range sessions {
+		if session.DeviceName == "Pixel 8" {
+			phoneSessionID = session.ID
+		}
+	}
+	require.NotEqual(t, uuid.Nil, phoneSessionID)
+
+	// Other users cannot revoke the session
+	assert.ErrorIs(t, env.sessionService.RevokeSession(uuid.New(), phoneSessionID), service.ErrSessionNotFound)
+
+	require.NoError(t, env.sessionService.RevokeSession(user.ID, phoneSessionID))
+	assert.True(t, env.revocationRepo.sessions[phoneSessionID])
+
+	// The revoked device can no longer refresh; the other one can
+	_, err = env.authService.RefreshToken(phoneTokens.RefreshToken, testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+	_, err = env.authService.RefreshToken(webTokens.RefreshToken, testClient)
+	assert.NoError(t, err)
+
+	sessions, err = env.sessionService.ListSessions(user.ID)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	// Revoking twice reports the session as gone
+	assert.ErrorIs(t, env.sessionService.RevokeSession(user.ID, phoneSessionID), service.ErrSessionNotFound)
+}
@@ -0,0 +1,282 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/config"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/repository"
+	"github.com/yashkadam007/brewkar/internal/service"
+	"github.com/yashkadam007/brewkar/pkg/oidc"
+	"github.com/yashkadam007/brewkar/tests/fakeoidc"
+)
+
+type socialTestEnv struct {
+	*testEnv
+	server       *fakeoidc.Server
+	identityRepo *memUserIdentityRepo
+	socialAuth   service.SocialAuthService
+}
+
+func newSocialTestEnv(t *testing.T) *socialTestEnv {
+	server := fakeoidc.New()
+	t.Cleanup(server.Close)
+
+	env := &socialTestEnv{
+		testEnv:      newTestEnv(),
+		server:       server,
+		identityRepo: &memUserIdentityRepo{},
+	}
+	provider := oidc.New(oidc.Config{
+		Name:         "google",
+		IssuerURL:    server.Issuer(),
+		ClientID:     fakeoidc.ClientID,
+		ClientSecret: fakeoidc.ClientSecret,
+	}, server.Client())
+
+	env.socialAuth = service.NewSocialAuthService(
+		env.userRepo,
+		env.identityRepo,
+		&memOAuthStateRepo{states: map[string]*repository.OAuthState{}},
+		env.authService,
+		env.verificationSvc,
+		[]service.IdentityProvider{provider},
+		config.AppConfig{Name: "Brewkar", BaseURL: "http://localhost:3000"},
+		config.OAuthConfig{StateExp: 10},
+	)
+	return env
+}
+
+// socialLogin runs the whole login flow as user at the fake provider.
+func (env *socialTestEnv) socialLogin(t *testing.T, user fakeoidc.User) (*domain.User, *service.TokenPair, error) {
+	t.Helper()
+	authURL, binding, err := env.socialAuth.StartLogin("google")
+	require.NoError(t, err)
+	code, state, err := env.server.Authorize(authURL, user)
+	require.NoError(t, err)
+	return env.socialAuth.CompleteLogin("google", state, binding, code, testClient)
+}
+
+var googleUser = fakeoidc.User{
+	Subject:       "google-123",
+	Email:         "jane@example.com",
+	EmailVerified: true,
+	Name:          "Jane Doe",
+}
+
+func TestSocialLoginSignsUpNewUser(t *testing.T) {
+	env := newSocialTestEnv(t)
+
+	user, tokens, err := env.socialLogin(t, googleUser)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.Equal(t, "jane@example.com", user.EmailAddress())
+	assert.Equal(t, "Jane Doe", user.DisplayName)
+	assert.True(t, user.IsEmailVerified())
+	assert.Empty(t, user.PasswordHash)
+	// The provider verified the address, so no verification email is needed
+	assert.Empty(t, env.mailer.sent)
+
+	identities, err := env.socialAuth.ListIdentities(user.ID)
+	require.NoError(t, err)
+	require.Len(t, identities, 1)
+	assert.Equal(t, "google", identities[0].Provider)
+	assert.Equal(t, "google-123", identities[0].Subject)
+
+	// Logging in again finds the same user
+	again, _, err := env.socialLogin(t, googleUser)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, again.ID)
+	assert.Len(t, env.userRepo.users, 1)
+}
+
+func TestSocialLoginSendsVerificationForUnverifiedEmail(t *testing.T) {
+	env := newSocialTestEnv(t)
+
+	unverified := googleUser
+	unverified.EmailVerified = false
+	user, _, err := env.socialLogin(t, unverified)
+	require.NoError(t, err)
+	assert.False(t, user.IsEmailVerified())
+	assert.NotEmpty(t, lastMailToken(env.testEnv, "verify-email"))
+}
+
+func TestSocialLoginLinksVerifiedAccount(t *testing.T) {
+	env := newSocialTestEnv(t)
+
+	existing, _, err := env.authService.Register("jane@example.com", "password123", "Jane", testClient)
+	require.NoError(t, err)
+	now := time.Now()
+	existing.EmailVerifiedAt = &now
+
+	user, _, err := env.socialLogin(t, googleUser)
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, user.ID)
+
+	identities, err := env.socialAuth.ListIdentities(existing.ID)
+	require.NoError(t, err)
+	assert.Len(t, identities, 1)
+}
+
+func TestSocialLoginRefusesUnverifiedAutoLink(t *testing.T) {
+	tests := []struct {
+		name             string
+		localVerified    bool
+		providerVerified bool
+	}{
+		{name: "Local Email Unverified", localVerified: false, providerVerified: true},
+		{name: "Provider Email Unverified", localVerified: true, providerVerified: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newSocialTestEnv(t)
+
+			existing, _, err := env.authService.Register("jane@example.com", "password123", "Jane", testClient)
+			require.NoError(t, err)
+			if tt.localVerified {
+				now := time.Now()
+				existing.EmailVerifiedAt = &now
+			}
+
+			providerUser := googleUser
+			providerUser.EmailVerified = tt.providerVerified
+			_, _, err = env.socialLogin(t, providerUser)
+			assert.ErrorIs(t, err, service.ErrAccountExists)
+			assert.Empty(t, env.identityRepo.identities)
+		})
+	}
+}
+
+func TestSocialLoginRequiresMFA(t *testing.T) {
+	env := newSocialTestEnv(t)
+
+	user, _, err := env.socialLogin(t, googleUser)
+	require.NoError(t, err)
+	env.mfaRepo.factors[user.ID] = &domain.MFAFactor{UserID: user.ID, Secret: "JBSWY3DPEHPK3PXP", ConfirmedAt: &user.CreatedAt}
+
+	_, _, err = env.socialLogin(t, googleUser)
+	var mfaErr *service.MFARequiredError
+	assert.True(t, errors.As(err, &mfaErr))
+}
+
+func TestSocialLoginRejectsInvalidState(t *testing.T) {
+	env := newSocialTestEnv(t)
+
+	authURL, binding, err := env.socialAuth.StartLogin("google")
+	require.NoError(t, err)
+	code, state, err := env.server.Authorize(authURL, googleUser)
+	require.NoError(t, err)
+
+	_, _, err = env.socialAuth.CompleteLogin("google", "forged-state", binding, code, testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidOAuthState)
+
+	_, _, err = env.socialAuth.CompleteLogin("google", state, binding, code, testClient)
+	require.NoError(t, err)
+
+	// States are single-use
+	_, _, err = env.socialAuth.CompleteLogin("google", state, binding, code, testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidOAuthState)
+	assert.Equal(t, 1, env.server.TokenRequests)
+}
+
+func TestSocialLoginBoundToStartingClient(t *testing.T) {
+	env := newSocialTestEnv(t)
+
+	// An attacker's callback cannot be completed by another client
+	authURL, _, err := env.socialAuth.StartLogin("google")
+	require.NoError(t, err)
+	code, state, err := env.server.Authorize(authURL, googleUser)
+	require.NoError(t, err)
+
+	_, _, err = env.socialAuth.CompleteLogin("google", state, "other-binding", code, testClient)
+	assert.ErrorIs(t, err, service.ErrInvalidOAuthState)
+	assert.Zero(t, env.server.TokenRequests)
+}
+
+func TestSocialLoginUnknownProvider(t *testing.T) {
+	env := newSocialTestEnv(t)
+
+	_, _, err := env.socialAuth.StartLogin("myspace")
+	assert.ErrorIs(t, err, service.ErrUnknownProvider)
+}
+
+func TestLinkIdentity(t *testing.T) {
+	env := newSocialTestEnv(t)
+
+	// An unverified local account can still link explicitly
+	user, _, err := env.authService.Register("jane@example.com", "password123", "Jane", testClient)
+	require.NoError(t, err)
+
+	authURL, err := env.socialAuth.StartLink("google", user.ID)
+	require.NoError(t, err)
+	code, state, err := env.server.Authorize(authURL, googleUser)
+	require.NoError(t, err)
+
+	identity, err := env.socialAuth.CompleteLink("google", user.ID, state, code)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, identity.UserID)
+
+	loggedIn, _, err := env.socialLogin(t, googleUser)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, loggedIn.ID)
+}
+
+func TestLinkIdentityRejectsOtherUsersState(t *testing.T) {
+	env := newSocialTestEnv(t)
+
+	user, _, err := env.authService.Register("jane@example.com", "password123", "Jane", testClient)
+	require.NoError(t, err)
+
+	authURL, err := env.socialAuth.StartLink("google", user.ID)
+	require.NoError(t, err)
+	code, state, err := env.server.Authorize(authURL, googleUser)
+	require.NoError(t, err)
+
+	_, err = env.socialAuth.CompleteLink("google", uuid.New(), state, code)
+	assert.ErrorIs(t, err, service.ErrInvalidOAuthState)
+}
+
+func TestLinkIdentityAlreadyLinkedElsewhere(t *testing.T) {
+	env := newSocialTestEnv(t)
+
+	_, _, err := env.socialLogin(t, googleUser)
+	require.NoError(t, err)
+
+	other, _, err := env.authService.Register("john@example.com", "password123", "John", testClient)
+	require.NoError(t, err)
+
+	authURL, err := env.socialAuth.StartLink("google", other.ID)
+	require.NoError(t, err)
+	code, state, err := env.server.Authorize(authURL, googleUser)
+	require.NoError(t, err)
+
+	_, err = env.socialAuth.CompleteLink("google", other.ID, state, code)
+	assert.ErrorIs(t, err, service.ErrIdentityAlreadyLinked)
+}
+
+func TestLinkIdentityUpgradesGuest(t *testing.T) {
+	env := newSocialTestEnv(t)
+
+	guest, _, err := env.guestService.CreateGuest(testClient)
+	require.NoError(t, err)
+
+	authURL, err := env.socialAuth.StartLink("google", guest.ID)
+	require.NoError(t, err)
+	code, state, err := env.server.Authorize(authURL, googleUser)
+	require.NoError(t, err)
+
+	_, err = env.socialAuth.CompleteLink("google", guest.ID, state, code)
+	require.NoError(t, err)
+
+	user := env.userRepo.users[guest.ID]
+	assert.False(t, user.IsGuest)
+	assert.Equal(t, "jane@example.com", user.EmailAddress())
+	assert.True(t, user.IsEmailVerified())
+	assert.Equal(t, "Jane Doe", user.DisplayName)
+}
@@ -0,0 +1,217 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/internal/domain"
+	"github.com/yashkadam007/brewkar/internal/service"
+)
+
+// The PNG signature is enough for content sniffing
+var pngAvatar = append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestUpdateProfile(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	updated, err := env.userService.UpdateProfile(user.ID, service.ProfileUpdate{
+		Bio:         strPtr("  Coffee enthusiast from Seattle "),
+		Preferences: json.RawMessage(`{"units":{"weight":"oz"}}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Coffee enthusiast from Seattle", updated.Bio)
+	assert.Equal(t, domain.WeightOunces, updated.Preferences.Units.Weight)
+	// Fields left out are not changed
+	assert.Equal(t, "Test User", updated.DisplayName)
+
+	updated, err = env.userService.UpdateProfile(user.ID, service.ProfileUpdate{DisplayName: strPtr("Coffee Master")})
+	require.NoError(t, err)
+	assert.Equal(t, "Coffee Master", updated.DisplayName)
+	assert.Equal(t, "Coffee enthusiast from Seattle", updated.Bio)
+}
+
+func TestUpdateProfileValidation(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		update service.ProfileUpdate
+		err    error
+	}{
+		{name: "Display Name Too Short", update: service.ProfileUpdate{DisplayName: strPtr(" ab ")}, err: service.ErrInvalidDisplayName},
+		{name: "Display Name Too Long", update: service.ProfileUpdate{DisplayName: strPtr(strings.Repeat("a", 51))}, err: service.ErrInvalidDisplayName},
+		{name: "Bio Too Long", update: service.ProfileUpdate{Bio: strPtr(strings.Repeat("a", 501))}, err: service.ErrBioTooLong},
+		{name: "Preferences Not An Object", update: service.ProfileUpdate{Preferences: json.RawMessage(`["grams"]`)}, err: service.ErrInvalidPreferences},
+		{name: "Preferences Null", update: service.ProfileUpdate{Preferences: json.RawMessage(`null`)}, err: service.ErrInvalidPreferences},
+		{name: "Preferences Unknown Setting", update: service.ProfileUpdate{Preferences: json.RawMessage(`{"weightUnit":"grams"}`)}, err: service.ErrInvalidPreferences},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.userService.UpdateProfile(user.ID, tt.update)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	// Display names are counted in characters, not bytes
+	_, err = env.userService.UpdateProfile(user.ID, service.ProfileUpdate{DisplayName: strPtr("Café")})
+	assert.NoError(t, err)
+}
+
+func TestUploadAvatar(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	updated, err := env.userService.UploadAvatar(user.ID, bytes.NewReader(pngAvatar))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(updated.AvatarURL, "https://files.example.com/avatars/"+user.ID.String()+"/"))
+	assert.True(t, strings.HasSuffix(updated.AvatarURL, ".png"))
+	firstKey := updated.AvatarKey
+	assert.Contains(t, env.storage.files, firstKey)
+
+	// A new avatar replaces the old file
+	updated, err = env.userService.UploadAvatar(user.ID, bytes.NewReader(pngAvatar))
+	require.NoError(t, err)
+	assert.NotEqual(t, firstKey, updated.AvatarKey)
+	assert.NotContains(t, env.storage.files, firstKey)
+	assert.Len(t, env.storage.files, 1)
+
+	updated, err = env.userService.RemoveAvatar(user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, updated.AvatarURL)
+	assert.Empty(t, env.storage.files)
+}
+
+func TestUploadAvatarRejectsBadFiles(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	_, err = env.userService.UploadAvatar(user.ID, strings.NewReader("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	assert.ErrorIs(t, err, service.ErrInvalidAvatar)
+
+	// The test config allows 1 KiB
+	tooLarge := append(append([]byte{}, pngAvatar...), make([]byte, 1024)...)
+	_, err = env.userService.UploadAvatar(user.ID, bytes.NewReader(tooLarge))
+	assert.ErrorIs(t, err, service.ErrAvatarTooLarge)
+	assert.Empty(t, env.storage.files)
+}
+
+func TestChangeEmail(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("old@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	require.NoError(t, env.verificationSvc.VerifyEmail(lastMailToken(env, "verify-email")))
+	require.NoError(t, env.passwordService.ForgotPassword("old@example.com"))
+	resetToken := lastMailToken(env, "reset-password")
+
+	_, err = env.userService.ChangeEmail(user.ID, "new@example.com", "wrongpassword")
+	assert.ErrorIs(t, err, service.ErrIncorrectPassword)
+
+	updated, err := env.userService.ChangeEmail(user.ID, "new@example.com", "password123")
+	require.NoError(t, err)
+	assert.Equal(t, "new@example.com", updated.EmailAddress())
+	// The new address has to be verified again
+	assert.False(t, updated.IsEmailVerified())
+
+	sent := env.mailer.sent
+	require.GreaterOrEqual(t, len(sent), 2)
+	assert.Equal(t, "new@example.com", sent[len(sent)-2].To)
+	assert.Contains(t, sent[len(sent)-2].Body, "verify-email?token=")
+	// The old address is told about the change
+	assert.Equal(t, "old@example.com", sent[len(sent)-1].To)
+
+	// Links sent to the old address stop working
+	assert.ErrorIs(t, env.passwordService.ResetPassword(resetToken, "newpassword123"), service.ErrInvalidResetToken)
+
+	_, _, err = env.authService.Login("new@example.com", "password123", testClient)
+	assert.NoError(t, err)
+}
+
+func TestChangeEmailInUse(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, _, err = env.authService.Register("taken@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	_, err = env.userService.ChangeEmail(user.ID, "taken@example.com", "password123")
+	assert.ErrorIs(t, err, service.ErrEmailAlreadyInUse)
+}
+
+func TestChangePassword(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+	_, currentTokens, err := env.authService.Login("test@example.com", "password123", service.ClientInfo{DeviceName: "Pixel 8"})
+	require.NoError(t, err)
+	_, otherTokens, err := env.authService.Login("test@example.com", "password123", testClient)
+	require.NoError(t, err)
+
+	sessions, err := env.sessionService.ListSessions(user.ID)
+	require.NoError(t, err)
+	var currentSessionID uuid.UUID
+	for _, session := range sessions {
+		if session.DeviceName == "Pixel 8" {
+			currentSessionID = session.ID
+		}
+	}
+	require.NotEqual(t, uuid.Nil, currentSessionID)
+
+	err = env.userService.ChangePassword(user.ID, currentSessionID, "wrongpassword", "newpassword123")
+	assert.ErrorIs(t, err, service.ErrIncorrectPassword)
+	err = env.userService.ChangePassword(user.ID, currentSessionID, "password123", breachedPassword)
+	assert.ErrorIs(t, err, service.ErrWeakPassword)
+
+	require.NoError(t, env.userService.ChangePassword(user.ID, currentSessionID, "password123", "newpassword123"))
+
+	_, _, err = env.authService.Login("test@example.com", "newpassword123", testClient)
+	assert.NoError(t, err)
+
+	// The current session survives, other devices are signed out
+	_, err = env.authService.RefreshToken(currentTokens.RefreshToken, testClient)
+	assert.NoError(t, err)
+	_, err = env.authService.RefreshToken(otherTokens.RefreshToken, testClient)
+	assert.Error(t, err)
+}
+
+func TestPasswordChecksAreRateLimited(t *testing.T) {
+	env := newTestEnv()
+	user, _, err := env.authService.Register("test@example.com", "password123", "Test User", testClient)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err = env.userService.ChangeEmail(user.ID, "new@example.com", "wrongpassword")
+		require.ErrorIs(t, err, service.ErrIncorrectPassword)
+	}
+
+	_, err = env.userService.ChangeEmail(user.ID, "new@example.com", "password123")
+	var rateLimitErr *service.RateLimitError
+	assert.True(t, errors.As(err, &rateLimitErr))
+}
+
+func TestGuestsCannotChangeEmailOrPassword(t *testing.T) {
+	env := newTestEnv()
+	guest, _, err := env.guestService.CreateGuest(testClient)
+	require.NoError(t, err)
+
+	_, err = env.userService.ChangeEmail(guest.ID, "guest@example.com", "")
+	assert.ErrorIs(t, err, service.ErrGuestAccount)
+	err = env.userService.ChangePassword(guest.ID, uuid.Nil, "", "newpassword123")
+	assert.ErrorIs(t, err, service.ErrGuestAccount)
+}
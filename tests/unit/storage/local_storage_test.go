@@ -0,0 +1,87 @@
+package storage_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/pkg/storage"
+)
+
+func TestLocalStoragePutServeDelete(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "uploads")
+	s, err := storage.NewLocalStorage(dir, "http://localhost:8080/uploads/")
+	require.NoError(t, err)
+
+	url, err := s.Put("avatars/user/a.png", strings.NewReader("image data"), "image/png")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/uploads/avatars/user/a.png", url)
+
+	content, err := os.ReadFile(filepath.Join(dir, "avatars", "user", "a.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "image data", string(content))
+
+	resp := httptest.NewRecorder()
+	s.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/avatars/user/a.png", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "image data", resp.Body.String())
+
+	// Directories are not listed
+	resp = httptest.NewRecorder()
+	s.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/avatars/user/", nil))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	r, err := s.Open("avatars/user/a.png")
+	require.NoError(t, err)
+	content, err = io.ReadAll(r)
+	require.NoError(t, err)
+	r.Close()
+	assert.Equal(t, "image data", string(content))
+
+	require.NoError(t, s.Delete("avatars/user/a.png"))
+	_, err = os.Stat(filepath.Join(dir, "avatars", "user", "a.png"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Deleting a missing file is fine
+	assert.NoError(t, s.Delete("avatars/user/a.png"))
+	_, err = s.Open("avatars/user/a.png")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestLocalStorageDoesNotServePrivateFiles(t *testing.T) {
+	s, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/uploads")
+	require.NoError(t, err)
+
+	_, err = s.Put(storage.PrivatePrefix+"exports/user/export.zip", strings.NewReader("secret"), "application/zip")
+	require.NoError(t, err)
+
+	for _, path := range []string{"/private/exports/user/export.zip", "/avatars/../private/exports/user/export.zip"} {
+		resp := httptest.NewRecorder()
+		s.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusNotFound, resp.Code, path)
+	}
+
+	r, err := s.Open(storage.PrivatePrefix + "exports/user/export.zip")
+	require.NoError(t, err)
+	defer r.Close()
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(content))
+}
+
+func TestLocalStorageRejectsKeysOutsideRoot(t *testing.T) {
+	s, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080/uploads")
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../escape.txt", "avatars/../../escape.txt", `avatars\..\escape.txt`} {
+		_, err := s.Put(key, strings.NewReader("x"), "text/plain")
+		assert.ErrorIs(t, err, storage.ErrInvalidKey, key)
+		assert.ErrorIs(t, s.Delete(key), storage.ErrInvalidKey, key)
+	}
+}
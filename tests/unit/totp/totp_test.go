@@ -0,0 +1,62 @@
+package totp_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yashkadam007/brewkar/pkg/totp"
+)
+
+// Base32 of the RFC 6238 SHA1 test key "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeMatchesRFCVectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := totp.Code(rfcSecret, totp.Step(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, code, "time %d", tt.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	current := totp.Step(now)
+
+	previous, err := totp.Code(rfcSecret, current-1)
+	require.NoError(t, err)
+	step, ok := totp.Validate(rfcSecret, previous, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, current-1, step)
+
+	// Outside the allowed drift
+	stale, err := totp.Code(rfcSecret, current-2)
+	require.NoError(t, err)
+	_, ok = totp.Validate(rfcSecret, stale, now, 1)
+	assert.False(t, ok)
+
+	_, ok = totp.Validate(rfcSecret, "12345", now, 1)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	uri := totp.URI("Brewkar", "test@example.com", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Brewkar:test@example.com?"))
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=Brewkar")
+}